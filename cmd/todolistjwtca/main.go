@@ -1,13 +1,22 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/blobstore"
 	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/config"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/events"
 	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/handlers"
 	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/middleware"
 	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/repository"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/service"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/worker"
 	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/pkg/logging"
 	"github.com/julienschmidt/httprouter"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
 	"time"
 )
 
@@ -26,21 +35,66 @@ func main() {
 	}
 	defer repository.CloseDB(db)
 
+	// Хранилище файлов вложений
+	store, err := blobstore.New(cfg.Attachments)
+	if err != nil {
+		logger.Fatalf("Ошибка при инициализации хранилища вложений: %v", err)
+	}
+
+	// Контекст приложения отменяется при получении сигнала завершения
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Запускаем фоновую очистку корзины
+	noteSvc := service.NewNoteService(repository.NewNoteRepository(db), repository.NewListRepository(db),
+		service.NewAuthorizer(repository.NewShareRepository(db)), cfg)
+	go worker.NewTrashPurger(noteSvc, cfg, logger).Run(ctx)
+
+	// Запускаем планировщик напоминаний
+	reminderSvc := service.NewReminderService(repository.NewReminderRepository(db), cfg)
+	go worker.NewReminderScheduler(reminderSvc, cfg, logger).Run(ctx)
+
+	// Запускаем удаление файлов удалённых вложений
+	attachmentSvc := service.NewAttachmentService(repository.NewAttachmentRepository(db), store,
+		service.NewAuthorizer(repository.NewShareRepository(db)), cfg)
+	go worker.NewAttachmentCleaner(attachmentSvc, cfg, logger).Run(ctx)
+
+	// Запускаем выполнение заданий импорта из других сервисов
+	importJobSvc := service.NewImportJobService(repository.NewImportJobRepository(db), repository.NewListRepository(db), store, cfg)
+	go worker.NewImportRunner(importJobSvc, cfg, logger).Run(ctx)
+
+	// Запускаем доставку исходящих webhook из очереди
+	webhookSvc := service.NewWebhookService(repository.NewWebhookRepository(db), cfg)
+	go worker.NewWebhookDispatcher(webhookSvc, cfg, logger).Run(ctx)
+
+	// Запускаем удаление ключей Idempotency-Key с истёкшим сроком хранения
+	idempotencySvc := service.NewIdempotencyService(repository.NewIdempotencyRepository(db), cfg)
+	go worker.NewIdempotencyPurger(idempotencySvc, cfg, logger).Run(ctx)
+
+	// Запускаем получение событий заметок из БД для потоков GET /events.
+	// При остановке брокер закрывает потоки, чтобы они не задерживали завершение сервера
+	broker := events.NewBroker(cfg.Events.BufferSize, cfg.Events.MaxStreamsPerUser)
+	go worker.NewEventListener(repository.NewNoteEventRepository(db), broker, logger).Run(ctx)
+	go func() {
+		<-ctx.Done()
+		broker.Close()
+	}()
+
 	// Создаем роутер
 	router := httprouter.New()
 
 	// Инициализируем обработчики (handlers) и передаем им зависимости
-	handler := handlers.NewHandler(cfg, logger, db)
+	handler := handlers.NewHandler(cfg, logger, db, store, broker)
 	handler.RegisterRoutes(router)
 
 	// Обработка cors, Context
 	corsHandler := middleware.CorsSettings().Handler(middleware.RequestContext(router))
 
 	// Запускаем сервер
-	start(corsHandler, cfg, logger)
+	start(ctx, corsHandler, cfg, logger)
 }
 
-func start(router http.Handler, cfg *config.Config, logger *logging.Logger) {
+func start(ctx context.Context, router http.Handler, cfg *config.Config, logger *logging.Logger) {
 	const timeout = 15 * time.Second
 
 	server := &http.Server{
@@ -51,6 +105,25 @@ func start(router http.Handler, cfg *config.Config, logger *logging.Logger) {
 		IdleTimeout:  timeout,
 	}
 
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		<-ctx.Done()
+
+		// Даём активным запросам завершиться перед остановкой
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			logger.Errorf("Ошибка при остановке сервера: %v", err)
+		}
+	}()
+
 	logger.Infof("Сервер запущен на %v", cfg.Port)
-	logger.Fatal(server.ListenAndServe())
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		logger.Fatal(err)
+	}
+
+	<-stopped
+	logger.Info("Сервер остановлен")
 }
@@ -0,0 +1,60 @@
+package blobstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/config"
+	"io"
+	"strings"
+)
+
+// ErrNotFound - объект отсутствует в хранилище
+var ErrNotFound = errors.New("blobstore: объект не найден")
+
+// BlobStore - хранилище содержимого файлов. Ключи формирует приложение: это пути из сегментов
+// [a-zA-Z0-9._-], разделённых "/", без пользовательского ввода
+type BlobStore interface {
+	// Put сохраняет size байт из r под ключом key
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	// Get открывает объект для чтения. Если объекта нет, возвращается ErrNotFound
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete удаляет объект. Удаление отсутствующего объекта не считается ошибкой
+	Delete(ctx context.Context, key string) error
+}
+
+// New создаёт хранилище по настройкам вложений (local или s3)
+func New(cfg config.Attachments) (BlobStore, error) {
+	switch strings.ToLower(cfg.Storage) {
+	case "", "local":
+		dir := cfg.Dir
+		if dir == "" {
+			dir = "./uploads"
+		}
+		return NewLocalStore(dir), nil
+	case "s3":
+		return NewS3Store(cfg.S3)
+	default:
+		return nil, fmt.Errorf("blobstore: неизвестный тип хранилища %q (local | s3)", cfg.Storage)
+	}
+}
+
+// validKey - проверяем, что ключ состоит из безопасных сегментов и не выходит за пределы хранилища
+func validKey(key string) bool {
+	if key == "" || strings.HasPrefix(key, "/") || strings.HasSuffix(key, "/") {
+		return false
+	}
+
+	for _, segment := range strings.Split(key, "/") {
+		if segment == "" || segment == "." || segment == ".." {
+			return false
+		}
+		for _, r := range segment {
+			if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '.' || r == '_' || r == '-') {
+				return false
+			}
+		}
+	}
+
+	return true
+}
@@ -6,13 +6,24 @@ import (
 	"github.com/joho/godotenv"
 	"os"
 	"sync"
+	"time"
 )
 
 // Структура конфигурации
 type Config struct {
-	Port  string         `yaml:"port"`
-	DB    DatabaseConfig `yaml:"db"`
-	Token Token          `yaml:"token"`
+	Port        string         `yaml:"port"`
+	DB          DatabaseConfig `yaml:"db"`
+	Token       Token          `yaml:"token"`
+	Trash       Trash          `yaml:"trash"`
+	Reminders   Reminders      `yaml:"reminders"`
+	SMTP        SMTP           `yaml:"smtp"`
+	Attachments Attachments    `yaml:"attachments"`
+	Imports     Imports        `yaml:"imports"`
+	Events      Events         `yaml:"events"`
+	Webhooks    Webhooks       `yaml:"webhooks"`
+	Idempotency Idempotency    `yaml:"idempotency"`
+	Calendar    Calendar       `yaml:"calendar"`
+	ShareLinks  ShareLinks     `yaml:"shareLinks"`
 }
 
 // Подконфигурация для базы данных
@@ -31,6 +42,97 @@ type Token struct {
 	Refresh string `yaml:"refresh"`
 }
 
+// Настройки корзины заметок
+type Trash struct {
+	Retention     time.Duration `yaml:"retention" env-default:"720h"`   // Сколько хранятся заметки в корзине до окончательного удаления
+	PurgeInterval time.Duration `yaml:"purgeInterval" env-default:"1h"` // Как часто запускается очистка корзины
+}
+
+// Настройки планировщика напоминаний
+type Reminders struct {
+	PollInterval   time.Duration `yaml:"pollInterval" env-default:"30s"`   // Как часто проверяются напоминания, которые пора отправить
+	BatchSize      int           `yaml:"batchSize" env-default:"50"`       // Сколько напоминаний захватывается за один проход
+	Lease          time.Duration `yaml:"lease" env-default:"2m"`           // На сколько напоминание захватывается одной репликой
+	MaxAttempts    int           `yaml:"maxAttempts" env-default:"5"`      // Количество попыток до статуса failed
+	RetryBackoff   time.Duration `yaml:"retryBackoff" env-default:"1m"`    // Задержка перед первой повторной попыткой (далее удваивается)
+	WebhookTimeout time.Duration `yaml:"webhookTimeout" env-default:"10s"` // Таймаут запроса к webhook
+}
+
+// Настройки SMTP для отправки напоминаний по email (если Host пустой, email-напоминания отключены)
+type SMTP struct {
+	Host     string `yaml:"host"`
+	Port     int    `yaml:"port" env-default:"587"`
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+	From     string `yaml:"from"`
+}
+
+// Настройки вложений заметок
+type Attachments struct {
+	Storage         string        `yaml:"storage" env-default:"local"`        // Хранилище файлов: local | s3
+	Dir             string        `yaml:"dir" env-default:"./uploads"`        // Каталог локального хранилища
+	MaxFileSize     int64         `yaml:"maxFileSize" env-default:"10485760"` // Максимальный размер файла в байтах (10 МиБ)
+	UserQuota       int64         `yaml:"userQuota" env-default:"104857600"`  // Объём вложений одного пользователя в байтах (100 МиБ)
+	CleanupInterval time.Duration `yaml:"cleanupInterval" env-default:"10m"`  // Как часто удаляются файлы окончательно удалённых заметок
+	S3              S3            `yaml:"s3"`
+}
+
+// Настройки S3-совместимого хранилища (AWS S3, MinIO)
+type S3 struct {
+	Endpoint  string `yaml:"endpoint"`                       // Адрес хранилища, например http://localhost:9000
+	Region    string `yaml:"region" env-default:"us-east-1"` // Регион для подписи запросов
+	Bucket    string `yaml:"bucket"`                         // Бакет для вложений
+	AccessKey string `yaml:"accessKey"`                      // Ключ доступа
+	SecretKey string `yaml:"secretKey"`                      // Секретный ключ
+	PathStyle bool   `yaml:"pathStyle" env-default:"true"`   // Адресация endpoint/bucket/key (нужна для MinIO)
+}
+
+// Настройки фонового импорта из Todoist, Microsoft To Do и Google Tasks
+type Imports struct {
+	PollInterval time.Duration `yaml:"pollInterval" env-default:"5s"`      // Как часто проверяется очередь заданий импорта
+	Lease        time.Duration `yaml:"lease" env-default:"2m"`             // На сколько задание захватывается одной репликой (продлевается по ходу работы)
+	MaxFileSize  int64         `yaml:"maxFileSize" env-default:"20971520"` // Максимальный размер файла экспорта в байтах (20 МиБ)
+}
+
+// Настройки потока событий заметок (GET /events)
+type Events struct {
+	BufferSize        int           `yaml:"bufferSize" env-default:"64"`        // Сколько событий ждёт отправки одному клиенту; при переполнении клиенту отправляется resync
+	MaxStreamsPerUser int           `yaml:"maxStreamsPerUser" env-default:"10"` // Максимальное количество открытых потоков одного пользователя
+	Heartbeat         time.Duration `yaml:"heartbeat" env-default:"25s"`        // Интервал пустых сообщений, чтобы прокси не закрывали соединение
+	WriteTimeout      time.Duration `yaml:"writeTimeout" env-default:"10s"`     // Таймаут записи одного события; медленный клиент отключается
+}
+
+// Настройки исходящих webhook
+type Webhooks struct {
+	PollInterval         time.Duration `yaml:"pollInterval" env-default:"5s"`  // Как часто проверяется очередь доставок
+	BatchSize            int           `yaml:"batchSize" env-default:"50"`     // Сколько доставок захватывается за один проход
+	Lease                time.Duration `yaml:"lease" env-default:"1m"`         // На сколько доставка захватывается одной репликой
+	Timeout              time.Duration `yaml:"timeout" env-default:"10s"`      // Таймаут запроса к получателю
+	MaxAttempts          int           `yaml:"maxAttempts" env-default:"8"`    // Количество попыток до статуса failed
+	RetryBackoff         time.Duration `yaml:"retryBackoff" env-default:"30s"` // Задержка перед первой повторной попыткой (далее удваивается)
+	Retention            time.Duration `yaml:"retention" env-default:"720h"`   // Сколько хранятся завершённые доставки в журнале
+	AllowPrivateNetworks bool          `yaml:"allowPrivateNetworks"`           // Разрешить адреса внутренних сетей (для локальной разработки)
+}
+
+// Настройки ключей Idempotency-Key
+type Idempotency struct {
+	TTL           time.Duration `yaml:"ttl" env-default:"24h"`             // Сколько хранится ответ; повтор с тем же ключом в течение этого времени получает его же
+	Lock          time.Duration `yaml:"lock" env-default:"1m"`             // Сколько ключ занят выполняющимся запросом (после сбоя запрос можно повторить)
+	MaxBodySize   int64         `yaml:"maxBodySize" env-default:"1048576"` // Максимальный размер тела запроса с ключом в байтах (1 МиБ); импорт и загрузка файлов ограничены отдельно, сверх этого объёма тело пишется во временный файл
+	PurgeInterval time.Duration `yaml:"purgeInterval" env-default:"1h"`    // Как часто удаляются ключи с истёкшим сроком хранения
+}
+
+// Настройки ленты задач iCalendar
+type Calendar struct {
+	SyncInterval time.Duration `yaml:"syncInterval" env-default:"10s"` // Минимальный интервал между загрузками PUT /ical/:token по одной ленте
+}
+
+// Настройки публичных ссылок на заметки
+type ShareLinks struct {
+	MaxPasswordAttempts int           `yaml:"maxPasswordAttempts" env-default:"5"` // Сколько неверных паролей подряд допускается до блокировки ссылки
+	Lockout             time.Duration `yaml:"lockout" env-default:"15m"`           // На сколько блокируется проверка пароля ссылки
+}
+
 // Глобальная переменная для хранения конфигурации
 var instance *Config
 var once sync.Once
@@ -75,5 +177,24 @@ func overrideWithEnv(cfg *Config) {
 	if refreshToken := os.Getenv("REFRESH_TOKEN"); refreshToken != "" {
 		cfg.Token.Refresh = refreshToken
 	}
+	if smtpPassword := os.Getenv("SMTP_PASSWORD"); smtpPassword != "" {
+		cfg.SMTP.Password = smtpPassword
+	}
+	if accessKey := os.Getenv("S3_ACCESS_KEY"); accessKey != "" {
+		cfg.Attachments.S3.AccessKey = accessKey
+	}
+	if secretKey := os.Getenv("S3_SECRET_KEY"); secretKey != "" {
+		cfg.Attachments.S3.SecretKey = secretKey
+	}
+	if retention := os.Getenv("TRASH_RETENTION"); retention != "" {
+		if d, err := time.ParseDuration(retention); err == nil {
+			cfg.Trash.Retention = d
+		}
+	}
+	if interval := os.Getenv("TRASH_PURGE_INTERVAL"); interval != "" {
+		if d, err := time.ParseDuration(interval); err == nil {
+			cfg.Trash.PurgeInterval = d
+		}
+	}
 
 }
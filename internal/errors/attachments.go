@@ -0,0 +1,15 @@
+package errors
+
+import "errors"
+
+var (
+	ErrAttachmentNotFound        = errors.New("Вложение не найдено")
+	ErrInvalidAttachment         = errors.New("Передайте непустой файл в поле file (multipart/form-data)")
+	ErrAttachmentTooLarge        = errors.New("Файл превышает допустимый размер")
+	ErrAttachmentQuotaExceeded   = errors.New("Превышен объём хранилища вложений")
+	ErrUnsupportedAttachmentType = errors.New("Допустимы только изображения (PNG, JPEG, GIF, WebP) и PDF")
+
+	ErrAttachmentFailed = errors.New("Не удалось сохранить вложение")
+	ErrGetAttachments   = errors.New("Ошибка при получении вложений")
+	ErrDeleteAttachment = errors.New("Ошибка при удалении вложения")
+)
@@ -0,0 +1,16 @@
+package errors
+
+import "errors"
+
+var (
+	ErrCalendarFeedNotFound = errors.New("Лента календаря не найдена или отозвана")
+	ErrInvalidCalendarFile  = errors.New("Не удалось разобрать файл iCalendar")
+	ErrTooManyCalendarTodos = errors.New("Слишком много задач в файле календаря (не более 1000)")
+	ErrCalendarFileTooLarge = errors.New("Файл календаря слишком большой")
+	ErrCalendarSyncTooOften = errors.New("Задачи календаря загружаются слишком часто, повторите позже")
+
+	ErrCalendarFeedFailed = errors.New("Не удалось создать ленту календаря")
+	ErrDeleteCalendarFeed = errors.New("Ошибка при отзыве ленты календаря")
+	ErrGetCalendarFeed    = errors.New("Ошибка при получении ленты календаря")
+	ErrSyncCalendar       = errors.New("Ошибка при загрузке задач календаря")
+)
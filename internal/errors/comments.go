@@ -0,0 +1,12 @@
+package errors
+
+import "errors"
+
+var (
+	ErrCommentNotFound = errors.New("Комментарий не найден")
+	ErrInvalidComment  = errors.New("Комментарий должен содержать от 1 до 2000 символов")
+
+	ErrCommentFailed = errors.New("Не удалось сохранить комментарий")
+	ErrDeleteComment = errors.New("Ошибка при удалении комментария")
+	ErrGetComments   = errors.New("Ошибка при получении комментариев")
+)
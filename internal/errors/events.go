@@ -0,0 +1,9 @@
+package errors
+
+import "errors"
+
+var (
+	ErrTooManyEventStreams    = errors.New("Открыто слишком много потоков событий")
+	ErrEventStreamUnsupported = errors.New("Потоковая передача не поддерживается")
+	ErrEventBrokerClosed      = errors.New("Поток событий остановлен")
+)
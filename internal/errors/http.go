@@ -1 +1,105 @@
 package errors
+
+import (
+	"errors"
+	"net/http"
+)
+
+// HTTPStatus возвращает HTTP-статус, соответствующий ошибке бизнес-логики.
+// Если ошибка не относится к известным, возвращается defaultCode.
+func HTTPStatus(err error, defaultCode int) int {
+	switch {
+	case errors.Is(err, ErrNoteNotFound),
+		errors.Is(err, ErrTagNotFound),
+		errors.Is(err, ErrListNotFound),
+		errors.Is(err, ErrItemNotFound),
+		errors.Is(err, ErrRevisionNotFound),
+		errors.Is(err, ErrReminderNotFound),
+		errors.Is(err, ErrUserNotFound),
+		errors.Is(err, ErrShareNotFound),
+		errors.Is(err, ErrShareLinkNotFound),
+		errors.Is(err, ErrCommentNotFound),
+		errors.Is(err, ErrAttachmentNotFound),
+		errors.Is(err, ErrCalendarFeedNotFound),
+		errors.Is(err, ErrImportJobNotFound),
+		errors.Is(err, ErrWebhookNotFound),
+		errors.Is(err, ErrWebhookDeliveryNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, ErrAttachmentTooLarge),
+		errors.Is(err, ErrAttachmentQuotaExceeded),
+		errors.Is(err, ErrImportFileTooLarge),
+		errors.Is(err, ErrCalendarFileTooLarge),
+		errors.Is(err, ErrIdempotencyRequestTooLarge):
+		return http.StatusRequestEntityTooLarge
+	case errors.Is(err, ErrUnsupportedAttachmentType):
+		return http.StatusUnsupportedMediaType
+	case errors.Is(err, ErrShareLinkExpired):
+		return http.StatusGone
+	case errors.Is(err, ErrShareLinkPassword):
+		return http.StatusUnauthorized
+	case errors.Is(err, ErrForbidden):
+		return http.StatusForbidden
+	case errors.Is(err, ErrNoteVersionMismatch):
+		return http.StatusPreconditionFailed
+	case errors.Is(err, ErrIdempotencyKeyMismatch):
+		return http.StatusUnprocessableEntity
+	case errors.Is(err, ErrTooManyEventStreams),
+		errors.Is(err, ErrCalendarSyncTooOften),
+		errors.Is(err, ErrShareLinkLocked):
+		return http.StatusTooManyRequests
+	case errors.Is(err, ErrEventBrokerClosed):
+		return http.StatusServiceUnavailable
+	case errors.Is(err, ErrTagAlreadyExists),
+		errors.Is(err, ErrDefaultListCannotBeDeleted),
+		errors.Is(err, ErrTooManyWebhooks),
+		errors.Is(err, ErrIdempotencyKeyInProgress):
+		return http.StatusConflict
+	case errors.Is(err, ErrNoteTooShort),
+		errors.Is(err, ErrIDCannotBeNegativeOrEqualToZero),
+		errors.Is(err, ErrInvalidNotesQuery),
+		errors.Is(err, ErrInvalidCursor),
+		errors.Is(err, ErrEmptySearchQuery),
+		errors.Is(err, ErrInvalidDueAt),
+		errors.Is(err, ErrInvalidPriority),
+		errors.Is(err, ErrInvalidDays),
+		errors.Is(err, ErrInvalidNotePosition),
+		errors.Is(err, ErrInvalidRRule),
+		errors.Is(err, ErrRecurrenceRequiresDueAt),
+		errors.Is(err, ErrInvalidTagName),
+		errors.Is(err, ErrInvalidTagsMode),
+		errors.Is(err, ErrInvalidListName),
+		errors.Is(err, ErrInvalidListColor),
+		errors.Is(err, ErrInvalidListPosition),
+		errors.Is(err, ErrInvalidItemText),
+		errors.Is(err, ErrInvalidItemsOrder),
+		errors.Is(err, ErrInvalidRevision),
+		errors.Is(err, ErrInvalidRemindAt),
+		errors.Is(err, ErrInvalidChannel),
+		errors.Is(err, ErrInvalidTarget),
+		errors.Is(err, ErrNotifierUnavailable),
+		errors.Is(err, ErrInvalidShareRole),
+		errors.Is(err, ErrInvalidShareUser),
+		errors.Is(err, ErrInvalidShareLinkExpiry),
+		errors.Is(err, ErrInvalidShareLinkPassword),
+		errors.Is(err, ErrInvalidComment),
+		errors.Is(err, ErrInvalidAttachment),
+		errors.Is(err, ErrInvalidNoteBatch),
+		errors.Is(err, ErrInvalidBatchOp),
+		errors.Is(err, ErrInvalidNoteFormat),
+		errors.Is(err, ErrInvalidImportFile),
+		errors.Is(err, ErrTooManyImportRows),
+		errors.Is(err, ErrInvalidImportValue),
+		errors.Is(err, ErrInvalidCalendarFile),
+		errors.Is(err, ErrTooManyCalendarTodos),
+		errors.Is(err, ErrInvalidImportSource),
+		errors.Is(err, ErrInvalidSyncToken),
+		errors.Is(err, ErrInvalidSyncPush),
+		errors.Is(err, ErrInvalidSyncTime),
+		errors.Is(err, ErrInvalidWebhookURL),
+		errors.Is(err, ErrInvalidWebhookEvent),
+		errors.Is(err, ErrInvalidIdempotencyKey):
+		return http.StatusBadRequest
+	default:
+		return defaultCode
+	}
+}
@@ -0,0 +1,11 @@
+package errors
+
+import "errors"
+
+var (
+	ErrInvalidIdempotencyKey      = errors.New("Некорректный Idempotency-Key (от 1 до 255 видимых символов ASCII)")
+	ErrIdempotencyKeyMismatch     = errors.New("Idempotency-Key уже использован для другого запроса")
+	ErrIdempotencyKeyInProgress   = errors.New("Запрос с этим Idempotency-Key ещё выполняется")
+	ErrIdempotencyRequestTooLarge = errors.New("Тело запроса с Idempotency-Key слишком большое")
+	ErrIdempotencyFailed          = errors.New("Ошибка при обработке Idempotency-Key")
+)
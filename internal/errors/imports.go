@@ -0,0 +1,13 @@
+package errors
+
+import "errors"
+
+var (
+	ErrInvalidImportSource = errors.New("Некорректный источник импорта (todoist | mstodo | gtasks)")
+	ErrImportJobNotFound   = errors.New("Задание импорта не найдено")
+	ErrImportJobLost       = errors.New("Задание импорта захвачено другим обработчиком")
+
+	ErrImportJobFailed = errors.New("Не удалось создать задание импорта")
+	ErrGetImportJobs   = errors.New("Ошибка при получении заданий импорта")
+	ErrRunImportJob    = errors.New("Ошибка при выполнении задания импорта")
+)
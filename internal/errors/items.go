@@ -0,0 +1,14 @@
+package errors
+
+import "errors"
+
+var (
+	ErrItemNotFound      = errors.New("Пункт чек-листа не найден")
+	ErrInvalidItemText   = errors.New("Текст пункта должен содержать от 1 до 500 символов")
+	ErrInvalidItemsOrder = errors.New("Новый порядок должен содержать все пункты заметки ровно по одному разу")
+
+	ErrItemFailed   = errors.New("Не удалось сохранить пункт чек-листа")
+	ErrDeleteItem   = errors.New("Ошибка при удалении пункта чек-листа")
+	ErrGetItems     = errors.New("Ошибка при получении пунктов чек-листа")
+	ErrReorderItems = errors.New("Не удалось изменить порядок пунктов чек-листа")
+)
@@ -0,0 +1,16 @@
+package errors
+
+import "errors"
+
+var (
+	ErrListNotFound               = errors.New("Список не найден")
+	ErrInvalidListName            = errors.New("Название списка должно содержать от 1 до 100 символов")
+	ErrInvalidListColor           = errors.New("Цвет списка должен быть в формате #RRGGBB")
+	ErrInvalidListPosition        = errors.New("Позиция списка не может быть отрицательной")
+	ErrDefaultListCannotBeDeleted = errors.New("Список по умолчанию нельзя удалить")
+
+	ErrListFailed = errors.New("Не удалось сохранить список")
+	ErrDeleteList = errors.New("Ошибка при удалении списка")
+	ErrGetLists   = errors.New("Ошибка при получении списков")
+	ErrMoveNote   = errors.New("Не удалось перенести заметку в другой список")
+)
@@ -6,12 +6,47 @@ var (
 	ErrNoteTooShort = errors.New("Слишком короткая заметка")
 	ErrNoteFailed   = errors.New("Вставить заметку не удалось")
 	ErrNoteNotFound = errors.New("Заметка Не Найдена")
+	ErrGetNote      = errors.New("Ошибка при получении заметки")
 
 	ErrIDCannotBeNegativeOrEqualToZero = errors.New("ID не может быть отрицательным или равным 0")
 
 	ErrNoteToUpdate           = errors.New("Не удалось обновить заметку")
+	ErrNoteVersionMismatch    = errors.New("Заметка изменена другим клиентом (версия не совпадает с If-Match)")
 	FailedToCheckAffectedRows = errors.New("Не удалось проверить затронутые строки")
 
 	ErrDeleteNote       = errors.New("Ошибка при удалении заметки")
 	ErrDeletingAllNotes = errors.New("Ошибка при удалении всех заметок")
+
+	ErrInvalidNotesQuery = errors.New("Некорректные параметры запроса заметок")
+	ErrInvalidCursor     = errors.New("Некорректный курсор")
+
+	ErrInvalidDueAt    = errors.New("Некорректный срок выполнения")
+	ErrInvalidPriority = errors.New("Некорректный приоритет (low | normal | high | urgent)")
+	ErrInvalidDays     = errors.New("Некорректное количество дней (1..365)")
+
+	ErrInvalidNotePosition = errors.New("Нужно указать соседние заметки: afterId и/или beforeId (afterId должна стоять раньше beforeId)")
+	ErrReorderNote         = errors.New("Не удалось изменить позицию заметки")
+
+	ErrInvalidRRule            = errors.New("Некорректное правило повторения")
+	ErrRecurrenceRequiresDueAt = errors.New("Для повторяющейся заметки нужно указать срок выполнения")
+	ErrNextOccurrence          = errors.New("Не удалось создать следующее повторение заметки")
+
+	ErrGetTrash    = errors.New("Ошибка при получении корзины")
+	ErrRestoreNote = errors.New("Не удалось восстановить заметку из корзины")
+	ErrEmptyTrash  = errors.New("Ошибка при очистке корзины")
+
+	ErrEmptySearchQuery = errors.New("Пустой поисковый запрос")
+	ErrSearchNotes      = errors.New("Ошибка при поиске заметок")
+
+	ErrInvalidNoteBatch = errors.New("Пакет должен содержать от 1 до 100 операций, mode: atomic | best_effort")
+	ErrInvalidBatchOp   = errors.New("Некорректная операция пакета (create | update | complete | delete | move)")
+	ErrNoteBatch        = errors.New("Не удалось выполнить пакет операций с заметками")
+
+	ErrInvalidNoteFormat  = errors.New("Некорректный формат (json | csv | md)")
+	ErrInvalidImportFile  = errors.New("Не удалось разобрать файл импорта")
+	ErrTooManyImportRows  = errors.New("Слишком много заметок в файле импорта (не более 5000)")
+	ErrInvalidImportValue = errors.New("Некорректное значение поля")
+	ErrImportFileTooLarge = errors.New("Файл импорта слишком большой")
+	ErrExportNotes        = errors.New("Ошибка при экспорте заметок")
+	ErrImportNotes        = errors.New("Ошибка при импорте заметок")
 )
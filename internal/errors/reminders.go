@@ -0,0 +1,18 @@
+package errors
+
+import "errors"
+
+var (
+	ErrReminderNotFound    = errors.New("Напоминание не найдено")
+	ErrInvalidRemindAt     = errors.New("Некорректное время напоминания")
+	ErrInvalidChannel      = errors.New("Некорректный канал напоминания (email | webhook)")
+	ErrInvalidTarget       = errors.New("Некорректный адрес доставки напоминания")
+	ErrNotifierUnavailable = errors.New("Канал доставки напоминаний не настроен")
+
+	ErrReminderFailed = errors.New("Не удалось сохранить напоминание")
+	ErrDeleteReminder = errors.New("Ошибка при удалении напоминания")
+	ErrGetReminders   = errors.New("Ошибка при получении напоминаний")
+	ErrSendReminder   = errors.New("Не удалось отправить напоминание")
+
+	ErrReminderLeaseLost = errors.New("Захват напоминания истёк, его обрабатывает другая реплика")
+)
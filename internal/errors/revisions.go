@@ -0,0 +1,13 @@
+package errors
+
+import "errors"
+
+var (
+	ErrRevisionNotFound = errors.New("Ревизия заметки не найдена")
+	ErrInvalidRevision  = errors.New("Некорректный номер ревизии")
+
+	ErrGetRevisions = errors.New("Ошибка при получении истории заметки")
+	ErrSaveRevision = errors.New("Не удалось сохранить ревизию заметки")
+	ErrDiffRevision = errors.New("Не удалось сравнить ревизии заметки")
+	ErrRevertNote   = errors.New("Не удалось вернуть заметку к ревизии")
+)
@@ -0,0 +1,17 @@
+package errors
+
+import "errors"
+
+var (
+	ErrShareLinkNotFound        = errors.New("Ссылка не найдена или отозвана")
+	ErrShareLinkExpired         = errors.New("Срок действия ссылки истёк")
+	ErrShareLinkPassword        = errors.New("Для открытия ссылки нужен верный пароль")
+	ErrShareLinkLocked          = errors.New("Слишком много неверных паролей, ссылка временно заблокирована")
+	ErrInvalidShareLinkExpiry   = errors.New("Срок действия ссылки должен быть в будущем")
+	ErrInvalidShareLinkPassword = errors.New("Пароль ссылки должен содержать от 8 до 72 байт")
+
+	ErrShareLinkFailed = errors.New("Не удалось создать ссылку")
+	ErrGetShareLinks   = errors.New("Ошибка при получении ссылок")
+	ErrRevokeShareLink = errors.New("Ошибка при отзыве ссылки")
+	ErrOpenShareLink   = errors.New("Не удалось открыть заметку по ссылке")
+)
@@ -0,0 +1,16 @@
+package errors
+
+import "errors"
+
+var (
+	ErrUserNotFound     = errors.New("Пользователь не найден")
+	ErrInvalidShareRole = errors.New("Некорректная роль доступа (viewer | editor)")
+	ErrInvalidShareUser = errors.New("Укажите имя пользователя или email другого пользователя")
+	ErrForbidden        = errors.New("Недостаточно прав для выполнения действия")
+	ErrShareNotFound    = errors.New("Доступ не найден")
+
+	ErrShareFailed = errors.New("Не удалось открыть доступ")
+	ErrDeleteShare = errors.New("Ошибка при закрытии доступа")
+	ErrGetShares   = errors.New("Ошибка при получении списка доступа")
+	ErrCheckAccess = errors.New("Ошибка при проверке прав доступа")
+)
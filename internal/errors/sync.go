@@ -0,0 +1,12 @@
+package errors
+
+import "errors"
+
+var (
+	ErrInvalidSyncToken = errors.New("Некорректный токен синхронизации")
+	ErrInvalidSyncPush  = errors.New("Некорректный пакет изменений: от 1 до 100 изменений")
+	ErrInvalidSyncTime  = errors.New("Некорректное время изменения (ожидается RFC3339)")
+
+	ErrGetSyncChanges = errors.New("Ошибка при получении изменений для синхронизации")
+	ErrSyncPush       = errors.New("Ошибка при сохранении изменений клиента")
+)
@@ -0,0 +1,16 @@
+package errors
+
+import "errors"
+
+var (
+	ErrTagNotFound      = errors.New("Метка не найдена")
+	ErrTagAlreadyExists = errors.New("Метка с таким названием уже существует")
+	ErrInvalidTagName   = errors.New("Название метки должно содержать от 1 до 50 символов")
+
+	ErrTagFailed       = errors.New("Не удалось сохранить метку")
+	ErrDeleteTag       = errors.New("Ошибка при удалении метки")
+	ErrGetTags         = errors.New("Ошибка при получении меток")
+	ErrAttachTag       = errors.New("Не удалось прикрепить метку к заметке")
+	ErrDetachTag       = errors.New("Не удалось открепить метку от заметки")
+	ErrInvalidTagsMode = errors.New("Некорректный режим фильтрации по меткам (any | all)")
+)
@@ -0,0 +1,20 @@
+package errors
+
+import "errors"
+
+var (
+	ErrWebhookNotFound         = errors.New("Подписка webhook не найдена")
+	ErrWebhookDeliveryNotFound = errors.New("Доставка webhook не найдена")
+	ErrInvalidWebhookURL       = errors.New("Некорректный адрес webhook (ожидается http или https)")
+	ErrInvalidWebhookEvent     = errors.New("Некорректный тип события (note.created | note.updated | note.completed | note.deleted)")
+	ErrTooManyWebhooks         = errors.New("Достигнуто максимальное количество подписок webhook")
+
+	ErrWebhookFailed    = errors.New("Не удалось сохранить подписку webhook")
+	ErrDeleteWebhook    = errors.New("Ошибка при удалении подписки webhook")
+	ErrGetWebhooks      = errors.New("Ошибка при получении подписок webhook")
+	ErrGetWebhookLog    = errors.New("Ошибка при получении журнала доставок webhook")
+	ErrRedeliverWebhook = errors.New("Не удалось повторить доставку webhook")
+	ErrDeliverWebhook   = errors.New("Ошибка при доставке webhook")
+
+	ErrWebhookLeaseLost = errors.New("Захват доставки webhook истёк, её обрабатывает другая реплика")
+)
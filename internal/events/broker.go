@@ -0,0 +1,144 @@
+// Package events рассылает события заметок открытым потокам клиентов (GET /events).
+// События приходят из PostgreSQL (LISTEN note_events) и раздаются подписчикам пользователя
+// внутри процесса; каждый экземпляр API получает все события и доставляет их своим клиентам.
+package events
+
+import (
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/errors"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/models"
+	"sync"
+)
+
+// Broker - подписки пользователей на события их заметок
+type Broker struct {
+	mu         sync.RWMutex
+	subs       map[int64]map[*Subscription]struct{}
+	bufferSize int
+	maxPerUser int
+	closed     bool
+}
+
+// Subscription - подписка одного потока. Публикация никогда не ждёт подписчика: если буфер событий
+// заполнен (клиент не успевает читать), событие отбрасывается, а в Lagged приходит сигнал -
+// клиенту нужно заново получить изменения через GET /sync
+type Subscription struct {
+	broker *Broker
+	userID int64
+	events chan models.NoteEvent
+	lagged chan struct{}
+	once   sync.Once
+}
+
+// NewBroker - bufferSize событий ждут отправки одному подписчику, maxPerUser - ограничение подписок пользователя
+func NewBroker(bufferSize, maxPerUser int) *Broker {
+	if bufferSize <= 0 {
+		bufferSize = 64
+	}
+	return &Broker{
+		subs:       make(map[int64]map[*Subscription]struct{}),
+		bufferSize: bufferSize,
+		maxPerUser: maxPerUser,
+	}
+}
+
+// Subscribe - подписаться на события пользователя. Подписку нужно закрыть через Close
+func (b *Broker) Subscribe(userID int64) (*Subscription, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return nil, errors.ErrEventBrokerClosed
+	}
+	if b.maxPerUser > 0 && len(b.subs[userID]) >= b.maxPerUser {
+		return nil, errors.ErrTooManyEventStreams
+	}
+
+	sub := &Subscription{
+		broker: b,
+		userID: userID,
+		events: make(chan models.NoteEvent, b.bufferSize),
+		lagged: make(chan struct{}, 1),
+	}
+
+	if b.subs[userID] == nil {
+		b.subs[userID] = make(map[*Subscription]struct{})
+	}
+	b.subs[userID][sub] = struct{}{}
+
+	return sub, nil
+}
+
+// Publish - отправить событие всем подписчикам владельца заметки
+func (b *Broker) Publish(event models.NoteEvent) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for sub := range b.subs[event.UserID] {
+		select {
+		case sub.events <- event:
+		default:
+			sub.markLagged()
+		}
+	}
+}
+
+// Resync - сообщить всем подписчикам, что события могли быть потеряны (например, после переподключения к БД)
+func (b *Broker) Resync() {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for _, subs := range b.subs {
+		for sub := range subs {
+			sub.markLagged()
+		}
+	}
+}
+
+// Close - закрыть все подписки; новые подписки не принимаются. Вызывается при остановке сервера,
+// чтобы открытые потоки завершились и не задерживали Shutdown
+func (b *Broker) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.closed = true
+	for userID, subs := range b.subs {
+		for sub := range subs {
+			sub.once.Do(func() { close(sub.events) })
+		}
+		delete(b.subs, userID)
+	}
+}
+
+// Events - события подписки; канал закрывается при закрытии подписки или брокера
+func (s *Subscription) Events() <-chan models.NoteEvent {
+	return s.events
+}
+
+// Lagged - сигнал о том, что часть событий отброшена
+func (s *Subscription) Lagged() <-chan struct{} {
+	return s.lagged
+}
+
+// Close - отписаться от событий
+func (s *Subscription) Close() {
+	b := s.broker
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if subs, ok := b.subs[s.userID]; ok {
+		delete(subs, s)
+		if len(subs) == 0 {
+			delete(b.subs, s.userID)
+		}
+	}
+	s.once.Do(func() { close(s.events) })
+}
+
+// markLagged - неблокирующий сигнал (повторные сигналы до прочтения объединяются)
+func (s *Subscription) markLagged() {
+	select {
+	case s.lagged <- struct{}{}:
+	default:
+	}
+}
@@ -0,0 +1,179 @@
+package handlers
+
+import (
+	"encoding/json"
+	goerrors "errors"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/errors"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/service"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/pkg/httperror"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/pkg/logging"
+	"github.com/julienschmidt/httprouter"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+)
+
+// multipartOverhead - запас на заголовки multipart сверх максимального размера файла
+const multipartOverhead = 1 << 20
+
+// AttachmentHandler обрабатывает запросы, связанные с вложениями заметок
+type AttachmentHandler struct {
+	attachmentService service.AttachmentService
+	logger            *logging.Logger
+}
+
+// NewAttachmentHandler создаёт новый обработчик вложений
+func NewAttachmentHandler(attachmentService service.AttachmentService, logger *logging.Logger) *AttachmentHandler {
+	return &AttachmentHandler{
+		attachmentService: attachmentService,
+		logger:            logger,
+	}
+}
+
+// Получить вложения заметки
+func (h *AttachmentHandler) getAttachments(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	ctx := r.Context()
+	userID, ok := r.Context().Value("user_id").(int64)
+	if !ok {
+		h.logger.Error(errors.ErrFailedToGetUserIDFromContext)
+		httperror.WriteJSONError(w, errors.ErrFailedToGetUserIDFromContext.Error(), nil, http.StatusInternalServerError)
+		return
+	}
+
+	noteID, _ := strconv.Atoi(ps.ByName("id"))
+
+	attachments, err := h.attachmentService.GetAttachments(ctx, userID, int64(noteID))
+	if err != nil {
+		h.logger.Errorf("%s : %v : %s", errors.ErrGetAttachments, noteID, err)
+		httperror.WriteJSONError(w, errors.ErrGetAttachments.Error(), err, errors.HTTPStatus(err, http.StatusInternalServerError))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	if err = json.NewEncoder(w).Encode(attachments); err != nil {
+		h.logger.Errorf("Ошибка при отправке вложений на клиент: %s", err)
+	}
+}
+
+// Загрузить файл (multipart/form-data, поле "file"). Файл читается потоком, без буферизации всей формы в памяти
+func (h *AttachmentHandler) uploadAttachment(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	ctx := r.Context()
+	userID, ok := r.Context().Value("user_id").(int64)
+	if !ok {
+		h.logger.Error(errors.ErrFailedToGetUserIDFromContext)
+		httperror.WriteJSONError(w, errors.ErrFailedToGetUserIDFromContext.Error(), nil, http.StatusInternalServerError)
+		return
+	}
+
+	noteID, _ := strconv.Atoi(ps.ByName("id"))
+
+	r.Body = http.MaxBytesReader(w, r.Body, h.attachmentService.MaxFileSize()+multipartOverhead)
+
+	reader, err := r.MultipartReader()
+	if err != nil {
+		h.logger.Errorf("%s : %v : %s", errors.ErrInvalidAttachment, noteID, err)
+		httperror.WriteJSONError(w, errors.ErrInvalidAttachment.Error(), err, http.StatusBadRequest)
+		return
+	}
+
+	// Ищем часть с файлом, остальные поля формы пропускаем
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			status := http.StatusBadRequest
+			var maxBytesErr *http.MaxBytesError
+			if goerrors.As(err, &maxBytesErr) {
+				err = errors.ErrAttachmentTooLarge
+				status = http.StatusRequestEntityTooLarge
+			}
+			h.logger.Errorf("%s : %v : %s", errors.ErrInvalidAttachment, noteID, err)
+			httperror.WriteJSONError(w, errors.ErrInvalidAttachment.Error(), err, status)
+			return
+		}
+
+		if part.FormName() != "file" || part.FileName() == "" {
+			part.Close()
+			continue
+		}
+
+		attachment, err := h.attachmentService.UploadAttachment(ctx, userID, int64(noteID), part.FileName(), part)
+		part.Close()
+		if err != nil {
+			h.logger.Errorf("%s : %v : %s", errors.ErrAttachmentFailed, noteID, err)
+			httperror.WriteJSONError(w, errors.ErrAttachmentFailed.Error(), err, errors.HTTPStatus(err, http.StatusInternalServerError))
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+
+		if err = json.NewEncoder(w).Encode(attachment); err != nil {
+			h.logger.Errorf("Ошибка при отправке вложения на клиент: %s", err)
+		}
+		return
+	}
+
+	h.logger.Errorf("%s : %v : нет поля file", errors.ErrInvalidAttachment, noteID)
+	httperror.WriteJSONError(w, errors.ErrInvalidAttachment.Error(), nil, http.StatusBadRequest)
+}
+
+// Скачать файл вложения
+func (h *AttachmentHandler) downloadAttachment(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	ctx := r.Context()
+	userID, ok := r.Context().Value("user_id").(int64)
+	if !ok {
+		h.logger.Error(errors.ErrFailedToGetUserIDFromContext)
+		httperror.WriteJSONError(w, errors.ErrFailedToGetUserIDFromContext.Error(), nil, http.StatusInternalServerError)
+		return
+	}
+
+	noteID, _ := strconv.Atoi(ps.ByName("id"))
+	attachmentID, _ := strconv.Atoi(ps.ByName("attachmentId"))
+
+	attachment, content, err := h.attachmentService.OpenAttachment(ctx, userID, int64(noteID), int64(attachmentID))
+	if err != nil {
+		h.logger.Errorf("%s : %v : %v : %s", errors.ErrGetAttachments, noteID, attachmentID, err)
+		httperror.WriteJSONError(w, errors.ErrGetAttachments.Error(), err, errors.HTTPStatus(err, http.StatusInternalServerError))
+		return
+	}
+	defer content.Close()
+
+	// Тип содержимого определён при загрузке, браузер не должен угадывать его заново
+	w.Header().Set("Content-Type", attachment.ContentType)
+	w.Header().Set("Content-Length", strconv.FormatInt(attachment.Size, 10))
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": attachment.FileName}))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(http.StatusOK)
+
+	if _, err = io.Copy(w, content); err != nil {
+		h.logger.Errorf("Ошибка при отправке файла на клиент: %s", err)
+	}
+}
+
+// Удалить вложение
+func (h *AttachmentHandler) deleteAttachment(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	ctx := r.Context()
+	userID, ok := r.Context().Value("user_id").(int64)
+	if !ok {
+		h.logger.Error(errors.ErrFailedToGetUserIDFromContext)
+		httperror.WriteJSONError(w, errors.ErrFailedToGetUserIDFromContext.Error(), nil, http.StatusInternalServerError)
+		return
+	}
+
+	noteID, _ := strconv.Atoi(ps.ByName("id"))
+	attachmentID, _ := strconv.Atoi(ps.ByName("attachmentId"))
+
+	if err := h.attachmentService.DeleteAttachment(ctx, userID, int64(noteID), int64(attachmentID)); err != nil {
+		h.logger.Errorf("%s : %v : %v : %s", errors.ErrDeleteAttachment, noteID, attachmentID, err)
+		httperror.WriteJSONError(w, errors.ErrDeleteAttachment.Error(), err, errors.HTTPStatus(err, http.StatusInternalServerError))
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"encoding/json"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/errors"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/service"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/pkg/httperror"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/pkg/logging"
+	"github.com/julienschmidt/httprouter"
+	"net/http"
+	"strings"
+)
+
+// maxCalendarFileSize - максимальный размер загружаемого файла календаря
+const maxCalendarFileSize = 5 << 20
+
+// CalendarHandler обрабатывает запросы, связанные с лентой задач iCalendar
+type CalendarHandler struct {
+	calendarService service.CalendarService
+	logger          *logging.Logger
+}
+
+// NewCalendarHandler создаёт новый обработчик ленты задач
+func NewCalendarHandler(calendarService service.CalendarService, logger *logging.Logger) *CalendarHandler {
+	return &CalendarHandler{
+		calendarService: calendarService,
+		logger:          logger,
+	}
+}
+
+// Создать приватную ссылку на ленту задач (повторный вызов заменяет токен)
+func (h *CalendarHandler) createCalendarFeed(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	ctx := r.Context()
+	userID, ok := r.Context().Value("user_id").(int64)
+	if !ok {
+		h.logger.Error(errors.ErrFailedToGetUserIDFromContext)
+		httperror.WriteJSONError(w, errors.ErrFailedToGetUserIDFromContext.Error(), nil, http.StatusInternalServerError)
+		return
+	}
+
+	feed, err := h.calendarService.CreateCalendarFeed(ctx, userID)
+	if err != nil {
+		h.logger.Errorf("%s : %s", errors.ErrCalendarFeedFailed, err)
+		httperror.WriteJSONError(w, errors.ErrCalendarFeedFailed.Error(), err, errors.HTTPStatus(err, http.StatusInternalServerError))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusCreated)
+
+	if err = json.NewEncoder(w).Encode(feed); err != nil {
+		h.logger.Errorf("Ошибка при отправке ссылки на ленту на клиент: %s", err)
+	}
+}
+
+// Отозвать ссылку на ленту задач
+func (h *CalendarHandler) deleteCalendarFeed(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	ctx := r.Context()
+	userID, ok := r.Context().Value("user_id").(int64)
+	if !ok {
+		h.logger.Error(errors.ErrFailedToGetUserIDFromContext)
+		httperror.WriteJSONError(w, errors.ErrFailedToGetUserIDFromContext.Error(), nil, http.StatusInternalServerError)
+		return
+	}
+
+	if err := h.calendarService.DeleteCalendarFeed(ctx, userID); err != nil {
+		h.logger.Errorf("%s : %s", errors.ErrDeleteCalendarFeed, err)
+		httperror.WriteJSONError(w, errors.ErrDeleteCalendarFeed.Error(), err, errors.HTTPStatus(err, http.StatusInternalServerError))
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// Лента задач VTODO по приватной ссылке (без авторизации). Файл формируется потоком
+func (h *CalendarHandler) getCalendarFeed(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	ctx := r.Context()
+
+	// Токен ленты - секрет: ответ не кешируется, а адрес не передаётся в Referer
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Referrer-Policy", "no-referrer")
+
+	counter := &writeCounter{ResponseWriter: w}
+
+	if err := h.calendarService.WriteCalendarFeed(ctx, calendarToken(ps), counter); err != nil {
+		// Сам токен в журнал не пишем
+		h.logger.Errorf("%s : %s", errors.ErrGetCalendarFeed, err)
+
+		// Если часть файла уже отправлена, статус изменить нельзя - клиент получит оборванный файл
+		if counter.written == 0 {
+			httperror.WriteJSONError(w, errors.ErrGetCalendarFeed.Error(), err, errors.HTTPStatus(err, http.StatusInternalServerError))
+		}
+	}
+}
+
+// Загрузить задачи VTODO, изменённые в календарном клиенте (тело - файл iCalendar)
+func (h *CalendarHandler) syncCalendarFeed(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	ctx := r.Context()
+
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Referrer-Policy", "no-referrer")
+
+	body := http.MaxBytesReader(w, r.Body, maxCalendarFileSize)
+
+	result, err := h.calendarService.SyncCalendarFeed(ctx, calendarToken(ps), body)
+	if err != nil {
+		h.logger.Errorf("%s : %s", errors.ErrSyncCalendar, err)
+		httperror.WriteJSONError(w, errors.ErrSyncCalendar.Error(), err, errors.HTTPStatus(err, http.StatusInternalServerError))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	if err = json.NewEncoder(w).Encode(result); err != nil {
+		h.logger.Errorf("Ошибка при отправке результатов загрузки календаря на клиент: %s", err)
+	}
+}
+
+// calendarToken - токен ленты из пути; календарные клиенты ожидают адрес с расширением .ics
+func calendarToken(ps httprouter.Params) string {
+	return strings.TrimSuffix(ps.ByName("token"), ".ics")
+}
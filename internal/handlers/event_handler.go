@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/config"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/errors"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/events"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/pkg/httperror"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/pkg/logging"
+	"github.com/julienschmidt/httprouter"
+	"net/http"
+	"time"
+)
+
+// Значения по умолчанию, если они не заданы в конфигурации
+const (
+	defaultEventsHeartbeat    = 25 * time.Second
+	defaultEventsWriteTimeout = 10 * time.Second
+)
+
+// eventsRetry - через сколько миллисекунд браузер переподключается после обрыва потока
+const eventsRetry = 5000
+
+// EventHandler обрабатывает поток событий заметок (Server-Sent Events)
+type EventHandler struct {
+	broker       *events.Broker
+	heartbeat    time.Duration
+	writeTimeout time.Duration
+	logger       *logging.Logger
+}
+
+// NewEventHandler создаёт новый обработчик потока событий
+func NewEventHandler(broker *events.Broker, cfg *config.Config, logger *logging.Logger) *EventHandler {
+	heartbeat := cfg.Events.Heartbeat
+	if heartbeat <= 0 {
+		heartbeat = defaultEventsHeartbeat
+	}
+	writeTimeout := cfg.Events.WriteTimeout
+	if writeTimeout <= 0 {
+		writeTimeout = defaultEventsWriteTimeout
+	}
+
+	return &EventHandler{
+		broker:       broker,
+		heartbeat:    heartbeat,
+		writeTimeout: writeTimeout,
+		logger:       logger,
+	}
+}
+
+// Поток событий заметок пользователя: note.created, note.updated, note.completed, note.deleted.
+// Событие содержит только идентификаторы, сами изменения клиент получает через GET /sync.
+// Событие resync означает, что часть событий потеряна (клиент не успевал читать или API
+// переподключался к БД) - нужно запросить GET /sync. Пропущенные при обрыве события не повторяются
+func (h *EventHandler) streamEvents(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	ctx := r.Context()
+	userID, ok := r.Context().Value("user_id").(int64)
+	if !ok {
+		h.logger.Error(errors.ErrFailedToGetUserIDFromContext)
+		httperror.WriteJSONError(w, errors.ErrFailedToGetUserIDFromContext.Error(), nil, http.StatusInternalServerError)
+		return
+	}
+
+	// WriteTimeout сервера оборвал бы поток: снимаем его и ограничиваем каждую запись отдельно
+	rc := http.NewResponseController(w)
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil {
+		h.logger.Errorf("%s : %s", errors.ErrEventStreamUnsupported, err)
+		httperror.WriteJSONError(w, errors.ErrEventStreamUnsupported.Error(), err, http.StatusInternalServerError)
+		return
+	}
+
+	sub, err := h.broker.Subscribe(userID)
+	if err != nil {
+		httperror.WriteJSONError(w, err.Error(), err, errors.HTTPStatus(err, http.StatusInternalServerError))
+		return
+	}
+	defer sub.Close()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no") // Отключаем буферизацию в nginx
+	w.WriteHeader(http.StatusOK)
+
+	// write - отправить сообщение клиенту; клиент, который не принимает данные дольше writeTimeout, отключается
+	write := func(format string, args ...any) error {
+		if err := rc.SetWriteDeadline(time.Now().Add(h.writeTimeout)); err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(w, format, args...); err != nil {
+			return err
+		}
+		return rc.Flush()
+	}
+
+	if err = write("retry: %d\n\n", eventsRetry); err != nil {
+		return
+	}
+
+	ticker := time.NewTicker(h.heartbeat)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case event, ok := <-sub.Events():
+			if !ok {
+				return // Сервер останавливается
+			}
+			data, _ := json.Marshal(event)
+			err = write("id: %d\nevent: note.%s\ndata: %s\n\n", event.Seq, event.Type, data)
+
+		case <-sub.Lagged():
+			err = write("event: resync\ndata: {}\n\n")
+
+		case <-ticker.C:
+			err = write(": ping\n\n")
+		}
+
+		if err != nil {
+			h.logger.Debugf("Поток событий пользователя %d закрыт: %s", userID, err)
+			return
+		}
+	}
+}
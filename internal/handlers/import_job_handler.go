@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/errors"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/service"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/transport/dto/request"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/pkg/httperror"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/pkg/logging"
+	"github.com/julienschmidt/httprouter"
+	"net/http"
+	"strconv"
+)
+
+// ImportJobHandler обрабатывает запросы, связанные с импортом из Todoist, Microsoft To Do и Google Tasks
+type ImportJobHandler struct {
+	importJobService service.ImportJobService
+	logger           *logging.Logger
+}
+
+// NewImportJobHandler создаёт новый обработчик заданий импорта
+func NewImportJobHandler(importJobService service.ImportJobService, logger *logging.Logger) *ImportJobHandler {
+	return &ImportJobHandler{
+		importJobService: importJobService,
+		logger:           logger,
+	}
+}
+
+// Поставить файл экспорта в очередь импорта (?source=todoist|mstodo|gtasks&list=). Тело запроса - файл
+func (h *ImportJobHandler) createImportJob(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	ctx := r.Context()
+	userID, ok := r.Context().Value("user_id").(int64)
+	if !ok {
+		h.logger.Error(errors.ErrFailedToGetUserIDFromContext)
+		httperror.WriteJSONError(w, errors.ErrFailedToGetUserIDFromContext.Error(), nil, http.StatusInternalServerError)
+		return
+	}
+
+	query := r.URL.Query()
+	req := request.ImportJobDTO{
+		Source: query.Get("source"),
+		List:   query.Get("list"),
+	}
+
+	job, err := h.importJobService.CreateImportJob(ctx, userID, req, r.Body)
+	if err != nil {
+		h.logger.Errorf("%s : %s", errors.ErrImportJobFailed, err)
+		httperror.WriteJSONError(w, errors.ErrImportJobFailed.Error(), err, errors.HTTPStatus(err, http.StatusInternalServerError))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Location", fmt.Sprintf("/imports/%d", job.ID))
+	w.WriteHeader(http.StatusAccepted)
+
+	if err = json.NewEncoder(w).Encode(job); err != nil {
+		h.logger.Errorf("Ошибка при отправке задания импорта на клиент: %s", err)
+	}
+}
+
+// Получить последние задания импорта
+func (h *ImportJobHandler) getImportJobs(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	ctx := r.Context()
+	userID, ok := r.Context().Value("user_id").(int64)
+	if !ok {
+		h.logger.Error(errors.ErrFailedToGetUserIDFromContext)
+		httperror.WriteJSONError(w, errors.ErrFailedToGetUserIDFromContext.Error(), nil, http.StatusInternalServerError)
+		return
+	}
+
+	jobs, err := h.importJobService.GetImportJobs(ctx, userID)
+	if err != nil {
+		h.logger.Errorf("%s : %s", errors.ErrGetImportJobs, err)
+		httperror.WriteJSONError(w, errors.ErrGetImportJobs.Error(), err, errors.HTTPStatus(err, http.StatusInternalServerError))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	if err = json.NewEncoder(w).Encode(jobs); err != nil {
+		h.logger.Errorf("Ошибка при отправке заданий импорта на клиент: %s", err)
+	}
+}
+
+// Получить прогресс задания импорта, после завершения - итоговый отчёт
+func (h *ImportJobHandler) getImportJob(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	ctx := r.Context()
+	userID, ok := r.Context().Value("user_id").(int64)
+	if !ok {
+		h.logger.Error(errors.ErrFailedToGetUserIDFromContext)
+		httperror.WriteJSONError(w, errors.ErrFailedToGetUserIDFromContext.Error(), nil, http.StatusInternalServerError)
+		return
+	}
+
+	id, _ := strconv.Atoi(ps.ByName("id"))
+
+	job, err := h.importJobService.GetImportJob(ctx, userID, int64(id))
+	if err != nil {
+		h.logger.Errorf("%s : %v : %s", errors.ErrGetImportJobs, id, err)
+		httperror.WriteJSONError(w, errors.ErrGetImportJobs.Error(), err, errors.HTTPStatus(err, http.StatusInternalServerError))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	if err = json.NewEncoder(w).Encode(job); err != nil {
+		h.logger.Errorf("Ошибка при отправке задания импорта на клиент: %s", err)
+	}
+}
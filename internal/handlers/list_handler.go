@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"encoding/json"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/errors"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/service"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/transport/dto/request"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/pkg/httperror"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/pkg/logging"
+	"github.com/julienschmidt/httprouter"
+	"net/http"
+	"strconv"
+)
+
+// ListHandler обрабатывает запросы, связанные со списками заметок
+type ListHandler struct {
+	listService service.ListService
+	logger      *logging.Logger
+}
+
+// NewListHandler создаёт новый обработчик списков
+func NewListHandler(listService service.ListService, logger *logging.Logger) *ListHandler {
+	return &ListHandler{
+		listService: listService,
+		logger:      logger,
+	}
+}
+
+// Получить все списки пользователя
+func (h *ListHandler) getAllLists(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	ctx := r.Context()
+	userID, ok := r.Context().Value("user_id").(int64)
+	if !ok {
+		h.logger.Error(errors.ErrFailedToGetUserIDFromContext)
+		httperror.WriteJSONError(w, errors.ErrFailedToGetUserIDFromContext.Error(), nil, http.StatusInternalServerError)
+		return
+	}
+
+	lists, err := h.listService.GetAllLists(ctx, userID)
+	if err != nil {
+		h.logger.Errorf("%s: %s", errors.ErrGetLists, err)
+		httperror.WriteJSONError(w, errors.ErrGetLists.Error(), err, errors.HTTPStatus(err, http.StatusInternalServerError))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	if err = json.NewEncoder(w).Encode(lists); err != nil {
+		h.logger.Errorf("Ошибка при отправке списков на клиент: %s", err)
+	}
+}
+
+// Создать список
+func (h *ListHandler) createList(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	ctx := r.Context()
+	userID, ok := r.Context().Value("user_id").(int64)
+	if !ok {
+		h.logger.Error(errors.ErrFailedToGetUserIDFromContext)
+		httperror.WriteJSONError(w, errors.ErrFailedToGetUserIDFromContext.Error(), nil, http.StatusInternalServerError)
+		return
+	}
+
+	var req request.ListDTO
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httperror.WriteJSONError(w, errors.ErrJSONNewDecoder.Error(), err, http.StatusBadRequest)
+		h.logger.Errorf("%s: %s", errors.ErrJSONNewDecoder, err)
+		return
+	}
+
+	list, err := h.listService.CreateList(ctx, userID, req)
+	if err != nil {
+		h.logger.Errorf("%s: %s", errors.ErrListFailed, err)
+		httperror.WriteJSONError(w, errors.ErrListFailed.Error(), err, errors.HTTPStatus(err, http.StatusInternalServerError))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+
+	if err = json.NewEncoder(w).Encode(list); err != nil {
+		h.logger.Errorf("Ошибка при отправке списка на клиент: %s", err)
+	}
+}
+
+// Обновить список
+func (h *ListHandler) updateList(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	ctx := r.Context()
+	userID, ok := r.Context().Value("user_id").(int64)
+	if !ok {
+		h.logger.Error(errors.ErrFailedToGetUserIDFromContext)
+		httperror.WriteJSONError(w, errors.ErrFailedToGetUserIDFromContext.Error(), nil, http.StatusInternalServerError)
+		return
+	}
+
+	var req request.ListDTO
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httperror.WriteJSONError(w, errors.ErrJSONNewDecoder.Error(), err, http.StatusBadRequest)
+		h.logger.Errorf("%s: %s", errors.ErrJSONNewDecoder, err)
+		return
+	}
+
+	id, _ := strconv.Atoi(ps.ByName("id"))
+
+	if err := h.listService.UpdateList(ctx, userID, int64(id), req); err != nil {
+		h.logger.Errorf("%s : %v : %s", errors.ErrListFailed, id, err)
+		httperror.WriteJSONError(w, errors.ErrListFailed.Error(), err, errors.HTTPStatus(err, http.StatusInternalServerError))
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// Удалить список (заметки переносятся в список по умолчанию)
+func (h *ListHandler) deleteList(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	ctx := r.Context()
+	userID, ok := r.Context().Value("user_id").(int64)
+	if !ok {
+		h.logger.Error(errors.ErrFailedToGetUserIDFromContext)
+		httperror.WriteJSONError(w, errors.ErrFailedToGetUserIDFromContext.Error(), nil, http.StatusInternalServerError)
+		return
+	}
+
+	id, _ := strconv.Atoi(ps.ByName("id"))
+
+	if err := h.listService.DeleteList(ctx, userID, int64(id)); err != nil {
+		h.logger.Errorf("%s : %v : %s", errors.ErrDeleteList, id, err)
+		httperror.WriteJSONError(w, errors.ErrDeleteList.Error(), err, errors.HTTPStatus(err, http.StatusInternalServerError))
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"encoding/json"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/errors"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/service"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/transport/dto/request"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/pkg/httperror"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/pkg/logging"
+	"github.com/julienschmidt/httprouter"
+	"net/http"
+	"strconv"
+)
+
+// NoteCommentHandler обрабатывает запросы, связанные с комментариями к заметкам
+type NoteCommentHandler struct {
+	noteCommentService service.NoteCommentService
+	logger             *logging.Logger
+}
+
+// NewNoteCommentHandler создаёт новый обработчик комментариев
+func NewNoteCommentHandler(noteCommentService service.NoteCommentService, logger *logging.Logger) *NoteCommentHandler {
+	return &NoteCommentHandler{
+		noteCommentService: noteCommentService,
+		logger:             logger,
+	}
+}
+
+// Получить комментарии к заметке
+func (h *NoteCommentHandler) getComments(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	ctx := r.Context()
+	userID, ok := r.Context().Value("user_id").(int64)
+	if !ok {
+		h.logger.Error(errors.ErrFailedToGetUserIDFromContext)
+		httperror.WriteJSONError(w, errors.ErrFailedToGetUserIDFromContext.Error(), nil, http.StatusInternalServerError)
+		return
+	}
+
+	noteID, _ := strconv.Atoi(ps.ByName("id"))
+
+	comments, err := h.noteCommentService.GetComments(ctx, userID, int64(noteID))
+	if err != nil {
+		h.logger.Errorf("%s : %v : %s", errors.ErrGetComments, noteID, err)
+		httperror.WriteJSONError(w, errors.ErrGetComments.Error(), err, errors.HTTPStatus(err, http.StatusInternalServerError))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	if err = json.NewEncoder(w).Encode(comments); err != nil {
+		h.logger.Errorf("Ошибка при отправке комментариев на клиент: %s", err)
+	}
+}
+
+// Добавить комментарий к заметке
+func (h *NoteCommentHandler) createComment(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	ctx := r.Context()
+	userID, ok := r.Context().Value("user_id").(int64)
+	if !ok {
+		h.logger.Error(errors.ErrFailedToGetUserIDFromContext)
+		httperror.WriteJSONError(w, errors.ErrFailedToGetUserIDFromContext.Error(), nil, http.StatusInternalServerError)
+		return
+	}
+
+	var req request.CommentDTO
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httperror.WriteJSONError(w, errors.ErrJSONNewDecoder.Error(), err, http.StatusBadRequest)
+		h.logger.Errorf("%s: %s", errors.ErrJSONNewDecoder, err)
+		return
+	}
+
+	noteID, _ := strconv.Atoi(ps.ByName("id"))
+
+	comment, err := h.noteCommentService.CreateComment(ctx, userID, int64(noteID), req)
+	if err != nil {
+		h.logger.Errorf("%s : %v : %s", errors.ErrCommentFailed, noteID, err)
+		httperror.WriteJSONError(w, errors.ErrCommentFailed.Error(), err, errors.HTTPStatus(err, http.StatusInternalServerError))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+
+	if err = json.NewEncoder(w).Encode(comment); err != nil {
+		h.logger.Errorf("Ошибка при отправке комментария на клиент: %s", err)
+	}
+}
+
+// Изменить комментарий
+func (h *NoteCommentHandler) updateComment(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	ctx := r.Context()
+	userID, ok := r.Context().Value("user_id").(int64)
+	if !ok {
+		h.logger.Error(errors.ErrFailedToGetUserIDFromContext)
+		httperror.WriteJSONError(w, errors.ErrFailedToGetUserIDFromContext.Error(), nil, http.StatusInternalServerError)
+		return
+	}
+
+	var req request.CommentDTO
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httperror.WriteJSONError(w, errors.ErrJSONNewDecoder.Error(), err, http.StatusBadRequest)
+		h.logger.Errorf("%s: %s", errors.ErrJSONNewDecoder, err)
+		return
+	}
+
+	noteID, _ := strconv.Atoi(ps.ByName("id"))
+	commentID, _ := strconv.Atoi(ps.ByName("commentId"))
+
+	if err := h.noteCommentService.UpdateComment(ctx, userID, int64(noteID), int64(commentID), req); err != nil {
+		h.logger.Errorf("%s : %v : %v : %s", errors.ErrCommentFailed, noteID, commentID, err)
+		httperror.WriteJSONError(w, errors.ErrCommentFailed.Error(), err, errors.HTTPStatus(err, http.StatusInternalServerError))
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// Удалить комментарий
+func (h *NoteCommentHandler) deleteComment(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	ctx := r.Context()
+	userID, ok := r.Context().Value("user_id").(int64)
+	if !ok {
+		h.logger.Error(errors.ErrFailedToGetUserIDFromContext)
+		httperror.WriteJSONError(w, errors.ErrFailedToGetUserIDFromContext.Error(), nil, http.StatusInternalServerError)
+		return
+	}
+
+	noteID, _ := strconv.Atoi(ps.ByName("id"))
+	commentID, _ := strconv.Atoi(ps.ByName("commentId"))
+
+	if err := h.noteCommentService.DeleteComment(ctx, userID, int64(noteID), int64(commentID)); err != nil {
+		h.logger.Errorf("%s : %v : %v : %s", errors.ErrDeleteComment, noteID, commentID, err)
+		httperror.WriteJSONError(w, errors.ErrDeleteComment.Error(), err, errors.HTTPStatus(err, http.StatusInternalServerError))
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
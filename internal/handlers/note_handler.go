@@ -3,8 +3,10 @@ package handlers
 import (
 	"encoding/json"
 	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/errors"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/models"
 	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/service"
 	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/transport/dto/request"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/pkg/etag"
 	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/pkg/httperror"
 	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/pkg/logging"
 	"github.com/julienschmidt/httprouter"
@@ -36,20 +38,170 @@ func (h *NoteHandler) getAllNotes(w http.ResponseWriter, r *http.Request, _ http
 		return
 	}
 
-	// GetAllNotes - получаем все заметки
-	allNotes, err := h.noteService.GetAllNotes(ctx, userID)
+	// GetAllNotes - получаем страницу заметок
+	allNotes, err := h.noteService.GetAllNotes(ctx, userID, notesQuery(r))
 	if err != nil {
-		h.logger.Errorf("Ошибка при получения всех заметок", err)
-		httperror.WriteJSONError(w, "Ошибка при получения всех заметок", err, http.StatusInternalServerError)
+		h.logger.Errorf("Ошибка при получения всех заметок: %s", err)
+		httperror.WriteJSONError(w, "Ошибка при получения всех заметок", err, errors.HTTPStatus(err, http.StatusInternalServerError))
+		return
+	}
+
+	// Список отправляется с ETag: если с прошлого запроса ничего не изменилось, клиент получит 304
+	if err = etag.WriteJSON(w, r, "", allNotes); err != nil {
+		h.logger.Errorf("Ошибка при отправке заметок на клиент: %s", err)
+	}
+}
+
+// Получить заметку (свою или общую). ETag - версия заметки, её можно передать в If-Match при изменении
+func (h *NoteHandler) getNote(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	ctx := r.Context()
+	userID, ok := r.Context().Value("user_id").(int64)
+	if !ok {
+		h.logger.Error(errors.ErrFailedToGetUserIDFromContext)
+		httperror.WriteJSONError(w, errors.ErrFailedToGetUserIDFromContext.Error(), nil, http.StatusInternalServerError)
+		return
+	}
+
+	id, _ := strconv.Atoi(ps.ByName("id"))
+
+	note, err := h.noteService.GetNote(ctx, userID, int64(id))
+	if err != nil {
+		h.logger.Errorf("%s : %v : %s", errors.ErrGetNote, id, err)
+		httperror.WriteJSONError(w, errors.ErrGetNote.Error(), err, errors.HTTPStatus(err, http.StatusInternalServerError))
+		return
+	}
+
+	if err = etag.WriteJSON(w, r, etag.FromVersion(note.Version), note); err != nil {
+		h.logger.Errorf("Ошибка при отправке заметки на клиент: %s", err)
+	}
+}
+
+// Получить заметки конкретного списка (те же фильтры и пагинация, что и у GET /notes)
+func (h *NoteHandler) getListNotes(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	ctx := r.Context()
+	userID, ok := r.Context().Value("user_id").(int64)
+	if !ok {
+		h.logger.Error(errors.ErrFailedToGetUserIDFromContext)
+		httperror.WriteJSONError(w, errors.ErrFailedToGetUserIDFromContext.Error(), nil, http.StatusInternalServerError)
+		return
+	}
+
+	req := notesQuery(r)
+	req.ListID = ps.ByName("id")
+
+	notes, err := h.noteService.GetAllNotes(ctx, userID, req)
+	if err != nil {
+		h.logger.Errorf("Ошибка при получения заметок списка %v: %s", req.ListID, err)
+		httperror.WriteJSONError(w, "Ошибка при получения заметок списка", err, errors.HTTPStatus(err, http.StatusInternalServerError))
+		return
+	}
+
+	if err = etag.WriteJSON(w, r, "", notes); err != nil {
+		h.logger.Errorf("Ошибка при отправке заметок на клиент: %s", err)
+	}
+}
+
+// notesQuery - параметры списка заметок из query string
+func notesQuery(r *http.Request) request.GetNotesDTO {
+	query := r.URL.Query()
+	return request.GetNotesDTO{
+		ListID:        query.Get("list_id"),
+		Completed:     query.Get("completed"),
+		CreatedAfter:  query.Get("created_after"),
+		CreatedBefore: query.Get("created_before"),
+		Tags:          query.Get("tags"),
+		TagsMode:      query.Get("tags_mode"),
+		Sort:          query.Get("sort"),
+		Order:         query.Get("order"),
+		Limit:         query.Get("limit"),
+		Cursor:        query.Get("cursor"),
+	}
+}
+
+// Полнотекстовый поиск по заметкам
+func (h *NoteHandler) searchNotes(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	ctx := r.Context()
+	userID, ok := r.Context().Value("user_id").(int64)
+	if !ok {
+		h.logger.Error(errors.ErrFailedToGetUserIDFromContext)
+		httperror.WriteJSONError(w, errors.ErrFailedToGetUserIDFromContext.Error(), nil, http.StatusInternalServerError)
+		return
+	}
+
+	query := r.URL.Query()
+	req := request.SearchNotesDTO{
+		Query: query.Get("q"),
+		Lang:  query.Get("lang"),
+		Limit: query.Get("limit"),
+	}
+
+	results, err := h.noteService.SearchNotes(ctx, userID, req)
+	if err != nil {
+		h.logger.Errorf("%s: %s", errors.ErrSearchNotes, err)
+		httperror.WriteJSONError(w, errors.ErrSearchNotes.Error(), err, errors.HTTPStatus(err, http.StatusInternalServerError))
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 
-	if err = json.NewEncoder(w).Encode(allNotes); err != nil {
-		h.logger.Errorf("Ошибка при отправке заметок на клиент", err)
-		httperror.WriteJSONError(w, "Ошибка при отправке заметок на клиент", err, http.StatusInternalServerError)
+	if err = json.NewEncoder(w).Encode(results); err != nil {
+		h.logger.Errorf("Ошибка при отправке результатов поиска на клиент: %s", err)
+	}
+}
+
+// Невыполненные заметки со сроком на сегодня
+func (h *NoteHandler) getTodayNotes(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	ctx := r.Context()
+	userID, ok := r.Context().Value("user_id").(int64)
+	if !ok {
+		h.logger.Error(errors.ErrFailedToGetUserIDFromContext)
+		httperror.WriteJSONError(w, errors.ErrFailedToGetUserIDFromContext.Error(), nil, http.StatusInternalServerError)
+		return
+	}
+
+	notes, err := h.noteService.GetTodayNotes(ctx, userID)
+	h.writeNotes(w, r, notes, err)
+}
+
+// Просроченные заметки
+func (h *NoteHandler) getOverdueNotes(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	ctx := r.Context()
+	userID, ok := r.Context().Value("user_id").(int64)
+	if !ok {
+		h.logger.Error(errors.ErrFailedToGetUserIDFromContext)
+		httperror.WriteJSONError(w, errors.ErrFailedToGetUserIDFromContext.Error(), nil, http.StatusInternalServerError)
+		return
+	}
+
+	notes, err := h.noteService.GetOverdueNotes(ctx, userID)
+	h.writeNotes(w, r, notes, err)
+}
+
+// Предстоящие заметки (по умолчанию на 7 дней вперёд, ?days=N)
+func (h *NoteHandler) getUpcomingNotes(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	ctx := r.Context()
+	userID, ok := r.Context().Value("user_id").(int64)
+	if !ok {
+		h.logger.Error(errors.ErrFailedToGetUserIDFromContext)
+		httperror.WriteJSONError(w, errors.ErrFailedToGetUserIDFromContext.Error(), nil, http.StatusInternalServerError)
+		return
+	}
+
+	notes, err := h.noteService.GetUpcomingNotes(ctx, userID, r.URL.Query().Get("days"))
+	h.writeNotes(w, r, notes, err)
+}
+
+// writeNotes - отправляет список заметок клиенту (с ETag, 304 при совпадении If-None-Match) или ошибку его получения
+func (h *NoteHandler) writeNotes(w http.ResponseWriter, r *http.Request, notes []models.AllNotes, err error) {
+	if err != nil {
+		h.logger.Errorf("Ошибка при получения заметок: %s", err)
+		httperror.WriteJSONError(w, "Ошибка при получения заметок", err, errors.HTTPStatus(err, http.StatusInternalServerError))
+		return
+	}
+
+	if err = etag.WriteJSON(w, r, "", notes); err != nil {
+		h.logger.Errorf("Ошибка при отправке заметок на клиент: %s", err)
 	}
 }
 
@@ -73,17 +225,63 @@ func (h *NoteHandler) createPost(w http.ResponseWriter, r *http.Request, _ httpr
 	}
 
 	// ValidateTheNoteBeforeInserting - валидация заметки перед вставкой
-	if err := h.noteService.ValidateNoteBeforeInserting(ctx, userID, req.Note); err != nil {
-		httperror.WriteJSONError(w, "Ошибка при добавлении новой заметки", err, http.StatusInternalServerError)
+	if err := h.noteService.ValidateNoteBeforeInserting(ctx, userID, req); err != nil {
+		httperror.WriteJSONError(w, "Ошибка при добавлении новой заметки", err, errors.HTTPStatus(err, http.StatusInternalServerError))
 		return
 	}
 
 	w.WriteHeader(http.StatusNoContent)
 }
 
+// Пакет операций с заметками в одной транзакции.
+// Если в режиме atomic пакет откатился, возвращается 422 с результатами операций
+func (h *NoteHandler) batchNotes(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	ctx := r.Context()
+	userID, ok := r.Context().Value("user_id").(int64)
+	if !ok {
+		h.logger.Error(errors.ErrFailedToGetUserIDFromContext)
+		httperror.WriteJSONError(w, errors.ErrFailedToGetUserIDFromContext.Error(), nil, http.StatusInternalServerError)
+		return
+	}
+
+	var req request.NoteBatchDTO
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httperror.WriteJSONError(w, errors.ErrJSONNewDecoder.Error(), err, http.StatusBadRequest)
+		h.logger.Errorf("%s: %s", errors.ErrJSONNewDecoder, err)
+		return
+	}
+
+	result, err := h.noteService.ExecuteNoteBatch(ctx, userID, req)
+	if err != nil {
+		h.logger.Errorf("%s : %s", errors.ErrNoteBatch, err)
+		httperror.WriteJSONError(w, errors.ErrNoteBatch.Error(), err, errors.HTTPStatus(err, http.StatusInternalServerError))
+		return
+	}
+
+	status := http.StatusOK
+	if !result.Committed {
+		status = http.StatusUnprocessableEntity
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	if err = json.NewEncoder(w).Encode(result); err != nil {
+		h.logger.Errorf("Ошибка при отправке результатов пакета на клиент: %s", err)
+	}
+}
+
 // Обновить заметку
 func (h *NoteHandler) updateNote(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
 	ctx := r.Context()
+	userID, ok := r.Context().Value("user_id").(int64)
+	if !ok {
+		h.logger.Error(errors.ErrFailedToGetUserIDFromContext)
+		httperror.WriteJSONError(w, errors.ErrFailedToGetUserIDFromContext.Error(), nil, http.StatusInternalServerError)
+		return
+	}
+
 	var req request.UpdateNoteDTO
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -95,24 +293,33 @@ func (h *NoteHandler) updateNote(w http.ResponseWriter, r *http.Request, ps http
 
 	id, _ := strconv.Atoi(ps.ByName("id"))
 
-	// UpdateNoteDataValidation - обновление заметки, валидация данных
-	if err := h.noteService.UpdateNoteDataValidation(ctx, int64(id), req.Note); err != nil {
-		httperror.WriteJSONError(w, "Ошибка при обновления записи в БД", err, http.StatusInternalServerError)
+	// UpdateNoteDataValidation - обновление заметки, валидация данных.
+	// Если передан If-Match, заметка обновляется, только если её версия не изменилась (иначе 412)
+	version, err := h.noteService.UpdateNoteDataValidation(ctx, userID, int64(id), req, etag.ParseVersions(r.Header.Get("If-Match")))
+	if err != nil {
+		httperror.WriteJSONError(w, "Ошибка при обновления записи в БД", err, errors.HTTPStatus(err, http.StatusInternalServerError))
 		h.logger.Errorf("Ошибка при обновлении записи по id: %v %s", id, err)
 		return
 	}
 
+	w.Header().Set("ETag", etag.FromVersion(version))
 	w.WriteHeader(http.StatusOK)
 }
 
 // Удалить конкретную заметку
 func (h *NoteHandler) deleteNote(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
 	ctx := r.Context()
+	userID, ok := r.Context().Value("user_id").(int64)
+	if !ok {
+		h.logger.Error(errors.ErrFailedToGetUserIDFromContext)
+		httperror.WriteJSONError(w, errors.ErrFailedToGetUserIDFromContext.Error(), nil, http.StatusInternalServerError)
+		return
+	}
 
 	id, _ := strconv.Atoi(ps.ByName("id"))
 
-	if err := h.noteService.DeleteNote(ctx, int64(id)); err != nil {
-		httperror.WriteJSONError(w, errors.ErrDeleteNote.Error(), err, http.StatusInternalServerError)
+	if err := h.noteService.DeleteNote(ctx, userID, int64(id)); err != nil {
+		httperror.WriteJSONError(w, errors.ErrDeleteNote.Error(), err, errors.HTTPStatus(err, http.StatusInternalServerError))
 		h.logger.Errorf("%s : %v : %s", errors.ErrDeleteNote, id, err)
 		return
 	}
@@ -123,6 +330,12 @@ func (h *NoteHandler) deleteNote(w http.ResponseWriter, r *http.Request, ps http
 // Отметить заметку выполненной
 func (h *NoteHandler) markNoteCompleted(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
 	ctx := r.Context()
+	userID, ok := r.Context().Value("user_id").(int64)
+	if !ok {
+		h.logger.Error(errors.ErrFailedToGetUserIDFromContext)
+		httperror.WriteJSONError(w, errors.ErrFailedToGetUserIDFromContext.Error(), nil, http.StatusInternalServerError)
+		return
+	}
 
 	id, _ := strconv.Atoi(ps.ByName("id"))
 
@@ -135,13 +348,15 @@ func (h *NoteHandler) markNoteCompleted(w http.ResponseWriter, r *http.Request,
 		return
 	}
 
-	// MarkNoteCompleted - Отметить заметку выполненной, валидация данных
-	if err := h.noteService.MarkNoteCompleted(ctx, int64(id), req.Check); err != nil {
-		httperror.WriteJSONError(w, errors.ErrNoteToUpdate.Error(), err, http.StatusInternalServerError)
+	// MarkNoteCompleted - Отметить заметку выполненной, валидация данных (с проверкой If-Match, как при обновлении)
+	version, err := h.noteService.MarkNoteCompleted(ctx, userID, int64(id), req, etag.ParseVersions(r.Header.Get("If-Match")))
+	if err != nil {
+		httperror.WriteJSONError(w, errors.ErrNoteToUpdate.Error(), err, errors.HTTPStatus(err, http.StatusInternalServerError))
 		h.logger.Errorf("%s : %v : %s", errors.ErrNoteToUpdate, id, err)
 		return
 	}
 
+	w.Header().Set("ETag", etag.FromVersion(version))
 	w.WriteHeader(http.StatusOK)
 }
 
@@ -158,7 +373,7 @@ func (h *NoteHandler) deleteAllNotes(w http.ResponseWriter, r *http.Request, _ h
 
 	if err := h.noteService.DeleteAllNotes(ctx, userID); err != nil {
 		h.logger.Errorf("%s: %s", errors.ErrDeletingAllNotes, err)
-		httperror.WriteJSONError(w, errors.ErrDeletingAllNotes.Error(), err, http.StatusInternalServerError)
+		httperror.WriteJSONError(w, errors.ErrDeletingAllNotes.Error(), err, errors.HTTPStatus(err, http.StatusInternalServerError))
 		return
 	}
 
@@ -176,9 +391,141 @@ func (h *NoteHandler) deleteAllCompletedNotes(w http.ResponseWriter, r *http.Req
 		return
 	}
 
-	if err := h.noteService.DeleteAllCompletedNotes(ctx, userID); err != nil {
+	if err := h.noteService.DeleteAllCompletedNotes(ctx, userID, 0); err != nil {
 		h.logger.Errorf("%s: %s", errors.ErrDeletingAllNotes, err)
-		httperror.WriteJSONError(w, errors.ErrDeletingAllNotes.Error(), err, http.StatusInternalServerError)
+		httperror.WriteJSONError(w, errors.ErrDeletingAllNotes.Error(), err, errors.HTTPStatus(err, http.StatusInternalServerError))
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// Удалить все выполненные заметки списка
+func (h *NoteHandler) deleteListCompletedNotes(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	ctx := r.Context()
+	userID, ok := r.Context().Value("user_id").(int64)
+	if !ok {
+		h.logger.Error(errors.ErrFailedToGetUserIDFromContext)
+		httperror.WriteJSONError(w, errors.ErrFailedToGetUserIDFromContext.Error(), nil, http.StatusInternalServerError)
+		return
+	}
+
+	listID, _ := strconv.Atoi(ps.ByName("id"))
+
+	if err := h.noteService.DeleteAllCompletedNotes(ctx, userID, int64(listID)); err != nil {
+		h.logger.Errorf("%s : %v : %s", errors.ErrDeletingAllNotes, listID, err)
+		httperror.WriteJSONError(w, errors.ErrDeletingAllNotes.Error(), err, errors.HTTPStatus(err, http.StatusInternalServerError))
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// Перенести заметку в другой список
+func (h *NoteHandler) moveNote(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	ctx := r.Context()
+	userID, ok := r.Context().Value("user_id").(int64)
+	if !ok {
+		h.logger.Error(errors.ErrFailedToGetUserIDFromContext)
+		httperror.WriteJSONError(w, errors.ErrFailedToGetUserIDFromContext.Error(), nil, http.StatusInternalServerError)
+		return
+	}
+
+	var req request.MoveNoteDTO
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httperror.WriteJSONError(w, errors.ErrJSONNewDecoder.Error(), err, http.StatusBadRequest)
+		h.logger.Errorf("%s: %s", errors.ErrJSONNewDecoder, err)
+		return
+	}
+
+	id, _ := strconv.Atoi(ps.ByName("id"))
+
+	if err := h.noteService.MoveNote(ctx, userID, int64(id), req.ListID); err != nil {
+		h.logger.Errorf("%s : %v : %s", errors.ErrMoveNote, id, err)
+		httperror.WriteJSONError(w, errors.ErrMoveNote.Error(), err, errors.HTTPStatus(err, http.StatusInternalServerError))
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// Переместить заметку между соседями (ручная сортировка)
+func (h *NoteHandler) moveNotePosition(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	ctx := r.Context()
+	userID, ok := r.Context().Value("user_id").(int64)
+	if !ok {
+		h.logger.Error(errors.ErrFailedToGetUserIDFromContext)
+		httperror.WriteJSONError(w, errors.ErrFailedToGetUserIDFromContext.Error(), nil, http.StatusInternalServerError)
+		return
+	}
+
+	var req request.NotePositionDTO
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httperror.WriteJSONError(w, errors.ErrJSONNewDecoder.Error(), err, http.StatusBadRequest)
+		h.logger.Errorf("%s: %s", errors.ErrJSONNewDecoder, err)
+		return
+	}
+
+	id, _ := strconv.Atoi(ps.ByName("id"))
+
+	if err := h.noteService.MoveNotePosition(ctx, userID, int64(id), req); err != nil {
+		h.logger.Errorf("%s : %v : %s", errors.ErrReorderNote, id, err)
+		httperror.WriteJSONError(w, errors.ErrReorderNote.Error(), err, errors.HTTPStatus(err, http.StatusInternalServerError))
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// Получить заметки из корзины
+func (h *NoteHandler) getTrash(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	userID, ok := r.Context().Value("user_id").(int64)
+	if !ok {
+		h.logger.Error(errors.ErrFailedToGetUserIDFromContext)
+		httperror.WriteJSONError(w, errors.ErrFailedToGetUserIDFromContext.Error(), nil, http.StatusInternalServerError)
+		return
+	}
+
+	notes, err := h.noteService.GetTrash(r.Context(), userID)
+	h.writeNotes(w, r, notes, err)
+}
+
+// Восстановить заметку из корзины
+func (h *NoteHandler) restoreNote(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	ctx := r.Context()
+	userID, ok := r.Context().Value("user_id").(int64)
+	if !ok {
+		h.logger.Error(errors.ErrFailedToGetUserIDFromContext)
+		httperror.WriteJSONError(w, errors.ErrFailedToGetUserIDFromContext.Error(), nil, http.StatusInternalServerError)
+		return
+	}
+
+	id, _ := strconv.Atoi(ps.ByName("id"))
+
+	if err := h.noteService.RestoreNote(ctx, userID, int64(id)); err != nil {
+		h.logger.Errorf("%s : %v : %s", errors.ErrRestoreNote, id, err)
+		httperror.WriteJSONError(w, errors.ErrRestoreNote.Error(), err, errors.HTTPStatus(err, http.StatusInternalServerError))
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// Очистить корзину (окончательно удалить заметки)
+func (h *NoteHandler) emptyTrash(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	ctx := r.Context()
+	userID, ok := r.Context().Value("user_id").(int64)
+	if !ok {
+		h.logger.Error(errors.ErrFailedToGetUserIDFromContext)
+		httperror.WriteJSONError(w, errors.ErrFailedToGetUserIDFromContext.Error(), nil, http.StatusInternalServerError)
+		return
+	}
+
+	if err := h.noteService.EmptyTrash(ctx, userID); err != nil {
+		h.logger.Errorf("%s: %s", errors.ErrEmptyTrash, err)
+		httperror.WriteJSONError(w, errors.ErrEmptyTrash.Error(), err, errors.HTTPStatus(err, http.StatusInternalServerError))
 		return
 	}
 
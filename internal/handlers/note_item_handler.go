@@ -0,0 +1,170 @@
+package handlers
+
+import (
+	"encoding/json"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/errors"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/service"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/transport/dto/request"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/pkg/httperror"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/pkg/logging"
+	"github.com/julienschmidt/httprouter"
+	"net/http"
+	"strconv"
+)
+
+// NoteItemHandler обрабатывает запросы, связанные с пунктами чек-листа заметки
+type NoteItemHandler struct {
+	noteItemService service.NoteItemService
+	logger          *logging.Logger
+}
+
+// NewNoteItemHandler создаёт новый обработчик пунктов чек-листа
+func NewNoteItemHandler(noteItemService service.NoteItemService, logger *logging.Logger) *NoteItemHandler {
+	return &NoteItemHandler{
+		noteItemService: noteItemService,
+		logger:          logger,
+	}
+}
+
+// Получить пункты чек-листа заметки
+func (h *NoteItemHandler) getItems(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	ctx := r.Context()
+	userID, ok := r.Context().Value("user_id").(int64)
+	if !ok {
+		h.logger.Error(errors.ErrFailedToGetUserIDFromContext)
+		httperror.WriteJSONError(w, errors.ErrFailedToGetUserIDFromContext.Error(), nil, http.StatusInternalServerError)
+		return
+	}
+
+	noteID, _ := strconv.Atoi(ps.ByName("id"))
+
+	items, err := h.noteItemService.GetItems(ctx, userID, int64(noteID))
+	if err != nil {
+		h.logger.Errorf("%s : %v : %s", errors.ErrGetItems, noteID, err)
+		httperror.WriteJSONError(w, errors.ErrGetItems.Error(), err, errors.HTTPStatus(err, http.StatusInternalServerError))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	if err = json.NewEncoder(w).Encode(items); err != nil {
+		h.logger.Errorf("Ошибка при отправке пунктов чек-листа на клиент: %s", err)
+	}
+}
+
+// Добавить пункт чек-листа
+func (h *NoteItemHandler) createItem(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	ctx := r.Context()
+	userID, ok := r.Context().Value("user_id").(int64)
+	if !ok {
+		h.logger.Error(errors.ErrFailedToGetUserIDFromContext)
+		httperror.WriteJSONError(w, errors.ErrFailedToGetUserIDFromContext.Error(), nil, http.StatusInternalServerError)
+		return
+	}
+
+	var req request.CreateItemDTO
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httperror.WriteJSONError(w, errors.ErrJSONNewDecoder.Error(), err, http.StatusBadRequest)
+		h.logger.Errorf("%s: %s", errors.ErrJSONNewDecoder, err)
+		return
+	}
+
+	noteID, _ := strconv.Atoi(ps.ByName("id"))
+
+	item, err := h.noteItemService.CreateItem(ctx, userID, int64(noteID), req)
+	if err != nil {
+		h.logger.Errorf("%s : %v : %s", errors.ErrItemFailed, noteID, err)
+		httperror.WriteJSONError(w, errors.ErrItemFailed.Error(), err, errors.HTTPStatus(err, http.StatusInternalServerError))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+
+	if err = json.NewEncoder(w).Encode(item); err != nil {
+		h.logger.Errorf("Ошибка при отправке пункта чек-листа на клиент: %s", err)
+	}
+}
+
+// Изменить пункт чек-листа
+func (h *NoteItemHandler) updateItem(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	ctx := r.Context()
+	userID, ok := r.Context().Value("user_id").(int64)
+	if !ok {
+		h.logger.Error(errors.ErrFailedToGetUserIDFromContext)
+		httperror.WriteJSONError(w, errors.ErrFailedToGetUserIDFromContext.Error(), nil, http.StatusInternalServerError)
+		return
+	}
+
+	var req request.UpdateItemDTO
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httperror.WriteJSONError(w, errors.ErrJSONNewDecoder.Error(), err, http.StatusBadRequest)
+		h.logger.Errorf("%s: %s", errors.ErrJSONNewDecoder, err)
+		return
+	}
+
+	noteID, _ := strconv.Atoi(ps.ByName("id"))
+	itemID, _ := strconv.Atoi(ps.ByName("itemId"))
+
+	if err := h.noteItemService.UpdateItem(ctx, userID, int64(noteID), int64(itemID), req); err != nil {
+		h.logger.Errorf("%s : %v : %v : %s", errors.ErrItemFailed, noteID, itemID, err)
+		httperror.WriteJSONError(w, errors.ErrItemFailed.Error(), err, errors.HTTPStatus(err, http.StatusInternalServerError))
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// Удалить пункт чек-листа
+func (h *NoteItemHandler) deleteItem(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	ctx := r.Context()
+	userID, ok := r.Context().Value("user_id").(int64)
+	if !ok {
+		h.logger.Error(errors.ErrFailedToGetUserIDFromContext)
+		httperror.WriteJSONError(w, errors.ErrFailedToGetUserIDFromContext.Error(), nil, http.StatusInternalServerError)
+		return
+	}
+
+	noteID, _ := strconv.Atoi(ps.ByName("id"))
+	itemID, _ := strconv.Atoi(ps.ByName("itemId"))
+
+	if err := h.noteItemService.DeleteItem(ctx, userID, int64(noteID), int64(itemID)); err != nil {
+		h.logger.Errorf("%s : %v : %v : %s", errors.ErrDeleteItem, noteID, itemID, err)
+		httperror.WriteJSONError(w, errors.ErrDeleteItem.Error(), err, errors.HTTPStatus(err, http.StatusInternalServerError))
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// Изменить порядок пунктов чек-листа
+func (h *NoteItemHandler) reorderItems(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	ctx := r.Context()
+	userID, ok := r.Context().Value("user_id").(int64)
+	if !ok {
+		h.logger.Error(errors.ErrFailedToGetUserIDFromContext)
+		httperror.WriteJSONError(w, errors.ErrFailedToGetUserIDFromContext.Error(), nil, http.StatusInternalServerError)
+		return
+	}
+
+	var req request.ReorderItemsDTO
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httperror.WriteJSONError(w, errors.ErrJSONNewDecoder.Error(), err, http.StatusBadRequest)
+		h.logger.Errorf("%s: %s", errors.ErrJSONNewDecoder, err)
+		return
+	}
+
+	noteID, _ := strconv.Atoi(ps.ByName("id"))
+
+	if err := h.noteItemService.ReorderItems(ctx, userID, int64(noteID), req); err != nil {
+		h.logger.Errorf("%s : %v : %s", errors.ErrReorderItems, noteID, err)
+		httperror.WriteJSONError(w, errors.ErrReorderItems.Error(), err, errors.HTTPStatus(err, http.StatusInternalServerError))
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
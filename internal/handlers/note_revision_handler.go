@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"encoding/json"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/errors"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/service"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/transport/dto/request"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/pkg/httperror"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/pkg/logging"
+	"github.com/julienschmidt/httprouter"
+	"net/http"
+	"strconv"
+)
+
+// NoteRevisionHandler обрабатывает запросы, связанные с историей изменений заметок
+type NoteRevisionHandler struct {
+	noteRevisionService service.NoteRevisionService
+	logger              *logging.Logger
+}
+
+// NewNoteRevisionHandler создаёт новый обработчик истории изменений
+func NewNoteRevisionHandler(noteRevisionService service.NoteRevisionService, logger *logging.Logger) *NoteRevisionHandler {
+	return &NoteRevisionHandler{
+		noteRevisionService: noteRevisionService,
+		logger:              logger,
+	}
+}
+
+// Получить историю изменений заметки
+func (h *NoteRevisionHandler) getHistory(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	ctx := r.Context()
+	userID, ok := r.Context().Value("user_id").(int64)
+	if !ok {
+		h.logger.Error(errors.ErrFailedToGetUserIDFromContext)
+		httperror.WriteJSONError(w, errors.ErrFailedToGetUserIDFromContext.Error(), nil, http.StatusInternalServerError)
+		return
+	}
+
+	noteID, _ := strconv.Atoi(ps.ByName("id"))
+
+	revisions, err := h.noteRevisionService.GetHistory(ctx, userID, int64(noteID))
+	if err != nil {
+		h.logger.Errorf("%s : %v : %s", errors.ErrGetRevisions, noteID, err)
+		httperror.WriteJSONError(w, errors.ErrGetRevisions.Error(), err, errors.HTTPStatus(err, http.StatusInternalServerError))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	if err = json.NewEncoder(w).Encode(revisions); err != nil {
+		h.logger.Errorf("Ошибка при отправке истории заметки на клиент: %s", err)
+	}
+}
+
+// Сравнить текст заметки между ревизиями
+func (h *NoteRevisionHandler) diffRevisions(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	ctx := r.Context()
+	userID, ok := r.Context().Value("user_id").(int64)
+	if !ok {
+		h.logger.Error(errors.ErrFailedToGetUserIDFromContext)
+		httperror.WriteJSONError(w, errors.ErrFailedToGetUserIDFromContext.Error(), nil, http.StatusInternalServerError)
+		return
+	}
+
+	noteID, _ := strconv.Atoi(ps.ByName("id"))
+
+	query := r.URL.Query()
+	diff, err := h.noteRevisionService.DiffRevisions(ctx, userID, int64(noteID), request.DiffRevisionsDTO{
+		From: query.Get("from"),
+		To:   query.Get("to"),
+	})
+	if err != nil {
+		h.logger.Errorf("%s : %v : %s", errors.ErrDiffRevision, noteID, err)
+		httperror.WriteJSONError(w, errors.ErrDiffRevision.Error(), err, errors.HTTPStatus(err, http.StatusInternalServerError))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	if err = json.NewEncoder(w).Encode(diff); err != nil {
+		h.logger.Errorf("Ошибка при отправке различий ревизий на клиент: %s", err)
+	}
+}
+
+// Вернуть заметку к ревизии
+func (h *NoteRevisionHandler) revertNote(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	ctx := r.Context()
+	userID, ok := r.Context().Value("user_id").(int64)
+	if !ok {
+		h.logger.Error(errors.ErrFailedToGetUserIDFromContext)
+		httperror.WriteJSONError(w, errors.ErrFailedToGetUserIDFromContext.Error(), nil, http.StatusInternalServerError)
+		return
+	}
+
+	noteID, _ := strconv.Atoi(ps.ByName("id"))
+	rev, _ := strconv.Atoi(ps.ByName("rev"))
+
+	if err := h.noteRevisionService.RevertNote(ctx, userID, int64(noteID), rev); err != nil {
+		h.logger.Errorf("%s : %v : %v : %s", errors.ErrRevertNote, noteID, rev, err)
+		httperror.WriteJSONError(w, errors.ErrRevertNote.Error(), err, errors.HTTPStatus(err, http.StatusInternalServerError))
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
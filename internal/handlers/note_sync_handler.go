@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"encoding/json"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/errors"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/transport/dto/request"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/pkg/httperror"
+	"github.com/julienschmidt/httprouter"
+	"net/http"
+)
+
+// Изменения заметок с момента предыдущей синхронизации (для клиентов, работающих без сети)
+func (h *NoteHandler) getSyncChanges(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	ctx := r.Context()
+	userID, ok := r.Context().Value("user_id").(int64)
+	if !ok {
+		h.logger.Error(errors.ErrFailedToGetUserIDFromContext)
+		httperror.WriteJSONError(w, errors.ErrFailedToGetUserIDFromContext.Error(), nil, http.StatusInternalServerError)
+		return
+	}
+
+	query := r.URL.Query()
+	req := request.SyncDTO{
+		Since: query.Get("since"),
+		Limit: query.Get("limit"),
+	}
+
+	changes, err := h.noteService.GetSyncChanges(ctx, userID, req)
+	if err != nil {
+		h.logger.Errorf("%s : %s", errors.ErrGetSyncChanges, err)
+		httperror.WriteJSONError(w, errors.ErrGetSyncChanges.Error(), err, errors.HTTPStatus(err, http.StatusInternalServerError))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	if err = json.NewEncoder(w).Encode(changes); err != nil {
+		h.logger.Errorf("Ошибка при отправке изменений на клиент: %s", err)
+	}
+}
+
+// Применить изменения, сделанные клиентом без сети. Конфликты и ошибки отдельных изменений
+// возвращаются в результатах, остальные изменения сохраняются
+func (h *NoteHandler) pushSyncChanges(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	ctx := r.Context()
+	userID, ok := r.Context().Value("user_id").(int64)
+	if !ok {
+		h.logger.Error(errors.ErrFailedToGetUserIDFromContext)
+		httperror.WriteJSONError(w, errors.ErrFailedToGetUserIDFromContext.Error(), nil, http.StatusInternalServerError)
+		return
+	}
+
+	var req request.SyncPushDTO
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httperror.WriteJSONError(w, errors.ErrJSONNewDecoder.Error(), err, http.StatusBadRequest)
+		h.logger.Errorf("%s: %s", errors.ErrJSONNewDecoder, err)
+		return
+	}
+
+	result, err := h.noteService.PushSyncChanges(ctx, userID, req)
+	if err != nil {
+		h.logger.Errorf("%s : %s", errors.ErrSyncPush, err)
+		httperror.WriteJSONError(w, errors.ErrSyncPush.Error(), err, errors.HTTPStatus(err, http.StatusInternalServerError))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	if err = json.NewEncoder(w).Encode(result); err != nil {
+		h.logger.Errorf("Ошибка при отправке результатов синхронизации на клиент: %s", err)
+	}
+}
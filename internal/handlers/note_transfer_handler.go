@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/errors"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/models"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/transport/dto/request"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/pkg/httperror"
+	"github.com/julienschmidt/httprouter"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// maxImportFileSize - максимальный размер файла импорта
+const maxImportFileSize = 5 << 20
+
+// noteExportContentTypes - тип содержимого файла экспорта по формату
+var noteExportContentTypes = map[string]string{
+	models.NoteFormatJSON:     "application/json; charset=utf-8",
+	models.NoteFormatCSV:      "text/csv; charset=utf-8",
+	models.NoteFormatMarkdown: "text/markdown; charset=utf-8",
+}
+
+// writeCounter - считает записанные в ответ байты, чтобы понять, можно ли ещё вернуть ошибку в JSON
+type writeCounter struct {
+	http.ResponseWriter
+	written int64
+}
+
+func (c *writeCounter) Write(p []byte) (int, error) {
+	n, err := c.ResponseWriter.Write(p)
+	c.written += int64(n)
+	return n, err
+}
+
+// Экспорт заметок в файл (?format=json|csv|md). Файл формируется потоком
+func (h *NoteHandler) exportNotes(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	ctx := r.Context()
+	userID, ok := r.Context().Value("user_id").(int64)
+	if !ok {
+		h.logger.Error(errors.ErrFailedToGetUserIDFromContext)
+		httperror.WriteJSONError(w, errors.ErrFailedToGetUserIDFromContext.Error(), nil, http.StatusInternalServerError)
+		return
+	}
+
+	format := strings.ToLower(strings.TrimSpace(r.URL.Query().Get("format")))
+	if format == "" {
+		format = models.NoteFormatJSON
+	}
+
+	contentType, ok := noteExportContentTypes[format]
+	if !ok {
+		httperror.WriteJSONError(w, errors.ErrExportNotes.Error(), errors.ErrInvalidNoteFormat, http.StatusBadRequest)
+		return
+	}
+
+	fileName := fmt.Sprintf("notes-%s.%s", time.Now().UTC().Format("2006-01-02"), format)
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", fileName))
+	w.Header().Set("Cache-Control", "no-store")
+
+	counter := &writeCounter{ResponseWriter: w}
+
+	if err := h.noteService.ExportNotes(ctx, userID, format, counter); err != nil {
+		h.logger.Errorf("%s : %s", errors.ErrExportNotes, err)
+
+		// Если часть файла уже отправлена, статус изменить нельзя - клиент получит оборванный файл
+		if counter.written == 0 {
+			w.Header().Del("Content-Disposition")
+			httperror.WriteJSONError(w, errors.ErrExportNotes.Error(), err, errors.HTTPStatus(err, http.StatusInternalServerError))
+		}
+	}
+}
+
+// Импорт заметок из файла в теле запроса (?format=json|csv|md&dry_run=true&list_id=)
+func (h *NoteHandler) importNotes(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	ctx := r.Context()
+	userID, ok := r.Context().Value("user_id").(int64)
+	if !ok {
+		h.logger.Error(errors.ErrFailedToGetUserIDFromContext)
+		httperror.WriteJSONError(w, errors.ErrFailedToGetUserIDFromContext.Error(), nil, http.StatusInternalServerError)
+		return
+	}
+
+	query := r.URL.Query()
+	req := request.ImportNotesDTO{
+		Format: query.Get("format"),
+		DryRun: query.Get("dry_run"),
+		ListID: query.Get("list_id"),
+	}
+
+	body := http.MaxBytesReader(w, r.Body, maxImportFileSize)
+
+	result, err := h.noteService.ImportNotes(ctx, userID, body, req)
+	if err != nil {
+		h.logger.Errorf("%s : %s", errors.ErrImportNotes, err)
+		httperror.WriteJSONError(w, errors.ErrImportNotes.Error(), err, errors.HTTPStatus(err, http.StatusInternalServerError))
+		return
+	}
+
+	status := http.StatusOK
+	if result.Created > 0 {
+		status = http.StatusCreated
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	if err = json.NewEncoder(w).Encode(result); err != nil {
+		h.logger.Errorf("Ошибка при отправке результатов импорта на клиент: %s", err)
+	}
+}
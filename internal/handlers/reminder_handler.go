@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"encoding/json"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/errors"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/service"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/transport/dto/request"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/pkg/httperror"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/pkg/logging"
+	"github.com/julienschmidt/httprouter"
+	"net/http"
+	"strconv"
+)
+
+// ReminderHandler обрабатывает запросы, связанные с напоминаниями
+type ReminderHandler struct {
+	reminderService service.ReminderService
+	logger          *logging.Logger
+}
+
+// NewReminderHandler создаёт новый обработчик напоминаний
+func NewReminderHandler(reminderService service.ReminderService, logger *logging.Logger) *ReminderHandler {
+	return &ReminderHandler{
+		reminderService: reminderService,
+		logger:          logger,
+	}
+}
+
+// Получить напоминания заметки
+func (h *ReminderHandler) getReminders(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	ctx := r.Context()
+	userID, ok := r.Context().Value("user_id").(int64)
+	if !ok {
+		h.logger.Error(errors.ErrFailedToGetUserIDFromContext)
+		httperror.WriteJSONError(w, errors.ErrFailedToGetUserIDFromContext.Error(), nil, http.StatusInternalServerError)
+		return
+	}
+
+	noteID, _ := strconv.Atoi(ps.ByName("id"))
+
+	reminders, err := h.reminderService.GetReminders(ctx, userID, int64(noteID))
+	if err != nil {
+		h.logger.Errorf("%s : %v : %s", errors.ErrGetReminders, noteID, err)
+		httperror.WriteJSONError(w, errors.ErrGetReminders.Error(), err, errors.HTTPStatus(err, http.StatusInternalServerError))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	if err = json.NewEncoder(w).Encode(reminders); err != nil {
+		h.logger.Errorf("Ошибка при отправке напоминаний на клиент: %s", err)
+	}
+}
+
+// Добавить напоминание к заметке
+func (h *ReminderHandler) createReminder(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	ctx := r.Context()
+	userID, ok := r.Context().Value("user_id").(int64)
+	if !ok {
+		h.logger.Error(errors.ErrFailedToGetUserIDFromContext)
+		httperror.WriteJSONError(w, errors.ErrFailedToGetUserIDFromContext.Error(), nil, http.StatusInternalServerError)
+		return
+	}
+
+	var req request.ReminderDTO
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httperror.WriteJSONError(w, errors.ErrJSONNewDecoder.Error(), err, http.StatusBadRequest)
+		h.logger.Errorf("%s: %s", errors.ErrJSONNewDecoder, err)
+		return
+	}
+
+	noteID, _ := strconv.Atoi(ps.ByName("id"))
+
+	reminder, err := h.reminderService.CreateReminder(ctx, userID, int64(noteID), req)
+	if err != nil {
+		h.logger.Errorf("%s : %v : %s", errors.ErrReminderFailed, noteID, err)
+		httperror.WriteJSONError(w, errors.ErrReminderFailed.Error(), err, errors.HTTPStatus(err, http.StatusInternalServerError))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+
+	if err = json.NewEncoder(w).Encode(reminder); err != nil {
+		h.logger.Errorf("Ошибка при отправке напоминания на клиент: %s", err)
+	}
+}
+
+// Удалить напоминание
+func (h *ReminderHandler) deleteReminder(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	ctx := r.Context()
+	userID, ok := r.Context().Value("user_id").(int64)
+	if !ok {
+		h.logger.Error(errors.ErrFailedToGetUserIDFromContext)
+		httperror.WriteJSONError(w, errors.ErrFailedToGetUserIDFromContext.Error(), nil, http.StatusInternalServerError)
+		return
+	}
+
+	noteID, _ := strconv.Atoi(ps.ByName("id"))
+	reminderID, _ := strconv.Atoi(ps.ByName("reminderId"))
+
+	if err := h.reminderService.DeleteReminder(ctx, userID, int64(noteID), int64(reminderID)); err != nil {
+		h.logger.Errorf("%s : %v : %v : %s", errors.ErrDeleteReminder, noteID, reminderID, err)
+		httperror.WriteJSONError(w, errors.ErrDeleteReminder.Error(), err, errors.HTTPStatus(err, http.StatusInternalServerError))
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
@@ -2,7 +2,9 @@ package handlers
 
 import (
 	"database/sql"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/blobstore"
 	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/config"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/events"
 	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/middleware"
 	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/repository"
 	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/service"
@@ -18,15 +20,96 @@ type Handler struct {
 	userSvc  service.UserService
 	noteRepo repository.NoteRepository
 	noteSvc  service.NoteService
+	tagRepo  repository.TagRepository
+	tagSvc   service.TagService
+	listRepo repository.ListRepository
+	listSvc  service.ListService
+
+	noteItemRepo repository.NoteItemRepository
+	noteItemSvc  service.NoteItemService
+
+	noteRevisionRepo repository.NoteRevisionRepository
+	noteRevisionSvc  service.NoteRevisionService
+
+	reminderRepo repository.ReminderRepository
+	reminderSvc  service.ReminderService
+
+	shareRepo repository.ShareRepository
+	shareSvc  service.ShareService
+
+	shareLinkRepo repository.ShareLinkRepository
+	shareLinkSvc  service.ShareLinkService
+
+	noteCommentRepo repository.NoteCommentRepository
+	noteCommentSvc  service.NoteCommentService
+
+	attachmentRepo repository.AttachmentRepository
+	attachmentSvc  service.AttachmentService
+
+	calendarRepo repository.CalendarRepository
+	calendarSvc  service.CalendarService
+
+	importJobRepo repository.ImportJobRepository
+	importJobSvc  service.ImportJobService
+
+	webhookRepo repository.WebhookRepository
+	webhookSvc  service.WebhookService
+
+	idempotencyRepo repository.IdempotencyRepository
+	idempotencySvc  service.IdempotencyService
+
+	broker *events.Broker // События заметок для потоков GET /events
 }
 
 // NewHandler создаёт новый обработчик
-func NewHandler(cfg *config.Config, logger *logging.Logger, db *sql.DB) *Handler {
+func NewHandler(cfg *config.Config, logger *logging.Logger, db *sql.DB, store blobstore.BlobStore, broker *events.Broker) *Handler {
 	userRepo := repository.NewUserRepository(db)
 	userSvc := service.NewUserService(userRepo, cfg)
 
+	listRepo := repository.NewListRepository(db)
+	listSvc := service.NewListService(listRepo, cfg)
+
+	// Единая проверка прав доступа к заметкам и спискам (в том числе общим)
+	shareRepo := repository.NewShareRepository(db)
+	authorizer := service.NewAuthorizer(shareRepo)
+
 	noteRepo := repository.NewNoteRepository(db)
-	noteSvc := service.NewNoteService(noteRepo, cfg)
+	noteSvc := service.NewNoteService(noteRepo, listRepo, authorizer, cfg)
+
+	tagRepo := repository.NewTagRepository(db)
+	tagSvc := service.NewTagService(tagRepo, cfg)
+
+	noteItemRepo := repository.NewNoteItemRepository(db)
+	noteItemSvc := service.NewNoteItemService(noteItemRepo, authorizer, cfg)
+
+	noteRevisionRepo := repository.NewNoteRevisionRepository(db)
+	noteRevisionSvc := service.NewNoteRevisionService(noteRevisionRepo, authorizer, cfg)
+
+	reminderRepo := repository.NewReminderRepository(db)
+	reminderSvc := service.NewReminderService(reminderRepo, cfg)
+
+	shareSvc := service.NewShareService(shareRepo, noteRepo, listRepo, authorizer, cfg)
+
+	shareLinkRepo := repository.NewShareLinkRepository(db)
+	shareLinkSvc := service.NewShareLinkService(shareLinkRepo, authorizer, cfg)
+
+	noteCommentRepo := repository.NewNoteCommentRepository(db)
+	noteCommentSvc := service.NewNoteCommentService(noteCommentRepo, authorizer, cfg)
+
+	attachmentRepo := repository.NewAttachmentRepository(db)
+	attachmentSvc := service.NewAttachmentService(attachmentRepo, store, authorizer, cfg)
+
+	calendarRepo := repository.NewCalendarRepository(db)
+	calendarSvc := service.NewCalendarService(calendarRepo, listRepo, noteSvc, cfg)
+
+	importJobRepo := repository.NewImportJobRepository(db)
+	importJobSvc := service.NewImportJobService(importJobRepo, listRepo, store, cfg)
+
+	webhookRepo := repository.NewWebhookRepository(db)
+	webhookSvc := service.NewWebhookService(webhookRepo, cfg)
+
+	idempotencyRepo := repository.NewIdempotencyRepository(db)
+	idempotencySvc := service.NewIdempotencyService(idempotencyRepo, cfg)
 
 	return &Handler{
 		cfg:      cfg,
@@ -35,6 +118,45 @@ func NewHandler(cfg *config.Config, logger *logging.Logger, db *sql.DB) *Handler
 		userSvc:  userSvc,
 		noteRepo: noteRepo,
 		noteSvc:  noteSvc,
+		tagRepo:  tagRepo,
+		tagSvc:   tagSvc,
+		listRepo: listRepo,
+		listSvc:  listSvc,
+
+		noteItemRepo: noteItemRepo,
+		noteItemSvc:  noteItemSvc,
+
+		noteRevisionRepo: noteRevisionRepo,
+		noteRevisionSvc:  noteRevisionSvc,
+
+		reminderRepo: reminderRepo,
+		reminderSvc:  reminderSvc,
+
+		shareRepo: shareRepo,
+		shareSvc:  shareSvc,
+
+		shareLinkRepo: shareLinkRepo,
+		shareLinkSvc:  shareLinkSvc,
+
+		noteCommentRepo: noteCommentRepo,
+		noteCommentSvc:  noteCommentSvc,
+
+		attachmentRepo: attachmentRepo,
+		attachmentSvc:  attachmentSvc,
+
+		calendarRepo: calendarRepo,
+		calendarSvc:  calendarSvc,
+
+		importJobRepo: importJobRepo,
+		importJobSvc:  importJobSvc,
+
+		webhookRepo: webhookRepo,
+		webhookSvc:  webhookSvc,
+
+		idempotencyRepo: idempotencyRepo,
+		idempotencySvc:  idempotencySvc,
+
+		broker: broker,
 	}
 }
 
@@ -42,6 +164,25 @@ func NewHandler(cfg *config.Config, logger *logging.Logger, db *sql.DB) *Handler
 func (h *Handler) RegisterRoutes(router *httprouter.Router) {
 	userHandler := NewUserHandler(h.userSvc, h.logger)
 	noteHandler := NewNoteHandler(h.noteSvc, h.logger)
+	tagHandler := NewTagHandler(h.tagSvc, h.logger)
+	listHandler := NewListHandler(h.listSvc, h.logger)
+	noteItemHandler := NewNoteItemHandler(h.noteItemSvc, h.logger)
+	noteRevisionHandler := NewNoteRevisionHandler(h.noteRevisionSvc, h.logger)
+	reminderHandler := NewReminderHandler(h.reminderSvc, h.logger)
+	shareHandler := NewShareHandler(h.shareSvc, h.logger)
+	shareLinkHandler := NewShareLinkHandler(h.shareLinkSvc, h.logger)
+	noteCommentHandler := NewNoteCommentHandler(h.noteCommentSvc, h.logger)
+	attachmentHandler := NewAttachmentHandler(h.attachmentSvc, h.logger)
+	calendarHandler := NewCalendarHandler(h.calendarSvc, h.logger)
+	importJobHandler := NewImportJobHandler(h.importJobSvc, h.logger)
+	eventHandler := NewEventHandler(h.broker, h.cfg, h.logger)
+	webhookHandler := NewWebhookHandler(h.webhookSvc, h.logger)
+
+	// Изменяющие запросы к заметкам принимают Idempotency-Key: повтор запроса получает сохранённый ответ
+	idempotent := middleware.Idempotency(h.idempotencySvc, h.cfg, h.logger)
+	// Импорт и загрузка файлов принимают тела больше Config.Idempotency.MaxBodySize
+	idempotentImport := middleware.IdempotencyWithLimit(h.idempotencySvc, h.cfg, h.logger, maxImportFileSize)
+	idempotentUpload := middleware.IdempotencyWithLimit(h.idempotencySvc, h.cfg, h.logger, h.attachmentSvc.MaxFileSize()+multipartOverhead)
 
 	router.POST("/register", userHandler.register)                       // Регистрация (создание нового пользователя)
 	router.POST("/login", userHandler.login)                             // Логин (получение access и refresh токенов)
@@ -50,12 +191,102 @@ func (h *Handler) RegisterRoutes(router *httprouter.Router) {
 	router.GET("/protected", middleware.Auth(userHandler.protected))     // Защищённый маршрут, доступный только при наличии валидного access-токена
 	router.GET("/users/me", middleware.Auth(userHandler.getUserProfile)) // Получить данные о текущем пользователе
 
-	router.GET("/notes", middleware.Auth(noteHandler.getAllNotes))                          // Получить все заметки
-	router.POST("/notes", middleware.Auth(noteHandler.createPost))                          // Создать заметку
-	router.DELETE("/notes", middleware.Auth(noteHandler.deleteAllNotes))                    // Удалить все заметки
-	router.DELETE("/notes/completed", middleware.Auth(noteHandler.deleteAllCompletedNotes)) // Удалить все выполненные заметки
-	router.PUT("/notes/:id", middleware.Auth(noteHandler.updateNote))                       // Обновить заметку
-	router.DELETE("/note/:id", middleware.Auth(noteHandler.deleteNote))                     // Удалить конкретную заметку
-	router.PUT("/notes/:id/completed", middleware.Auth(noteHandler.markNoteCompleted))      // Отметить заметку выполненной
+	// У httprouter отдельное дерево маршрутов для каждого метода. Статических PUT-маршрутов под /notes/ нет,
+	// поэтому заметка изменяется по /notes/:id/... Для GET, POST и DELETE параметр :id конфликтовал бы со
+	// статическими /notes/search, /notes/batch, /notes/completed и т. п., поэтому эти методы используют /note/:id/...
+	router.GET("/notes", middleware.Auth(noteHandler.getAllNotes))                                      // Получить заметки (пагинация, фильтры, сортировка)
+	router.GET("/notes/search", middleware.Auth(noteHandler.searchNotes))                               // Полнотекстовый поиск по заметкам
+	router.GET("/notes/today", middleware.Auth(noteHandler.getTodayNotes))                              // Заметки со сроком на сегодня
+	router.GET("/notes/overdue", middleware.Auth(noteHandler.getOverdueNotes))                          // Просроченные заметки
+	router.GET("/notes/upcoming", middleware.Auth(noteHandler.getUpcomingNotes))                        // Предстоящие заметки
+	router.GET("/notes/export", middleware.Auth(noteHandler.exportNotes))                               // Экспорт заметок (json | csv | md)
+	router.POST("/notes", middleware.Auth(idempotent(noteHandler.createPost)))                          // Создать заметку
+	router.POST("/notes/batch", middleware.Auth(idempotent(noteHandler.batchNotes)))                    // Пакет операций с заметками в одной транзакции
+	router.POST("/notes/import", middleware.Auth(idempotentImport(noteHandler.importNotes)))            // Импорт заметок (?format=json|csv|md, dry_run=true - только отчёт)
+	router.DELETE("/notes", middleware.Auth(idempotent(noteHandler.deleteAllNotes)))                    // Удалить все заметки (в корзину)
+	router.DELETE("/notes/completed", middleware.Auth(idempotent(noteHandler.deleteAllCompletedNotes))) // Удалить все выполненные заметки (в корзину)
+	router.PUT("/notes/:id", middleware.Auth(idempotent(noteHandler.updateNote)))                       // Обновить заметку (If-Match: версия заметки, иначе 412)
+	router.GET("/note/:id", middleware.Auth(noteHandler.getNote))                                       // Получить заметку (ETag - версия заметки, поддерживается If-None-Match)
+	router.DELETE("/note/:id", middleware.Auth(idempotent(noteHandler.deleteNote)))                     // Удалить конкретную заметку (в корзину)
+	router.PUT("/notes/:id/completed", middleware.Auth(idempotent(noteHandler.markNoteCompleted)))      // Отметить заметку выполненной (If-Match: версия заметки, иначе 412)
+	router.PUT("/notes/:id/list", middleware.Auth(idempotent(noteHandler.moveNote)))                    // Перенести заметку в другой список
+	router.PUT("/notes/:id/position", middleware.Auth(idempotent(noteHandler.moveNotePosition)))        // Переместить заметку между соседями (ручная сортировка)
+
+	router.GET("/tags", middleware.Auth(tagHandler.getAllTags))                               // Получить все метки
+	router.POST("/tags", middleware.Auth(tagHandler.createTag))                               // Создать метку
+	router.PUT("/tags/:id", middleware.Auth(tagHandler.updateTag))                            // Переименовать метку
+	router.DELETE("/tags/:id", middleware.Auth(tagHandler.deleteTag))                         // Удалить метку
+	router.POST("/note/:id/tags/:tagId", middleware.Auth(idempotent(tagHandler.attachTag)))   // Прикрепить метку к заметке
+	router.DELETE("/note/:id/tags/:tagId", middleware.Auth(idempotent(tagHandler.detachTag))) // Открепить метку от заметки
+
+	router.GET("/lists", middleware.Auth(listHandler.getAllLists))                                                 // Получить все списки
+	router.POST("/lists", middleware.Auth(listHandler.createList))                                                 // Создать список
+	router.PUT("/lists/:id", middleware.Auth(listHandler.updateList))                                              // Обновить список
+	router.DELETE("/lists/:id", middleware.Auth(listHandler.deleteList))                                           // Удалить список
+	router.GET("/lists/:id/notes", middleware.Auth(noteHandler.getListNotes))                                      // Получить заметки списка
+	router.DELETE("/lists/:id/notes/completed", middleware.Auth(idempotent(noteHandler.deleteListCompletedNotes))) // Удалить выполненные заметки списка
+
+	router.GET("/note/:id/items", middleware.Auth(noteItemHandler.getItems))                          // Получить пункты чек-листа заметки
+	router.POST("/note/:id/items", middleware.Auth(idempotent(noteItemHandler.createItem)))           // Добавить пункт чек-листа
+	router.PUT("/notes/:id/items", middleware.Auth(idempotent(noteItemHandler.reorderItems)))         // Изменить порядок пунктов чек-листа
+	router.PUT("/notes/:id/items/:itemId", middleware.Auth(idempotent(noteItemHandler.updateItem)))   // Изменить пункт чек-листа
+	router.DELETE("/note/:id/items/:itemId", middleware.Auth(idempotent(noteItemHandler.deleteItem))) // Удалить пункт чек-листа
+
+	router.GET("/trash", middleware.Auth(noteHandler.getTrash))                             // Получить заметки из корзины
+	router.POST("/trash/:id/restore", middleware.Auth(idempotent(noteHandler.restoreNote))) // Восстановить заметку из корзины
+	router.DELETE("/trash", middleware.Auth(idempotent(noteHandler.emptyTrash)))            // Очистить корзину
+
+	router.GET("/note/:id/history", middleware.Auth(noteRevisionHandler.getHistory))                  // История изменений заметки
+	router.GET("/note/:id/history/diff", middleware.Auth(noteRevisionHandler.diffRevisions))          // Различия текста заметки между ревизиями
+	router.POST("/note/:id/revert/:rev", middleware.Auth(idempotent(noteRevisionHandler.revertNote))) // Вернуть заметку к ревизии
+
+	router.GET("/note/:id/reminders", middleware.Auth(reminderHandler.getReminders))                              // Получить напоминания заметки
+	router.POST("/note/:id/reminders", middleware.Auth(idempotent(reminderHandler.createReminder)))               // Добавить напоминание
+	router.DELETE("/note/:id/reminders/:reminderId", middleware.Auth(idempotent(reminderHandler.deleteReminder))) // Удалить напоминание
+
+	router.GET("/note/:id/shares", middleware.Auth(shareHandler.getNoteShares))                          // Пользователи с доступом к заметке
+	router.POST("/note/:id/shares", middleware.Auth(idempotent(shareHandler.shareNote)))                 // Открыть доступ к заметке
+	router.DELETE("/note/:id/shares/:userId", middleware.Auth(idempotent(shareHandler.revokeNoteShare))) // Закрыть доступ к заметке
+	router.GET("/lists/:id/shares", middleware.Auth(shareHandler.getListShares))                         // Пользователи с доступом к списку
+	router.POST("/lists/:id/shares", middleware.Auth(shareHandler.shareList))                            // Открыть доступ к списку
+	router.DELETE("/lists/:id/shares/:userId", middleware.Auth(shareHandler.revokeListShare))            // Закрыть доступ к списку
+	router.GET("/shared", middleware.Auth(shareHandler.getSharedWithMe))                                 // Заметки и списки, к которым открыт доступ
+
+	router.POST("/note/:id/share-link", middleware.Auth(idempotent(shareLinkHandler.createShareLink)))            // Создать публичную ссылку на заметку
+	router.GET("/note/:id/share-links", middleware.Auth(shareLinkHandler.getShareLinks))                          // Получить ссылки на заметку
+	router.DELETE("/note/:id/share-links/:linkId", middleware.Auth(idempotent(shareLinkHandler.revokeShareLink))) // Отозвать ссылку на заметку
+	router.GET("/s/:token", shareLinkHandler.openShareLink)                                                       // Открыть заметку по публичной ссылке (без авторизации)
+
+	router.GET("/note/:id/comments", middleware.Auth(noteCommentHandler.getComments))                             // Получить комментарии к заметке
+	router.POST("/note/:id/comments", middleware.Auth(idempotent(noteCommentHandler.createComment)))              // Добавить комментарий
+	router.PUT("/notes/:id/comments/:commentId", middleware.Auth(idempotent(noteCommentHandler.updateComment)))   // Изменить комментарий (только автор)
+	router.DELETE("/note/:id/comments/:commentId", middleware.Auth(idempotent(noteCommentHandler.deleteComment))) // Удалить комментарий (автор или владелец заметки)
+
+	router.GET("/note/:id/attachments", middleware.Auth(attachmentHandler.getAttachments))                                // Получить вложения заметки
+	router.POST("/note/:id/attachments", middleware.Auth(idempotentUpload(attachmentHandler.uploadAttachment)))           // Загрузить файл (multipart/form-data, поле file)
+	router.GET("/note/:id/attachments/:attachmentId", middleware.Auth(attachmentHandler.downloadAttachment))              // Скачать файл вложения
+	router.DELETE("/note/:id/attachments/:attachmentId", middleware.Auth(idempotent(attachmentHandler.deleteAttachment))) // Удалить вложение
+
+	router.POST("/calendar/feed", middleware.Auth(calendarHandler.createCalendarFeed))   // Создать (или заменить) приватную ссылку на ленту задач iCalendar
+	router.DELETE("/calendar/feed", middleware.Auth(calendarHandler.deleteCalendarFeed)) // Отозвать ссылку на ленту задач
+	router.GET("/ical/:token", calendarHandler.getCalendarFeed)                          // Лента задач VTODO по приватной ссылке (без авторизации)
+	router.PUT("/ical/:token", calendarHandler.syncCalendarFeed)                         // Загрузить задачи VTODO из календарного клиента (без авторизации)
+
+	router.POST("/imports", middleware.Auth(importJobHandler.createImportJob)) // Импорт из Todoist, Microsoft To Do, Google Tasks (?source=todoist|mstodo|gtasks&list=), выполняется в фоне
+	router.GET("/imports", middleware.Auth(importJobHandler.getImportJobs))    // Последние задания импорта
+	router.GET("/imports/:id", middleware.Auth(importJobHandler.getImportJob)) // Прогресс задания импорта и итоговый отчёт
+
+	router.GET("/sync", middleware.Auth(noteHandler.getSyncChanges))               // Изменения заметок после токена since (синхронизация клиентов без сети)
+	router.POST("/sync", middleware.Auth(idempotent(noteHandler.pushSyncChanges))) // Применить изменения клиента с обнаружением конфликтов (last-writer-wins)
+
+	router.GET("/events", middleware.Auth(eventHandler.streamEvents)) // Поток событий заметок (Server-Sent Events): note.created, note.updated, note.completed, note.deleted, resync
+
+	router.GET("/webhooks", middleware.Auth(webhookHandler.getWebhooks))                                            // Получить подписки webhook
+	router.POST("/webhooks", middleware.Auth(webhookHandler.createWebhook))                                         // Создать подписку (секрет подписи возвращается только в ответе)
+	router.PUT("/webhooks/:id", middleware.Auth(webhookHandler.updateWebhook))                                      // Изменить адрес, фильтр событий и состояние подписки
+	router.DELETE("/webhooks/:id", middleware.Auth(webhookHandler.deleteWebhook))                                   // Удалить подписку вместе с журналом доставок
+	router.POST("/webhooks/:id/ping", middleware.Auth(webhookHandler.pingWebhook))                                  // Отправить проверочное событие ping
+	router.GET("/webhooks/:id/deliveries", middleware.Auth(webhookHandler.getWebhookDeliveries))                    // Журнал доставок (статус, ответ получателя, попытки)
+	router.POST("/webhooks/:id/deliveries/:deliveryId/redeliver", middleware.Auth(webhookHandler.redeliverWebhook)) // Повторить доставку
 
 }
@@ -0,0 +1,208 @@
+package handlers
+
+import (
+	"encoding/json"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/errors"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/service"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/transport/dto/request"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/pkg/httperror"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/pkg/logging"
+	"github.com/julienschmidt/httprouter"
+	"net/http"
+	"strconv"
+)
+
+// ShareHandler обрабатывает запросы, связанные с совместным доступом к заметкам и спискам
+type ShareHandler struct {
+	shareService service.ShareService
+	logger       *logging.Logger
+}
+
+// NewShareHandler создаёт новый обработчик совместного доступа
+func NewShareHandler(shareService service.ShareService, logger *logging.Logger) *ShareHandler {
+	return &ShareHandler{
+		shareService: shareService,
+		logger:       logger,
+	}
+}
+
+// Открыть доступ к заметке другому пользователю
+func (h *ShareHandler) shareNote(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	ctx := r.Context()
+	userID, ok := r.Context().Value("user_id").(int64)
+	if !ok {
+		h.logger.Error(errors.ErrFailedToGetUserIDFromContext)
+		httperror.WriteJSONError(w, errors.ErrFailedToGetUserIDFromContext.Error(), nil, http.StatusInternalServerError)
+		return
+	}
+
+	var req request.ShareDTO
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httperror.WriteJSONError(w, errors.ErrJSONNewDecoder.Error(), err, http.StatusBadRequest)
+		h.logger.Errorf("%s: %s", errors.ErrJSONNewDecoder, err)
+		return
+	}
+
+	id, _ := strconv.Atoi(ps.ByName("id"))
+
+	if err := h.shareService.ShareNote(ctx, userID, int64(id), req); err != nil {
+		h.logger.Errorf("%s : %v : %s", errors.ErrShareFailed, id, err)
+		httperror.WriteJSONError(w, errors.ErrShareFailed.Error(), err, errors.HTTPStatus(err, http.StatusInternalServerError))
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// Получить пользователей, которым открыт доступ к заметке
+func (h *ShareHandler) getNoteShares(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	ctx := r.Context()
+	userID, ok := r.Context().Value("user_id").(int64)
+	if !ok {
+		h.logger.Error(errors.ErrFailedToGetUserIDFromContext)
+		httperror.WriteJSONError(w, errors.ErrFailedToGetUserIDFromContext.Error(), nil, http.StatusInternalServerError)
+		return
+	}
+
+	id, _ := strconv.Atoi(ps.ByName("id"))
+
+	shares, err := h.shareService.GetNoteShares(ctx, userID, int64(id))
+	if err != nil {
+		h.logger.Errorf("%s : %v : %s", errors.ErrGetShares, id, err)
+		httperror.WriteJSONError(w, errors.ErrGetShares.Error(), err, errors.HTTPStatus(err, http.StatusInternalServerError))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	if err = json.NewEncoder(w).Encode(shares); err != nil {
+		h.logger.Errorf("Ошибка при отправке списка доступа на клиент: %s", err)
+	}
+}
+
+// Закрыть доступ пользователя к заметке
+func (h *ShareHandler) revokeNoteShare(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	ctx := r.Context()
+	userID, ok := r.Context().Value("user_id").(int64)
+	if !ok {
+		h.logger.Error(errors.ErrFailedToGetUserIDFromContext)
+		httperror.WriteJSONError(w, errors.ErrFailedToGetUserIDFromContext.Error(), nil, http.StatusInternalServerError)
+		return
+	}
+
+	id, _ := strconv.Atoi(ps.ByName("id"))
+	targetID, _ := strconv.Atoi(ps.ByName("userId"))
+
+	if err := h.shareService.RevokeNoteShare(ctx, userID, int64(id), int64(targetID)); err != nil {
+		h.logger.Errorf("%s : %v : %v : %s", errors.ErrDeleteShare, id, targetID, err)
+		httperror.WriteJSONError(w, errors.ErrDeleteShare.Error(), err, errors.HTTPStatus(err, http.StatusInternalServerError))
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// Открыть доступ к списку другому пользователю
+func (h *ShareHandler) shareList(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	ctx := r.Context()
+	userID, ok := r.Context().Value("user_id").(int64)
+	if !ok {
+		h.logger.Error(errors.ErrFailedToGetUserIDFromContext)
+		httperror.WriteJSONError(w, errors.ErrFailedToGetUserIDFromContext.Error(), nil, http.StatusInternalServerError)
+		return
+	}
+
+	var req request.ShareDTO
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httperror.WriteJSONError(w, errors.ErrJSONNewDecoder.Error(), err, http.StatusBadRequest)
+		h.logger.Errorf("%s: %s", errors.ErrJSONNewDecoder, err)
+		return
+	}
+
+	id, _ := strconv.Atoi(ps.ByName("id"))
+
+	if err := h.shareService.ShareList(ctx, userID, int64(id), req); err != nil {
+		h.logger.Errorf("%s : %v : %s", errors.ErrShareFailed, id, err)
+		httperror.WriteJSONError(w, errors.ErrShareFailed.Error(), err, errors.HTTPStatus(err, http.StatusInternalServerError))
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// Получить пользователей, которым открыт доступ к списку
+func (h *ShareHandler) getListShares(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	ctx := r.Context()
+	userID, ok := r.Context().Value("user_id").(int64)
+	if !ok {
+		h.logger.Error(errors.ErrFailedToGetUserIDFromContext)
+		httperror.WriteJSONError(w, errors.ErrFailedToGetUserIDFromContext.Error(), nil, http.StatusInternalServerError)
+		return
+	}
+
+	id, _ := strconv.Atoi(ps.ByName("id"))
+
+	shares, err := h.shareService.GetListShares(ctx, userID, int64(id))
+	if err != nil {
+		h.logger.Errorf("%s : %v : %s", errors.ErrGetShares, id, err)
+		httperror.WriteJSONError(w, errors.ErrGetShares.Error(), err, errors.HTTPStatus(err, http.StatusInternalServerError))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	if err = json.NewEncoder(w).Encode(shares); err != nil {
+		h.logger.Errorf("Ошибка при отправке списка доступа на клиент: %s", err)
+	}
+}
+
+// Закрыть доступ пользователя к списку
+func (h *ShareHandler) revokeListShare(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	ctx := r.Context()
+	userID, ok := r.Context().Value("user_id").(int64)
+	if !ok {
+		h.logger.Error(errors.ErrFailedToGetUserIDFromContext)
+		httperror.WriteJSONError(w, errors.ErrFailedToGetUserIDFromContext.Error(), nil, http.StatusInternalServerError)
+		return
+	}
+
+	id, _ := strconv.Atoi(ps.ByName("id"))
+	targetID, _ := strconv.Atoi(ps.ByName("userId"))
+
+	if err := h.shareService.RevokeListShare(ctx, userID, int64(id), int64(targetID)); err != nil {
+		h.logger.Errorf("%s : %v : %v : %s", errors.ErrDeleteShare, id, targetID, err)
+		httperror.WriteJSONError(w, errors.ErrDeleteShare.Error(), err, errors.HTTPStatus(err, http.StatusInternalServerError))
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// Получить заметки и списки, к которым пользователю открыли доступ другие пользователи
+func (h *ShareHandler) getSharedWithMe(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	ctx := r.Context()
+	userID, ok := r.Context().Value("user_id").(int64)
+	if !ok {
+		h.logger.Error(errors.ErrFailedToGetUserIDFromContext)
+		httperror.WriteJSONError(w, errors.ErrFailedToGetUserIDFromContext.Error(), nil, http.StatusInternalServerError)
+		return
+	}
+
+	shared, err := h.shareService.GetSharedWithMe(ctx, userID)
+	if err != nil {
+		h.logger.Errorf("%s : %s", errors.ErrGetShares, err)
+		httperror.WriteJSONError(w, errors.ErrGetShares.Error(), err, errors.HTTPStatus(err, http.StatusInternalServerError))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	if err = json.NewEncoder(w).Encode(shared); err != nil {
+		h.logger.Errorf("Ошибка при отправке общих заметок на клиент: %s", err)
+	}
+}
@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"encoding/json"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/errors"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/service"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/transport/dto/request"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/pkg/httperror"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/pkg/logging"
+	"github.com/julienschmidt/httprouter"
+	"net/http"
+	"strconv"
+)
+
+// shareLinkPasswordHeader - заголовок с паролем публичной ссылки
+const shareLinkPasswordHeader = "X-Share-Password"
+
+// ShareLinkHandler обрабатывает запросы, связанные с публичными ссылками на заметки
+type ShareLinkHandler struct {
+	shareLinkService service.ShareLinkService
+	logger           *logging.Logger
+}
+
+// NewShareLinkHandler создаёт новый обработчик публичных ссылок
+func NewShareLinkHandler(shareLinkService service.ShareLinkService, logger *logging.Logger) *ShareLinkHandler {
+	return &ShareLinkHandler{
+		shareLinkService: shareLinkService,
+		logger:           logger,
+	}
+}
+
+// Создать публичную ссылку на заметку
+func (h *ShareLinkHandler) createShareLink(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	ctx := r.Context()
+	userID, ok := r.Context().Value("user_id").(int64)
+	if !ok {
+		h.logger.Error(errors.ErrFailedToGetUserIDFromContext)
+		httperror.WriteJSONError(w, errors.ErrFailedToGetUserIDFromContext.Error(), nil, http.StatusInternalServerError)
+		return
+	}
+
+	var req request.ShareLinkDTO
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httperror.WriteJSONError(w, errors.ErrJSONNewDecoder.Error(), err, http.StatusBadRequest)
+		h.logger.Errorf("%s: %s", errors.ErrJSONNewDecoder, err)
+		return
+	}
+
+	noteID, _ := strconv.Atoi(ps.ByName("id"))
+
+	link, err := h.shareLinkService.CreateShareLink(ctx, userID, int64(noteID), req)
+	if err != nil {
+		h.logger.Errorf("%s : %v : %s", errors.ErrShareLinkFailed, noteID, err)
+		httperror.WriteJSONError(w, errors.ErrShareLinkFailed.Error(), err, errors.HTTPStatus(err, http.StatusInternalServerError))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusCreated)
+
+	if err = json.NewEncoder(w).Encode(link); err != nil {
+		h.logger.Errorf("Ошибка при отправке ссылки на клиент: %s", err)
+	}
+}
+
+// Получить ссылки на заметку
+func (h *ShareLinkHandler) getShareLinks(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	ctx := r.Context()
+	userID, ok := r.Context().Value("user_id").(int64)
+	if !ok {
+		h.logger.Error(errors.ErrFailedToGetUserIDFromContext)
+		httperror.WriteJSONError(w, errors.ErrFailedToGetUserIDFromContext.Error(), nil, http.StatusInternalServerError)
+		return
+	}
+
+	noteID, _ := strconv.Atoi(ps.ByName("id"))
+
+	links, err := h.shareLinkService.GetShareLinks(ctx, userID, int64(noteID))
+	if err != nil {
+		h.logger.Errorf("%s : %v : %s", errors.ErrGetShareLinks, noteID, err)
+		httperror.WriteJSONError(w, errors.ErrGetShareLinks.Error(), err, errors.HTTPStatus(err, http.StatusInternalServerError))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	if err = json.NewEncoder(w).Encode(links); err != nil {
+		h.logger.Errorf("Ошибка при отправке ссылок на клиент: %s", err)
+	}
+}
+
+// Отозвать ссылку на заметку
+func (h *ShareLinkHandler) revokeShareLink(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	ctx := r.Context()
+	userID, ok := r.Context().Value("user_id").(int64)
+	if !ok {
+		h.logger.Error(errors.ErrFailedToGetUserIDFromContext)
+		httperror.WriteJSONError(w, errors.ErrFailedToGetUserIDFromContext.Error(), nil, http.StatusInternalServerError)
+		return
+	}
+
+	noteID, _ := strconv.Atoi(ps.ByName("id"))
+	linkID, _ := strconv.Atoi(ps.ByName("linkId"))
+
+	if err := h.shareLinkService.RevokeShareLink(ctx, userID, int64(noteID), int64(linkID)); err != nil {
+		h.logger.Errorf("%s : %v : %v : %s", errors.ErrRevokeShareLink, noteID, linkID, err)
+		httperror.WriteJSONError(w, errors.ErrRevokeShareLink.Error(), err, errors.HTTPStatus(err, http.StatusInternalServerError))
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// Открыть заметку по публичной ссылке (без авторизации). Пароль передаётся в заголовке X-Share-Password
+func (h *ShareLinkHandler) openShareLink(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	ctx := r.Context()
+
+	// Токен ссылки - секрет: ответ не кешируется, а адрес не передаётся в Referer
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Referrer-Policy", "no-referrer")
+
+	note, err := h.shareLinkService.OpenShareLink(ctx, ps.ByName("token"), r.Header.Get(shareLinkPasswordHeader))
+	if err != nil {
+		// Сам токен в журнал не пишем
+		h.logger.Errorf("%s : %s", errors.ErrOpenShareLink, err)
+		httperror.WriteJSONError(w, errors.ErrOpenShareLink.Error(), err, errors.HTTPStatus(err, http.StatusInternalServerError))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	if err = json.NewEncoder(w).Encode(note); err != nil {
+		h.logger.Errorf("Ошибка при отправке заметки по ссылке на клиент: %s", err)
+	}
+}
@@ -0,0 +1,179 @@
+package handlers
+
+import (
+	"encoding/json"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/errors"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/service"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/transport/dto/request"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/pkg/httperror"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/pkg/logging"
+	"github.com/julienschmidt/httprouter"
+	"net/http"
+	"strconv"
+)
+
+// TagHandler обрабатывает запросы, связанные с метками
+type TagHandler struct {
+	tagService service.TagService
+	logger     *logging.Logger
+}
+
+// NewTagHandler создаёт новый обработчик меток
+func NewTagHandler(tagService service.TagService, logger *logging.Logger) *TagHandler {
+	return &TagHandler{
+		tagService: tagService,
+		logger:     logger,
+	}
+}
+
+// Получить все метки пользователя
+func (h *TagHandler) getAllTags(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	ctx := r.Context()
+	userID, ok := r.Context().Value("user_id").(int64)
+	if !ok {
+		h.logger.Error(errors.ErrFailedToGetUserIDFromContext)
+		httperror.WriteJSONError(w, errors.ErrFailedToGetUserIDFromContext.Error(), nil, http.StatusInternalServerError)
+		return
+	}
+
+	tags, err := h.tagService.GetAllTags(ctx, userID)
+	if err != nil {
+		h.logger.Errorf("%s: %s", errors.ErrGetTags, err)
+		httperror.WriteJSONError(w, errors.ErrGetTags.Error(), err, errors.HTTPStatus(err, http.StatusInternalServerError))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	if err = json.NewEncoder(w).Encode(tags); err != nil {
+		h.logger.Errorf("Ошибка при отправке меток на клиент: %s", err)
+	}
+}
+
+// Создать метку
+func (h *TagHandler) createTag(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	ctx := r.Context()
+	userID, ok := r.Context().Value("user_id").(int64)
+	if !ok {
+		h.logger.Error(errors.ErrFailedToGetUserIDFromContext)
+		httperror.WriteJSONError(w, errors.ErrFailedToGetUserIDFromContext.Error(), nil, http.StatusInternalServerError)
+		return
+	}
+
+	var req request.TagDTO
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httperror.WriteJSONError(w, errors.ErrJSONNewDecoder.Error(), err, http.StatusBadRequest)
+		h.logger.Errorf("%s: %s", errors.ErrJSONNewDecoder, err)
+		return
+	}
+
+	tag, err := h.tagService.CreateTag(ctx, userID, req.Name)
+	if err != nil {
+		h.logger.Errorf("%s: %s", errors.ErrTagFailed, err)
+		httperror.WriteJSONError(w, errors.ErrTagFailed.Error(), err, errors.HTTPStatus(err, http.StatusInternalServerError))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+
+	if err = json.NewEncoder(w).Encode(tag); err != nil {
+		h.logger.Errorf("Ошибка при отправке метки на клиент: %s", err)
+	}
+}
+
+// Переименовать метку
+func (h *TagHandler) updateTag(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	ctx := r.Context()
+	userID, ok := r.Context().Value("user_id").(int64)
+	if !ok {
+		h.logger.Error(errors.ErrFailedToGetUserIDFromContext)
+		httperror.WriteJSONError(w, errors.ErrFailedToGetUserIDFromContext.Error(), nil, http.StatusInternalServerError)
+		return
+	}
+
+	var req request.TagDTO
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httperror.WriteJSONError(w, errors.ErrJSONNewDecoder.Error(), err, http.StatusBadRequest)
+		h.logger.Errorf("%s: %s", errors.ErrJSONNewDecoder, err)
+		return
+	}
+
+	id, _ := strconv.Atoi(ps.ByName("id"))
+
+	if err := h.tagService.UpdateTag(ctx, userID, int64(id), req.Name); err != nil {
+		h.logger.Errorf("%s : %v : %s", errors.ErrTagFailed, id, err)
+		httperror.WriteJSONError(w, errors.ErrTagFailed.Error(), err, errors.HTTPStatus(err, http.StatusInternalServerError))
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// Удалить метку
+func (h *TagHandler) deleteTag(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	ctx := r.Context()
+	userID, ok := r.Context().Value("user_id").(int64)
+	if !ok {
+		h.logger.Error(errors.ErrFailedToGetUserIDFromContext)
+		httperror.WriteJSONError(w, errors.ErrFailedToGetUserIDFromContext.Error(), nil, http.StatusInternalServerError)
+		return
+	}
+
+	id, _ := strconv.Atoi(ps.ByName("id"))
+
+	if err := h.tagService.DeleteTag(ctx, userID, int64(id)); err != nil {
+		h.logger.Errorf("%s : %v : %s", errors.ErrDeleteTag, id, err)
+		httperror.WriteJSONError(w, errors.ErrDeleteTag.Error(), err, errors.HTTPStatus(err, http.StatusInternalServerError))
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// Прикрепить метку к заметке
+func (h *TagHandler) attachTag(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	ctx := r.Context()
+	userID, ok := r.Context().Value("user_id").(int64)
+	if !ok {
+		h.logger.Error(errors.ErrFailedToGetUserIDFromContext)
+		httperror.WriteJSONError(w, errors.ErrFailedToGetUserIDFromContext.Error(), nil, http.StatusInternalServerError)
+		return
+	}
+
+	noteID, _ := strconv.Atoi(ps.ByName("id"))
+	tagID, _ := strconv.Atoi(ps.ByName("tagId"))
+
+	if err := h.tagService.AttachTag(ctx, userID, int64(noteID), int64(tagID)); err != nil {
+		h.logger.Errorf("%s : %v : %v : %s", errors.ErrAttachTag, noteID, tagID, err)
+		httperror.WriteJSONError(w, errors.ErrAttachTag.Error(), err, errors.HTTPStatus(err, http.StatusInternalServerError))
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// Открепить метку от заметки
+func (h *TagHandler) detachTag(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	ctx := r.Context()
+	userID, ok := r.Context().Value("user_id").(int64)
+	if !ok {
+		h.logger.Error(errors.ErrFailedToGetUserIDFromContext)
+		httperror.WriteJSONError(w, errors.ErrFailedToGetUserIDFromContext.Error(), nil, http.StatusInternalServerError)
+		return
+	}
+
+	noteID, _ := strconv.Atoi(ps.ByName("id"))
+	tagID, _ := strconv.Atoi(ps.ByName("tagId"))
+
+	if err := h.tagService.DetachTag(ctx, userID, int64(noteID), int64(tagID)); err != nil {
+		h.logger.Errorf("%s : %v : %v : %s", errors.ErrDetachTag, noteID, tagID, err)
+		httperror.WriteJSONError(w, errors.ErrDetachTag.Error(), err, errors.HTTPStatus(err, http.StatusInternalServerError))
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
@@ -0,0 +1,224 @@
+package handlers
+
+import (
+	"encoding/json"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/errors"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/service"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/transport/dto/request"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/pkg/httperror"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/pkg/logging"
+	"github.com/julienschmidt/httprouter"
+	"net/http"
+	"strconv"
+)
+
+// WebhookHandler обрабатывает запросы, связанные с исходящими webhook
+type WebhookHandler struct {
+	webhookService service.WebhookService
+	logger         *logging.Logger
+}
+
+// NewWebhookHandler создаёт новый обработчик webhook
+func NewWebhookHandler(webhookService service.WebhookService, logger *logging.Logger) *WebhookHandler {
+	return &WebhookHandler{
+		webhookService: webhookService,
+		logger:         logger,
+	}
+}
+
+// Получить подписки пользователя
+func (h *WebhookHandler) getWebhooks(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	ctx := r.Context()
+	userID, ok := r.Context().Value("user_id").(int64)
+	if !ok {
+		h.logger.Error(errors.ErrFailedToGetUserIDFromContext)
+		httperror.WriteJSONError(w, errors.ErrFailedToGetUserIDFromContext.Error(), nil, http.StatusInternalServerError)
+		return
+	}
+
+	webhooks, err := h.webhookService.GetWebhooks(ctx, userID)
+	if err != nil {
+		h.logger.Errorf("%s : %s", errors.ErrGetWebhooks, err)
+		httperror.WriteJSONError(w, errors.ErrGetWebhooks.Error(), err, errors.HTTPStatus(err, http.StatusInternalServerError))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	if err = json.NewEncoder(w).Encode(webhooks); err != nil {
+		h.logger.Errorf("Ошибка при отправке подписок на клиент: %s", err)
+	}
+}
+
+// Создать подписку. Секрет подписи возвращается только в этом ответе
+func (h *WebhookHandler) createWebhook(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	ctx := r.Context()
+	userID, ok := r.Context().Value("user_id").(int64)
+	if !ok {
+		h.logger.Error(errors.ErrFailedToGetUserIDFromContext)
+		httperror.WriteJSONError(w, errors.ErrFailedToGetUserIDFromContext.Error(), nil, http.StatusInternalServerError)
+		return
+	}
+
+	var req request.WebhookDTO
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httperror.WriteJSONError(w, errors.ErrJSONNewDecoder.Error(), err, http.StatusBadRequest)
+		h.logger.Errorf("%s: %s", errors.ErrJSONNewDecoder, err)
+		return
+	}
+
+	webhook, err := h.webhookService.CreateWebhook(ctx, userID, req)
+	if err != nil {
+		h.logger.Errorf("%s : %s", errors.ErrWebhookFailed, err)
+		httperror.WriteJSONError(w, errors.ErrWebhookFailed.Error(), err, errors.HTTPStatus(err, http.StatusInternalServerError))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+
+	if err = json.NewEncoder(w).Encode(webhook); err != nil {
+		h.logger.Errorf("Ошибка при отправке подписки на клиент: %s", err)
+	}
+}
+
+// Изменить подписку
+func (h *WebhookHandler) updateWebhook(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	ctx := r.Context()
+	userID, ok := r.Context().Value("user_id").(int64)
+	if !ok {
+		h.logger.Error(errors.ErrFailedToGetUserIDFromContext)
+		httperror.WriteJSONError(w, errors.ErrFailedToGetUserIDFromContext.Error(), nil, http.StatusInternalServerError)
+		return
+	}
+
+	var req request.WebhookDTO
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httperror.WriteJSONError(w, errors.ErrJSONNewDecoder.Error(), err, http.StatusBadRequest)
+		h.logger.Errorf("%s: %s", errors.ErrJSONNewDecoder, err)
+		return
+	}
+
+	id, _ := strconv.Atoi(ps.ByName("id"))
+
+	webhook, err := h.webhookService.UpdateWebhook(ctx, userID, int64(id), req)
+	if err != nil {
+		h.logger.Errorf("%s : %v : %s", errors.ErrWebhookFailed, id, err)
+		httperror.WriteJSONError(w, errors.ErrWebhookFailed.Error(), err, errors.HTTPStatus(err, http.StatusInternalServerError))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	if err = json.NewEncoder(w).Encode(webhook); err != nil {
+		h.logger.Errorf("Ошибка при отправке подписки на клиент: %s", err)
+	}
+}
+
+// Удалить подписку
+func (h *WebhookHandler) deleteWebhook(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	ctx := r.Context()
+	userID, ok := r.Context().Value("user_id").(int64)
+	if !ok {
+		h.logger.Error(errors.ErrFailedToGetUserIDFromContext)
+		httperror.WriteJSONError(w, errors.ErrFailedToGetUserIDFromContext.Error(), nil, http.StatusInternalServerError)
+		return
+	}
+
+	id, _ := strconv.Atoi(ps.ByName("id"))
+
+	if err := h.webhookService.DeleteWebhook(ctx, userID, int64(id)); err != nil {
+		h.logger.Errorf("%s : %v : %s", errors.ErrDeleteWebhook, id, err)
+		httperror.WriteJSONError(w, errors.ErrDeleteWebhook.Error(), err, errors.HTTPStatus(err, http.StatusInternalServerError))
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// Поставить в очередь проверочную доставку (событие ping)
+func (h *WebhookHandler) pingWebhook(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	ctx := r.Context()
+	userID, ok := r.Context().Value("user_id").(int64)
+	if !ok {
+		h.logger.Error(errors.ErrFailedToGetUserIDFromContext)
+		httperror.WriteJSONError(w, errors.ErrFailedToGetUserIDFromContext.Error(), nil, http.StatusInternalServerError)
+		return
+	}
+
+	id, _ := strconv.Atoi(ps.ByName("id"))
+
+	delivery, err := h.webhookService.PingWebhook(ctx, userID, int64(id))
+	if err != nil {
+		h.logger.Errorf("%s : %v : %s", errors.ErrDeliverWebhook, id, err)
+		httperror.WriteJSONError(w, errors.ErrDeliverWebhook.Error(), err, errors.HTTPStatus(err, http.StatusInternalServerError))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+
+	if err = json.NewEncoder(w).Encode(delivery); err != nil {
+		h.logger.Errorf("Ошибка при отправке доставки на клиент: %s", err)
+	}
+}
+
+// Журнал доставок подписки (новые первыми, ?limit= до 200)
+func (h *WebhookHandler) getWebhookDeliveries(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	ctx := r.Context()
+	userID, ok := r.Context().Value("user_id").(int64)
+	if !ok {
+		h.logger.Error(errors.ErrFailedToGetUserIDFromContext)
+		httperror.WriteJSONError(w, errors.ErrFailedToGetUserIDFromContext.Error(), nil, http.StatusInternalServerError)
+		return
+	}
+
+	id, _ := strconv.Atoi(ps.ByName("id"))
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+
+	deliveries, err := h.webhookService.GetWebhookDeliveries(ctx, userID, int64(id), limit)
+	if err != nil {
+		h.logger.Errorf("%s : %v : %s", errors.ErrGetWebhookLog, id, err)
+		httperror.WriteJSONError(w, errors.ErrGetWebhookLog.Error(), err, errors.HTTPStatus(err, http.StatusInternalServerError))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	if err = json.NewEncoder(w).Encode(deliveries); err != nil {
+		h.logger.Errorf("Ошибка при отправке журнала доставок на клиент: %s", err)
+	}
+}
+
+// Повторить доставку из журнала: в очередь ставится копия с тем же телом
+func (h *WebhookHandler) redeliverWebhook(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	ctx := r.Context()
+	userID, ok := r.Context().Value("user_id").(int64)
+	if !ok {
+		h.logger.Error(errors.ErrFailedToGetUserIDFromContext)
+		httperror.WriteJSONError(w, errors.ErrFailedToGetUserIDFromContext.Error(), nil, http.StatusInternalServerError)
+		return
+	}
+
+	id, _ := strconv.Atoi(ps.ByName("id"))
+	deliveryID, _ := strconv.Atoi(ps.ByName("deliveryId"))
+
+	delivery, err := h.webhookService.RedeliverWebhook(ctx, userID, int64(id), int64(deliveryID))
+	if err != nil {
+		h.logger.Errorf("%s : %v : %v : %s", errors.ErrRedeliverWebhook, id, deliveryID, err)
+		httperror.WriteJSONError(w, errors.ErrRedeliverWebhook.Error(), err, errors.HTTPStatus(err, http.StatusInternalServerError))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+
+	if err = json.NewEncoder(w).Encode(delivery); err != nil {
+		h.logger.Errorf("Ошибка при отправке доставки на клиент: %s", err)
+	}
+}
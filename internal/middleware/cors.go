@@ -23,6 +23,14 @@ func CorsSettings() *cors.Cors {
 			"Content-Type",
 			"Authorization",
 			"X-Requested-With", // Добавлен заголовок из corsMiddleware
+			"X-Share-Password", // Пароль публичной ссылки на заметку
+			"If-Match",         // Версия заметки при изменении (412 при несовпадении)
+			"If-None-Match",    // ETag ранее полученного ответа (304, если ничего не изменилось)
+			"Idempotency-Key",  // Ключ повторяемого изменяющего запроса
+		},
+		ExposedHeaders: []string{
+			"ETag",                // Версия заметки / содержимого ответа
+			"Idempotent-Replayed", // Ответ повторён по Idempotency-Key
 		},
 		OptionsPassthrough: false, // Прекращаем обработку preflight-запросов после CORS
 	})
@@ -0,0 +1,234 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/config"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/errors"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/models"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/service"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/pkg/httperror"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/pkg/logging"
+	"github.com/julienschmidt/httprouter"
+	"hash"
+	"io"
+	"net/http"
+	"os"
+	"time"
+)
+
+const (
+	defaultIdempotencyMaxBodySize = 1 << 20         // Максимальный размер тела запроса с ключом, если не задан в конфигурации
+	maxIdempotentResponseSize     = 1 << 20         // Ответ большего размера не сохраняется (повтор выполнит запрос заново)
+	idempotencyStoreTimeout       = 5 * time.Second // Таймаут сохранения ответа (контекст запроса к этому моменту может быть отменён)
+)
+
+// idempotentHeaders - заголовки ответа, которые сохраняются и возвращаются при повторе
+var idempotentHeaders = []string{"Content-Type", "ETag", "Location"}
+
+// Idempotency - middleware для изменяющих запросов с заголовком Idempotency-Key. Должен вызываться внутри Auth:
+// ключи хранятся отдельно для каждого пользователя.
+//
+// Первый запрос с ключом выполняется, его ответ сохраняется (кроме ответов 5xx - после них запрос можно повторить).
+// Повтор с тем же ключом и тем же запросом получает сохранённый ответ с заголовком Idempotent-Replayed: true,
+// повтор с другим телом - 422, повтор, пока первый запрос ещё выполняется, - 409. Запросы без ключа не меняются.
+// Тело запроса ограничено Config.Idempotency.MaxBodySize; для маршрутов с большими телами - IdempotencyWithLimit
+func Idempotency(idempotencyService service.IdempotencyService, cfg *config.Config, logger *logging.Logger) func(httprouter.Handle) httprouter.Handle {
+	return IdempotencyWithLimit(idempotencyService, cfg, logger, 0)
+}
+
+// IdempotencyWithLimit - Idempotency с собственным ограничением тела запроса (импорт, загрузка файлов).
+// maxBodySize <= 0 - ограничение из конфигурации. Тело хешируется потоком: в памяти остаётся не больше
+// Config.Idempotency.MaxBodySize, остальное записывается во временный файл и передаётся обработчику оттуда
+func IdempotencyWithLimit(idempotencyService service.IdempotencyService, cfg *config.Config, logger *logging.Logger, maxBodySize int64) func(httprouter.Handle) httprouter.Handle {
+	maxMemory := cfg.Idempotency.MaxBodySize
+	if maxMemory <= 0 {
+		maxMemory = defaultIdempotencyMaxBodySize
+	}
+	if maxBodySize <= 0 {
+		maxBodySize = maxMemory
+	}
+
+	return func(next httprouter.Handle) httprouter.Handle {
+		return func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+			key := r.Header.Get("Idempotency-Key")
+			if key == "" {
+				next(w, r, ps)
+				return
+			}
+
+			userID, ok := r.Context().Value("user_id").(int64)
+			if !ok {
+				logger.Error(errors.ErrFailedToGetUserIDFromContext)
+				httperror.WriteJSONError(w, errors.ErrFailedToGetUserIDFromContext.Error(), nil, http.StatusInternalServerError)
+				return
+			}
+
+			// Тело читается целиком: оно входит в отпечаток запроса и затем передаётся обработчику
+			fingerprint := newRequestFingerprint(r)
+			body := &spooledBody{maxMemory: maxMemory}
+			defer body.Close()
+
+			size, err := io.Copy(io.MultiWriter(fingerprint, body), io.LimitReader(r.Body, maxBodySize+1))
+			if err != nil {
+				logger.Errorf("%s : %s", errors.ErrIdempotencyFailed, err)
+				httperror.WriteJSONError(w, errors.ErrIdempotencyFailed.Error(), err, http.StatusBadRequest)
+				return
+			}
+			if size > maxBodySize {
+				httperror.WriteJSONError(w, errors.ErrIdempotencyRequestTooLarge.Error(), nil, http.StatusRequestEntityTooLarge)
+				return
+			}
+
+			reader, err := body.Reader()
+			if err != nil {
+				logger.Errorf("%s : %s", errors.ErrIdempotencyFailed, err)
+				httperror.WriteJSONError(w, errors.ErrIdempotencyFailed.Error(), err, http.StatusInternalServerError)
+				return
+			}
+			r.Body = io.NopCloser(reader)
+
+			record, replay, err := idempotencyService.AcquireIdempotencyKey(r.Context(), userID, key, hex.EncodeToString(fingerprint.Sum(nil)))
+			if err != nil {
+				logger.Errorf("%s : %s : %s", errors.ErrIdempotencyFailed, key, err)
+				httperror.WriteJSONError(w, errors.ErrIdempotencyFailed.Error(), err, errors.HTTPStatus(err, http.StatusInternalServerError))
+				return
+			}
+
+			if replay {
+				writeStoredResponse(w, record)
+				return
+			}
+
+			recorder := &responseRecorder{ResponseWriter: w}
+			next(recorder, r, ps)
+
+			ctx, cancel := context.WithTimeout(context.WithoutCancel(r.Context()), idempotencyStoreTimeout)
+			defer cancel()
+
+			if recorder.status >= http.StatusInternalServerError || recorder.overflow {
+				if err = idempotencyService.ReleaseIdempotencyKey(ctx, *record); err != nil {
+					logger.Errorf("%s : %s : %s", errors.ErrIdempotencyFailed, key, err)
+				}
+				return
+			}
+
+			record.ResponseStatus = recorder.statusCode()
+			record.ResponseHeaders = make(map[string]string, len(idempotentHeaders))
+			for _, name := range idempotentHeaders {
+				if value := w.Header().Get(name); value != "" {
+					record.ResponseHeaders[name] = value
+				}
+			}
+			record.ResponseBody = recorder.body.Bytes()
+
+			if err = idempotencyService.CompleteIdempotencyKey(ctx, *record); err != nil {
+				logger.Errorf("%s : %s : %s", errors.ErrIdempotencyFailed, key, err)
+			}
+		}
+	}
+}
+
+// newRequestFingerprint - отпечаток запроса: метод, путь и параметры; тело дописывается в хеш по мере чтения
+func newRequestFingerprint(r *http.Request) hash.Hash {
+	fingerprint := sha256.New()
+	fingerprint.Write([]byte(r.Method + " " + r.URL.Path + "?" + r.URL.RawQuery + "\n"))
+	return fingerprint
+}
+
+// spooledBody - копия тела запроса: в памяти, пока она не больше maxMemory, иначе во временном файле
+type spooledBody struct {
+	maxMemory int64
+	buf       bytes.Buffer
+	file      *os.File
+}
+
+func (b *spooledBody) Write(p []byte) (int, error) {
+	if b.file == nil && int64(b.buf.Len()+len(p)) <= b.maxMemory {
+		return b.buf.Write(p)
+	}
+
+	if b.file == nil {
+		file, err := os.CreateTemp("", "idempotency-*")
+		if err != nil {
+			return 0, err
+		}
+		b.file = file
+		if _, err = file.Write(b.buf.Bytes()); err != nil {
+			return 0, err
+		}
+		b.buf.Reset()
+	}
+
+	return b.file.Write(p)
+}
+
+// Reader - прочитать сохранённое тело с начала
+func (b *spooledBody) Reader() (io.Reader, error) {
+	if b.file == nil {
+		return bytes.NewReader(b.buf.Bytes()), nil
+	}
+	if _, err := b.file.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	return b.file, nil
+}
+
+// Close - удалить временный файл, если тело в него записывалось
+func (b *spooledBody) Close() error {
+	if b.file == nil {
+		return nil
+	}
+	b.file.Close()
+	return os.Remove(b.file.Name())
+}
+
+// writeStoredResponse - повторно отправить сохранённый ответ
+func writeStoredResponse(w http.ResponseWriter, record *models.IdempotencyKeys) {
+	for name, value := range record.ResponseHeaders {
+		w.Header().Set(name, value)
+	}
+	w.Header().Set("Idempotent-Replayed", "true")
+	w.WriteHeader(record.ResponseStatus)
+	_, _ = w.Write(record.ResponseBody)
+}
+
+// responseRecorder - передаёт ответ клиенту и запоминает его статус и тело
+type responseRecorder struct {
+	http.ResponseWriter
+	status   int
+	body     bytes.Buffer
+	overflow bool // Ответ больше maxIdempotentResponseSize и не сохраняется
+}
+
+func (rec *responseRecorder) WriteHeader(status int) {
+	if rec.status == 0 {
+		rec.status = status
+	}
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+func (rec *responseRecorder) Write(p []byte) (int, error) {
+	if rec.status == 0 {
+		rec.status = http.StatusOK
+	}
+	if !rec.overflow {
+		if rec.body.Len()+len(p) > maxIdempotentResponseSize {
+			rec.overflow = true
+			rec.body.Reset()
+		} else {
+			rec.body.Write(p)
+		}
+	}
+	return rec.ResponseWriter.Write(p)
+}
+
+// statusCode - статус ответа (200, если обработчик ничего не записал)
+func (rec *responseRecorder) statusCode() int {
+	if rec.status == 0 {
+		return http.StatusOK
+	}
+	return rec.status
+}
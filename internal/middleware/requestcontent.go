@@ -6,9 +6,19 @@ import (
 	"time"
 )
 
+// streamingPaths - долгие соединения (поток событий), для которых тайм-аут не устанавливается
+var streamingPaths = map[string]bool{
+	"/events": true,
+}
+
 // Middleware для установки контекста с тайм-аутом
 func RequestContext(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if streamingPaths[r.URL.Path] {
+			next.ServeHTTP(w, r)
+			return
+		}
+
 		// Устанавливаем контекст с тайм-аутом 10 секунд
 		ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
 		defer cancel() // Отмена контекста после завершения
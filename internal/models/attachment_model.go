@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// Структура для таблицы attachments
+type Attachments struct {
+	ID          int64     `json:"ID" gorm:"primaryKey;column:id"`         // Первичный ключ
+	NoteID      *int64    `json:"noteID" gorm:"column:note_id"`           // Заметка (NULL - заметка удалена, файл ждёт удаления)
+	UserID      int64     `json:"userID" gorm:"column:user_id"`           // Владелец заметки
+	FileName    string    `json:"fileName" gorm:"column:file_name"`       // Исходное имя файла
+	ContentType string    `json:"contentType" gorm:"column:content_type"` // Тип содержимого
+	Size        int64     `json:"size" gorm:"column:size"`                // Размер в байтах
+	StorageKey  string    `json:"-" gorm:"column:storage_key"`            // Ключ объекта в хранилище
+	CreatedAt   time.Time `json:"createdAt" gorm:"column:created_at"`     // Время загрузки
+}
@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+// Структура для таблицы calendar_feeds
+type CalendarFeeds struct {
+	UserID         int64      `json:"userID" gorm:"primaryKey;column:user_id"`                 // Владелец ленты
+	TokenHash      string     `json:"-" gorm:"column:token_hash"`                              // SHA-256 токена ленты
+	CreatedAt      time.Time  `json:"createdAt" gorm:"column:created_at"`                      // Время создания (или смены) токена
+	LastAccessedAt *time.Time `json:"lastAccessedAt,omitempty" gorm:"column:last_accessed_at"` // Время последнего обращения клиента
+	LastSyncedAt   *time.Time `json:"lastSyncedAt,omitempty" gorm:"column:last_synced_at"`     // Время последней загрузки задач
+}
+
+// CalendarTodo - заметка в ленте задач iCalendar (VTODO)
+type CalendarTodo struct {
+	ID         int64      // ID заметки
+	ICalUID    string     // UID задачи, созданной в календарном клиенте (пустой - UID строится по ID)
+	Note       string     // Текст заметки (с HTML-экранированием, как в БД)
+	Completed  bool       // Статус выполнения
+	CreatedAt  time.Time  // Дата создания
+	DueAt      *time.Time // Срок выполнения
+	Priority   string     // Приоритет: low, normal, high, urgent
+	RRule      string     // Правило повторения RFC 5545
+	RRuleStart *time.Time // Начало серии повторений (DTSTART)
+	List       string     // Название списка
+	Deleted    bool       // Заметка в корзине
+}
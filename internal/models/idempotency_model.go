@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// Структура для таблицы idempotency_keys
+type IdempotencyKeys struct {
+	UserID          int64             `json:"userID" gorm:"primaryKey;column:user_id"`        // Владелец ключа
+	Key             string            `json:"key" gorm:"primaryKey;column:key"`               // Значение заголовка Idempotency-Key
+	Fingerprint     string            `json:"fingerprint" gorm:"column:fingerprint"`          // Отпечаток запроса
+	Status          string            `json:"status" gorm:"column:status"`                    // Статус: processing, completed
+	ResponseStatus  int               `json:"responseStatus" gorm:"column:response_status"`   // HTTP-статус сохранённого ответа
+	ResponseHeaders map[string]string `json:"responseHeaders" gorm:"column:response_headers"` // Заголовки сохранённого ответа
+	ResponseBody    []byte            `json:"-" gorm:"column:response_body"`                  // Тело сохранённого ответа
+	CreatedAt       time.Time         `json:"createdAt" gorm:"column:created_at"`             // Время первого запроса
+	ExpiresAt       time.Time         `json:"expiresAt" gorm:"column:expires_at"`             // Окончание срока хранения
+}
+
+// Статусы ключа Idempotency-Key
+const (
+	IdempotencyProcessing = "processing"
+	IdempotencyCompleted  = "completed"
+)
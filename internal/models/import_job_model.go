@@ -0,0 +1,41 @@
+package models
+
+import "time"
+
+// Статусы задания импорта
+const (
+	ImportJobQueued  = "queued"  // Ждёт обработчика
+	ImportJobRunning = "running" // Выполняется
+	ImportJobDone    = "done"    // Завершено (отдельные задачи могли не импортироваться - см. Errors)
+	ImportJobFailed  = "failed"  // Не выполнено (файл недоступен или не разобран)
+)
+
+// Структура для таблицы import_jobs
+type ImportJobs struct {
+	ID           int64            `json:"ID" gorm:"primaryKey;column:id"`                 // Первичный ключ
+	UserID       int64            `json:"userID" gorm:"column:user_id"`                   // Владелец задания
+	Source       string           `json:"source" gorm:"column:source"`                    // Источник: todoist, mstodo, gtasks
+	ListName     string           `json:"listName" gorm:"column:list_name"`               // Список для задач без проекта
+	StorageKey   string           `json:"-" gorm:"column:storage_key"`                    // Ключ файла в хранилище
+	Status       string           `json:"status" gorm:"column:status"`                    // queued, running, done, failed
+	Total        int              `json:"total" gorm:"column:total"`                      // Задач в файле
+	Processed    int              `json:"processed" gorm:"column:processed"`              // Обработано задач
+	Progress     int              `json:"progress" gorm:"-"`                              // Выполнено, % (0..100)
+	ListsCreated int              `json:"listsCreated" gorm:"column:lists_created"`       // Создано списков
+	NotesCreated int              `json:"notesCreated" gorm:"column:notes_created"`       // Создано заметок
+	ItemsCreated int              `json:"itemsCreated" gorm:"column:items_created"`       // Создано пунктов чек-листа
+	Failed       int              `json:"failed" gorm:"column:failed"`                    // Задачи с ошибками
+	Errors       []ImportJobError `json:"errors" gorm:"column:errors"`                    // Ошибки по задачам (не более 100)
+	Error        string           `json:"error,omitempty" gorm:"column:error"`            // Причина, по которой задание не выполнено
+	Attempts     int              `json:"-" gorm:"column:attempts"`                       // Количество захватов обработчиком
+	CreatedAt    time.Time        `json:"createdAt" gorm:"column:created_at"`             // Время загрузки файла
+	StartedAt    *time.Time       `json:"startedAt,omitempty" gorm:"column:started_at"`   // Время начала обработки
+	FinishedAt   *time.Time       `json:"finishedAt,omitempty" gorm:"column:finished_at"` // Время завершения
+}
+
+// ImportJobError - задача, которую не удалось импортировать
+type ImportJobError struct {
+	Task  int    `json:"task"`  // Номер задачи в файле (с 1)
+	Title string `json:"title"` // Текст задачи
+	Error string `json:"error"` // Текст ошибки
+}
@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// DefaultListName - название списка по умолчанию
+const DefaultListName = "Inbox"
+
+// Структура для таблицы lists
+type Lists struct {
+	ID        int64     `json:"ID" gorm:"primaryKey;column:id"`     // Первичный ключ
+	UserID    int64     `json:"userID" gorm:"column:user_id"`       // Владелец списка
+	Name      string    `json:"name" gorm:"column:name"`            // Название списка
+	Color     string    `json:"color" gorm:"column:color"`          // Цвет в формате #RRGGBB
+	Position  int       `json:"position" gorm:"column:position"`    // Порядок списка
+	IsDefault bool      `json:"isDefault" gorm:"column:is_default"` // Список по умолчанию (Inbox)
+	CreatedAt time.Time `gorm:"column:created_at"`                  // Дата создания
+}
@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// Структура для таблицы note_comments
+type NoteComments struct {
+	ID         int64      `json:"ID" gorm:"primaryKey;column:id"`             // Первичный ключ
+	NoteID     int64      `json:"noteID" gorm:"column:note_id"`               // Заметка, к которой относится комментарий
+	UserID     int64      `json:"userID" gorm:"column:user_id"`               // Автор комментария
+	AuthorName string     `json:"authorName" gorm:"-"`                        // Имя автора
+	Body       string     `json:"body" gorm:"column:body"`                    // Текст комментария
+	CreatedAt  time.Time  `json:"createdAt" gorm:"column:created_at"`         // Время создания
+	EditedAt   *time.Time `json:"editedAt,omitempty" gorm:"column:edited_at"` // Время последнего изменения
+}
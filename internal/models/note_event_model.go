@@ -0,0 +1,18 @@
+package models
+
+// Типы событий заметок
+const (
+	NoteEventCreated   = "created"
+	NoteEventUpdated   = "updated"
+	NoteEventCompleted = "completed"
+	NoteEventDeleted   = "deleted" // Перемещение в корзину или окончательное удаление
+)
+
+// NoteEvent - событие изменения заметки (приходит из PostgreSQL через NOTIFY note_events)
+type NoteEvent struct {
+	Type   string `json:"type"`   // created | updated | completed | deleted
+	UserID int64  `json:"userID"` // Владелец заметки - получатель события
+	NoteID int64  `json:"noteID"` // Изменённая заметка
+	ListID int64  `json:"listID"` // Список заметки
+	Seq    int64  `json:"seq"`    // Номер изменения (changeSeq заметки после изменения)
+}
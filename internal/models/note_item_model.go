@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+// Структура для таблицы note_items
+type NoteItems struct {
+	ID        int64     `json:"ID" gorm:"primaryKey;column:id"`    // Первичный ключ
+	NoteID    int64     `json:"noteID" gorm:"column:note_id"`      // Заметка, к которой относится пункт
+	Text      string    `json:"text" gorm:"column:text"`           // Текст пункта
+	Completed bool      `json:"completed" gorm:"column:completed"` // Статус выполнения
+	Position  int       `json:"position" gorm:"column:position"`   // Порядок пункта внутри заметки
+	CreatedAt time.Time `gorm:"column:created_at"`                 // Дата создания
+}
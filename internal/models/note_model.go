@@ -4,9 +4,82 @@ import "time"
 
 // Структура для таблицы all_notes
 type AllNotes struct {
-	ID        int64     `json:"ID" gorm:"primaryKey;column:id"`    // Первичный ключ
-	Note      string    `json:"note" gorm:"column:note"`           // Поле заметки
-	Completed bool      `json:"completed" gorm:"column:completed"` // Статус выполнения
-	UserID    int64     `json:"userID" gorm:"column:user_id"`      // Связь с таблицей users
-	CreatedAt time.Time `gorm:"column:created_at"`                 // Дата создания
+	ID         int64      `json:"ID" gorm:"primaryKey;column:id"`               // Первичный ключ
+	Note       string     `json:"note" gorm:"column:note"`                      // Поле заметки
+	Completed  bool       `json:"completed" gorm:"column:completed"`            // Статус выполнения
+	UserID     int64      `json:"userID" gorm:"column:user_id"`                 // Связь с таблицей users
+	ListID     int64      `json:"listID" gorm:"column:list_id"`                 // Список, в котором находится заметка
+	CreatedAt  time.Time  `gorm:"column:created_at"`                            // Дата создания
+	DueAt      *time.Time `json:"dueAt" gorm:"column:due_at"`                   // Срок выполнения (может быть NULL)
+	Priority   string     `json:"priority" gorm:"column:priority"`              // Приоритет: low, normal, high, urgent
+	Position   int64      `json:"position" gorm:"column:position"`              // Позиция при ручной сортировке
+	DeletedAt  *time.Time `json:"deletedAt,omitempty" gorm:"column:deleted_at"` // Время перемещения в корзину (NULL - не удалена)
+	RRule      string     `json:"rrule" gorm:"column:rrule"`                    // Правило повторения RFC 5545 (пустое - не повторяется)
+	PreviousID *int64     `json:"previousID" gorm:"column:previous_id"`         // Выполненное повторение, из которого создана заметка
+	Tags       []Tags     `json:"tags" gorm:"many2many:note_tags"`              // Метки заметки
+	ICalUID    string     `json:"-" gorm:"column:ical_uid"`                     // UID задачи из календарного клиента (задаётся только при создании)
+	ChangeSeq  int64      `json:"changeSeq" gorm:"column:change_seq"`           // Номер последнего изменения (для синхронизации и обнаружения конфликтов)
+	UpdatedAt  time.Time  `json:"updatedAt" gorm:"column:updated_at"`           // Время последнего изменения
+	Version    int64      `json:"version" gorm:"column:version"`                // Версия заметки (ETag для If-Match)
+
+	ItemsTotal     int `json:"itemsTotal" gorm:"-"`     // Количество пунктов чек-листа
+	ItemsCompleted int `json:"itemsCompleted" gorm:"-"` // Количество выполненных пунктов чек-листа
+	CommentsCount  int `json:"commentsCount" gorm:"-"`  // Количество комментариев
+}
+
+// NoteUpdate - изменение заметки. Текст меняется всегда, остальные поля - только если заданы
+type NoteUpdate struct {
+	ID       int64      // Заметка
+	UserID   int64      // Владелец заметки
+	Note     string     // Новый текст
+	SetDueAt bool       // Менять ли срок
+	DueAt    *time.Time // Новый срок при SetDueAt (nil - убрать срок)
+	Priority *string    // Новый приоритет (nil - не меняется)
+	RRule    *string    // Новое правило повторения (nil - не меняется, пустое - убрать повторение)
+}
+
+// Приоритеты заметок
+const (
+	PriorityLow    = "low"
+	PriorityNormal = "normal"
+	PriorityHigh   = "high"
+	PriorityUrgent = "urgent"
+)
+
+// NoteFilter - параметры выборки заметок пользователя
+type NoteFilter struct {
+	ListID        int64       // Фильтр по списку (0 - все списки)
+	Completed     *bool       // Фильтр по статусу выполнения (nil - без фильтра)
+	CreatedAfter  *time.Time  // Заметки, созданные после указанного времени
+	CreatedBefore *time.Time  // Заметки, созданные до указанного времени
+	TagIDs        []int64     // Фильтр по меткам
+	MatchAllTags  bool        // true - заметка должна иметь все метки, false - хотя бы одну
+	SortBy        string      // Поле сортировки: position, created_at, id, note
+	Desc          bool        // Сортировка по убыванию
+	Limit         int         // Максимальное количество заметок на странице
+	After         *NoteCursor // Курсор, после которого начинается страница
+}
+
+// NoteCursor - позиция последней заметки на предыдущей странице
+type NoteCursor struct {
+	SortBy    string    `json:"s"`           // Поле сортировки, для которого выдан курсор
+	Desc      bool      `json:"d,omitempty"` // Направление сортировки
+	ID        int64     `json:"i"`           // ID последней заметки
+	Note      string    `json:"n,omitempty"` // Текст последней заметки (сортировка по note)
+	CreatedAt time.Time `json:"c"`           // Дата создания последней заметки (сортировка по created_at)
+	Position  int64     `json:"p,omitempty"` // Позиция последней заметки (сортировка по position)
+}
+
+// NextOccurrenceFunc - расчёт следующего повторения серии rule, начавшейся в start, после срока due.
+// ok == false, если серия закончилась
+type NextOccurrenceFunc func(rule string, start, due time.Time) (next time.Time, ok bool)
+
+// NotePositionGap - шаг между позициями соседних заметок после перебалансировки
+const NotePositionGap int64 = 1 << 16
+
+// NoteSearchResult - заметка, найденная полнотекстовым поиском
+type NoteSearchResult struct {
+	AllNotes
+	Rank    float32 `json:"rank"`    // Релевантность заметки запросу
+	Snippet string  `json:"snippet"` // Фрагмент текста с подсвеченными совпадениями
 }
@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+// Структура для таблицы note_revisions (ревизия заметки: состояние до и после изменения)
+type NoteRevisions struct {
+	ID           int64      `json:"ID" gorm:"primaryKey;column:id"`           // Первичный ключ
+	NoteID       int64      `json:"noteID" gorm:"column:note_id"`             // Связь с таблицей all_notes
+	Rev          int        `json:"rev" gorm:"column:rev"`                    // Номер ревизии внутри заметки
+	UserID       int64      `json:"userID" gorm:"column:user_id"`             // Кто внёс изменение
+	Action       string     `json:"action" gorm:"column:action"`              // Тип изменения: update, complete, revert
+	OldNote      string     `json:"oldNote" gorm:"column:old_note"`           // Текст до изменения
+	NewNote      string     `json:"newNote" gorm:"column:new_note"`           // Текст после изменения
+	OldCompleted bool       `json:"oldCompleted" gorm:"column:old_completed"` // Статус выполнения до изменения
+	NewCompleted bool       `json:"newCompleted" gorm:"column:new_completed"` // Статус выполнения после изменения
+	OldDueAt     *time.Time `json:"oldDueAt" gorm:"column:old_due_at"`        // Срок выполнения до изменения
+	NewDueAt     *time.Time `json:"newDueAt" gorm:"column:new_due_at"`        // Срок выполнения после изменения
+	OldPriority  string     `json:"oldPriority" gorm:"column:old_priority"`   // Приоритет до изменения
+	NewPriority  string     `json:"newPriority" gorm:"column:new_priority"`   // Приоритет после изменения
+	CreatedAt    time.Time  `json:"createdAt" gorm:"column:created_at"`       // Когда внесено изменение
+}
+
+// Типы изменений заметки
+const (
+	RevisionActionUpdate   = "update"
+	RevisionActionComplete = "complete"
+	RevisionActionRevert   = "revert"
+)
@@ -0,0 +1,31 @@
+package models
+
+import "time"
+
+// Структура для таблицы note_tombstones
+type NoteTombstones struct {
+	NoteID    int64     `json:"noteID" gorm:"primaryKey;column:note_id"` // ID окончательно удалённой заметки
+	UserID    int64     `json:"userID" gorm:"column:user_id"`            // Владелец заметки
+	ChangeSeq int64     `json:"changeSeq" gorm:"column:change_seq"`      // Номер изменения (удаления)
+	DeletedAt time.Time `json:"deletedAt" gorm:"column:deleted_at"`      // Время удаления
+}
+
+// SyncToken - позиция клиента в журнале изменений: последнее полученное изменение (номер изменения, ID заметки)
+type SyncToken struct {
+	Seq int64 `json:"s"`
+	ID  int64 `json:"i,omitempty"`
+}
+
+// SyncChanges - страница изменений заметок пользователя по возрастанию номера изменения
+type SyncChanges struct {
+	Notes   []AllNotes       // Созданные и изменённые заметки (в том числе перемещённые в корзину)
+	Deleted []NoteTombstones // Окончательно удалённые заметки
+	Next    SyncToken        // Позиция, с которой продолжать синхронизацию
+	HasMore bool             // Изменения получены не полностью
+}
+
+// NoteSyncState - версия заметки для обнаружения конфликтов при загрузке изменений клиента
+type NoteSyncState struct {
+	ChangeSeq int64     // Номер последнего изменения
+	UpdatedAt time.Time // Время последнего изменения
+}
@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// Форматы экспорта и импорта заметок
+const (
+	NoteFormatJSON     = "json"
+	NoteFormatCSV      = "csv"
+	NoteFormatMarkdown = "md"
+)
+
+// NoteRecord - заметка в файле экспорта или импорта (JSON, CSV, Markdown-чек-лист).
+// Текст и название списка хранятся без HTML-экранирования
+type NoteRecord struct {
+	Note      string     `json:"note"`                // Текст заметки
+	Completed bool       `json:"completed"`           // Статус выполнения
+	CreatedAt *time.Time `json:"createdAt,omitempty"` // Дата создания
+	DueAt     *time.Time `json:"dueAt,omitempty"`     // Срок выполнения
+	Priority  string     `json:"priority,omitempty"`  // Приоритет: low, normal, high, urgent
+	List      string     `json:"list,omitempty"`      // Название списка
+	RRule     string     `json:"rrule,omitempty"`     // Правило повторения RFC 5545
+}
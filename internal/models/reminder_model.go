@@ -0,0 +1,41 @@
+package models
+
+import "time"
+
+// Структура для таблицы reminders
+type Reminders struct {
+	ID            int64      `json:"ID" gorm:"primaryKey;column:id"`               // Первичный ключ
+	NoteID        int64      `json:"noteID" gorm:"column:note_id"`                 // Связь с таблицей all_notes
+	UserID        int64      `json:"userID" gorm:"column:user_id"`                 // Владелец напоминания
+	RemindAt      time.Time  `json:"remindAt" gorm:"column:remind_at"`             // Когда отправить напоминание
+	Channel       string     `json:"channel" gorm:"column:channel"`                // Канал доставки: email, webhook
+	Target        string     `json:"target" gorm:"column:target"`                  // Email или URL webhook
+	Status        string     `json:"status" gorm:"column:status"`                  // Статус: pending, sending, sent, failed
+	Attempts      int        `json:"attempts" gorm:"column:attempts"`              // Количество попыток отправки
+	NextAttemptAt time.Time  `json:"nextAttemptAt" gorm:"column:next_attempt_at"`  // Время следующей попытки
+	LastError     string     `json:"lastError,omitempty" gorm:"column:last_error"` // Ошибка последней неудачной попытки
+	SentAt        *time.Time `json:"sentAt,omitempty" gorm:"column:sent_at"`       // Время успешной отправки
+	CreatedAt     time.Time  `json:"createdAt" gorm:"column:created_at"`           // Дата создания
+}
+
+// Каналы доставки напоминаний
+const (
+	ReminderChannelEmail   = "email"
+	ReminderChannelWebhook = "webhook"
+)
+
+// Статусы доставки напоминаний
+const (
+	ReminderStatusPending = "pending"
+	ReminderStatusSending = "sending"
+	ReminderStatusSent    = "sent"
+	ReminderStatusFailed  = "failed"
+)
+
+// DueReminder - напоминание, захваченное планировщиком, вместе с текстом заметки
+type DueReminder struct {
+	Reminders
+	Note        string     // Текст заметки
+	DueAt       *time.Time // Срок выполнения заметки
+	LockedUntil time.Time  // До какого времени напоминание захвачено (признак захвата для Mark* и Release*)
+}
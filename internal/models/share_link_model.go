@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// Структура для таблицы share_links
+type ShareLinks struct {
+	ID             int64      `json:"ID" gorm:"primaryKey;column:id"`                          // Первичный ключ
+	NoteID         int64      `json:"noteID" gorm:"column:note_id"`                            // Заметка, доступная по ссылке
+	UserID         int64      `json:"userID" gorm:"column:user_id"`                            // Владелец заметки
+	TokenHash      string     `json:"-" gorm:"column:token_hash"`                              // SHA-256 токена ссылки
+	PasswordHash   string     `json:"-" gorm:"column:password_hash"`                           // bcrypt-хеш пароля (пустой - без пароля)
+	HasPassword    bool       `json:"hasPassword" gorm:"-"`                                    // Защищена ли ссылка паролем
+	ExpiresAt      *time.Time `json:"expiresAt" gorm:"column:expires_at"`                      // Срок действия (NULL - бессрочно)
+	RevokedAt      *time.Time `json:"revokedAt,omitempty" gorm:"column:revoked_at"`            // Время отзыва ссылки
+	AccessCount    int64      `json:"accessCount" gorm:"column:access_count"`                  // Количество открытий
+	LastAccessedAt *time.Time `json:"lastAccessedAt,omitempty" gorm:"column:last_accessed_at"` // Время последнего открытия
+	FailedAttempts int        `json:"failedAttempts" gorm:"column:failed_attempts"`            // Неверные пароли подряд
+	LockedUntil    *time.Time `json:"lockedUntil,omitempty" gorm:"column:locked_until"`        // До какого времени проверка пароля заблокирована
+	CreatedAt      time.Time  `json:"createdAt" gorm:"column:created_at"`                      // Дата создания
+}
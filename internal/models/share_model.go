@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+// Роли доступа к заметкам и спискам
+const (
+	ShareRoleOwner  = "owner"  // Владелец: полный доступ, управление доступом
+	ShareRoleEditor = "editor" // Редактор: просмотр и изменение заметок
+	ShareRoleViewer = "viewer" // Читатель: только просмотр
+)
+
+// Структура для таблиц note_shares и list_shares
+type Shares struct {
+	UserID    int64     `json:"userID" gorm:"column:user_id"`       // Пользователь, которому открыт доступ
+	UserName  string    `json:"username" gorm:"-"`                  // Имя пользователя
+	Email     string    `json:"email" gorm:"-"`                     // Email пользователя
+	Role      string    `json:"role" gorm:"column:role"`            // Роль: viewer, editor
+	CreatedAt time.Time `json:"createdAt" gorm:"column:created_at"` // Когда открыт доступ
+}
+
+// SharedNote - заметка другого пользователя, доступная текущему
+type SharedNote struct {
+	AllNotes
+	Role      string `json:"role"`      // Роль текущего пользователя
+	OwnerName string `json:"ownerName"` // Имя владельца заметки
+}
+
+// SharedList - список другого пользователя, доступный текущему
+type SharedList struct {
+	Lists
+	Role      string `json:"role"`      // Роль текущего пользователя
+	OwnerName string `json:"ownerName"` // Имя владельца списка
+}
@@ -0,0 +1,11 @@
+package models
+
+import "time"
+
+// Структура для таблицы tags
+type Tags struct {
+	ID        int64     `json:"ID" gorm:"primaryKey;column:id"` // Первичный ключ
+	UserID    int64     `json:"userID" gorm:"column:user_id"`   // Владелец метки
+	Name      string    `json:"name" gorm:"column:name"`        // Название метки
+	CreatedAt time.Time `gorm:"column:created_at"`              // Дата создания
+}
@@ -0,0 +1,67 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Структура для таблицы webhooks
+type Webhooks struct {
+	ID        int64     `json:"ID" gorm:"primaryKey;column:id"`        // Первичный ключ
+	UserID    int64     `json:"userID" gorm:"column:user_id"`          // Владелец подписки
+	URL       string    `json:"url" gorm:"column:url"`                 // Адрес получателя
+	Secret    string    `json:"secret,omitempty" gorm:"column:secret"` // Секрет подписи (возвращается только при создании)
+	Events    []string  `json:"events" gorm:"column:events"`           // Типы событий (пустой - все события)
+	Active    bool      `json:"active" gorm:"column:active"`           // Подписка включена
+	CreatedAt time.Time `json:"createdAt" gorm:"column:created_at"`    // Дата создания
+}
+
+// Типы событий webhook
+const (
+	WebhookEventNoteCreated   = "note.created"
+	WebhookEventNoteUpdated   = "note.updated"
+	WebhookEventNoteCompleted = "note.completed"
+	WebhookEventNoteDeleted   = "note.deleted"
+	WebhookEventPing          = "ping" // Проверочная доставка, отправляется всегда независимо от фильтра
+)
+
+// Структура для таблицы webhook_deliveries
+type WebhookDeliveries struct {
+	ID             int64           `json:"ID" gorm:"primaryKey;column:id"`                     // Первичный ключ
+	WebhookID      int64           `json:"webhookID" gorm:"column:webhook_id"`                 // Подписка
+	Event          string          `json:"event" gorm:"column:event"`                          // Тип события
+	Payload        json.RawMessage `json:"payload" gorm:"column:payload"`                      // Тело запроса
+	Status         string          `json:"status" gorm:"column:status"`                        // Статус: pending, sending, delivered, failed
+	Attempts       int             `json:"attempts" gorm:"column:attempts"`                    // Количество попыток
+	NextAttemptAt  time.Time       `json:"nextAttemptAt" gorm:"column:next_attempt_at"`        // Время следующей попытки
+	ResponseStatus *int            `json:"responseStatus" gorm:"column:response_status"`       // HTTP-статус последнего ответа
+	ResponseBody   string          `json:"responseBody,omitempty" gorm:"column:response_body"` // Начало тела последнего ответа
+	DurationMs     *int            `json:"durationMs" gorm:"column:duration_ms"`               // Длительность последней попытки
+	LastError      string          `json:"lastError,omitempty" gorm:"column:last_error"`       // Ошибка последней неудачной попытки
+	RedeliveryOf   *int64          `json:"redeliveryOf,omitempty" gorm:"column:redelivery_of"` // Исходная доставка (повторная доставка вручную)
+	CreatedAt      time.Time       `json:"createdAt" gorm:"column:created_at"`                 // Время события
+	DeliveredAt    *time.Time      `json:"deliveredAt,omitempty" gorm:"column:delivered_at"`   // Время успешной доставки
+}
+
+// Статусы доставки webhook
+const (
+	WebhookDeliveryPending   = "pending"
+	WebhookDeliverySending   = "sending"
+	WebhookDeliveryDelivered = "delivered"
+	WebhookDeliveryFailed    = "failed"
+)
+
+// DueWebhookDelivery - доставка, захваченная обработчиком, вместе с адресом и секретом подписки
+type DueWebhookDelivery struct {
+	WebhookDeliveries
+	URL         string
+	Secret      string
+	LockedUntil time.Time // До какого времени доставка захвачена (признак захвата для Mark* и Release*)
+}
+
+// WebhookAttempt - итог попытки доставки для журнала
+type WebhookAttempt struct {
+	ResponseStatus *int   // HTTP-статус ответа (nil - ответа нет)
+	ResponseBody   string // Начало тела ответа
+	DurationMs     int    // Длительность попытки
+}
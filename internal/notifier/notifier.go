@@ -0,0 +1,21 @@
+package notifier
+
+import (
+	"context"
+	"time"
+)
+
+// Message - напоминание, которое нужно доставить пользователю
+type Message struct {
+	ReminderID int64      `json:"reminderID"`
+	NoteID     int64      `json:"noteID"`
+	Note       string     `json:"note"`  // Текст заметки
+	DueAt      *time.Time `json:"dueAt"` // Срок выполнения заметки
+	RemindAt   time.Time  `json:"remindAt"`
+	Target     string     `json:"-"` // Email или URL webhook
+}
+
+// Notifier - канал доставки напоминаний
+type Notifier interface {
+	Notify(ctx context.Context, msg Message) error
+}
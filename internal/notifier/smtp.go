@@ -0,0 +1,142 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/config"
+	"html"
+	"mime"
+	"net"
+	"net/smtp"
+	"strconv"
+	"time"
+)
+
+// SMTPNotifier отправляет напоминания по email через SMTP-сервер
+type SMTPNotifier struct {
+	cfg config.SMTP
+}
+
+// NewSMTPNotifier создаёт email-канал доставки напоминаний
+func NewSMTPNotifier(cfg config.SMTP) *SMTPNotifier {
+	return &SMTPNotifier{
+		cfg: cfg,
+	}
+}
+
+// Notify отправляет письмо с напоминанием на msg.Target.
+// Соединение открывается с ctx и получает его срок, поэтому отправка не переживает отмену ctx
+func (n *SMTPNotifier) Notify(ctx context.Context, msg Message) error {
+	addr := net.JoinHostPort(n.cfg.Host, strconv.Itoa(n.cfg.Port))
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return fmt.Errorf("smtp: %w", err)
+	}
+	defer conn.Close()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		if err = conn.SetDeadline(deadline); err != nil {
+			return fmt.Errorf("smtp: %w", err)
+		}
+	}
+
+	// Отмена ctx без срока прерывает чтение и запись на соединении
+	stop := context.AfterFunc(ctx, func() { _ = conn.SetDeadline(time.Now()) })
+	defer stop()
+
+	if err = n.send(conn, msg); err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return fmt.Errorf("smtp: %w", err)
+	}
+
+	return nil
+}
+
+// send - SMTP-диалог на открытом соединении, как в smtp.SendMail: STARTTLS, если сервер его поддерживает,
+// авторизация, если задан Username, и одно письмо на msg.Target
+func (n *SMTPNotifier) send(conn net.Conn, msg Message) error {
+	c, err := smtp.NewClient(conn, n.cfg.Host)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	if err = c.Hello("localhost"); err != nil {
+		return err
+	}
+
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err = c.StartTLS(&tls.Config{ServerName: n.cfg.Host}); err != nil {
+			return err
+		}
+	}
+
+	if n.cfg.Username != "" {
+		if ok, _ := c.Extension("AUTH"); !ok {
+			return errors.New("server doesn't support AUTH")
+		}
+		if err = c.Auth(smtp.PlainAuth("", n.cfg.Username, n.cfg.Password, n.cfg.Host)); err != nil {
+			return err
+		}
+	}
+
+	if err = c.Mail(n.cfg.From); err != nil {
+		return err
+	}
+	if err = c.Rcpt(msg.Target); err != nil {
+		return err
+	}
+
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err = w.Write(n.buildMessage(msg)); err != nil {
+		return err
+	}
+	if err = w.Close(); err != nil {
+		return err
+	}
+
+	return c.Quit()
+}
+
+// buildMessage - собираем письмо (заголовки и текст в UTF-8)
+func (n *SMTPNotifier) buildMessage(msg Message) []byte {
+	// Текст заметки хранится экранированным для HTML, в письме нужен исходный текст
+	note := html.UnescapeString(msg.Note)
+
+	var body bytes.Buffer
+	fmt.Fprintf(&body, "Напоминание: %s\r\n", note)
+	if msg.DueAt != nil {
+		fmt.Fprintf(&body, "Срок выполнения: %s\r\n", msg.DueAt.Format(time.RFC1123Z))
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", n.cfg.From)
+	fmt.Fprintf(&buf, "To: %s\r\n", msg.Target)
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", "Напоминание: "+truncate(note, 60)))
+	fmt.Fprintf(&buf, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	buf.WriteString("Content-Transfer-Encoding: 8bit\r\n\r\n")
+	buf.Write(body.Bytes())
+
+	return buf.Bytes()
+}
+
+// truncate - обрезаем строку до max символов
+func truncate(value string, max int) string {
+	runes := []rune(value)
+	if len(runes) <= max {
+		return value
+	}
+	return string(runes[:max]) + "…"
+}
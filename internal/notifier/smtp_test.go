@@ -0,0 +1,193 @@
+package notifier
+
+import (
+	"bufio"
+	"context"
+	stdErrors "errors"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/config"
+)
+
+// smtpSession - что фейковый SMTP-сервер получил за одно соединение
+type smtpSession struct {
+	from string
+	rcpt []string
+	data string
+}
+
+// startFakeSMTP - минимальный SMTP-сервер без TLS и авторизации; принимает одно письмо
+func startFakeSMTP(t *testing.T) (host string, port int, sessions <-chan smtpSession) {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	out := make(chan smtpSession, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		r := bufio.NewReader(conn)
+		reply := func(line string) { _, _ = conn.Write([]byte(line + "\r\n")) }
+
+		var s smtpSession
+		reply("220 fake ESMTP")
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			cmd := strings.TrimRight(line, "\r\n")
+			upper := strings.ToUpper(cmd)
+
+			switch {
+			case strings.HasPrefix(upper, "EHLO"), strings.HasPrefix(upper, "HELO"):
+				reply("250 fake")
+			case strings.HasPrefix(upper, "MAIL FROM:"):
+				s.from = strings.Trim(cmd[len("MAIL FROM:"):], "<> ")
+				reply("250 OK")
+			case strings.HasPrefix(upper, "RCPT TO:"):
+				s.rcpt = append(s.rcpt, strings.Trim(cmd[len("RCPT TO:"):], "<> "))
+				reply("250 OK")
+			case upper == "DATA":
+				reply("354 go ahead")
+				var data strings.Builder
+				for {
+					l, err := r.ReadString('\n')
+					if err != nil {
+						return
+					}
+					if l == ".\r\n" {
+						break
+					}
+					data.WriteString(l)
+				}
+				s.data = data.String()
+				reply("250 OK")
+			case upper == "QUIT":
+				reply("221 bye")
+				out <- s
+				return
+			default:
+				reply("502 not implemented")
+			}
+		}
+	}()
+
+	addr := ln.Addr().(*net.TCPAddr)
+	return addr.IP.String(), addr.Port, out
+}
+
+func TestSMTPNotifierSendsMail(t *testing.T) {
+	host, port, sessions := startFakeSMTP(t)
+
+	n := NewSMTPNotifier(config.SMTP{Host: host, Port: port, From: "noreply@example.com"})
+
+	due := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	err := n.Notify(context.Background(), Message{
+		Note:   "позвонить &quot;маме&quot;",
+		DueAt:  &due,
+		Target: "owner@example.com",
+	})
+	if err != nil {
+		t.Fatalf("Notify: %v", err)
+	}
+
+	select {
+	case s := <-sessions:
+		if s.from != "noreply@example.com" {
+			t.Errorf("MAIL FROM = %q", s.from)
+		}
+		if len(s.rcpt) != 1 || s.rcpt[0] != "owner@example.com" {
+			t.Errorf("RCPT TO = %v, want only owner@example.com", s.rcpt)
+		}
+		if !strings.Contains(s.data, "To: owner@example.com\r\n") {
+			t.Errorf("missing To header:\n%s", s.data)
+		}
+		if !strings.Contains(s.data, `Напоминание: позвонить "маме"`) {
+			t.Errorf("body does not contain unescaped note:\n%s", s.data)
+		}
+		if !strings.Contains(s.data, "Срок выполнения: "+due.Format(time.RFC1123Z)) {
+			t.Errorf("body does not contain due date:\n%s", s.data)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("fake SMTP server did not receive a message")
+	}
+}
+
+func TestSMTPNotifierServerUnavailable(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	port := ln.Addr().(*net.TCPAddr).Port
+	ln.Close()
+
+	n := NewSMTPNotifier(config.SMTP{Host: "127.0.0.1", Port: port, From: "noreply@example.com"})
+	if err := n.Notify(context.Background(), Message{Target: "owner@example.com"}); err == nil {
+		t.Fatal("expected error when SMTP server is unavailable")
+	}
+}
+
+func TestSMTPNotifierStopsOnContextDeadline(t *testing.T) {
+	// Сервер принимает соединение, но не отвечает на него
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	closed := make(chan struct{})
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		// Read завершится, когда клиент закроет соединение
+		_, _ = conn.Read(make([]byte, 1))
+		close(closed)
+	}()
+
+	n := NewSMTPNotifier(config.SMTP{Host: "127.0.0.1", Port: ln.Addr().(*net.TCPAddr).Port, From: "noreply@example.com"})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	if err = n.Notify(ctx, Message{Target: "owner@example.com"}); !stdErrors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Notify: err = %v, want %v", err, context.DeadlineExceeded)
+	}
+
+	select {
+	case <-closed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("SMTP connection left open after the deadline")
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		in   string
+		max  int
+		want string
+	}{
+		{"короткий", 10, "короткий"},
+		{"ровно", 5, "ровно"},
+		{"длинный текст", 7, "длинный…"},
+	}
+	for _, tt := range tests {
+		if got := truncate(tt.in, tt.max); got != tt.want {
+			t.Errorf("truncate(%q, %d) = %q, want %q", tt.in, tt.max, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+)
+
+// WebhookNotifier отправляет напоминания POST-запросом с JSON на URL пользователя
+type WebhookNotifier struct {
+	client *http.Client
+}
+
+// NewWebhookNotifier создаёт webhook-канал доставки напоминаний.
+// client должен блокировать адреса внутренних сетей (см. webhook.NewHTTPClient)
+func NewWebhookNotifier(client *http.Client) *WebhookNotifier {
+	return &WebhookNotifier{
+		client: client,
+	}
+}
+
+// Notify отправляет напоминание на msg.Target. Ответ не из диапазона 2xx считается ошибкой
+func (n *WebhookNotifier) Notify(ctx context.Context, msg Message) error {
+	msg.Note = html.UnescapeString(msg.Note)
+
+	payload, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, msg.Target, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("webhook: %w", err)
+	}
+	defer resp.Body.Close()
+
+	// Дочитываем тело, чтобы соединение могло быть переиспользовано
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook: неожиданный статус ответа %s", resp.Status)
+	}
+
+	return nil
+}
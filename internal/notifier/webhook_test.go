@@ -0,0 +1,83 @@
+package notifier
+
+import (
+	"context"
+	"encoding/json"
+	stdErrors "errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/pkg/webhook"
+)
+
+func TestWebhookNotifierSendsJSON(t *testing.T) {
+	var got map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			t.Errorf("method = %s, want POST", r.Method)
+		}
+		if ct := r.Header.Get("Content-Type"); ct != "application/json" {
+			t.Errorf("Content-Type = %q", ct)
+		}
+		body, _ := io.ReadAll(r.Body)
+		if err := json.Unmarshal(body, &got); err != nil {
+			t.Errorf("body is not JSON: %v", err)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	n := NewWebhookNotifier(webhook.NewHTTPClient(time.Second, true))
+	err := n.Notify(context.Background(), Message{
+		ReminderID: 7,
+		NoteID:     3,
+		Note:       "купить &amp; забрать",
+		RemindAt:   time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+		Target:     srv.URL,
+	})
+	if err != nil {
+		t.Fatalf("Notify: %v", err)
+	}
+
+	if got["note"] != "купить & забрать" {
+		t.Errorf("note = %v, want unescaped text", got["note"])
+	}
+	if got["reminderID"] != float64(7) || got["noteID"] != float64(3) {
+		t.Errorf("ids = %v/%v", got["reminderID"], got["noteID"])
+	}
+	if _, ok := got["Target"]; ok {
+		t.Error("target must not be sent in payload")
+	}
+}
+
+func TestWebhookNotifierNon2xx(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer srv.Close()
+
+	n := NewWebhookNotifier(webhook.NewHTTPClient(time.Second, true))
+	if err := n.Notify(context.Background(), Message{Target: srv.URL}); err == nil {
+		t.Fatal("expected error for 502 response")
+	}
+}
+
+func TestWebhookNotifierBlocksPrivateNetworks(t *testing.T) {
+	called := false
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+	defer srv.Close()
+
+	n := NewWebhookNotifier(webhook.NewHTTPClient(time.Second, false))
+	err := n.Notify(context.Background(), Message{Target: srv.URL})
+	if !stdErrors.Is(err, webhook.ErrPrivateAddress) {
+		t.Fatalf("err = %v, want ErrPrivateAddress", err)
+	}
+	if called {
+		t.Error("request reached loopback receiver")
+	}
+}
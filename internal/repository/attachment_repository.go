@@ -0,0 +1,212 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/errors"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/models"
+)
+
+// AttachmentRepository - интерфейс для работы с метаданными вложений заметок
+type AttachmentRepository interface {
+	GetAttachmentsFromDB(ctx context.Context, userID, noteID int64) ([]models.Attachments, error)
+	GetAttachmentFromDB(ctx context.Context, userID, noteID, id int64) (*models.Attachments, error)
+	GetUsedStorageDB(ctx context.Context, userID int64) (int64, error)
+	InsertAttachmentToDB(ctx context.Context, attachment models.Attachments, quota int64) (*models.Attachments, error)
+	DetachAttachmentDB(ctx context.Context, userID, noteID, id int64) (*models.Attachments, error)
+	GetOrphanedAttachmentsDB(ctx context.Context, limit int) ([]models.Attachments, error)
+	DeleteOrphanedAttachmentDB(ctx context.Context, id int64) error
+}
+
+type attachmentRepository struct {
+	db *sql.DB
+}
+
+func NewAttachmentRepository(db *sql.DB) AttachmentRepository {
+	return &attachmentRepository{
+		db: db,
+	}
+}
+
+// attachmentColumns - поля вложения, которые читаются из БД (порядок совпадает со scanAttachment)
+const attachmentColumns = "id,note_id,user_id,file_name,content_type,size,storage_key,created_at"
+
+// scanAttachment - читаем вложение из строки результата
+func scanAttachment(row rowScanner, attachment *models.Attachments) error {
+	return row.Scan(
+		&attachment.ID,
+		&attachment.NoteID,
+		&attachment.UserID,
+		&attachment.FileName,
+		&attachment.ContentType,
+		&attachment.Size,
+		&attachment.StorageKey,
+		&attachment.CreatedAt,
+	)
+}
+
+// GetAttachmentsFromDB - получаем вложения заметки пользователя (в порядке загрузки)
+func (r *attachmentRepository) GetAttachmentsFromDB(ctx context.Context, userID, noteID int64) ([]models.Attachments, error) {
+	if err := checkNoteOwner(ctx, r.db, userID, noteID); err != nil {
+		return nil, err
+	}
+
+	query := "SELECT " + attachmentColumns + " FROM attachments WHERE note_id = $1 ORDER BY created_at, id"
+
+	rows, err := r.db.QueryContext(ctx, query, noteID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errors.ErrGetAttachments, err)
+	}
+	defer rows.Close()
+
+	attachments := make([]models.Attachments, 0)
+
+	for rows.Next() {
+		var attachment models.Attachments
+		if err = scanAttachment(rows, &attachment); err != nil {
+			return nil, err
+		}
+		attachments = append(attachments, attachment)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return attachments, nil
+}
+
+// GetAttachmentFromDB - получаем вложение заметки пользователя по ID
+func (r *attachmentRepository) GetAttachmentFromDB(ctx context.Context, userID, noteID, id int64) (*models.Attachments, error) {
+	if err := checkNoteOwner(ctx, r.db, userID, noteID); err != nil {
+		return nil, err
+	}
+
+	query := "SELECT " + attachmentColumns + " FROM attachments WHERE id = $1 AND note_id = $2"
+
+	var attachment models.Attachments
+	err := scanAttachment(r.db.QueryRowContext(ctx, query, id, noteID), &attachment)
+	if err == sql.ErrNoRows {
+		return nil, errors.ErrAttachmentNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errors.ErrGetAttachments, err)
+	}
+
+	return &attachment, nil
+}
+
+// GetUsedStorageDB - суммарный размер вложений пользователя (включая заметки в корзине)
+func (r *attachmentRepository) GetUsedStorageDB(ctx context.Context, userID int64) (int64, error) {
+	query := "SELECT COALESCE(SUM(size), 0) FROM attachments WHERE user_id = $1 AND note_id IS NOT NULL"
+
+	var used int64
+	if err := r.db.QueryRowContext(ctx, query, userID).Scan(&used); err != nil {
+		return 0, fmt.Errorf("%w: %v", errors.ErrGetAttachments, err)
+	}
+
+	return used, nil
+}
+
+// InsertAttachmentToDB - сохранить метаданные загруженного файла, если он помещается в квоту пользователя.
+// Загрузки одного пользователя сериализуются блокировкой его строки в users, чтобы параллельные запросы не превысили квоту
+func (r *attachmentRepository) InsertAttachmentToDB(ctx context.Context, attachment models.Attachments, quota int64) (*models.Attachments, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errors.ErrAttachmentFailed, err)
+	}
+	defer tx.Rollback()
+
+	if _, err = tx.ExecContext(ctx, "SELECT id FROM users WHERE id = $1 FOR NO KEY UPDATE", attachment.UserID); err != nil {
+		return nil, fmt.Errorf("%w: %v", errors.ErrAttachmentFailed, err)
+	}
+
+	if err = checkNoteOwner(ctx, tx, attachment.UserID, *attachment.NoteID); err != nil {
+		return nil, err
+	}
+
+	var used int64
+	query := "SELECT COALESCE(SUM(size), 0) FROM attachments WHERE user_id = $1 AND note_id IS NOT NULL"
+	if err = tx.QueryRowContext(ctx, query, attachment.UserID).Scan(&used); err != nil {
+		return nil, fmt.Errorf("%w: %v", errors.ErrAttachmentFailed, err)
+	}
+
+	if used+attachment.Size > quota {
+		return nil, errors.ErrAttachmentQuotaExceeded
+	}
+
+	query = `INSERT INTO attachments (note_id,user_id,file_name,content_type,size,storage_key)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING ` + attachmentColumns
+
+	var created models.Attachments
+	err = scanAttachment(tx.QueryRowContext(ctx, query, attachment.NoteID, attachment.UserID, attachment.FileName,
+		attachment.ContentType, attachment.Size, attachment.StorageKey), &created)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errors.ErrAttachmentFailed, err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("%w: %v", errors.ErrAttachmentFailed, err)
+	}
+
+	return &created, nil
+}
+
+// DetachAttachmentDB - открепить вложение от заметки. Файл удаляется из хранилища отдельно
+// (сразу или фоновой очисткой), поэтому запись остаётся до его удаления
+func (r *attachmentRepository) DetachAttachmentDB(ctx context.Context, userID, noteID, id int64) (*models.Attachments, error) {
+	if err := checkNoteOwner(ctx, r.db, userID, noteID); err != nil {
+		return nil, err
+	}
+
+	query := "UPDATE attachments SET note_id = NULL WHERE id = $1 AND note_id = $2 RETURNING " + attachmentColumns
+
+	var attachment models.Attachments
+	err := scanAttachment(r.db.QueryRowContext(ctx, query, id, noteID), &attachment)
+	if err == sql.ErrNoRows {
+		return nil, errors.ErrAttachmentNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errors.ErrDeleteAttachment, err)
+	}
+
+	return &attachment, nil
+}
+
+// GetOrphanedAttachmentsDB - вложения, открепленные от заметок или оставшиеся от окончательно удалённых заметок
+func (r *attachmentRepository) GetOrphanedAttachmentsDB(ctx context.Context, limit int) ([]models.Attachments, error) {
+	query := "SELECT " + attachmentColumns + " FROM attachments WHERE note_id IS NULL ORDER BY id LIMIT $1"
+
+	rows, err := r.db.QueryContext(ctx, query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errors.ErrGetAttachments, err)
+	}
+	defer rows.Close()
+
+	attachments := make([]models.Attachments, 0)
+
+	for rows.Next() {
+		var attachment models.Attachments
+		if err = scanAttachment(rows, &attachment); err != nil {
+			return nil, err
+		}
+		attachments = append(attachments, attachment)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return attachments, nil
+}
+
+// DeleteOrphanedAttachmentDB - удалить запись вложения после удаления файла из хранилища
+func (r *attachmentRepository) DeleteOrphanedAttachmentDB(ctx context.Context, id int64) error {
+	if _, err := r.db.ExecContext(ctx, "DELETE FROM attachments WHERE id = $1 AND note_id IS NULL", id); err != nil {
+		return fmt.Errorf("%w: %v", errors.ErrDeleteAttachment, err)
+	}
+
+	return nil
+}
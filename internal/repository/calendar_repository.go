@@ -0,0 +1,191 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/errors"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/models"
+	"time"
+)
+
+// CalendarRepository - интерфейс для работы с лентой задач iCalendar
+type CalendarRepository interface {
+	UpsertCalendarFeedDB(ctx context.Context, userID int64, tokenHash string) (*models.CalendarFeeds, error)
+	DeleteCalendarFeedDB(ctx context.Context, userID int64) error
+	OpenCalendarFeedDB(ctx context.Context, tokenHash string) (int64, error)
+	OpenCalendarSyncDB(ctx context.Context, tokenHash string, interval time.Duration) (int64, error)
+	GetCalendarTodosDB(ctx context.Context, userID int64, fn func(todo models.CalendarTodo) error) error
+	FindCalendarTodosDB(ctx context.Context, userID int64, ids []int64, uids []string) ([]models.CalendarTodo, error)
+}
+
+type calendarRepository struct {
+	db *sql.DB
+}
+
+func NewCalendarRepository(db *sql.DB) CalendarRepository {
+	return &calendarRepository{
+		db: db,
+	}
+}
+
+// calendarTodoColumns - поля заметки для ленты (порядок совпадает со scanCalendarTodo)
+const calendarTodoColumns = `n.id, COALESCE(n.ical_uid, ''), n.note, n.completed, n.created_at, n.due_at, n.priority,
+	n.rrule, n.rrule_start, l.name, n.deleted_at IS NOT NULL`
+
+// scanCalendarTodo - читаем заметку ленты из строки результата
+func scanCalendarTodo(row rowScanner, todo *models.CalendarTodo) error {
+	return row.Scan(
+		&todo.ID,
+		&todo.ICalUID,
+		&todo.Note,
+		&todo.Completed,
+		&todo.CreatedAt,
+		&todo.DueAt,
+		&todo.Priority,
+		&todo.RRule,
+		&todo.RRuleStart,
+		&todo.List,
+		&todo.Deleted,
+	)
+}
+
+// UpsertCalendarFeedDB - создать ленту пользователя или заменить её токен (старая ссылка перестаёт работать)
+func (r *calendarRepository) UpsertCalendarFeedDB(ctx context.Context, userID int64, tokenHash string) (*models.CalendarFeeds, error) {
+	query := `INSERT INTO calendar_feeds (user_id, token_hash) VALUES ($1, $2)
+		ON CONFLICT (user_id) DO UPDATE SET token_hash = EXCLUDED.token_hash, created_at = now(), last_accessed_at = NULL, last_synced_at = NULL
+		RETURNING user_id, token_hash, created_at, last_accessed_at`
+
+	var feed models.CalendarFeeds
+	err := r.db.QueryRowContext(ctx, query, userID, tokenHash).Scan(&feed.UserID, &feed.TokenHash, &feed.CreatedAt, &feed.LastAccessedAt)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errors.ErrCalendarFeedFailed, err)
+	}
+
+	return &feed, nil
+}
+
+// DeleteCalendarFeedDB - отозвать ленту пользователя
+func (r *calendarRepository) DeleteCalendarFeedDB(ctx context.Context, userID int64) error {
+	result, err := r.db.ExecContext(ctx, "DELETE FROM calendar_feeds WHERE user_id = $1", userID)
+	if err != nil {
+		return fmt.Errorf("%w: %v", errors.ErrDeleteCalendarFeed, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%w: %v", errors.FailedToCheckAffectedRows, err)
+	}
+
+	if rowsAffected == 0 {
+		return errors.ErrCalendarFeedNotFound
+	}
+
+	return nil
+}
+
+// OpenCalendarFeedDB - найти владельца ленты по хешу токена и отметить обращение
+func (r *calendarRepository) OpenCalendarFeedDB(ctx context.Context, tokenHash string) (int64, error) {
+	query := "UPDATE calendar_feeds SET last_accessed_at = now() WHERE token_hash = $1 RETURNING user_id"
+
+	var userID int64
+	err := r.db.QueryRowContext(ctx, query, tokenHash).Scan(&userID)
+	if err == sql.ErrNoRows {
+		return 0, errors.ErrCalendarFeedNotFound
+	}
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", errors.ErrGetCalendarFeed, err)
+	}
+
+	return userID, nil
+}
+
+// OpenCalendarSyncDB - найти владельца ленты по хешу токена перед загрузкой задач.
+// Загрузка разрешена не чаще раза в interval: отметка last_synced_at ставится атомарно,
+// поэтому ограничение действует для всех реплик API. Слишком частая загрузка - ErrCalendarSyncTooOften
+func (r *calendarRepository) OpenCalendarSyncDB(ctx context.Context, tokenHash string, interval time.Duration) (int64, error) {
+	query := `UPDATE calendar_feeds SET last_accessed_at = now(), last_synced_at = now()
+		WHERE token_hash = $1
+		  AND (last_synced_at IS NULL OR last_synced_at <= now() - $2::double precision * interval '1 millisecond')
+		RETURNING user_id`
+
+	var userID int64
+	err := r.db.QueryRowContext(ctx, query, tokenHash, interval.Milliseconds()).Scan(&userID)
+	if err == sql.ErrNoRows {
+		// Строка не обновлена: ленты нет или интервал ещё не прошёл
+		var exists bool
+		if err = r.db.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM calendar_feeds WHERE token_hash = $1)", tokenHash).Scan(&exists); err != nil {
+			return 0, fmt.Errorf("%w: %v", errors.ErrSyncCalendar, err)
+		}
+		if !exists {
+			return 0, errors.ErrCalendarFeedNotFound
+		}
+		return 0, errors.ErrCalendarSyncTooOften
+	}
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", errors.ErrSyncCalendar, err)
+	}
+
+	return userID, nil
+}
+
+// GetCalendarTodosDB - читаем заметки пользователя (кроме корзины) по одной и передаём в fn, не загружая все в память
+func (r *calendarRepository) GetCalendarTodosDB(ctx context.Context, userID int64, fn func(todo models.CalendarTodo) error) error {
+	query := "SELECT " + calendarTodoColumns + `
+		FROM all_notes n
+		JOIN lists l ON l.id = n.list_id
+		WHERE n.user_id = $1 AND n.deleted_at IS NULL
+		ORDER BY l.position, l.id, n.position, n.id`
+
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return fmt.Errorf("%w: %v", errors.ErrGetCalendarFeed, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var todo models.CalendarTodo
+		if err = scanCalendarTodo(rows, &todo); err != nil {
+			return fmt.Errorf("%w: %v", errors.ErrGetCalendarFeed, err)
+		}
+
+		if err = fn(todo); err != nil {
+			return err
+		}
+	}
+
+	if err = rows.Err(); err != nil {
+		return fmt.Errorf("%w: %v", errors.ErrGetCalendarFeed, err)
+	}
+
+	return nil
+}
+
+// FindCalendarTodosDB - заметки пользователя по ID или UID календаря, включая заметки в корзине
+func (r *calendarRepository) FindCalendarTodosDB(ctx context.Context, userID int64, ids []int64, uids []string) ([]models.CalendarTodo, error) {
+	query := "SELECT " + calendarTodoColumns + `
+		FROM all_notes n
+		JOIN lists l ON l.id = n.list_id
+		WHERE n.user_id = $1 AND (n.id = ANY($2) OR n.ical_uid = ANY($3))`
+
+	rows, err := r.db.QueryContext(ctx, query, userID, ids, uids)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errors.ErrSyncCalendar, err)
+	}
+	defer rows.Close()
+
+	todos := make([]models.CalendarTodo, 0)
+	for rows.Next() {
+		var todo models.CalendarTodo
+		if err = scanCalendarTodo(rows, &todo); err != nil {
+			return nil, fmt.Errorf("%w: %v", errors.ErrSyncCalendar, err)
+		}
+		todos = append(todos, todo)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %v", errors.ErrSyncCalendar, err)
+	}
+
+	return todos, nil
+}
@@ -2,12 +2,17 @@ package repository
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
 
 	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/config"
+	"github.com/jackc/pgx/v5/pgconn"
 	_ "github.com/jackc/pgx/v5/stdlib" // Подключаем драйвер PostgreSQL
 )
 
+// uniqueViolationCode - код ошибки PostgreSQL при нарушении ограничения уникальности
+const uniqueViolationCode = "23505"
+
 // NewDB создает подключение к БД
 func NewDB(cfg *config.Config) (*sql.DB, error) {
 	dsn := fmt.Sprintf(
@@ -35,3 +40,9 @@ func CloseDB(db *sql.DB) {
 		db.Close()
 	}
 }
+
+// isUniqueViolation проверяет, что ошибка вызвана нарушением ограничения уникальности
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == uniqueViolationCode
+}
@@ -0,0 +1,133 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/errors"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/models"
+	"time"
+)
+
+// IdempotencyRepository - интерфейс для работы с ключами Idempotency-Key
+type IdempotencyRepository interface {
+	AcquireIdempotencyKeyDB(ctx context.Context, userID int64, key, fingerprint string, lock time.Duration) (*models.IdempotencyKeys, bool, error)
+	CompleteIdempotencyKeyDB(ctx context.Context, record models.IdempotencyKeys, ttl time.Duration) error
+	ReleaseIdempotencyKeyDB(ctx context.Context, record models.IdempotencyKeys) error
+	PurgeIdempotencyKeysDB(ctx context.Context) (int64, error)
+}
+
+type idempotencyRepository struct {
+	db *sql.DB
+}
+
+func NewIdempotencyRepository(db *sql.DB) IdempotencyRepository {
+	return &idempotencyRepository{
+		db: db,
+	}
+}
+
+// idempotencyColumns - поля ключа, которые читаются из БД (порядок совпадает со scanIdempotencyKey)
+const idempotencyColumns = "user_id,key,fingerprint,status,COALESCE(response_status, 0),response_headers,COALESCE(response_body, ''),created_at,expires_at"
+
+// scanIdempotencyKey - читаем ключ из строки результата
+func scanIdempotencyKey(row rowScanner, record *models.IdempotencyKeys) error {
+	var headers []byte
+
+	err := row.Scan(&record.UserID, &record.Key, &record.Fingerprint, &record.Status, &record.ResponseStatus,
+		&headers, &record.ResponseBody, &record.CreatedAt, &record.ExpiresAt)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(headers, &record.ResponseHeaders)
+}
+
+// AcquireIdempotencyKeyDB - занять ключ пользователя для выполнения запроса на время lock.
+// Ключ с истёкшим сроком (в том числе занятый запросом, который не завершился) занимается заново.
+// Если ключ занят или уже хранит ответ, возвращается его запись и acquired == false
+func (r *idempotencyRepository) AcquireIdempotencyKeyDB(ctx context.Context, userID int64, key, fingerprint string, lock time.Duration) (*models.IdempotencyKeys, bool, error) {
+	acquire := `INSERT INTO idempotency_keys (user_id,key,fingerprint,expires_at)
+		VALUES ($1, $2, $3, now() + $4::double precision * interval '1 millisecond')
+		ON CONFLICT (user_id, key) DO UPDATE
+			SET fingerprint = EXCLUDED.fingerprint, status = 'processing', response_status = NULL,
+			    response_headers = '{}', response_body = NULL, created_at = now(), expires_at = EXCLUDED.expires_at
+			WHERE idempotency_keys.expires_at < now()
+		RETURNING ` + idempotencyColumns
+
+	existing := "SELECT " + idempotencyColumns + " FROM idempotency_keys WHERE user_id = $1 AND key = $2"
+
+	// Между попытками занять ключ и прочитать его запись ключ может быть удалён (запрос завершился ошибкой),
+	// тогда попытка повторяется
+	for range 3 {
+		var record models.IdempotencyKeys
+
+		err := scanIdempotencyKey(r.db.QueryRowContext(ctx, acquire, userID, key, fingerprint, lock.Milliseconds()), &record)
+		if err == nil {
+			return &record, true, nil
+		}
+		if err != sql.ErrNoRows {
+			return nil, false, fmt.Errorf("%w: %v", errors.ErrIdempotencyFailed, err)
+		}
+
+		err = scanIdempotencyKey(r.db.QueryRowContext(ctx, existing, userID, key), &record)
+		if err == nil {
+			return &record, false, nil
+		}
+		if err != sql.ErrNoRows {
+			return nil, false, fmt.Errorf("%w: %v", errors.ErrIdempotencyFailed, err)
+		}
+	}
+
+	return nil, false, errors.ErrIdempotencyKeyInProgress
+}
+
+// CompleteIdempotencyKeyDB - сохранить ответ на запрос и хранить ключ ttl. Запись обновляется, только если
+// ключ всё ещё занят этим запросом (created_at совпадает): после истечения lock его мог занять повтор
+func (r *idempotencyRepository) CompleteIdempotencyKeyDB(ctx context.Context, record models.IdempotencyKeys, ttl time.Duration) error {
+	headers, err := json.Marshal(record.ResponseHeaders)
+	if err != nil {
+		return fmt.Errorf("%w: %v", errors.ErrIdempotencyFailed, err)
+	}
+
+	query := `UPDATE idempotency_keys
+		SET status = 'completed', response_status = $3, response_headers = $4::jsonb, response_body = $5,
+		    expires_at = now() + $6::double precision * interval '1 millisecond'
+		WHERE user_id = $1 AND key = $2 AND created_at = $7 AND status = 'processing'`
+
+	_, err = r.db.ExecContext(ctx, query, record.UserID, record.Key, record.ResponseStatus, string(headers),
+		record.ResponseBody, ttl.Milliseconds(), record.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("%w: %v", errors.ErrIdempotencyFailed, err)
+	}
+
+	return nil
+}
+
+// ReleaseIdempotencyKeyDB - освободить ключ, занятый запросом, ответ на который не сохраняется
+// (как и в CompleteIdempotencyKeyDB, только если ключ всё ещё занят этим запросом)
+func (r *idempotencyRepository) ReleaseIdempotencyKeyDB(ctx context.Context, record models.IdempotencyKeys) error {
+	query := "DELETE FROM idempotency_keys WHERE user_id = $1 AND key = $2 AND created_at = $3 AND status = 'processing'"
+
+	if _, err := r.db.ExecContext(ctx, query, record.UserID, record.Key, record.CreatedAt); err != nil {
+		return fmt.Errorf("%w: %v", errors.ErrIdempotencyFailed, err)
+	}
+
+	return nil
+}
+
+// PurgeIdempotencyKeysDB - удалить ключи с истёкшим сроком хранения
+func (r *idempotencyRepository) PurgeIdempotencyKeysDB(ctx context.Context) (int64, error) {
+	result, err := r.db.ExecContext(ctx, "DELETE FROM idempotency_keys WHERE expires_at < now()")
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", errors.ErrIdempotencyFailed, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", errors.FailedToCheckAffectedRows, err)
+	}
+
+	return rowsAffected, nil
+}
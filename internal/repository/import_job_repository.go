@@ -0,0 +1,290 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/errors"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/models"
+	"time"
+)
+
+// maxImportJobErrors - сколько ошибок по задачам сохраняется в задании
+const maxImportJobErrors = 100
+
+// ImportJobRepository - интерфейс для работы с заданиями импорта из других сервисов
+type ImportJobRepository interface {
+	InsertImportJobDB(ctx context.Context, job models.ImportJobs) (*models.ImportJobs, error)
+	GetImportJobsFromDB(ctx context.Context, userID int64, limit int) ([]models.ImportJobs, error)
+	GetImportJobFromDB(ctx context.Context, userID, id int64) (*models.ImportJobs, error)
+	ClaimImportJobDB(ctx context.Context, lease time.Duration) (*models.ImportJobs, error)
+	SetImportJobTotalDB(ctx context.Context, job models.ImportJobs, total int, lease time.Duration) error
+	InsertImportListDB(ctx context.Context, job models.ImportJobs, list models.Lists, lease time.Duration) (int64, error)
+	InsertImportNoteDB(ctx context.Context, job models.ImportJobs, note models.AllNotes, items []models.NoteItems, lease time.Duration) error
+	FailImportTaskDB(ctx context.Context, job models.ImportJobs, taskErr models.ImportJobError, lease time.Duration) error
+	FinishImportJobDB(ctx context.Context, job models.ImportJobs, status, reason string) error
+}
+
+type importJobRepository struct {
+	db *sql.DB
+}
+
+func NewImportJobRepository(db *sql.DB) ImportJobRepository {
+	return &importJobRepository{
+		db: db,
+	}
+}
+
+// importJobColumns - поля задания, которые читаются из БД (порядок совпадает со scanImportJob)
+const importJobColumns = `id,user_id,source,list_name,storage_key,status,total,processed,lists_created,notes_created,
+	items_created,failed,errors,error,attempts,created_at,started_at,finished_at`
+
+// scanImportJob - читаем задание из строки результата
+func scanImportJob(row rowScanner, job *models.ImportJobs) error {
+	var jobErrors []byte
+
+	err := row.Scan(
+		&job.ID,
+		&job.UserID,
+		&job.Source,
+		&job.ListName,
+		&job.StorageKey,
+		&job.Status,
+		&job.Total,
+		&job.Processed,
+		&job.ListsCreated,
+		&job.NotesCreated,
+		&job.ItemsCreated,
+		&job.Failed,
+		&jobErrors,
+		&job.Error,
+		&job.Attempts,
+		&job.CreatedAt,
+		&job.StartedAt,
+		&job.FinishedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	job.Errors = make([]models.ImportJobError, 0)
+	return json.Unmarshal(jobErrors, &job.Errors)
+}
+
+// InsertImportJobDB - поставить задание в очередь
+func (r *importJobRepository) InsertImportJobDB(ctx context.Context, job models.ImportJobs) (*models.ImportJobs, error) {
+	query := `INSERT INTO import_jobs (user_id,source,list_name,storage_key,total)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING ` + importJobColumns
+
+	var created models.ImportJobs
+	err := scanImportJob(r.db.QueryRowContext(ctx, query, job.UserID, job.Source, job.ListName, job.StorageKey, job.Total), &created)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errors.ErrImportJobFailed, err)
+	}
+
+	return &created, nil
+}
+
+// GetImportJobsFromDB - последние задания пользователя (новые первыми)
+func (r *importJobRepository) GetImportJobsFromDB(ctx context.Context, userID int64, limit int) ([]models.ImportJobs, error) {
+	query := "SELECT " + importJobColumns + " FROM import_jobs WHERE user_id = $1 ORDER BY created_at DESC, id DESC LIMIT $2"
+
+	rows, err := r.db.QueryContext(ctx, query, userID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errors.ErrGetImportJobs, err)
+	}
+	defer rows.Close()
+
+	jobs := make([]models.ImportJobs, 0)
+
+	for rows.Next() {
+		var job models.ImportJobs
+		if err = scanImportJob(rows, &job); err != nil {
+			return nil, fmt.Errorf("%w: %v", errors.ErrGetImportJobs, err)
+		}
+		jobs = append(jobs, job)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %v", errors.ErrGetImportJobs, err)
+	}
+
+	return jobs, nil
+}
+
+// GetImportJobFromDB - задание пользователя по ID
+func (r *importJobRepository) GetImportJobFromDB(ctx context.Context, userID, id int64) (*models.ImportJobs, error) {
+	query := "SELECT " + importJobColumns + " FROM import_jobs WHERE id = $1 AND user_id = $2"
+
+	var job models.ImportJobs
+	err := scanImportJob(r.db.QueryRowContext(ctx, query, id, userID), &job)
+	if err == sql.ErrNoRows {
+		return nil, errors.ErrImportJobNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errors.ErrGetImportJobs, err)
+	}
+
+	return &job, nil
+}
+
+// ClaimImportJobDB - захватить самое старое задание из очереди на время lease (nil - очередь пуста).
+// FOR UPDATE SKIP LOCKED позволяет нескольким репликам API разбирать очередь, не мешая друг другу;
+// задание, захваченное упавшей репликой, снова становится доступно после истечения lease.
+// Номер захвата (attempts) служит токеном: изменения задания проверяют, что оно не перехвачено
+func (r *importJobRepository) ClaimImportJobDB(ctx context.Context, lease time.Duration) (*models.ImportJobs, error) {
+	query := `WITH next AS (
+			SELECT id AS next_id
+			FROM import_jobs
+			WHERE status = 'queued' OR (status = 'running' AND locked_until < now())
+			ORDER BY created_at, id
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		UPDATE import_jobs SET status = 'running', attempts = attempts + 1,
+			locked_until = now() + $1::double precision * interval '1 millisecond',
+			started_at = COALESCE(started_at, now())
+		FROM next
+		WHERE import_jobs.id = next.next_id
+		RETURNING ` + importJobColumns
+
+	var job models.ImportJobs
+	err := scanImportJob(r.db.QueryRowContext(ctx, query, lease.Milliseconds()), &job)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errors.ErrRunImportJob, err)
+	}
+
+	return &job, nil
+}
+
+// SetImportJobTotalDB - сохранить количество задач в файле и продлить захват
+func (r *importJobRepository) SetImportJobTotalDB(ctx context.Context, job models.ImportJobs, total int, lease time.Duration) error {
+	return touchImportJob(ctx, r.db, job, lease, "total = $4", total)
+}
+
+// InsertImportListDB - создать список для импортируемых задач; счётчик созданных списков
+// увеличивается в той же транзакции
+func (r *importJobRepository) InsertImportListDB(ctx context.Context, job models.ImportJobs, list models.Lists, lease time.Duration) (int64, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", errors.ErrListFailed, err)
+	}
+	defer tx.Rollback()
+
+	if err = touchImportJob(ctx, tx, job, lease, "lists_created = lists_created + 1"); err != nil {
+		return 0, err
+	}
+
+	query := `INSERT INTO lists (user_id,name,color,position)
+		VALUES ($1, $2, $3, (SELECT COALESCE(MAX(position) + 1, 0) FROM lists WHERE user_id = $1))
+		RETURNING id`
+
+	var id int64
+	if err = tx.QueryRowContext(ctx, query, list.UserID, list.Name, list.Color).Scan(&id); err != nil {
+		return 0, fmt.Errorf("%w: %v", errors.ErrListFailed, err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return 0, fmt.Errorf("%w: %v", errors.ErrListFailed, err)
+	}
+
+	return id, nil
+}
+
+// InsertImportNoteDB - добавить заметку с пунктами чек-листа и отметить задачу обработанной в одной транзакции:
+// после сбоя задание продолжается со следующей задачи, не создавая дубликатов
+func (r *importJobRepository) InsertImportNoteDB(ctx context.Context, job models.ImportJobs, note models.AllNotes, items []models.NoteItems, lease time.Duration) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%w: %v", errors.ErrNoteFailed, err)
+	}
+	defer tx.Rollback()
+
+	err = touchImportJob(ctx, tx, job, lease,
+		"processed = processed + 1, notes_created = notes_created + 1, items_created = items_created + $4", len(items))
+	if err != nil {
+		return err
+	}
+
+	noteID, err := insertNote(ctx, tx, note)
+	if err != nil {
+		return fmt.Errorf("%w: %w", errors.ErrNoteFailed, err)
+	}
+
+	for _, item := range items {
+		_, err = tx.ExecContext(ctx, "INSERT INTO note_items (note_id,text,completed,position) VALUES ($1, $2, $3, $4)",
+			noteID, item.Text, item.Completed, item.Position)
+		if err != nil {
+			return fmt.Errorf("%w: %v", errors.ErrItemFailed, err)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("%w: %v", errors.ErrNoteFailed, err)
+	}
+
+	return nil
+}
+
+// FailImportTaskDB - отметить задачу обработанной с ошибкой
+func (r *importJobRepository) FailImportTaskDB(ctx context.Context, job models.ImportJobs, taskErr models.ImportJobError, lease time.Duration) error {
+	data, err := json.Marshal([]models.ImportJobError{taskErr})
+	if err != nil {
+		return fmt.Errorf("%w: %v", errors.ErrRunImportJob, err)
+	}
+
+	return touchImportJob(ctx, r.db, job, lease,
+		`processed = processed + 1, failed = failed + 1,
+		errors = CASE WHEN jsonb_array_length(errors) < $5 THEN errors || $4::jsonb ELSE errors END`,
+		string(data), maxImportJobErrors)
+}
+
+// FinishImportJobDB - завершить задание со статусом done или failed (reason - причина неудачи)
+func (r *importJobRepository) FinishImportJobDB(ctx context.Context, job models.ImportJobs, status, reason string) error {
+	query := `UPDATE import_jobs SET status = $3, error = $4, finished_at = now(), locked_until = NULL
+		WHERE id = $1 AND attempts = $2 AND status = 'running'`
+
+	result, err := r.db.ExecContext(ctx, query, job.ID, job.Attempts, status, reason)
+	if err != nil {
+		return fmt.Errorf("%w: %v", errors.ErrRunImportJob, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%w: %v", errors.FailedToCheckAffectedRows, err)
+	}
+
+	if rowsAffected == 0 {
+		return errors.ErrImportJobLost
+	}
+
+	return nil
+}
+
+// touchImportJob - изменить счётчики задания (set - выражение SET с параметрами начиная с $4) и продлить захват.
+// Если задание перехвачено другим обработчиком, возвращается ErrImportJobLost
+func touchImportJob(ctx context.Context, q execer, job models.ImportJobs, lease time.Duration, set string, args ...any) error {
+	query := `UPDATE import_jobs SET ` + set + `, locked_until = now() + $3::double precision * interval '1 millisecond'
+		WHERE id = $1 AND attempts = $2 AND status = 'running'`
+
+	result, err := q.ExecContext(ctx, query, append([]any{job.ID, job.Attempts, lease.Milliseconds()}, args...)...)
+	if err != nil {
+		return fmt.Errorf("%w: %v", errors.ErrRunImportJob, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%w: %v", errors.FailedToCheckAffectedRows, err)
+	}
+
+	if rowsAffected == 0 {
+		return errors.ErrImportJobLost
+	}
+
+	return nil
+}
@@ -0,0 +1,229 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/errors"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/models"
+)
+
+// ListRepository - интерфейс для работы со списками заметок
+type ListRepository interface {
+	GetAllListsFromDB(ctx context.Context, userID int64) ([]models.Lists, error)
+	InsertListToDB(ctx context.Context, list models.Lists, position *int) (*models.Lists, error)
+	UpdateListToDB(ctx context.Context, list models.Lists, position *int) error
+	DeleteListFromDB(ctx context.Context, userID, id int64) error
+	GetDefaultListIDFromDB(ctx context.Context, userID int64) (int64, error)
+	ListExistsDB(ctx context.Context, userID, id int64) error
+	GetSharedListsFromDB(ctx context.Context, userID int64) ([]models.SharedList, error)
+}
+
+type listRepository struct {
+	db *sql.DB
+}
+
+func NewListRepository(db *sql.DB) ListRepository {
+	return &listRepository{
+		db: db,
+	}
+}
+
+// GetAllListsFromDB - получаем все списки пользователя из БД
+func (r *listRepository) GetAllListsFromDB(ctx context.Context, userID int64) ([]models.Lists, error) {
+	query := "SELECT id,user_id,name,color,position,is_default,created_at FROM lists WHERE user_id = $1 ORDER BY position, id"
+
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errors.ErrGetLists, err)
+	}
+	defer rows.Close()
+
+	lists := make([]models.Lists, 0)
+
+	for rows.Next() {
+		var list models.Lists
+		err = rows.Scan(&list.ID, &list.UserID, &list.Name, &list.Color, &list.Position, &list.IsDefault, &list.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		lists = append(lists, list)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return lists, nil
+}
+
+// InsertListToDB - добавить новый список в БД. Если position == nil, список добавляется в конец
+func (r *listRepository) InsertListToDB(ctx context.Context, list models.Lists, position *int) (*models.Lists, error) {
+	query := `INSERT INTO lists (user_id,name,color,position)
+		VALUES ($1, $2, $3, COALESCE($4, (SELECT COALESCE(MAX(position) + 1, 0) FROM lists WHERE user_id = $1)))
+		RETURNING id,user_id,name,color,position,is_default,created_at`
+
+	var created models.Lists
+	err := r.db.QueryRowContext(ctx, query, list.UserID, list.Name, list.Color, position).Scan(
+		&created.ID,
+		&created.UserID,
+		&created.Name,
+		&created.Color,
+		&created.Position,
+		&created.IsDefault,
+		&created.CreatedAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errors.ErrListFailed, err)
+	}
+
+	return &created, nil
+}
+
+// UpdateListToDB - обновить название, цвет и позицию списка пользователя. Если position == nil, позиция не меняется
+func (r *listRepository) UpdateListToDB(ctx context.Context, list models.Lists, position *int) error {
+	query := "UPDATE lists SET name = $1, color = $2, position = COALESCE($3, position) WHERE id = $4 AND user_id = $5"
+
+	result, err := r.db.ExecContext(ctx, query, list.Name, list.Color, position, list.ID, list.UserID)
+	if err != nil {
+		return fmt.Errorf("%w: %v", errors.ErrListFailed, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%w: %v", errors.FailedToCheckAffectedRows, err)
+	}
+
+	if rowsAffected == 0 {
+		return errors.ErrListNotFound
+	}
+
+	return nil
+}
+
+// DeleteListFromDB - удалить список пользователя. Заметки списка переносятся в список по умолчанию,
+// чтобы удаление списка не приводило к потере заметок
+func (r *listRepository) DeleteListFromDB(ctx context.Context, userID, id int64) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%w: %v", errors.ErrDeleteList, err)
+	}
+	defer tx.Rollback()
+
+	var isDefault bool
+	err = tx.QueryRowContext(ctx, "SELECT is_default FROM lists WHERE id = $1 AND user_id = $2 FOR UPDATE", id, userID).Scan(&isDefault)
+	if err == sql.ErrNoRows {
+		return errors.ErrListNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("%w: %v", errors.ErrDeleteList, err)
+	}
+
+	if isDefault {
+		return errors.ErrDefaultListCannotBeDeleted
+	}
+
+	defaultListID, err := getDefaultListID(ctx, tx, userID)
+	if err != nil {
+		return fmt.Errorf("%w: %v", errors.ErrDeleteList, err)
+	}
+
+	if _, err = tx.ExecContext(ctx, "UPDATE all_notes SET list_id = $1 WHERE list_id = $2 AND user_id = $3", defaultListID, id, userID); err != nil {
+		return fmt.Errorf("%w: %v", errors.ErrDeleteList, err)
+	}
+
+	if _, err = tx.ExecContext(ctx, "DELETE FROM lists WHERE id = $1 AND user_id = $2", id, userID); err != nil {
+		return fmt.Errorf("%w: %v", errors.ErrDeleteList, err)
+	}
+
+	return tx.Commit()
+}
+
+// GetDefaultListIDFromDB - получить ID списка по умолчанию (Inbox), при необходимости создаём его
+func (r *listRepository) GetDefaultListIDFromDB(ctx context.Context, userID int64) (int64, error) {
+	return getDefaultListID(ctx, r.db, userID)
+}
+
+// ListExistsDB - проверить, что список существует и принадлежит пользователю
+func (r *listRepository) ListExistsDB(ctx context.Context, userID, id int64) error {
+	query := "SELECT EXISTS(SELECT 1 FROM lists WHERE id = $1 AND user_id = $2)"
+
+	var exists bool
+	if err := r.db.QueryRowContext(ctx, query, id, userID).Scan(&exists); err != nil {
+		return err
+	}
+
+	if !exists {
+		return errors.ErrListNotFound
+	}
+
+	return nil
+}
+
+// queryRower - общий интерфейс для *sql.DB и *sql.Tx, достаточный для чтения одной строки
+type queryRower interface {
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// execer - общий интерфейс для *sql.DB и *sql.Tx, достаточный для INSERT/UPDATE/DELETE без чтения результата
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+// getDefaultListID - создаём список по умолчанию, если его ещё нет, и возвращаем его ID
+func getDefaultListID(ctx context.Context, q queryRower, userID int64) (int64, error) {
+	query := `WITH created AS (
+			INSERT INTO lists (user_id,name,is_default) VALUES ($1, $2, TRUE)
+			ON CONFLICT (user_id) WHERE is_default DO NOTHING
+			RETURNING id
+		)
+		SELECT id FROM created
+		UNION ALL
+		SELECT id FROM lists WHERE user_id = $1 AND is_default
+		LIMIT 1`
+
+	var id int64
+	err := q.QueryRowContext(ctx, query, userID, models.DefaultListName).Scan(&id)
+	if err == sql.ErrNoRows {
+		// Список создаётся параллельной транзакцией и ещё не виден в снимке запроса - повторяем
+		err = q.QueryRowContext(ctx, query, userID, models.DefaultListName).Scan(&id)
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
+
+// GetSharedListsFromDB - списки других пользователей, к которым пользователю открыт доступ
+func (r *listRepository) GetSharedListsFromDB(ctx context.Context, userID int64) ([]models.SharedList, error) {
+	query := `SELECT l.id,l.user_id,l.name,l.color,l.position,l.is_default,l.created_at,s.role,u.user_name
+		FROM list_shares s
+		JOIN lists l ON l.id = s.list_id
+		JOIN users u ON u.id = l.user_id
+		WHERE s.user_id = $1
+		ORDER BY u.user_name, l.position, l.id`
+
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errors.ErrGetLists, err)
+	}
+	defer rows.Close()
+
+	lists := make([]models.SharedList, 0)
+
+	for rows.Next() {
+		var list models.SharedList
+		err = rows.Scan(&list.ID, &list.UserID, &list.Name, &list.Color, &list.Position, &list.IsDefault, &list.CreatedAt, &list.Role, &list.OwnerName)
+		if err != nil {
+			return nil, err
+		}
+		lists = append(lists, list)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return lists, nil
+}
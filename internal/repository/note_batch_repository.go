@@ -0,0 +1,109 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/errors"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/models"
+)
+
+// batchSavepoint - имя точки сохранения для отката одной операции пакета
+const batchSavepoint = "note_batch_op"
+
+// NoteBatch - изменения заметок внутри транзакции пакетной операции (POST /notes/batch).
+// Методы совпадают по смыслу с одноимёнными методами NoteRepository
+type NoteBatch interface {
+	InsertNoteToDB(ctx context.Context, note models.AllNotes) (int64, error)
+	UpdateNoteToDB(ctx context.Context, actorID int64, note models.NoteUpdate) error
+	MarkNoteCompletedToDB(ctx context.Context, actorID, userID, id int64, check, completeItems bool, next models.NextOccurrenceFunc) error
+	DeleteNoteFromDB(ctx context.Context, userID, id int64) error
+	MoveNoteToListDB(ctx context.Context, userID, id, listID int64) error
+	LockNoteSyncStateDB(ctx context.Context, userID, id int64) (*models.NoteSyncState, error)
+
+	// Savepoint, RollbackToSavepoint и ReleaseSavepoint позволяют откатить одну операцию, не прерывая транзакцию
+	Savepoint(ctx context.Context) error
+	RollbackToSavepoint(ctx context.Context) error
+	ReleaseSavepoint(ctx context.Context) error
+}
+
+type noteBatch struct {
+	tx *sql.Tx
+}
+
+// ExecNoteBatchDB - выполнить fn в одной транзакции. Если fn вернула ошибку, все изменения откатываются
+func (r *noteRepository) ExecNoteBatchDB(ctx context.Context, fn func(batch NoteBatch) error) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%w: %v", errors.ErrNoteBatch, err)
+	}
+	defer tx.Rollback()
+
+	if err = fn(&noteBatch{tx: tx}); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("%w: %v", errors.ErrNoteBatch, err)
+	}
+
+	return nil
+}
+
+func (b *noteBatch) InsertNoteToDB(ctx context.Context, note models.AllNotes) (int64, error) {
+	return insertNote(ctx, b.tx, note)
+}
+
+func (b *noteBatch) UpdateNoteToDB(ctx context.Context, actorID int64, note models.NoteUpdate) error {
+	return updateNote(ctx, b.tx, actorID, note, nil)
+}
+
+func (b *noteBatch) MarkNoteCompletedToDB(ctx context.Context, actorID, userID, id int64, check, completeItems bool, next models.NextOccurrenceFunc) error {
+	return markNoteCompleted(ctx, b.tx, actorID, userID, id, check, completeItems, next, nil)
+}
+
+func (b *noteBatch) DeleteNoteFromDB(ctx context.Context, userID, id int64) error {
+	return deleteNote(ctx, b.tx, userID, id)
+}
+
+func (b *noteBatch) MoveNoteToListDB(ctx context.Context, userID, id, listID int64) error {
+	return moveNoteToList(ctx, b.tx, userID, id, listID)
+}
+
+// LockNoteSyncStateDB - версия заметки владельца userID; строка блокируется до конца транзакции,
+// чтобы заметку не изменили между проверкой конфликта и применением изменения
+func (b *noteBatch) LockNoteSyncStateDB(ctx context.Context, userID, id int64) (*models.NoteSyncState, error) {
+	query := "SELECT change_seq, updated_at FROM all_notes WHERE id = $1 AND user_id = $2 FOR UPDATE"
+
+	var state models.NoteSyncState
+	err := b.tx.QueryRowContext(ctx, query, id, userID).Scan(&state.ChangeSeq, &state.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, errors.ErrNoteNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errors.ErrSyncPush, err)
+	}
+
+	return &state, nil
+}
+
+func (b *noteBatch) Savepoint(ctx context.Context) error {
+	if _, err := b.tx.ExecContext(ctx, "SAVEPOINT "+batchSavepoint); err != nil {
+		return fmt.Errorf("%w: %v", errors.ErrNoteBatch, err)
+	}
+	return nil
+}
+
+func (b *noteBatch) RollbackToSavepoint(ctx context.Context) error {
+	if _, err := b.tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT "+batchSavepoint); err != nil {
+		return fmt.Errorf("%w: %v", errors.ErrNoteBatch, err)
+	}
+	return b.ReleaseSavepoint(ctx)
+}
+
+func (b *noteBatch) ReleaseSavepoint(ctx context.Context) error {
+	if _, err := b.tx.ExecContext(ctx, "RELEASE SAVEPOINT "+batchSavepoint); err != nil {
+		return fmt.Errorf("%w: %v", errors.ErrNoteBatch, err)
+	}
+	return nil
+}
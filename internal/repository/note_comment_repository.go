@@ -0,0 +1,155 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/errors"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/models"
+)
+
+// NoteCommentRepository - интерфейс для работы с комментариями к заметкам
+type NoteCommentRepository interface {
+	GetCommentsFromDB(ctx context.Context, userID, noteID int64) ([]models.NoteComments, error)
+	GetCommentFromDB(ctx context.Context, noteID, id int64) (*models.NoteComments, error)
+	InsertCommentToDB(ctx context.Context, userID, noteID, authorID int64, body string) (*models.NoteComments, error)
+	UpdateCommentToDB(ctx context.Context, noteID, id, authorID int64, body string) error
+	DeleteCommentFromDB(ctx context.Context, noteID, id int64) error
+}
+
+type noteCommentRepository struct {
+	db *sql.DB
+}
+
+func NewNoteCommentRepository(db *sql.DB) NoteCommentRepository {
+	return &noteCommentRepository{
+		db: db,
+	}
+}
+
+// commentColumns - поля комментария, которые читаются из БД вместе с именем автора (порядок совпадает со scanComment)
+const commentColumns = "c.id,c.note_id,c.user_id,u.user_name,c.body,c.created_at,c.edited_at"
+
+// scanComment - читаем комментарий из строки результата
+func scanComment(row rowScanner, comment *models.NoteComments) error {
+	return row.Scan(
+		&comment.ID,
+		&comment.NoteID,
+		&comment.UserID,
+		&comment.AuthorName,
+		&comment.Body,
+		&comment.CreatedAt,
+		&comment.EditedAt,
+	)
+}
+
+// GetCommentsFromDB - получаем комментарии к заметке пользователя (старые первыми)
+func (r *noteCommentRepository) GetCommentsFromDB(ctx context.Context, userID, noteID int64) ([]models.NoteComments, error) {
+	if err := checkNoteOwner(ctx, r.db, userID, noteID); err != nil {
+		return nil, err
+	}
+
+	query := "SELECT " + commentColumns + " FROM note_comments c JOIN users u ON u.id = c.user_id " +
+		"WHERE c.note_id = $1 ORDER BY c.created_at, c.id"
+
+	rows, err := r.db.QueryContext(ctx, query, noteID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errors.ErrGetComments, err)
+	}
+	defer rows.Close()
+
+	comments := make([]models.NoteComments, 0)
+
+	for rows.Next() {
+		var comment models.NoteComments
+		if err = scanComment(rows, &comment); err != nil {
+			return nil, err
+		}
+		comments = append(comments, comment)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return comments, nil
+}
+
+// GetCommentFromDB - получаем комментарий к заметке по ID
+func (r *noteCommentRepository) GetCommentFromDB(ctx context.Context, noteID, id int64) (*models.NoteComments, error) {
+	query := "SELECT " + commentColumns + " FROM note_comments c JOIN users u ON u.id = c.user_id " +
+		"WHERE c.id = $1 AND c.note_id = $2"
+
+	var comment models.NoteComments
+	err := scanComment(r.db.QueryRowContext(ctx, query, id, noteID), &comment)
+	if err == sql.ErrNoRows {
+		return nil, errors.ErrCommentNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errors.ErrGetComments, err)
+	}
+
+	return &comment, nil
+}
+
+// InsertCommentToDB - добавить комментарий authorID к заметке пользователя userID (владельца)
+func (r *noteCommentRepository) InsertCommentToDB(ctx context.Context, userID, noteID, authorID int64, body string) (*models.NoteComments, error) {
+	if err := checkNoteOwner(ctx, r.db, userID, noteID); err != nil {
+		return nil, err
+	}
+
+	query := `WITH c AS (
+			INSERT INTO note_comments (note_id,user_id,body) VALUES ($1, $2, $3)
+			RETURNING id,note_id,user_id,body,created_at,edited_at
+		)
+		SELECT ` + commentColumns + ` FROM c JOIN users u ON u.id = c.user_id`
+
+	var comment models.NoteComments
+	if err := scanComment(r.db.QueryRowContext(ctx, query, noteID, authorID, body), &comment); err != nil {
+		return nil, fmt.Errorf("%w: %v", errors.ErrCommentFailed, err)
+	}
+
+	return &comment, nil
+}
+
+// UpdateCommentToDB - изменить текст комментария. Изменить комментарий может только его автор
+func (r *noteCommentRepository) UpdateCommentToDB(ctx context.Context, noteID, id, authorID int64, body string) error {
+	query := "UPDATE note_comments SET body = $1, edited_at = now() WHERE id = $2 AND note_id = $3 AND user_id = $4"
+
+	result, err := r.db.ExecContext(ctx, query, body, id, noteID, authorID)
+	if err != nil {
+		return fmt.Errorf("%w: %v", errors.ErrCommentFailed, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%w: %v", errors.FailedToCheckAffectedRows, err)
+	}
+
+	if rowsAffected == 0 {
+		return errors.ErrCommentNotFound
+	}
+
+	return nil
+}
+
+// DeleteCommentFromDB - удалить комментарий к заметке
+func (r *noteCommentRepository) DeleteCommentFromDB(ctx context.Context, noteID, id int64) error {
+	query := "DELETE FROM note_comments WHERE id = $1 AND note_id = $2"
+
+	result, err := r.db.ExecContext(ctx, query, id, noteID)
+	if err != nil {
+		return fmt.Errorf("%w: %v", errors.ErrDeleteComment, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%w: %v", errors.FailedToCheckAffectedRows, err)
+	}
+
+	if rowsAffected == 0 {
+		return errors.ErrCommentNotFound
+	}
+
+	return nil
+}
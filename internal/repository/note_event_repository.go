@@ -0,0 +1,68 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/models"
+	"github.com/jackc/pgx/v5/stdlib"
+)
+
+// noteEventsChannel - канал NOTIFY, в который триггер all_notes_event отправляет события заметок
+const noteEventsChannel = "note_events"
+
+// NoteEventRepository - интерфейс для получения событий заметок из PostgreSQL
+type NoteEventRepository interface {
+	ListenNoteEventsDB(ctx context.Context, ready func(), fn func(event models.NoteEvent)) error
+}
+
+type noteEventRepository struct {
+	db *sql.DB
+}
+
+func NewNoteEventRepository(db *sql.DB) NoteEventRepository {
+	return &noteEventRepository{
+		db: db,
+	}
+}
+
+// ListenNoteEventsDB - подписаться на события заметок (LISTEN) на отдельном соединении и вызывать fn
+// для каждого события, пока не будет отменён ctx или не оборвётся соединение. ready вызывается после
+// подписки: события, отправленные до неё, не доставляются. Соединение не возвращается в пул
+func (r *noteEventRepository) ListenNoteEventsDB(ctx context.Context, ready func(), fn func(event models.NoteEvent)) error {
+	conn, err := r.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("не удалось получить соединение для LISTEN: %w", err)
+	}
+	defer conn.Close()
+
+	var listenErr error
+
+	_ = conn.Raw(func(driverConn any) error {
+		pgxConn := driverConn.(*stdlib.Conn).Conn()
+
+		if _, listenErr = pgxConn.Exec(ctx, "LISTEN "+noteEventsChannel); listenErr != nil {
+			return driver.ErrBadConn
+		}
+		ready()
+
+		for {
+			notification, err := pgxConn.WaitForNotification(ctx)
+			if err != nil {
+				listenErr = err
+				// Соединение с активной подпиской закрываем, а не возвращаем в пул
+				return driver.ErrBadConn
+			}
+
+			var event models.NoteEvent
+			if err = json.Unmarshal([]byte(notification.Payload), &event); err != nil {
+				continue
+			}
+			fn(event)
+		}
+	})
+
+	return listenErr
+}
@@ -0,0 +1,205 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/errors"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/models"
+)
+
+// NoteItemRepository - интерфейс для работы с пунктами чек-листа заметок
+type NoteItemRepository interface {
+	GetItemsFromDB(ctx context.Context, userID, noteID int64) ([]models.NoteItems, error)
+	InsertItemToDB(ctx context.Context, userID, noteID int64, text string) (*models.NoteItems, error)
+	UpdateItemToDB(ctx context.Context, userID, noteID, id int64, text *string, completed *bool) error
+	DeleteItemFromDB(ctx context.Context, userID, noteID, id int64) error
+	ReorderItemsDB(ctx context.Context, userID, noteID int64, ids []int64) error
+}
+
+type noteItemRepository struct {
+	db *sql.DB
+}
+
+func NewNoteItemRepository(db *sql.DB) NoteItemRepository {
+	return &noteItemRepository{
+		db: db,
+	}
+}
+
+// GetItemsFromDB - получаем пункты чек-листа заметки пользователя в порядке их расположения
+func (r *noteItemRepository) GetItemsFromDB(ctx context.Context, userID, noteID int64) ([]models.NoteItems, error) {
+	if err := checkNoteOwner(ctx, r.db, userID, noteID); err != nil {
+		return nil, err
+	}
+
+	query := "SELECT id,note_id,text,completed,position,created_at FROM note_items WHERE note_id = $1 ORDER BY position, id"
+
+	rows, err := r.db.QueryContext(ctx, query, noteID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errors.ErrGetItems, err)
+	}
+	defer rows.Close()
+
+	items := make([]models.NoteItems, 0)
+
+	for rows.Next() {
+		var item models.NoteItems
+		err = rows.Scan(&item.ID, &item.NoteID, &item.Text, &item.Completed, &item.Position, &item.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+// InsertItemToDB - добавить пункт в конец чек-листа заметки пользователя
+func (r *noteItemRepository) InsertItemToDB(ctx context.Context, userID, noteID int64, text string) (*models.NoteItems, error) {
+	if err := checkNoteOwner(ctx, r.db, userID, noteID); err != nil {
+		return nil, err
+	}
+
+	query := `INSERT INTO note_items (note_id,text,position)
+		VALUES ($1, $2, (SELECT COALESCE(MAX(position) + 1, 0) FROM note_items WHERE note_id = $1))
+		RETURNING id,note_id,text,completed,position,created_at`
+
+	var item models.NoteItems
+	err := r.db.QueryRowContext(ctx, query, noteID, text).Scan(
+		&item.ID,
+		&item.NoteID,
+		&item.Text,
+		&item.Completed,
+		&item.Position,
+		&item.CreatedAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errors.ErrItemFailed, err)
+	}
+
+	return &item, nil
+}
+
+// UpdateItemToDB - изменить текст и/или статус пункта чек-листа (nil - поле не меняется)
+func (r *noteItemRepository) UpdateItemToDB(ctx context.Context, userID, noteID, id int64, text *string, completed *bool) error {
+	if err := checkNoteOwner(ctx, r.db, userID, noteID); err != nil {
+		return err
+	}
+
+	query := "UPDATE note_items SET text = COALESCE($1, text), completed = COALESCE($2, completed) WHERE id = $3 AND note_id = $4"
+
+	result, err := r.db.ExecContext(ctx, query, text, completed, id, noteID)
+	if err != nil {
+		return fmt.Errorf("%w: %v", errors.ErrItemFailed, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%w: %v", errors.FailedToCheckAffectedRows, err)
+	}
+
+	if rowsAffected == 0 {
+		return errors.ErrItemNotFound
+	}
+
+	return nil
+}
+
+// DeleteItemFromDB - удалить пункт чек-листа заметки пользователя
+func (r *noteItemRepository) DeleteItemFromDB(ctx context.Context, userID, noteID, id int64) error {
+	if err := checkNoteOwner(ctx, r.db, userID, noteID); err != nil {
+		return err
+	}
+
+	query := "DELETE FROM note_items WHERE id = $1 AND note_id = $2"
+
+	result, err := r.db.ExecContext(ctx, query, id, noteID)
+	if err != nil {
+		return fmt.Errorf("%w: %v", errors.ErrDeleteItem, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%w: %v", errors.FailedToCheckAffectedRows, err)
+	}
+
+	if rowsAffected == 0 {
+		return errors.ErrItemNotFound
+	}
+
+	return nil
+}
+
+// ReorderItemsDB - задать новый порядок пунктов чек-листа. ids должен содержать все пункты заметки
+func (r *noteItemRepository) ReorderItemsDB(ctx context.Context, userID, noteID int64, ids []int64) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%w: %v", errors.ErrReorderItems, err)
+	}
+	defer tx.Rollback()
+
+	if err = checkNoteOwner(ctx, tx, userID, noteID); err != nil {
+		return err
+	}
+
+	// Блокируем пункты заметки, чтобы параллельные изменения не нарушили порядок
+	rows, err := tx.QueryContext(ctx, "SELECT id FROM note_items WHERE note_id = $1 FOR UPDATE", noteID)
+	if err != nil {
+		return fmt.Errorf("%w: %v", errors.ErrReorderItems, err)
+	}
+
+	existing := make(map[int64]bool)
+	for rows.Next() {
+		var id int64
+		if err = rows.Scan(&id); err != nil {
+			rows.Close()
+			return err
+		}
+		existing[id] = true
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return err
+	}
+
+	if len(existing) != len(ids) {
+		return errors.ErrInvalidItemsOrder
+	}
+	for _, id := range ids {
+		if !existing[id] {
+			return errors.ErrInvalidItemsOrder
+		}
+		delete(existing, id) // Повторяющийся ID не найдётся во второй раз
+	}
+
+	query := `UPDATE note_items SET position = x.ord - 1
+		FROM unnest($1::bigint[]) WITH ORDINALITY AS x(id, ord)
+		WHERE note_items.id = x.id AND note_items.note_id = $2`
+
+	if _, err = tx.ExecContext(ctx, query, ids, noteID); err != nil {
+		return fmt.Errorf("%w: %v", errors.ErrReorderItems, err)
+	}
+
+	return tx.Commit()
+}
+
+// checkNoteOwner - проверяем, что заметка существует и принадлежит пользователю
+func checkNoteOwner(ctx context.Context, q queryRower, userID, noteID int64) error {
+	query := "SELECT EXISTS(SELECT 1 FROM all_notes WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL)"
+
+	var exists bool
+	if err := q.QueryRowContext(ctx, query, noteID, userID).Scan(&exists); err != nil {
+		return err
+	}
+
+	if !exists {
+		return errors.ErrNoteNotFound
+	}
+
+	return nil
+}
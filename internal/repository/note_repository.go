@@ -6,18 +6,34 @@ import (
 	"fmt"
 	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/errors"
 	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/models"
+	"slices"
+	"strings"
 	"time"
 )
 
 // NoteRepository - интерфейс для работы с заметками
 type NoteRepository interface {
-	GetAllNotesFromDB(ctx context.Context, userID int64) ([]models.AllNotes, error)
-	InsertNoteToDB(ctx context.Context, userID int64, note string, createdAt time.Time) error
-	UpdateNoteToDB(ctx context.Context, id int64, note string) error
-	DeleteNoteFromDB(ctx context.Context, id int64) error
-	MarkNoteCompletedToDB(ctx context.Context, id int64, check bool) error
+	GetAllNotesFromDB(ctx context.Context, userID int64, filter models.NoteFilter) ([]models.AllNotes, int64, error)
+	GetNoteFromDB(ctx context.Context, userID, id int64) (*models.AllNotes, error)
+	SearchNotesFromDB(ctx context.Context, userID int64, query string, configs []string, limit int) ([]models.NoteSearchResult, error)
+	GetNotesDueBetweenFromDB(ctx context.Context, userID int64, from *time.Time, to time.Time) ([]models.AllNotes, error)
+	InsertNoteToDB(ctx context.Context, note models.AllNotes) error
+	UpdateNoteToDB(ctx context.Context, actorID int64, note models.NoteUpdate, ifMatch []int64) (int64, error)
+	DeleteNoteFromDB(ctx context.Context, userID, id int64) error
+	MarkNoteCompletedToDB(ctx context.Context, actorID, userID, id int64, check, completeItems bool, next models.NextOccurrenceFunc, ifMatch []int64) (int64, error)
 	DeleteAllNotesFromDB(ctx context.Context, userID int64) error
-	DeleteAllCompletedNotesFromDB(ctx context.Context, userID int64) error
+	DeleteAllCompletedNotesFromDB(ctx context.Context, userID, listID int64) error
+	MoveNoteToListDB(ctx context.Context, userID, id, listID int64) error
+	MoveNotePositionDB(ctx context.Context, userID, id, afterID, beforeID int64) error
+	GetTrashFromDB(ctx context.Context, userID int64) ([]models.AllNotes, error)
+	RestoreNoteFromTrashDB(ctx context.Context, userID, id int64) error
+	EmptyTrashDB(ctx context.Context, userID int64) error
+	PurgeTrashDB(ctx context.Context, deletedBefore time.Time) (int64, error)
+	GetSharedNotesFromDB(ctx context.Context, userID int64) ([]models.SharedNote, error)
+	ExecNoteBatchDB(ctx context.Context, fn func(batch NoteBatch) error) error
+	GetSyncChangesFromDB(ctx context.Context, userID int64, after models.SyncToken, limit int) (*models.SyncChanges, error)
+	ExportNotesDB(ctx context.Context, userID int64, fn func(record models.NoteRecord) error) error
+	ImportNotesDB(ctx context.Context, notes []models.AllNotes) error
 }
 
 type noteRepository struct {
@@ -30,80 +46,505 @@ func NewNoteRepository(db *sql.DB) NoteRepository {
 	}
 }
 
-// GetAllNotesFromDB - получаем все заметки из БД
-func (r *noteRepository) GetAllNotesFromDB(ctx context.Context, userID int64) ([]models.AllNotes, error) {
-	query := "SELECT id,note,completed,user_id,created_at FROM all_notes WHERE user_id = $1"
+// noteColumns - поля заметки, которые читаются из БД (порядок совпадает со scanNote)
+const noteColumns = "id,note,completed,user_id,list_id,created_at,due_at,priority,position,deleted_at,rrule,previous_id,change_seq,updated_at,version"
+
+// rowScanner - общий интерфейс для *sql.Row и *sql.Rows
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+// scanNote - читаем заметку из строки результата; extra - дополнительные поля после полей заметки
+func scanNote(row rowScanner, note *models.AllNotes, extra ...any) error {
+	dest := []any{
+		&note.ID,
+		&note.Note,
+		&note.Completed,
+		&note.UserID,
+		&note.ListID,
+		&note.CreatedAt,
+		&note.DueAt,
+		&note.Priority,
+		&note.Position,
+		&note.DeletedAt,
+		&note.RRule,
+		&note.PreviousID,
+		&note.ChangeSeq,
+		&note.UpdatedAt,
+		&note.Version,
+	}
+	return row.Scan(append(dest, extra...)...)
+}
+
+// noteSortColumns - допустимые поля сортировки заметок
+var noteSortColumns = map[string]string{
+	"position":   "position",
+	"created_at": "created_at",
+	"id":         "id",
+	"note":       "note",
+}
+
+// GetAllNotesFromDB - получаем страницу заметок из БД и общее количество заметок по фильтру
+func (r *noteRepository) GetAllNotesFromDB(ctx context.Context, userID int64, filter models.NoteFilter) ([]models.AllNotes, int64, error) {
+	column, ok := noteSortColumns[filter.SortBy]
+	if !ok {
+		return nil, 0, errors.ErrInvalidNotesQuery
+	}
+
+	conditions := []string{"user_id = $1", "deleted_at IS NULL"}
+	args := []any{userID}
+
+	// addArg добавляет аргумент запроса и возвращает его плейсхолдер
+	addArg := func(value any) string {
+		args = append(args, value)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	if filter.ListID > 0 {
+		conditions = append(conditions, "list_id = "+addArg(filter.ListID))
+	}
+	if filter.Completed != nil {
+		conditions = append(conditions, "completed = "+addArg(*filter.Completed))
+	}
+	if filter.CreatedAfter != nil {
+		conditions = append(conditions, "created_at > "+addArg(*filter.CreatedAfter))
+	}
+	if filter.CreatedBefore != nil {
+		conditions = append(conditions, "created_at < "+addArg(*filter.CreatedBefore))
+	}
+	if len(filter.TagIDs) > 0 {
+		tagsCondition := "id IN (SELECT note_id FROM note_tags WHERE tag_id = ANY(" + addArg(filter.TagIDs) + ")"
+		if filter.MatchAllTags {
+			tagsCondition += " GROUP BY note_id HAVING COUNT(DISTINCT tag_id) = " + addArg(len(filter.TagIDs))
+		}
+		conditions = append(conditions, tagsCondition+")")
+	}
+
+	// Общее количество считаем без учёта курсора
+	var total int64
+	countQuery := "SELECT COUNT(*) FROM all_notes WHERE " + strings.Join(conditions, " AND ")
+	if err := r.db.QueryRowContext(ctx, countQuery, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	direction, operator := "ASC", ">"
+	if filter.Desc {
+		direction, operator = "DESC", "<"
+	}
+
+	// Keyset-пагинация: продолжаем строго после последней заметки предыдущей страницы
+	if filter.After != nil {
+		switch column {
+		case "id":
+			conditions = append(conditions, "id "+operator+" "+addArg(filter.After.ID))
+		case "note":
+			conditions = append(conditions, fmt.Sprintf("(note, id) %s (%s, %s)", operator, addArg(filter.After.Note), addArg(filter.After.ID)))
+		case "created_at":
+			conditions = append(conditions, fmt.Sprintf("(created_at, id) %s (%s, %s)", operator, addArg(filter.After.CreatedAt), addArg(filter.After.ID)))
+		case "position":
+			conditions = append(conditions, fmt.Sprintf("(position, id) %s (%s, %s)", operator, addArg(filter.After.Position), addArg(filter.After.ID)))
+		}
+	}
+
+	orderBy := "id " + direction
+	if column != "id" {
+		orderBy = column + " " + direction + ", id " + direction
+	}
+
+	query := fmt.Sprintf(
+		"SELECT %s FROM all_notes WHERE %s ORDER BY %s LIMIT %s",
+		noteColumns, strings.Join(conditions, " AND "), orderBy, addArg(filter.Limit),
+	)
 
 	// Используем QueryContext вместо QueryRowContext для множественных записей
-	rows, err := r.db.QueryContext(ctx, query, userID)
+	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 	defer rows.Close()
 
-	var notes []models.AllNotes
+	notes := make([]models.AllNotes, 0, filter.Limit)
 
 	// Итерируемся по всем строкам
 	for rows.Next() {
 		var note models.AllNotes
-		err = rows.Scan(
-			&note.ID,
-			&note.Note,
-			&note.Completed,
-			&note.UserID,
-			&note.CreatedAt,
-		)
-		if err != nil {
-			return nil, err
+		if err = scanNote(rows, &note); err != nil {
+			return nil, 0, err
 		}
 		notes = append(notes, note)
 	}
 
 	// Проверяем ошибки после итерации
 	if err = rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	if err = r.loadNoteRelations(ctx, notes); err != nil {
+		return nil, 0, err
+	}
+
+	return notes, total, nil
+}
+
+// GetNoteFromDB - получаем заметку пользователя (не из корзины) вместе со связанными данными
+func (r *noteRepository) GetNoteFromDB(ctx context.Context, userID, id int64) (*models.AllNotes, error) {
+	query := "SELECT " + noteColumns + " FROM all_notes WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL"
+
+	notes := make([]models.AllNotes, 1)
+	err := scanNote(r.db.QueryRowContext(ctx, query, id, userID), &notes[0])
+	if err == sql.ErrNoRows {
+		return nil, errors.ErrNoteNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errors.ErrGetNote, err)
+	}
+
+	if err = r.loadNoteRelations(ctx, notes); err != nil {
+		return nil, err
+	}
+
+	return &notes[0], nil
+}
+
+// loadNoteRelations - дополняем заметки связанными данными (метки, прогресс чек-листа, количество комментариев)
+func (r *noteRepository) loadNoteRelations(ctx context.Context, notes []models.AllNotes) error {
+	if err := r.loadNoteTags(ctx, notes); err != nil {
+		return err
+	}
+	if err := r.loadNoteProgress(ctx, notes); err != nil {
+		return err
+	}
+	return r.loadNoteCommentsCount(ctx, notes)
+}
+
+// loadNoteCommentsCount - загружаем количество комментариев к заметкам одним запросом
+func (r *noteRepository) loadNoteCommentsCount(ctx context.Context, notes []models.AllNotes) error {
+	if len(notes) == 0 {
+		return nil
+	}
+
+	ids := make([]int64, 0, len(notes))
+	index := make(map[int64]int, len(notes))
+	for i := range notes {
+		ids = append(ids, notes[i].ID)
+		index[notes[i].ID] = i
+	}
+
+	query := "SELECT note_id, COUNT(*) FROM note_comments WHERE note_id = ANY($1) GROUP BY note_id"
+
+	rows, err := r.db.QueryContext(ctx, query, ids)
+	if err != nil {
+		return fmt.Errorf("%w: %v", errors.ErrGetComments, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var noteID int64
+		var count int
+		if err = rows.Scan(&noteID, &count); err != nil {
+			return err
+		}
+		notes[index[noteID]].CommentsCount = count
+	}
+
+	return rows.Err()
+}
+
+// loadNoteProgress - загружаем количество пунктов чек-листа (всего и выполненных) одним запросом
+func (r *noteRepository) loadNoteProgress(ctx context.Context, notes []models.AllNotes) error {
+	if len(notes) == 0 {
+		return nil
+	}
+
+	ids := make([]int64, 0, len(notes))
+	index := make(map[int64]int, len(notes))
+	for i := range notes {
+		ids = append(ids, notes[i].ID)
+		index[notes[i].ID] = i
+	}
+
+	query := `SELECT note_id, COUNT(*), COUNT(*) FILTER (WHERE completed)
+		FROM note_items
+		WHERE note_id = ANY($1)
+		GROUP BY note_id`
+
+	rows, err := r.db.QueryContext(ctx, query, ids)
+	if err != nil {
+		return fmt.Errorf("%w: %v", errors.ErrGetItems, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var noteID int64
+		var total, completed int
+		if err = rows.Scan(&noteID, &total, &completed); err != nil {
+			return err
+		}
+		notes[index[noteID]].ItemsTotal = total
+		notes[index[noteID]].ItemsCompleted = completed
+	}
+
+	return rows.Err()
+}
+
+// loadNoteTags - загружаем метки для переданных заметок одним запросом
+func (r *noteRepository) loadNoteTags(ctx context.Context, notes []models.AllNotes) error {
+	if len(notes) == 0 {
+		return nil
+	}
+
+	ids := make([]int64, 0, len(notes))
+	index := make(map[int64]int, len(notes))
+	for i := range notes {
+		notes[i].Tags = make([]models.Tags, 0)
+		ids = append(ids, notes[i].ID)
+		index[notes[i].ID] = i
+	}
+
+	query := `SELECT nt.note_id, t.id, t.user_id, t.name, t.created_at
+		FROM note_tags nt
+		JOIN tags t ON t.id = nt.tag_id
+		WHERE nt.note_id = ANY($1)
+		ORDER BY t.name`
+
+	rows, err := r.db.QueryContext(ctx, query, ids)
+	if err != nil {
+		return fmt.Errorf("%w: %v", errors.ErrGetTags, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var noteID int64
+		var tag models.Tags
+		if err = rows.Scan(&noteID, &tag.ID, &tag.UserID, &tag.Name, &tag.CreatedAt); err != nil {
+			return err
+		}
+		notes[index[noteID]].Tags = append(notes[index[noteID]].Tags, tag)
+	}
+
+	return rows.Err()
+}
+
+// SearchNotesFromDB - полнотекстовый поиск по заметкам пользователя.
+// configs - конфигурации текстового поиска PostgreSQL (russian, english), по которым строится запрос;
+// первая из них используется для подсветки фрагментов.
+func (r *noteRepository) SearchNotesFromDB(ctx context.Context, userID int64, query string, configs []string, limit int) ([]models.NoteSearchResult, error) {
+	if len(configs) == 0 {
+		return nil, errors.ErrSearchNotes
+	}
+
+	args := []any{userID, query}
+
+	tsQueries := make([]string, 0, len(configs))
+	for _, cfg := range configs {
+		args = append(args, cfg)
+		tsQueries = append(tsQueries, fmt.Sprintf("websearch_to_tsquery($%d::regconfig, $2)", len(args)))
+	}
+	args = append(args, limit)
+
+	sqlQuery := fmt.Sprintf(`
+		WITH q AS (SELECT %s AS query)
+		SELECT %s,
+		       ts_rank(n.search_vector, q.query) AS rank,
+		       ts_headline($3::regconfig, n.note, q.query, 'StartSel=<mark>, StopSel=</mark>, MaxFragments=2, MaxWords=20, MinWords=5') AS snippet
+		FROM all_notes n, q
+		WHERE n.user_id = $1 AND n.deleted_at IS NULL AND n.search_vector @@ q.query
+		ORDER BY rank DESC, n.id DESC
+		LIMIT $%d`,
+		strings.Join(tsQueries, " || "), noteColumns, len(args),
+	)
+
+	rows, err := r.db.QueryContext(ctx, sqlQuery, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errors.ErrSearchNotes, err)
+	}
+	defer rows.Close()
+
+	results := make([]models.NoteSearchResult, 0)
+
+	for rows.Next() {
+		var result models.NoteSearchResult
+		if err = scanNote(rows, &result.AllNotes, &result.Rank, &result.Snippet); err != nil {
+			return nil, err
+		}
+		results = append(results, result)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// GetNotesDueBetweenFromDB - получаем невыполненные заметки со сроком в интервале [from, to).
+// Если from == nil, нижняя граница не учитывается
+func (r *noteRepository) GetNotesDueBetweenFromDB(ctx context.Context, userID int64, from *time.Time, to time.Time) ([]models.AllNotes, error) {
+	query := "SELECT " + noteColumns + ` FROM all_notes
+		WHERE user_id = $1 AND deleted_at IS NULL AND completed = FALSE AND due_at IS NOT NULL
+		  AND ($2::timestamptz IS NULL OR due_at >= $2) AND due_at < $3
+		ORDER BY due_at, array_position(ARRAY['urgent','high','normal','low'], priority), id`
+
+	rows, err := r.db.QueryContext(ctx, query, userID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	notes := make([]models.AllNotes, 0)
+
+	for rows.Next() {
+		var note models.AllNotes
+		if err = scanNote(rows, &note); err != nil {
+			return nil, err
+		}
+		notes = append(notes, note)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if err = r.loadNoteRelations(ctx, notes); err != nil {
 		return nil, err
 	}
 
 	return notes, nil
 }
 
-// InsertNoteToDB - добавить новую заметку в БД
-func (r *noteRepository) InsertNoteToDB(ctx context.Context, userID int64, note string, createdAt time.Time) error {
-	query := "INSERT INTO all_notes (note,user_id,created_at) VALUES ($1, $2, $3)"
+// InsertNoteToDB - добавить новую заметку в БД (в конец ручной сортировки). Список заметки должен принадлежать пользователю
+func (r *noteRepository) InsertNoteToDB(ctx context.Context, note models.AllNotes) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%w: %v", errors.ErrNoteFailed, err)
+	}
+	defer tx.Rollback()
+
+	if _, err = insertNote(ctx, tx, note); err != nil {
+		return err
+	}
 
-	// Используйте ExecContext для операций INSERT/UPDATE/DELETE
-	_, err := r.db.ExecContext(ctx, query, note, userID, createdAt)
-	return err
+	return tx.Commit()
 }
 
-// UpdateNoteToDB - обновить заметку в БД
-func (r *noteRepository) UpdateNoteToDB(ctx context.Context, id int64, note string) error {
-	query := "UPDATE all_notes SET note = $1 WHERE id = $2"
+// insertNote - добавить заметку в конец ручной сортировки и вернуть её ID (общая часть InsertNoteToDB и пакетных операций).
+// Позиция вычисляется под блокировкой пользователя, как и в MoveNotePositionDB
+func insertNote(ctx context.Context, tx *sql.Tx, note models.AllNotes) (int64, error) {
+	if err := lockNotePositions(ctx, tx, note.UserID); err != nil {
+		return 0, err
+	}
 
-	// Используйте ExecContext для операций INSERT/UPDATE/DELETE
-	result, err := r.db.ExecContext(ctx, query, note, id)
+	query := `INSERT INTO all_notes (note,user_id,list_id,created_at,due_at,priority,position,rrule,rrule_start,completed,ical_uid)
+		SELECT $1, $2, id, $3, $4, $5, (SELECT COALESCE(MAX(position), 0) + $7 FROM all_notes WHERE user_id = $2),
+		       $8, CASE WHEN $8 = '' THEN NULL ELSE $4::timestamptz END, $9, NULLIF($10, '')
+		FROM lists WHERE id = $6 AND user_id = $2
+		RETURNING id`
+
+	var id int64
+	err := tx.QueryRowContext(ctx, query, note.Note, note.UserID, note.CreatedAt, note.DueAt, note.Priority, note.ListID,
+		models.NotePositionGap, note.RRule, note.Completed, note.ICalUID).Scan(&id)
+	if err == sql.ErrNoRows {
+		return 0, errors.ErrListNotFound
+	}
 	if err != nil {
-		return errors.ErrNoteToUpdate
+		return 0, err
 	}
 
-	// Проверяем, что запись была обновлена
-	rowsAffected, err := result.RowsAffected()
+	return id, nil
+}
+
+// UpdateNoteToDB - обновить заметку пользователя в БД. Предыдущее состояние сохраняется
+// в истории изменений в той же транзакции; actorID - автор изменения (владелец или редактор).
+// ifMatch - допустимые версии заметки (If-Match, nil - без проверки). Возвращает новую версию заметки
+func (r *noteRepository) UpdateNoteToDB(ctx context.Context, actorID int64, note models.NoteUpdate, ifMatch []int64) (int64, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
-		return errors.FailedToCheckAffectedRows
+		return 0, fmt.Errorf("%w: %v", errors.ErrNoteToUpdate, err)
 	}
+	defer tx.Rollback()
 
-	if rowsAffected == 0 {
-		return errors.ErrNoteNotFound
+	if err = updateNote(ctx, tx, actorID, note, ifMatch); err != nil {
+		return 0, err
+	}
+
+	version, err := noteVersion(ctx, tx, note.ID)
+	if err != nil {
+		return 0, err
+	}
+
+	return version, tx.Commit()
+}
+
+// updateNote - обновить заметку и записать ревизию в открытой транзакции. Незаданные поля note остаются прежними
+func updateNote(ctx context.Context, tx *sql.Tx, actorID int64, note models.NoteUpdate, ifMatch []int64) error {
+	old, err := lockNoteState(ctx, tx, note.UserID, note.ID)
+	if err != nil {
+		return err
+	}
+
+	if err = checkNoteVersion(old, ifMatch); err != nil {
+		return err
+	}
+
+	updated := old
+	updated.note = note.Note
+	if note.SetDueAt {
+		updated.dueAt = note.DueAt
+	}
+	if note.Priority != nil {
+		updated.priority = *note.Priority
+	}
+	if note.RRule != nil {
+		updated.rrule = *note.RRule
 	}
 
+	// Правило повторения могло остаться прежним, а срок - удалиться (или наоборот)
+	if updated.rrule != "" && updated.dueAt == nil {
+		return errors.ErrRecurrenceRequiresDueAt
+	}
+
+	// Начало серии повторений сохраняется, пока не меняется само правило (иначе сбился бы счёт COUNT)
+	query := `UPDATE all_notes SET note = $1, due_at = $2, priority = $3,
+			rrule_start = CASE WHEN $6 = '' THEN NULL WHEN rrule = $6 AND rrule_start IS NOT NULL THEN rrule_start ELSE $2::timestamptz END,
+			rrule = $6
+		WHERE id = $4 AND user_id = $5`
+
+	// Используйте ExecContext для операций INSERT/UPDATE/DELETE
+	if _, err = tx.ExecContext(ctx, query, updated.note, updated.dueAt, updated.priority, note.ID, note.UserID, updated.rrule); err != nil {
+		return fmt.Errorf("%w: %v", errors.ErrNoteToUpdate, err)
+	}
+
+	return saveRevision(ctx, tx, note.ID, actorID, models.RevisionActionUpdate, old, updated)
+}
+
+// checkNoteVersion - условие If-Match: текущая версия заблокированной заметки должна быть среди ifMatch.
+// nil - изменение без проверки версии
+func checkNoteVersion(state noteState, ifMatch []int64) error {
+	if ifMatch != nil && !slices.Contains(ifMatch, state.version) {
+		return errors.ErrNoteVersionMismatch
+	}
 	return nil
 }
 
-// DeleteNoteFromDB - удалить заметку из БД
-func (r *noteRepository) DeleteNoteFromDB(ctx context.Context, id int64) error {
-	query := "DELETE FROM all_notes WHERE id = $1"
+// noteVersion - текущая версия заметки (в транзакции - с учётом её изменений)
+func noteVersion(ctx context.Context, q queryRower, id int64) (int64, error) {
+	var version int64
+	if err := q.QueryRowContext(ctx, "SELECT version FROM all_notes WHERE id = $1", id).Scan(&version); err != nil {
+		return 0, fmt.Errorf("%w: %v", errors.ErrNoteToUpdate, err)
+	}
+	return version, nil
+}
 
-	result, err := r.db.ExecContext(ctx, query, id)
+// DeleteNoteFromDB - переместить заметку пользователя в корзину (мягкое удаление)
+func (r *noteRepository) DeleteNoteFromDB(ctx context.Context, userID, id int64) error {
+	return deleteNote(ctx, r.db, userID, id)
+}
+
+// deleteNote - переместить заметку в корзину (общая часть DeleteNoteFromDB и пакетных операций)
+func deleteNote(ctx context.Context, q execer, userID, id int64) error {
+	query := "UPDATE all_notes SET deleted_at = now() WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL"
+
+	result, err := q.ExecContext(ctx, query, id, userID)
 	if err != nil {
 		return fmt.Errorf("%w: %v", errors.ErrDeleteNote, err)
 	}
@@ -121,16 +562,77 @@ func (r *noteRepository) DeleteNoteFromDB(ctx context.Context, id int64) error {
 	return nil
 }
 
-// MarkNoteCompleted - Отметить заметку выполненной в БД
-func (r *noteRepository) MarkNoteCompletedToDB(ctx context.Context, id int64, check bool) error {
-	query := "UPDATE all_notes SET completed = $1 WHERE id = $2"
+// MarkNoteCompleted - Отметить заметку пользователя выполненной в БД.
+// Если completeItems == true, в той же транзакции отмечаются выполненными и все пункты чек-листа.
+// Изменение статуса сохраняется в истории изменений. Если выполняется повторяющаяся заметка,
+// в той же транзакции создаётся следующее повторение (срок рассчитывает next).
+// userID - владелец заметки, actorID - автор изменения (владелец или редактор).
+// ifMatch - допустимые версии заметки (If-Match, nil - без проверки). Возвращает новую версию заметки
+func (r *noteRepository) MarkNoteCompletedToDB(ctx context.Context, actorID, userID, id int64, check, completeItems bool, next models.NextOccurrenceFunc, ifMatch []int64) (int64, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", errors.ErrNoteToUpdate, err)
+	}
+	defer tx.Rollback()
 
-	// Используйте ExecContext для операций INSERT/UPDATE/DELETE
-	result, err := r.db.ExecContext(ctx, query, check, id)
+	if err = markNoteCompleted(ctx, tx, actorID, userID, id, check, completeItems, next, ifMatch); err != nil {
+		return 0, err
+	}
+
+	// Версия читается после всех изменений: отметка пунктов чек-листа тоже увеличивает версию заметки
+	version, err := noteVersion(ctx, tx, id)
+	if err != nil {
+		return 0, err
+	}
+
+	return version, tx.Commit()
+}
+
+// markNoteCompleted - изменить статус заметки в открытой транзакции (см. MarkNoteCompletedToDB)
+func markNoteCompleted(ctx context.Context, tx *sql.Tx, actorID, userID, id int64, check, completeItems bool, next models.NextOccurrenceFunc, ifMatch []int64) error {
+	old, err := lockNoteState(ctx, tx, userID, id)
 	if err != nil {
+		return err
+	}
+
+	if err = checkNoteVersion(old, ifMatch); err != nil {
+		return err
+	}
+
+	if _, err = tx.ExecContext(ctx, "UPDATE all_notes SET completed = $1 WHERE id = $2 AND user_id = $3", check, id, userID); err != nil {
 		return fmt.Errorf("%w: %v", errors.ErrNoteToUpdate, err)
 	}
 
+	if completeItems {
+		if _, err = tx.ExecContext(ctx, "UPDATE note_items SET completed = TRUE WHERE note_id = $1", id); err != nil {
+			return fmt.Errorf("%w: %v", errors.ErrItemFailed, err)
+		}
+	}
+
+	updated := old
+	updated.completed = check
+
+	if err = saveRevision(ctx, tx, id, actorID, models.RevisionActionComplete, old, updated); err != nil {
+		return err
+	}
+
+	if check && !old.completed && next != nil {
+		return createNextOccurrence(ctx, tx, userID, id, next)
+	}
+
+	return nil
+}
+
+// DeleteAllNotes - Переместить все заметки пользователя в корзину
+func (r *noteRepository) DeleteAllNotesFromDB(ctx context.Context, userID int64) error {
+	query := "UPDATE all_notes SET deleted_at = now() WHERE user_id = $1 AND deleted_at IS NULL"
+
+	// Используйте ExecContext для операций INSERT/UPDATE/DELETE
+	result, err := r.db.ExecContext(ctx, query, userID)
+	if err != nil {
+		return fmt.Errorf("%w: %v", errors.ErrDeletingAllNotes, err)
+	}
+
 	// Проверяем, что запись была обновлена
 	rowsAffected, err := result.RowsAffected()
 	if err != nil {
@@ -144,12 +646,13 @@ func (r *noteRepository) MarkNoteCompletedToDB(ctx context.Context, id int64, ch
 	return nil
 }
 
-// DeleteAllNotes - Удалить все заметки из БД
-func (r *noteRepository) DeleteAllNotesFromDB(ctx context.Context, userID int64) error {
-	query := "DELETE FROM all_notes WHERE user_id = $1"
+// DeleteAllCompletedNotesFromDB - Переместить все выполненные заметки в корзину.
+// Если listID > 0, в корзину переносятся только выполненные заметки этого списка
+func (r *noteRepository) DeleteAllCompletedNotesFromDB(ctx context.Context, userID, listID int64) error {
+	query := "UPDATE all_notes SET deleted_at = now() WHERE user_id = $1 AND deleted_at IS NULL AND completed = $2 AND ($3::bigint = 0 OR list_id = $3)"
 
 	// Используйте ExecContext для операций INSERT/UPDATE/DELETE
-	result, err := r.db.ExecContext(ctx, query, userID)
+	result, err := r.db.ExecContext(ctx, query, userID, true, listID)
 	if err != nil {
 		return fmt.Errorf("%w: %v", errors.ErrDeletingAllNotes, err)
 	}
@@ -167,17 +670,22 @@ func (r *noteRepository) DeleteAllNotesFromDB(ctx context.Context, userID int64)
 	return nil
 }
 
-// DeleteAllCompletedNotesFromDB - Удалить все выполненные заметки из БД
-func (r *noteRepository) DeleteAllCompletedNotesFromDB(ctx context.Context, userID int64) error {
-	query := "DELETE FROM all_notes WHERE user_id = $1 AND completed = $2"
+// MoveNoteToListDB - перенести заметку пользователя в другой его список
+func (r *noteRepository) MoveNoteToListDB(ctx context.Context, userID, id, listID int64) error {
+	return moveNoteToList(ctx, r.db, userID, id, listID)
+}
 
-	// Используйте ExecContext для операций INSERT/UPDATE/DELETE
-	result, err := r.db.ExecContext(ctx, query, userID, true)
+// moveNoteToList - перенести заметку в другой список (общая часть MoveNoteToListDB и пакетных операций)
+func moveNoteToList(ctx context.Context, q execer, userID, id, listID int64) error {
+	query := `UPDATE all_notes SET list_id = l.id
+		FROM lists l
+		WHERE all_notes.id = $1 AND all_notes.user_id = $2 AND all_notes.deleted_at IS NULL AND l.id = $3 AND l.user_id = $2`
+
+	result, err := q.ExecContext(ctx, query, id, userID, listID)
 	if err != nil {
-		return fmt.Errorf("%w: %v", errors.ErrDeletingAllNotes, err)
+		return fmt.Errorf("%w: %v", errors.ErrMoveNote, err)
 	}
 
-	// Проверяем, что запись была обновлена
 	rowsAffected, err := result.RowsAffected()
 	if err != nil {
 		return fmt.Errorf("%w: %v", errors.FailedToCheckAffectedRows, err)
@@ -189,3 +697,335 @@ func (r *noteRepository) DeleteAllCompletedNotesFromDB(ctx context.Context, user
 
 	return nil
 }
+
+// MoveNotePositionDB - поставить заметку между соседями afterID и beforeID (0 - сосед не указан).
+// Перемещения одного пользователя выполняются последовательно под advisory-блокировкой,
+// поэтому параллельные запросы не могут выдать двум заметкам одну и ту же позицию
+func (r *noteRepository) MoveNotePositionDB(ctx context.Context, userID, id, afterID, beforeID int64) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%w: %v", errors.ErrReorderNote, err)
+	}
+	defer tx.Rollback()
+
+	if err = lockNotePositions(ctx, tx, userID); err != nil {
+		return err
+	}
+
+	if err = checkNoteOwner(ctx, tx, userID, id); err != nil {
+		return err
+	}
+
+	position, ok, err := notePositionBetween(ctx, tx, userID, id, afterID, beforeID)
+	if err != nil {
+		return err
+	}
+
+	// Между соседями не осталось свободных позиций - перебалансируем и считаем заново
+	if !ok {
+		if err = rebalanceNotePositions(ctx, tx, userID); err != nil {
+			return err
+		}
+
+		position, ok, err = notePositionBetween(ctx, tx, userID, id, afterID, beforeID)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return errors.ErrInvalidNotePosition
+		}
+	}
+
+	if _, err = tx.ExecContext(ctx, "UPDATE all_notes SET position = $1 WHERE id = $2 AND user_id = $3", position, id, userID); err != nil {
+		return fmt.Errorf("%w: %v", errors.ErrReorderNote, err)
+	}
+
+	return tx.Commit()
+}
+
+// notePositionBetween - вычисляем позицию посередине между соседями.
+// ok == false, если между соседями нет свободной позиции
+func notePositionBetween(ctx context.Context, tx *sql.Tx, userID, id, afterID, beforeID int64) (int64, bool, error) {
+	var low, high sql.NullInt64
+
+	if afterID > 0 {
+		position, err := neighborPosition(ctx, tx, userID, afterID)
+		if err != nil {
+			return 0, false, err
+		}
+		low = sql.NullInt64{Int64: position, Valid: true}
+	}
+	if beforeID > 0 {
+		position, err := neighborPosition(ctx, tx, userID, beforeID)
+		if err != nil {
+			return 0, false, err
+		}
+		high = sql.NullInt64{Int64: position, Valid: true}
+	}
+
+	// Если указан только один сосед, второй - ближайшая к нему заметка пользователя.
+	// Заметки с той же позицией, что и у соседа, тоже учитываются: тогда промежутка нет и нужна перебалансировка
+	switch {
+	case low.Valid && !high.Valid:
+		query := "SELECT MIN(position) FROM all_notes WHERE user_id = $1 AND deleted_at IS NULL AND position >= $2 AND id <> $3 AND id <> $4"
+		if err := tx.QueryRowContext(ctx, query, userID, low.Int64, id, afterID).Scan(&high); err != nil {
+			return 0, false, fmt.Errorf("%w: %v", errors.ErrReorderNote, err)
+		}
+		if !high.Valid {
+			return low.Int64 + models.NotePositionGap, true, nil
+		}
+	case high.Valid && !low.Valid:
+		query := "SELECT MAX(position) FROM all_notes WHERE user_id = $1 AND deleted_at IS NULL AND position <= $2 AND id <> $3 AND id <> $4"
+		if err := tx.QueryRowContext(ctx, query, userID, high.Int64, id, beforeID).Scan(&low); err != nil {
+			return 0, false, fmt.Errorf("%w: %v", errors.ErrReorderNote, err)
+		}
+		if !low.Valid {
+			return high.Int64 - models.NotePositionGap, true, nil
+		}
+	}
+
+	if high.Int64-low.Int64 < 2 {
+		return 0, false, nil
+	}
+
+	return low.Int64 + (high.Int64-low.Int64)/2, true, nil
+}
+
+// neighborPosition - позиция соседней заметки пользователя
+func neighborPosition(ctx context.Context, tx *sql.Tx, userID, id int64) (int64, error) {
+	var position int64
+	err := tx.QueryRowContext(ctx, "SELECT position FROM all_notes WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL", id, userID).Scan(&position)
+	if err == sql.ErrNoRows {
+		return 0, errors.ErrNoteNotFound
+	}
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", errors.ErrReorderNote, err)
+	}
+
+	return position, nil
+}
+
+// lockNotePositions - блокировка позиций заметок пользователя до конца транзакции.
+// Под ней выполняются все операции, вычисляющие новую позицию, поэтому позиции не повторяются
+func lockNotePositions(ctx context.Context, tx *sql.Tx, userID int64) error {
+	if _, err := tx.ExecContext(ctx, "SELECT pg_advisory_xact_lock($1)", userID); err != nil {
+		return fmt.Errorf("%w: %v", errors.ErrReorderNote, err)
+	}
+	return nil
+}
+
+// rebalanceNotePositions - заново раздаём позиции заметкам пользователя (кроме заметок в корзине)
+// с шагом NotePositionGap, сохраняя текущий порядок. Вызывается под блокировкой пользователя.
+// Позиция входит в представление заметки, поэтому её изменение - обычное изменение заметки: триггеры
+// увеличивают version и change_seq (клиенты синхронизации получают новый порядок) и отправляют событие
+// updated. Переписываются только заметки, позиция которых действительно меняется
+func rebalanceNotePositions(ctx context.Context, tx *sql.Tx, userID int64) error {
+	query := `UPDATE all_notes n SET position = o.rn * $2
+		FROM (
+			SELECT id, ROW_NUMBER() OVER (ORDER BY position, id) AS rn
+			FROM all_notes
+			WHERE user_id = $1 AND deleted_at IS NULL
+		) o
+		WHERE n.id = o.id AND n.position IS DISTINCT FROM o.rn * $2`
+
+	if _, err := tx.ExecContext(ctx, query, userID, models.NotePositionGap); err != nil {
+		return fmt.Errorf("%w: %v", errors.ErrReorderNote, err)
+	}
+
+	return nil
+}
+
+// GetTrashFromDB - получаем заметки пользователя, находящиеся в корзине (последние удалённые первыми)
+func (r *noteRepository) GetTrashFromDB(ctx context.Context, userID int64) ([]models.AllNotes, error) {
+	query := "SELECT " + noteColumns + " FROM all_notes WHERE user_id = $1 AND deleted_at IS NOT NULL ORDER BY deleted_at DESC, id DESC"
+
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errors.ErrGetTrash, err)
+	}
+	defer rows.Close()
+
+	notes := make([]models.AllNotes, 0)
+
+	for rows.Next() {
+		var note models.AllNotes
+		if err = scanNote(rows, &note); err != nil {
+			return nil, err
+		}
+		notes = append(notes, note)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if err = r.loadNoteRelations(ctx, notes); err != nil {
+		return nil, err
+	}
+
+	return notes, nil
+}
+
+// RestoreNoteFromTrashDB - восстановить заметку пользователя из корзины
+func (r *noteRepository) RestoreNoteFromTrashDB(ctx context.Context, userID, id int64) error {
+	query := "UPDATE all_notes SET deleted_at = NULL WHERE id = $1 AND user_id = $2 AND deleted_at IS NOT NULL"
+
+	result, err := r.db.ExecContext(ctx, query, id, userID)
+	if err != nil {
+		return fmt.Errorf("%w: %v", errors.ErrRestoreNote, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%w: %v", errors.FailedToCheckAffectedRows, err)
+	}
+
+	if rowsAffected == 0 {
+		return errors.ErrNoteNotFound
+	}
+
+	return nil
+}
+
+// EmptyTrashDB - окончательно удалить все заметки пользователя из корзины
+func (r *noteRepository) EmptyTrashDB(ctx context.Context, userID int64) error {
+	query := "DELETE FROM all_notes WHERE user_id = $1 AND deleted_at IS NOT NULL"
+
+	if _, err := r.db.ExecContext(ctx, query, userID); err != nil {
+		return fmt.Errorf("%w: %v", errors.ErrEmptyTrash, err)
+	}
+
+	return nil
+}
+
+// PurgeTrashDB - окончательно удалить заметки всех пользователей, попавшие в корзину раньше deletedBefore.
+// Возвращает количество удалённых заметок
+func (r *noteRepository) PurgeTrashDB(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	query := "DELETE FROM all_notes WHERE deleted_at IS NOT NULL AND deleted_at < $1"
+
+	result, err := r.db.ExecContext(ctx, query, deletedBefore)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", errors.ErrEmptyTrash, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", errors.FailedToCheckAffectedRows, err)
+	}
+
+	return rowsAffected, nil
+}
+
+// GetSharedNotesFromDB - заметки других пользователей, доступные пользователю лично или через общий список.
+// Если доступ открыт несколькими способами, берётся наибольшая роль
+func (r *noteRepository) GetSharedNotesFromDB(ctx context.Context, userID int64) ([]models.SharedNote, error) {
+	query := `WITH access AS (
+			SELECT note_id, role FROM note_shares WHERE user_id = $1
+			UNION ALL
+			SELECT n.id, s.role FROM list_shares s JOIN all_notes n ON n.list_id = s.list_id WHERE s.user_id = $1
+		), best AS (
+			SELECT note_id, CASE WHEN bool_or(role = 'editor') THEN 'editor' ELSE 'viewer' END AS role
+			FROM access GROUP BY note_id
+		)
+		SELECT ` + noteColumns + `, best.role, (SELECT users.user_name FROM users WHERE users.id = all_notes.user_id)
+		FROM all_notes JOIN best ON best.note_id = all_notes.id
+		WHERE all_notes.deleted_at IS NULL AND all_notes.user_id <> $1
+		ORDER BY all_notes.created_at DESC, all_notes.id DESC`
+
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errors.ErrGetShares, err)
+	}
+	defer rows.Close()
+
+	var (
+		notes  = make([]models.AllNotes, 0)
+		roles  []string
+		owners []string
+	)
+
+	for rows.Next() {
+		var (
+			note  models.AllNotes
+			role  string
+			owner string
+		)
+		if err = scanNote(rows, &note, &role, &owner); err != nil {
+			return nil, err
+		}
+		notes = append(notes, note)
+		roles = append(roles, role)
+		owners = append(owners, owner)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if err = r.loadNoteRelations(ctx, notes); err != nil {
+		return nil, err
+	}
+
+	shared := make([]models.SharedNote, len(notes))
+	for i := range notes {
+		shared[i] = models.SharedNote{AllNotes: notes[i], Role: roles[i], OwnerName: owners[i]}
+	}
+
+	return shared, nil
+}
+
+// createNextOccurrence - создаём следующее повторение выполненной заметки: копируем текст, список,
+// приоритет, метки и пункты чек-листа (невыполненными). Выполненная заметка остаётся в истории.
+// Если следующее повторение уже создавалось (повторная отметка), ничего не делаем
+func createNextOccurrence(ctx context.Context, tx *sql.Tx, userID, id int64, next models.NextOccurrenceFunc) error {
+	var rule string
+	var start, due *time.Time
+
+	query := "SELECT rrule, rrule_start, due_at FROM all_notes WHERE id = $1 AND user_id = $2"
+	if err := tx.QueryRowContext(ctx, query, id, userID).Scan(&rule, &start, &due); err != nil {
+		return fmt.Errorf("%w: %v", errors.ErrNextOccurrence, err)
+	}
+
+	if rule == "" || due == nil {
+		return nil
+	}
+	if start == nil {
+		start = due
+	}
+
+	nextDue, ok := next(rule, *start, *due)
+	if !ok {
+		return nil
+	}
+
+	if err := lockNotePositions(ctx, tx, userID); err != nil {
+		return err
+	}
+
+	query = `INSERT INTO all_notes (note,user_id,list_id,created_at,due_at,priority,position,rrule,rrule_start,previous_id)
+		SELECT note, user_id, list_id, $2, $3, priority,
+		       (SELECT COALESCE(MAX(position), 0) + $4 FROM all_notes WHERE user_id = $5),
+		       rrule, rrule_start, id
+		FROM all_notes WHERE id = $1
+		ON CONFLICT (previous_id) WHERE previous_id IS NOT NULL DO NOTHING
+		RETURNING id`
+
+	var nextID int64
+	err := tx.QueryRowContext(ctx, query, id, time.Now().UTC(), nextDue, models.NotePositionGap, userID).Scan(&nextID)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("%w: %v", errors.ErrNextOccurrence, err)
+	}
+
+	if _, err = tx.ExecContext(ctx, "INSERT INTO note_tags (note_id,tag_id) SELECT $1, tag_id FROM note_tags WHERE note_id = $2", nextID, id); err != nil {
+		return fmt.Errorf("%w: %v", errors.ErrNextOccurrence, err)
+	}
+
+	if _, err = tx.ExecContext(ctx, "INSERT INTO note_items (note_id,text,position) SELECT $1, text, position FROM note_items WHERE note_id = $2", nextID, id); err != nil {
+		return fmt.Errorf("%w: %v", errors.ErrNextOccurrence, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,203 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/errors"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/models"
+	"time"
+)
+
+// NoteRevisionRepository - интерфейс для работы с историей изменений заметок
+type NoteRevisionRepository interface {
+	GetRevisionsFromDB(ctx context.Context, userID, noteID int64) ([]models.NoteRevisions, error)
+	GetRevisionFromDB(ctx context.Context, userID, noteID int64, rev int) (*models.NoteRevisions, error)
+	RevertNoteToRevisionDB(ctx context.Context, actorID, userID, noteID int64, rev int) error
+}
+
+type noteRevisionRepository struct {
+	db *sql.DB
+}
+
+func NewNoteRevisionRepository(db *sql.DB) NoteRevisionRepository {
+	return &noteRevisionRepository{
+		db: db,
+	}
+}
+
+// revisionColumns - поля ревизии, которые читаются из БД (порядок совпадает со scanRevision)
+const revisionColumns = "id,note_id,rev,user_id,action,old_note,new_note,old_completed,new_completed,old_due_at,new_due_at,old_priority,new_priority,created_at"
+
+// scanRevision - читаем ревизию из строки результата
+func scanRevision(row rowScanner, revision *models.NoteRevisions) error {
+	return row.Scan(
+		&revision.ID,
+		&revision.NoteID,
+		&revision.Rev,
+		&revision.UserID,
+		&revision.Action,
+		&revision.OldNote,
+		&revision.NewNote,
+		&revision.OldCompleted,
+		&revision.NewCompleted,
+		&revision.OldDueAt,
+		&revision.NewDueAt,
+		&revision.OldPriority,
+		&revision.NewPriority,
+		&revision.CreatedAt,
+	)
+}
+
+// GetRevisionsFromDB - получаем историю изменений заметки пользователя (последние ревизии первыми)
+func (r *noteRevisionRepository) GetRevisionsFromDB(ctx context.Context, userID, noteID int64) ([]models.NoteRevisions, error) {
+	if err := checkNoteOwner(ctx, r.db, userID, noteID); err != nil {
+		return nil, err
+	}
+
+	query := "SELECT " + revisionColumns + " FROM note_revisions WHERE note_id = $1 ORDER BY rev DESC"
+
+	rows, err := r.db.QueryContext(ctx, query, noteID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errors.ErrGetRevisions, err)
+	}
+	defer rows.Close()
+
+	revisions := make([]models.NoteRevisions, 0)
+
+	for rows.Next() {
+		var revision models.NoteRevisions
+		if err = scanRevision(rows, &revision); err != nil {
+			return nil, err
+		}
+		revisions = append(revisions, revision)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return revisions, nil
+}
+
+// GetRevisionFromDB - получаем одну ревизию заметки пользователя
+func (r *noteRevisionRepository) GetRevisionFromDB(ctx context.Context, userID, noteID int64, rev int) (*models.NoteRevisions, error) {
+	if err := checkNoteOwner(ctx, r.db, userID, noteID); err != nil {
+		return nil, err
+	}
+
+	return getRevision(ctx, r.db, noteID, rev)
+}
+
+// RevertNoteToRevisionDB - вернуть текст, срок и приоритет заметки к состоянию после ревизии rev.
+// Возврат сам записывается в историю как новая ревизия от имени actorID (владелец или редактор)
+func (r *noteRevisionRepository) RevertNoteToRevisionDB(ctx context.Context, actorID, userID, noteID int64, rev int) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%w: %v", errors.ErrRevertNote, err)
+	}
+	defer tx.Rollback()
+
+	old, err := lockNoteState(ctx, tx, userID, noteID)
+	if err != nil {
+		return err
+	}
+
+	revision, err := getRevision(ctx, tx, noteID, rev)
+	if err != nil {
+		return err
+	}
+
+	reverted := old
+	reverted.note = revision.NewNote
+	reverted.dueAt = revision.NewDueAt
+	reverted.priority = revision.NewPriority
+
+	query := "UPDATE all_notes SET note = $1, due_at = $2, priority = $3 WHERE id = $4"
+	if _, err = tx.ExecContext(ctx, query, reverted.note, reverted.dueAt, reverted.priority, noteID); err != nil {
+		return fmt.Errorf("%w: %v", errors.ErrRevertNote, err)
+	}
+
+	if err = saveRevision(ctx, tx, noteID, actorID, models.RevisionActionRevert, old, reverted); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// getRevision - читаем ревизию заметки по номеру
+func getRevision(ctx context.Context, q queryRower, noteID int64, rev int) (*models.NoteRevisions, error) {
+	query := "SELECT " + revisionColumns + " FROM note_revisions WHERE note_id = $1 AND rev = $2"
+
+	var revision models.NoteRevisions
+	err := scanRevision(q.QueryRowContext(ctx, query, noteID, rev), &revision)
+	if err == sql.ErrNoRows {
+		return nil, errors.ErrRevisionNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errors.ErrGetRevisions, err)
+	}
+
+	return &revision, nil
+}
+
+// noteState - состояние заметки, которое сохраняется в ревизиях
+type noteState struct {
+	note      string
+	completed bool
+	dueAt     *time.Time
+	priority  string
+	rrule     string // Правило повторения (не участвует в сравнении состояний)
+	version   int64  // Версия заметки (не участвует в сравнении состояний)
+}
+
+// equal - совпадают ли состояния (сроки сравниваются как моменты времени)
+func (s noteState) equal(other noteState) bool {
+	if s.note != other.note || s.completed != other.completed || s.priority != other.priority {
+		return false
+	}
+	if s.dueAt == nil || other.dueAt == nil {
+		return s.dueAt == nil && other.dueAt == nil
+	}
+	return s.dueAt.Equal(*other.dueAt)
+}
+
+// lockNoteState - читаем текущее состояние заметки пользователя и блокируем её строку до конца транзакции
+func lockNoteState(ctx context.Context, tx *sql.Tx, userID, noteID int64) (noteState, error) {
+	query := "SELECT note, completed, due_at, priority, rrule, version FROM all_notes WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL FOR UPDATE"
+
+	var state noteState
+	err := tx.QueryRowContext(ctx, query, noteID, userID).Scan(&state.note, &state.completed, &state.dueAt, &state.priority, &state.rrule, &state.version)
+	if err == sql.ErrNoRows {
+		return state, errors.ErrNoteNotFound
+	}
+	if err != nil {
+		return state, fmt.Errorf("%w: %v", errors.ErrNoteToUpdate, err)
+	}
+
+	return state, nil
+}
+
+// saveRevision - записываем ревизию в транзакции изменения заметки. Если состояние не изменилось, ревизия не создаётся.
+// Строка заметки должна быть заблокирована (lockNoteState), поэтому номера ревизий не пересекаются
+func saveRevision(ctx context.Context, tx *sql.Tx, noteID, userID int64, action string, before, after noteState) error {
+	if before.equal(after) {
+		return nil
+	}
+
+	query := `INSERT INTO note_revisions (note_id,rev,user_id,action,old_note,new_note,old_completed,new_completed,old_due_at,new_due_at,old_priority,new_priority)
+		VALUES ($1, (SELECT COALESCE(MAX(rev), 0) + 1 FROM note_revisions WHERE note_id = $1), $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`
+
+	_, err := tx.ExecContext(ctx, query,
+		noteID, userID, action,
+		before.note, after.note,
+		before.completed, after.completed,
+		before.dueAt, after.dueAt,
+		before.priority, after.priority,
+	)
+	if err != nil {
+		return fmt.Errorf("%w: %v", errors.ErrSaveRevision, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,124 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/errors"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/models"
+)
+
+// GetSyncChangesFromDB - страница изменений заметок пользователя после позиции after (не более limit).
+// Номер изменения - ID транзакции, поэтому отдаются только изменения транзакций, завершённых до начала
+// чтения (ID меньше xmin снимка): транзакция с меньшим номером, ещё не закоммиченная, попадёт
+// в следующую синхронизацию, а не потеряется. Если изменений больше нет, позиция сдвигается до xmin
+func (r *noteRepository) GetSyncChangesFromDB(ctx context.Context, userID int64, after models.SyncToken, limit int) (*models.SyncChanges, error) {
+	// Снимок фиксируется первым запросом и общий для всех запросов транзакции
+	tx, err := r.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errors.ErrGetSyncChanges, err)
+	}
+	defer tx.Rollback()
+
+	var watermark int64
+	if err = tx.QueryRowContext(ctx, "SELECT pg_snapshot_xmin(pg_current_snapshot())::text::bigint").Scan(&watermark); err != nil {
+		return nil, fmt.Errorf("%w: %v", errors.ErrGetSyncChanges, err)
+	}
+
+	query := "SELECT " + noteColumns + ` FROM all_notes
+		WHERE user_id = $1 AND change_seq < $2 AND (change_seq, id) > ($3, $4)
+		ORDER BY change_seq, id
+		LIMIT $5`
+
+	rows, err := tx.QueryContext(ctx, query, userID, watermark, after.Seq, after.ID, limit+1)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errors.ErrGetSyncChanges, err)
+	}
+	defer rows.Close()
+
+	notes := make([]models.AllNotes, 0)
+
+	for rows.Next() {
+		var note models.AllNotes
+		if err = scanNote(rows, &note); err != nil {
+			return nil, fmt.Errorf("%w: %v", errors.ErrGetSyncChanges, err)
+		}
+		notes = append(notes, note)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %v", errors.ErrGetSyncChanges, err)
+	}
+
+	query = `SELECT note_id, user_id, change_seq, deleted_at FROM note_tombstones
+		WHERE user_id = $1 AND change_seq < $2 AND (change_seq, note_id) > ($3, $4)
+		ORDER BY change_seq, note_id
+		LIMIT $5`
+
+	rows, err = tx.QueryContext(ctx, query, userID, watermark, after.Seq, after.ID, limit+1)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errors.ErrGetSyncChanges, err)
+	}
+	defer rows.Close()
+
+	tombstones := make([]models.NoteTombstones, 0)
+
+	for rows.Next() {
+		var tombstone models.NoteTombstones
+		if err = rows.Scan(&tombstone.NoteID, &tombstone.UserID, &tombstone.ChangeSeq, &tombstone.DeletedAt); err != nil {
+			return nil, fmt.Errorf("%w: %v", errors.ErrGetSyncChanges, err)
+		}
+		tombstones = append(tombstones, tombstone)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %v", errors.ErrGetSyncChanges, err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("%w: %v", errors.ErrGetSyncChanges, err)
+	}
+
+	changes := mergeSyncChanges(notes, tombstones, limit)
+	if !changes.HasMore {
+		changes.Next = after
+		if watermark > after.Seq {
+			changes.Next = models.SyncToken{Seq: watermark}
+		}
+	}
+
+	if err = r.loadNoteRelations(ctx, changes.Notes); err != nil {
+		return nil, fmt.Errorf("%w: %v", errors.ErrGetSyncChanges, err)
+	}
+
+	return changes, nil
+}
+
+// mergeSyncChanges - объединяем изменения и удаления по возрастанию (номер изменения, ID заметки)
+// и оставляем первые limit; Next - позиция последнего оставленного изменения
+func mergeSyncChanges(notes []models.AllNotes, tombstones []models.NoteTombstones, limit int) *models.SyncChanges {
+	changes := &models.SyncChanges{
+		Notes:   make([]models.AllNotes, 0, len(notes)),
+		Deleted: make([]models.NoteTombstones, 0, len(tombstones)),
+	}
+
+	i, j := 0, 0
+	for i+j < limit && (i < len(notes) || j < len(tombstones)) {
+		takeNote := j == len(tombstones) ||
+			(i < len(notes) && (notes[i].ChangeSeq < tombstones[j].ChangeSeq ||
+				notes[i].ChangeSeq == tombstones[j].ChangeSeq && notes[i].ID < tombstones[j].NoteID))
+
+		if takeNote {
+			changes.Notes = append(changes.Notes, notes[i])
+			changes.Next = models.SyncToken{Seq: notes[i].ChangeSeq, ID: notes[i].ID}
+			i++
+		} else {
+			changes.Deleted = append(changes.Deleted, tombstones[j])
+			changes.Next = models.SyncToken{Seq: tombstones[j].ChangeSeq, ID: tombstones[j].NoteID}
+			j++
+		}
+	}
+
+	changes.HasMore = i < len(notes) || j < len(tombstones)
+	return changes
+}
@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/errors"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/models"
+	"time"
+)
+
+// ExportNotesDB - читаем заметки пользователя (кроме корзины) по одной и передаём в fn, не загружая все в память.
+// Порядок совпадает с интерфейсом: списки по порядку, внутри списка - ручная сортировка
+func (r *noteRepository) ExportNotesDB(ctx context.Context, userID int64, fn func(record models.NoteRecord) error) error {
+	query := `SELECT n.note, n.completed, n.created_at, n.due_at, n.priority, l.name, n.rrule
+		FROM all_notes n
+		JOIN lists l ON l.id = n.list_id
+		WHERE n.user_id = $1 AND n.deleted_at IS NULL
+		ORDER BY l.position, l.id, n.position, n.id`
+
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return fmt.Errorf("%w: %v", errors.ErrExportNotes, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var record models.NoteRecord
+		var createdAt time.Time
+
+		if err = rows.Scan(&record.Note, &record.Completed, &createdAt, &record.DueAt, &record.Priority, &record.List, &record.RRule); err != nil {
+			return fmt.Errorf("%w: %v", errors.ErrExportNotes, err)
+		}
+		record.CreatedAt = &createdAt
+
+		if err = fn(record); err != nil {
+			return err
+		}
+	}
+
+	if err = rows.Err(); err != nil {
+		return fmt.Errorf("%w: %v", errors.ErrExportNotes, err)
+	}
+
+	return nil
+}
+
+// ImportNotesDB - добавить импортированные заметки в одной транзакции (все или ни одной)
+func (r *noteRepository) ImportNotesDB(ctx context.Context, notes []models.AllNotes) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%w: %v", errors.ErrImportNotes, err)
+	}
+	defer tx.Rollback()
+
+	for _, note := range notes {
+		if _, err = insertNote(ctx, tx, note); err != nil {
+			return fmt.Errorf("%w: %w", errors.ErrImportNotes, err)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("%w: %v", errors.ErrImportNotes, err)
+	}
+
+	return nil
+}
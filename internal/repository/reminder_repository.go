@@ -0,0 +1,237 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/errors"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/models"
+	"time"
+)
+
+// ReminderRepository - интерфейс для работы с напоминаниями
+type ReminderRepository interface {
+	GetRemindersFromDB(ctx context.Context, userID, noteID int64) ([]models.Reminders, error)
+	InsertReminderToDB(ctx context.Context, reminder models.Reminders) (*models.Reminders, error)
+	DeleteReminderFromDB(ctx context.Context, userID, noteID, id int64) error
+	ClaimDueRemindersDB(ctx context.Context, limit int, lease time.Duration) ([]models.DueReminder, error)
+	MarkReminderSentDB(ctx context.Context, id int64, lockedUntil time.Time) error
+	MarkReminderFailedDB(ctx context.Context, id int64, lockedUntil time.Time, lastError string, nextAttemptAt *time.Time) error
+	ReleaseReminderDB(ctx context.Context, id int64, lockedUntil time.Time) error
+}
+
+type reminderRepository struct {
+	db *sql.DB
+}
+
+func NewReminderRepository(db *sql.DB) ReminderRepository {
+	return &reminderRepository{
+		db: db,
+	}
+}
+
+// reminderColumns - поля напоминания, которые читаются из БД (порядок совпадает со scanReminder)
+const reminderColumns = "id,note_id,user_id,remind_at,channel,target,status,attempts,next_attempt_at,last_error,sent_at,created_at"
+
+// scanReminder - читаем напоминание из строки результата; extra - дополнительные поля после полей напоминания
+func scanReminder(row rowScanner, reminder *models.Reminders, extra ...any) error {
+	dest := []any{
+		&reminder.ID,
+		&reminder.NoteID,
+		&reminder.UserID,
+		&reminder.RemindAt,
+		&reminder.Channel,
+		&reminder.Target,
+		&reminder.Status,
+		&reminder.Attempts,
+		&reminder.NextAttemptAt,
+		&reminder.LastError,
+		&reminder.SentAt,
+		&reminder.CreatedAt,
+	}
+	return row.Scan(append(dest, extra...)...)
+}
+
+// GetRemindersFromDB - получаем напоминания заметки пользователя
+func (r *reminderRepository) GetRemindersFromDB(ctx context.Context, userID, noteID int64) ([]models.Reminders, error) {
+	if err := checkNoteOwner(ctx, r.db, userID, noteID); err != nil {
+		return nil, err
+	}
+
+	query := "SELECT " + reminderColumns + " FROM reminders WHERE note_id = $1 ORDER BY remind_at, id"
+
+	rows, err := r.db.QueryContext(ctx, query, noteID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errors.ErrGetReminders, err)
+	}
+	defer rows.Close()
+
+	reminders := make([]models.Reminders, 0)
+
+	for rows.Next() {
+		var reminder models.Reminders
+		if err = scanReminder(rows, &reminder); err != nil {
+			return nil, err
+		}
+		reminders = append(reminders, reminder)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return reminders, nil
+}
+
+// InsertReminderToDB - добавить напоминание к заметке пользователя.
+// Email-напоминание отправляется только на email владельца аккаунта: если адрес указан,
+// он должен совпадать с email пользователя, иначе возвращается ErrInvalidTarget
+func (r *reminderRepository) InsertReminderToDB(ctx context.Context, reminder models.Reminders) (*models.Reminders, error) {
+	query := `INSERT INTO reminders (note_id,user_id,remind_at,channel,target,next_attempt_at)
+		SELECT n.id, n.user_id, $3, $4, CASE WHEN $4 = 'email' THEN u.email ELSE $5 END, $3
+		FROM all_notes n
+		JOIN users u ON u.id = n.user_id
+		WHERE n.id = $1 AND n.user_id = $2 AND n.deleted_at IS NULL
+		  AND ($4 <> 'email' OR $5 = '' OR lower($5) = lower(u.email))
+		RETURNING ` + reminderColumns
+
+	var created models.Reminders
+	err := scanReminder(r.db.QueryRowContext(ctx, query, reminder.NoteID, reminder.UserID, reminder.RemindAt, reminder.Channel, reminder.Target), &created)
+	if err == sql.ErrNoRows {
+		// Строка не вставлена: либо заметки нет, либо адрес не совпал с email пользователя
+		if err = checkNoteOwner(ctx, r.db, reminder.UserID, reminder.NoteID); err != nil {
+			return nil, err
+		}
+		return nil, errors.ErrInvalidTarget
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errors.ErrReminderFailed, err)
+	}
+
+	return &created, nil
+}
+
+// DeleteReminderFromDB - удалить напоминание заметки пользователя
+func (r *reminderRepository) DeleteReminderFromDB(ctx context.Context, userID, noteID, id int64) error {
+	if err := checkNoteOwner(ctx, r.db, userID, noteID); err != nil {
+		return err
+	}
+
+	result, err := r.db.ExecContext(ctx, "DELETE FROM reminders WHERE id = $1 AND note_id = $2", id, noteID)
+	if err != nil {
+		return fmt.Errorf("%w: %v", errors.ErrDeleteReminder, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%w: %v", errors.FailedToCheckAffectedRows, err)
+	}
+
+	if rowsAffected == 0 {
+		return errors.ErrReminderNotFound
+	}
+
+	return nil
+}
+
+// ClaimDueRemindersDB - захватить напоминания, которые пора отправить, на время lease.
+// Email-напоминания адресуются текущему email владельца, даже если он изменился после создания.
+// FOR UPDATE SKIP LOCKED позволяет нескольким репликам API разбирать очередь, не мешая друг другу;
+// напоминания, захваченные упавшей репликой, снова становятся доступны после истечения lease
+func (r *reminderRepository) ClaimDueRemindersDB(ctx context.Context, limit int, lease time.Duration) ([]models.DueReminder, error) {
+	query := `WITH due AS (
+			SELECT r.id
+			FROM reminders r
+			JOIN all_notes n ON n.id = r.note_id
+			WHERE n.deleted_at IS NULL
+			  AND ((r.status = 'pending' AND r.next_attempt_at <= now())
+			    OR (r.status = 'sending' AND r.locked_until < now()))
+			ORDER BY r.next_attempt_at
+			LIMIT $1
+			FOR UPDATE OF r SKIP LOCKED
+		), claimed AS (
+			UPDATE reminders SET status = 'sending', locked_until = now() + $2::double precision * interval '1 millisecond', attempts = attempts + 1
+			FROM due
+			WHERE reminders.id = due.id
+			RETURNING reminders.*
+		)
+		SELECT c.id, c.note_id, c.user_id, c.remind_at, c.channel,
+		       CASE WHEN c.channel = 'email' THEN u.email ELSE c.target END,
+		       c.status, c.attempts, c.next_attempt_at, c.last_error, c.sent_at, c.created_at, n.note, n.due_at, c.locked_until
+		FROM claimed c
+		JOIN all_notes n ON n.id = c.note_id
+		JOIN users u ON u.id = c.user_id`
+
+	rows, err := r.db.QueryContext(ctx, query, limit, lease.Milliseconds())
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errors.ErrGetReminders, err)
+	}
+	defer rows.Close()
+
+	reminders := make([]models.DueReminder, 0)
+
+	for rows.Next() {
+		var reminder models.DueReminder
+		if err = scanReminder(rows, &reminder.Reminders, &reminder.Note, &reminder.DueAt, &reminder.LockedUntil); err != nil {
+			return nil, err
+		}
+		reminders = append(reminders, reminder)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return reminders, nil
+}
+
+// MarkReminderSentDB - отметить напоминание отправленным.
+// lockedUntil - время захвата из ClaimDueRemindersDB: если захват истёк и напоминание взяла другая реплика,
+// строка не меняется и возвращается ErrReminderLeaseLost
+func (r *reminderRepository) MarkReminderSentDB(ctx context.Context, id int64, lockedUntil time.Time) error {
+	query := `UPDATE reminders SET status = 'sent', sent_at = now(), locked_until = NULL, last_error = ''
+		WHERE id = $1 AND status = 'sending' AND locked_until = $2`
+
+	return r.execClaimed(ctx, query, id, lockedUntil)
+}
+
+// MarkReminderFailedDB - записать ошибку отправки. Если nextAttemptAt == nil, попытки закончились
+// и напоминание получает статус failed, иначе оно вернётся в очередь в nextAttemptAt.
+// Захват проверяется так же, как в MarkReminderSentDB
+func (r *reminderRepository) MarkReminderFailedDB(ctx context.Context, id int64, lockedUntil time.Time, lastError string, nextAttemptAt *time.Time) error {
+	query := `UPDATE reminders
+		SET status = CASE WHEN $4::timestamptz IS NULL THEN 'failed' ELSE 'pending' END,
+		    next_attempt_at = COALESCE($4, next_attempt_at),
+		    locked_until = NULL,
+		    last_error = $3
+		WHERE id = $1 AND status = 'sending' AND locked_until = $2`
+
+	return r.execClaimed(ctx, query, id, lockedUntil, lastError, nextAttemptAt)
+}
+
+// ReleaseReminderDB - вернуть захваченное, но не отправленное напоминание в очередь; попытка не учитывается
+func (r *reminderRepository) ReleaseReminderDB(ctx context.Context, id int64, lockedUntil time.Time) error {
+	query := `UPDATE reminders SET status = 'pending', locked_until = NULL, attempts = attempts - 1
+		WHERE id = $1 AND status = 'sending' AND locked_until = $2`
+
+	return r.execClaimed(ctx, query, id, lockedUntil)
+}
+
+// execClaimed - изменить захваченное напоминание; ни одной изменённой строки - захват потерян
+func (r *reminderRepository) execClaimed(ctx context.Context, query string, args ...any) error {
+	result, err := r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("%w: %v", errors.ErrReminderFailed, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%w: %v", errors.FailedToCheckAffectedRows, err)
+	}
+
+	if rowsAffected == 0 {
+		return errors.ErrReminderLeaseLost
+	}
+
+	return nil
+}
@@ -0,0 +1,239 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/errors"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/models"
+	"time"
+)
+
+// ShareLinkRepository - интерфейс для работы с публичными ссылками на заметки
+type ShareLinkRepository interface {
+	InsertShareLinkDB(ctx context.Context, link models.ShareLinks) (*models.ShareLinks, error)
+	GetShareLinksFromDB(ctx context.Context, userID, noteID int64) ([]models.ShareLinks, error)
+	RevokeShareLinkDB(ctx context.Context, userID, noteID, id int64) error
+	GetShareLinkByTokenDB(ctx context.Context, tokenHash string) (*models.ShareLinks, error)
+	ReserveShareLinkAttemptDB(ctx context.Context, id int64, maxAttempts int, lockout time.Duration) error
+	OpenShareLinkDB(ctx context.Context, id int64) (*models.AllNotes, []models.NoteItems, error)
+}
+
+type shareLinkRepository struct {
+	db *sql.DB
+}
+
+func NewShareLinkRepository(db *sql.DB) ShareLinkRepository {
+	return &shareLinkRepository{
+		db: db,
+	}
+}
+
+// shareLinkColumns - поля ссылки, которые читаются из БД (порядок совпадает со scanShareLink)
+const shareLinkColumns = "id,note_id,user_id,token_hash,password_hash,expires_at,revoked_at,access_count,last_accessed_at,failed_attempts,locked_until,created_at"
+
+// scanShareLink - читаем ссылку из строки результата
+func scanShareLink(row rowScanner, link *models.ShareLinks) error {
+	err := row.Scan(
+		&link.ID,
+		&link.NoteID,
+		&link.UserID,
+		&link.TokenHash,
+		&link.PasswordHash,
+		&link.ExpiresAt,
+		&link.RevokedAt,
+		&link.AccessCount,
+		&link.LastAccessedAt,
+		&link.FailedAttempts,
+		&link.LockedUntil,
+		&link.CreatedAt,
+	)
+	link.HasPassword = link.PasswordHash != ""
+	return err
+}
+
+// InsertShareLinkDB - создать ссылку на заметку пользователя
+func (r *shareLinkRepository) InsertShareLinkDB(ctx context.Context, link models.ShareLinks) (*models.ShareLinks, error) {
+	query := `INSERT INTO share_links (note_id,user_id,token_hash,password_hash,expires_at)
+		SELECT id, user_id, $3, $4, $5 FROM all_notes WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL
+		RETURNING ` + shareLinkColumns
+
+	var created models.ShareLinks
+	err := scanShareLink(r.db.QueryRowContext(ctx, query, link.NoteID, link.UserID, link.TokenHash, link.PasswordHash, link.ExpiresAt), &created)
+	if err == sql.ErrNoRows {
+		return nil, errors.ErrNoteNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errors.ErrShareLinkFailed, err)
+	}
+
+	return &created, nil
+}
+
+// GetShareLinksFromDB - ссылки на заметку пользователя, включая отозванные (последние созданные первыми)
+func (r *shareLinkRepository) GetShareLinksFromDB(ctx context.Context, userID, noteID int64) ([]models.ShareLinks, error) {
+	if err := checkNoteOwner(ctx, r.db, userID, noteID); err != nil {
+		return nil, err
+	}
+
+	query := "SELECT " + shareLinkColumns + " FROM share_links WHERE note_id = $1 ORDER BY created_at DESC, id DESC"
+
+	rows, err := r.db.QueryContext(ctx, query, noteID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errors.ErrGetShareLinks, err)
+	}
+	defer rows.Close()
+
+	links := make([]models.ShareLinks, 0)
+
+	for rows.Next() {
+		var link models.ShareLinks
+		if err = scanShareLink(rows, &link); err != nil {
+			return nil, err
+		}
+		links = append(links, link)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return links, nil
+}
+
+// RevokeShareLinkDB - отозвать ссылку. Отозванная ссылка перестаёт открываться сразу же
+func (r *shareLinkRepository) RevokeShareLinkDB(ctx context.Context, userID, noteID, id int64) error {
+	query := "UPDATE share_links SET revoked_at = now() WHERE id = $1 AND note_id = $2 AND user_id = $3 AND revoked_at IS NULL"
+
+	result, err := r.db.ExecContext(ctx, query, id, noteID, userID)
+	if err != nil {
+		return fmt.Errorf("%w: %v", errors.ErrRevokeShareLink, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%w: %v", errors.FailedToCheckAffectedRows, err)
+	}
+
+	if rowsAffected == 0 {
+		return errors.ErrShareLinkNotFound
+	}
+
+	return nil
+}
+
+// GetShareLinkByTokenDB - действующая (не отозванная) ссылка по хешу токена
+func (r *shareLinkRepository) GetShareLinkByTokenDB(ctx context.Context, tokenHash string) (*models.ShareLinks, error) {
+	query := "SELECT " + shareLinkColumns + " FROM share_links WHERE token_hash = $1 AND revoked_at IS NULL"
+
+	var link models.ShareLinks
+	err := scanShareLink(r.db.QueryRowContext(ctx, query, tokenHash), &link)
+	if err == sql.ErrNoRows {
+		return nil, errors.ErrShareLinkNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errors.ErrOpenShareLink, err)
+	}
+
+	return &link, nil
+}
+
+// ReserveShareLinkAttemptDB - учесть попытку ввода пароля до его проверки. Попытка считается неверной,
+// пока ссылку не откроют (OpenShareLinkDB сбрасывает счётчик), поэтому одновременные запросы не обходят ограничение.
+// На maxAttempts-й попытке подряд проверка пароля блокируется на lockout; пока блокировка действует - ErrShareLinkLocked
+func (r *shareLinkRepository) ReserveShareLinkAttemptDB(ctx context.Context, id int64, maxAttempts int, lockout time.Duration) error {
+	// locked_until в строке, прошедшей условие WHERE, - истёкшая блокировка: счёт попыток начинается заново
+	query := `UPDATE share_links SET
+			failed_attempts = CASE WHEN locked_until IS NULL THEN failed_attempts ELSE 0 END + 1,
+			locked_until = CASE WHEN CASE WHEN locked_until IS NULL THEN failed_attempts ELSE 0 END + 1 >= $2
+				THEN now() + $3::double precision * interval '1 millisecond' END
+		WHERE id = $1 AND revoked_at IS NULL AND (locked_until IS NULL OR locked_until <= now())`
+
+	result, err := r.db.ExecContext(ctx, query, id, maxAttempts, lockout.Milliseconds())
+	if err != nil {
+		return fmt.Errorf("%w: %v", errors.ErrOpenShareLink, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%w: %v", errors.FailedToCheckAffectedRows, err)
+	}
+
+	if rowsAffected == 0 {
+		// Строка не обновлена: ссылку отозвали или проверка пароля заблокирована
+		var revoked bool
+		if err = r.db.QueryRowContext(ctx, "SELECT revoked_at IS NOT NULL FROM share_links WHERE id = $1", id).Scan(&revoked); err != nil {
+			if err == sql.ErrNoRows {
+				return errors.ErrShareLinkNotFound
+			}
+			return fmt.Errorf("%w: %v", errors.ErrOpenShareLink, err)
+		}
+		if revoked {
+			return errors.ErrShareLinkNotFound
+		}
+		return errors.ErrShareLinkLocked
+	}
+
+	return nil
+}
+
+// OpenShareLinkDB - учесть открытие ссылки и прочитать заметку с пунктами чек-листа.
+// Счётчик увеличивается в одной транзакции с чтением, только если ссылка всё ещё действует и заметка не удалена;
+// счётчик неверных паролей при этом сбрасывается
+func (r *shareLinkRepository) OpenShareLinkDB(ctx context.Context, id int64) (*models.AllNotes, []models.NoteItems, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", errors.ErrOpenShareLink, err)
+	}
+	defer tx.Rollback()
+
+	var noteID int64
+	query := `UPDATE share_links SET access_count = access_count + 1, last_accessed_at = now(), failed_attempts = 0
+		WHERE id = $1 AND revoked_at IS NULL AND (expires_at IS NULL OR expires_at > now())
+		RETURNING note_id`
+
+	if err = tx.QueryRowContext(ctx, query, id).Scan(&noteID); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil, errors.ErrShareLinkNotFound
+		}
+		return nil, nil, fmt.Errorf("%w: %v", errors.ErrOpenShareLink, err)
+	}
+
+	var note models.AllNotes
+	query = "SELECT " + noteColumns + " FROM all_notes WHERE id = $1 AND deleted_at IS NULL"
+
+	if err = scanNote(tx.QueryRowContext(ctx, query, noteID), &note); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil, errors.ErrShareLinkNotFound
+		}
+		return nil, nil, fmt.Errorf("%w: %v", errors.ErrOpenShareLink, err)
+	}
+
+	query = "SELECT id,note_id,text,completed,position,created_at FROM note_items WHERE note_id = $1 ORDER BY position, id"
+
+	rows, err := tx.QueryContext(ctx, query, noteID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", errors.ErrOpenShareLink, err)
+	}
+	defer rows.Close()
+
+	items := make([]models.NoteItems, 0)
+
+	for rows.Next() {
+		var item models.NoteItems
+		if err = rows.Scan(&item.ID, &item.NoteID, &item.Text, &item.Completed, &item.Position, &item.CreatedAt); err != nil {
+			return nil, nil, err
+		}
+		items = append(items, item)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", errors.ErrOpenShareLink, err)
+	}
+
+	return &note, items, nil
+}
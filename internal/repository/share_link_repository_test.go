@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"context"
+	stdErrors "errors"
+	"testing"
+	"time"
+
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/errors"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/models"
+)
+
+func TestReserveShareLinkAttemptDB(t *testing.T) {
+	db := openTestDB(t)
+	ctx := context.Background()
+	repo := NewShareLinkRepository(db)
+
+	userID, listID := createTestUser(t, db, "links")
+	noteID := createTestNote(t, db, userID, listID, "по ссылке")
+
+	link, err := repo.InsertShareLinkDB(ctx, models.ShareLinks{NoteID: noteID, UserID: userID, TokenHash: "hash", PasswordHash: "bcrypt"})
+	if err != nil {
+		t.Fatalf("InsertShareLinkDB: %v", err)
+	}
+
+	const maxAttempts = 3
+	for i := 0; i < maxAttempts; i++ {
+		if err = repo.ReserveShareLinkAttemptDB(ctx, link.ID, maxAttempts, time.Hour); err != nil {
+			t.Fatalf("attempt %d: %v", i+1, err)
+		}
+	}
+	if err = repo.ReserveShareLinkAttemptDB(ctx, link.ID, maxAttempts, time.Hour); !stdErrors.Is(err, errors.ErrShareLinkLocked) {
+		t.Fatalf("locked: err = %v, want %v", err, errors.ErrShareLinkLocked)
+	}
+
+	// Истёкшая блокировка: счёт попыток начинается заново
+	if _, err = db.Exec("UPDATE share_links SET locked_until = now() - interval '1 second' WHERE id = $1", link.ID); err != nil {
+		t.Fatalf("expire lockout: %v", err)
+	}
+	if err = repo.ReserveShareLinkAttemptDB(ctx, link.ID, maxAttempts, time.Hour); err != nil {
+		t.Fatalf("after lockout: %v", err)
+	}
+
+	// Успешное открытие сбрасывает счётчик
+	if _, _, err = repo.OpenShareLinkDB(ctx, link.ID); err != nil {
+		t.Fatalf("OpenShareLinkDB: %v", err)
+	}
+	opened, err := repo.GetShareLinkByTokenDB(ctx, "hash")
+	if err != nil {
+		t.Fatalf("GetShareLinkByTokenDB: %v", err)
+	}
+	if opened.FailedAttempts != 0 || opened.LockedUntil != nil {
+		t.Errorf("after open: failedAttempts = %d, lockedUntil = %v", opened.FailedAttempts, opened.LockedUntil)
+	}
+
+	if err = repo.RevokeShareLinkDB(ctx, userID, noteID, link.ID); err != nil {
+		t.Fatalf("RevokeShareLinkDB: %v", err)
+	}
+	if err = repo.ReserveShareLinkAttemptDB(ctx, link.ID, maxAttempts, time.Hour); !stdErrors.Is(err, errors.ErrShareLinkNotFound) {
+		t.Fatalf("revoked: err = %v, want %v", err, errors.ErrShareLinkNotFound)
+	}
+}
@@ -0,0 +1,196 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/errors"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/models"
+	"strings"
+)
+
+// ShareRepository - интерфейс для работы с совместным доступом к заметкам и спискам
+type ShareRepository interface {
+	GetNoteAccessDB(ctx context.Context, userID, noteID int64) (ownerID int64, role string, err error)
+	GetListAccessDB(ctx context.Context, userID, listID int64) (ownerID int64, role string, err error)
+	FindUserByLoginDB(ctx context.Context, login string) (*models.Users, error)
+	UpsertNoteShareDB(ctx context.Context, noteID, userID int64, role string) error
+	GetNoteSharesFromDB(ctx context.Context, noteID int64) ([]models.Shares, error)
+	DeleteNoteShareDB(ctx context.Context, noteID, userID int64) error
+	UpsertListShareDB(ctx context.Context, listID, userID int64, role string) error
+	GetListSharesFromDB(ctx context.Context, listID int64) ([]models.Shares, error)
+	DeleteListShareDB(ctx context.Context, listID, userID int64) error
+}
+
+type shareRepository struct {
+	db *sql.DB
+}
+
+func NewShareRepository(db *sql.DB) ShareRepository {
+	return &shareRepository{
+		db: db,
+	}
+}
+
+// GetNoteAccessDB - владелец заметки и роль пользователя: owner, editor или viewer.
+// Доступ к заметке даёт как личное приглашение, так и доступ к её списку (берётся наибольшая роль).
+// Пустая роль - доступа нет; удалённая или несуществующая заметка - ErrNoteNotFound
+func (r *shareRepository) GetNoteAccessDB(ctx context.Context, userID, noteID int64) (int64, string, error) {
+	query := `SELECT n.user_id,
+			CASE WHEN n.user_id = $2 THEN 'owner' ELSE COALESCE((
+				SELECT CASE WHEN bool_or(s.role = 'editor') THEN 'editor' ELSE 'viewer' END
+				FROM (
+					SELECT role FROM note_shares WHERE note_id = n.id AND user_id = $2
+					UNION ALL
+					SELECT role FROM list_shares WHERE list_id = n.list_id AND user_id = $2
+				) s
+				HAVING COUNT(*) > 0
+			), '') END
+		FROM all_notes n
+		WHERE n.id = $1 AND n.deleted_at IS NULL`
+
+	var (
+		ownerID int64
+		role    string
+	)
+	if err := r.db.QueryRowContext(ctx, query, noteID, userID).Scan(&ownerID, &role); err != nil {
+		if err == sql.ErrNoRows {
+			return 0, "", errors.ErrNoteNotFound
+		}
+		return 0, "", fmt.Errorf("%w: %v", errors.ErrCheckAccess, err)
+	}
+
+	return ownerID, role, nil
+}
+
+// GetListAccessDB - владелец списка и роль пользователя: owner, editor или viewer (пустая - доступа нет)
+func (r *shareRepository) GetListAccessDB(ctx context.Context, userID, listID int64) (int64, string, error) {
+	query := `SELECT l.user_id,
+			CASE WHEN l.user_id = $2 THEN 'owner'
+			     ELSE COALESCE((SELECT role FROM list_shares WHERE list_id = l.id AND user_id = $2), '') END
+		FROM lists l
+		WHERE l.id = $1`
+
+	var (
+		ownerID int64
+		role    string
+	)
+	if err := r.db.QueryRowContext(ctx, query, listID, userID).Scan(&ownerID, &role); err != nil {
+		if err == sql.ErrNoRows {
+			return 0, "", errors.ErrListNotFound
+		}
+		return 0, "", fmt.Errorf("%w: %v", errors.ErrCheckAccess, err)
+	}
+
+	return ownerID, role, nil
+}
+
+// FindUserByLoginDB - найти пользователя по имени или email (email без учёта регистра).
+// Совпадение по имени пользователя имеет приоритет
+func (r *shareRepository) FindUserByLoginDB(ctx context.Context, login string) (*models.Users, error) {
+	query := `SELECT id,user_name,email FROM users
+		WHERE user_name = $1 OR lower(email) = lower($1)
+		ORDER BY (user_name = $1) DESC
+		LIMIT 1`
+
+	var user models.Users
+	if err := r.db.QueryRowContext(ctx, query, strings.TrimSpace(login)).Scan(&user.ID, &user.UserName, &user.Email); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.ErrUserNotFound
+		}
+		return nil, fmt.Errorf("%w: %v", errors.ErrShareFailed, err)
+	}
+
+	return &user, nil
+}
+
+// UpsertNoteShareDB - открыть доступ к заметке или изменить роль уже приглашённого пользователя
+func (r *shareRepository) UpsertNoteShareDB(ctx context.Context, noteID, userID int64, role string) error {
+	return r.upsertShare(ctx, "note_shares", "note_id", noteID, userID, role)
+}
+
+// GetNoteSharesFromDB - пользователи, которым открыт доступ к заметке
+func (r *shareRepository) GetNoteSharesFromDB(ctx context.Context, noteID int64) ([]models.Shares, error) {
+	return r.getShares(ctx, "note_shares", "note_id", noteID)
+}
+
+// DeleteNoteShareDB - закрыть доступ пользователя к заметке
+func (r *shareRepository) DeleteNoteShareDB(ctx context.Context, noteID, userID int64) error {
+	return r.deleteShare(ctx, "note_shares", "note_id", noteID, userID)
+}
+
+// UpsertListShareDB - открыть доступ к списку или изменить роль уже приглашённого пользователя
+func (r *shareRepository) UpsertListShareDB(ctx context.Context, listID, userID int64, role string) error {
+	return r.upsertShare(ctx, "list_shares", "list_id", listID, userID, role)
+}
+
+// GetListSharesFromDB - пользователи, которым открыт доступ к списку
+func (r *shareRepository) GetListSharesFromDB(ctx context.Context, listID int64) ([]models.Shares, error) {
+	return r.getShares(ctx, "list_shares", "list_id", listID)
+}
+
+// DeleteListShareDB - закрыть доступ пользователя к списку
+func (r *shareRepository) DeleteListShareDB(ctx context.Context, listID, userID int64) error {
+	return r.deleteShare(ctx, "list_shares", "list_id", listID, userID)
+}
+
+// upsertShare - общая вставка для note_shares и list_shares (table и column - константы, не ввод пользователя)
+func (r *shareRepository) upsertShare(ctx context.Context, table, column string, resourceID, userID int64, role string) error {
+	query := "INSERT INTO " + table + " (" + column + ",user_id,role) VALUES ($1, $2, $3) " +
+		"ON CONFLICT (" + column + ",user_id) DO UPDATE SET role = EXCLUDED.role"
+
+	if _, err := r.db.ExecContext(ctx, query, resourceID, userID, role); err != nil {
+		return fmt.Errorf("%w: %v", errors.ErrShareFailed, err)
+	}
+
+	return nil
+}
+
+// getShares - общая выборка для note_shares и list_shares
+func (r *shareRepository) getShares(ctx context.Context, table, column string, resourceID int64) ([]models.Shares, error) {
+	query := "SELECT s.user_id,u.user_name,u.email,s.role,s.created_at FROM " + table + " s " +
+		"JOIN users u ON u.id = s.user_id WHERE s." + column + " = $1 ORDER BY s.created_at, s.user_id"
+
+	rows, err := r.db.QueryContext(ctx, query, resourceID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errors.ErrGetShares, err)
+	}
+	defer rows.Close()
+
+	shares := make([]models.Shares, 0)
+
+	for rows.Next() {
+		var share models.Shares
+		if err = rows.Scan(&share.UserID, &share.UserName, &share.Email, &share.Role, &share.CreatedAt); err != nil {
+			return nil, err
+		}
+		shares = append(shares, share)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return shares, nil
+}
+
+// deleteShare - общее удаление для note_shares и list_shares
+func (r *shareRepository) deleteShare(ctx context.Context, table, column string, resourceID, userID int64) error {
+	query := "DELETE FROM " + table + " WHERE " + column + " = $1 AND user_id = $2"
+
+	result, err := r.db.ExecContext(ctx, query, resourceID, userID)
+	if err != nil {
+		return fmt.Errorf("%w: %v", errors.ErrDeleteShare, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%w: %v", errors.FailedToCheckAffectedRows, err)
+	}
+
+	if rowsAffected == 0 {
+		return errors.ErrShareNotFound
+	}
+
+	return nil
+}
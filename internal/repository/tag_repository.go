@@ -0,0 +1,168 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/errors"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/models"
+)
+
+// TagRepository - интерфейс для работы с метками
+type TagRepository interface {
+	GetAllTagsFromDB(ctx context.Context, userID int64) ([]models.Tags, error)
+	InsertTagToDB(ctx context.Context, userID int64, name string) (*models.Tags, error)
+	UpdateTagToDB(ctx context.Context, userID, id int64, name string) error
+	DeleteTagFromDB(ctx context.Context, userID, id int64) error
+	AttachTagToNoteDB(ctx context.Context, userID, noteID, tagID int64) error
+	DetachTagFromNoteDB(ctx context.Context, userID, noteID, tagID int64) error
+}
+
+type tagRepository struct {
+	db *sql.DB
+}
+
+func NewTagRepository(db *sql.DB) TagRepository {
+	return &tagRepository{
+		db: db,
+	}
+}
+
+// GetAllTagsFromDB - получаем все метки пользователя из БД
+func (r *tagRepository) GetAllTagsFromDB(ctx context.Context, userID int64) ([]models.Tags, error) {
+	query := "SELECT id,user_id,name,created_at FROM tags WHERE user_id = $1 ORDER BY name"
+
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errors.ErrGetTags, err)
+	}
+	defer rows.Close()
+
+	tags := make([]models.Tags, 0)
+
+	for rows.Next() {
+		var tag models.Tags
+		if err = rows.Scan(&tag.ID, &tag.UserID, &tag.Name, &tag.CreatedAt); err != nil {
+			return nil, err
+		}
+		tags = append(tags, tag)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return tags, nil
+}
+
+// InsertTagToDB - добавить новую метку в БД
+func (r *tagRepository) InsertTagToDB(ctx context.Context, userID int64, name string) (*models.Tags, error) {
+	query := "INSERT INTO tags (user_id,name) VALUES ($1, $2) RETURNING id,user_id,name,created_at"
+
+	var tag models.Tags
+	err := r.db.QueryRowContext(ctx, query, userID, name).Scan(&tag.ID, &tag.UserID, &tag.Name, &tag.CreatedAt)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return nil, errors.ErrTagAlreadyExists
+		}
+		return nil, fmt.Errorf("%w: %v", errors.ErrTagFailed, err)
+	}
+
+	return &tag, nil
+}
+
+// UpdateTagToDB - переименовать метку пользователя в БД
+func (r *tagRepository) UpdateTagToDB(ctx context.Context, userID, id int64, name string) error {
+	query := "UPDATE tags SET name = $1 WHERE id = $2 AND user_id = $3"
+
+	result, err := r.db.ExecContext(ctx, query, name, id, userID)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return errors.ErrTagAlreadyExists
+		}
+		return fmt.Errorf("%w: %v", errors.ErrTagFailed, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%w: %v", errors.FailedToCheckAffectedRows, err)
+	}
+
+	if rowsAffected == 0 {
+		return errors.ErrTagNotFound
+	}
+
+	return nil
+}
+
+// DeleteTagFromDB - удалить метку пользователя из БД (связи с заметками удаляются каскадно)
+func (r *tagRepository) DeleteTagFromDB(ctx context.Context, userID, id int64) error {
+	query := "DELETE FROM tags WHERE id = $1 AND user_id = $2"
+
+	result, err := r.db.ExecContext(ctx, query, id, userID)
+	if err != nil {
+		return fmt.Errorf("%w: %v", errors.ErrDeleteTag, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%w: %v", errors.FailedToCheckAffectedRows, err)
+	}
+
+	if rowsAffected == 0 {
+		return errors.ErrTagNotFound
+	}
+
+	return nil
+}
+
+// AttachTagToNoteDB - прикрепить метку к заметке. Заметка и метка должны принадлежать пользователю
+func (r *tagRepository) AttachTagToNoteDB(ctx context.Context, userID, noteID, tagID int64) error {
+	if err := r.checkNoteAndTag(ctx, userID, noteID, tagID); err != nil {
+		return err
+	}
+
+	query := "INSERT INTO note_tags (note_id,tag_id) VALUES ($1, $2) ON CONFLICT DO NOTHING"
+
+	if _, err := r.db.ExecContext(ctx, query, noteID, tagID); err != nil {
+		return fmt.Errorf("%w: %v", errors.ErrAttachTag, err)
+	}
+
+	return nil
+}
+
+// DetachTagFromNoteDB - открепить метку от заметки пользователя
+func (r *tagRepository) DetachTagFromNoteDB(ctx context.Context, userID, noteID, tagID int64) error {
+	if err := r.checkNoteAndTag(ctx, userID, noteID, tagID); err != nil {
+		return err
+	}
+
+	query := "DELETE FROM note_tags WHERE note_id = $1 AND tag_id = $2"
+
+	if _, err := r.db.ExecContext(ctx, query, noteID, tagID); err != nil {
+		return fmt.Errorf("%w: %v", errors.ErrDetachTag, err)
+	}
+
+	return nil
+}
+
+// checkNoteAndTag - проверяем, что заметка и метка существуют и принадлежат пользователю
+func (r *tagRepository) checkNoteAndTag(ctx context.Context, userID, noteID, tagID int64) error {
+	query := `SELECT
+		EXISTS(SELECT 1 FROM all_notes WHERE id = $1 AND user_id = $3 AND deleted_at IS NULL),
+		EXISTS(SELECT 1 FROM tags WHERE id = $2 AND user_id = $3)`
+
+	var noteExists, tagExists bool
+	if err := r.db.QueryRowContext(ctx, query, noteID, tagID, userID).Scan(&noteExists, &tagExists); err != nil {
+		return err
+	}
+
+	if !noteExists {
+		return errors.ErrNoteNotFound
+	}
+	if !tagExists {
+		return errors.ErrTagNotFound
+	}
+
+	return nil
+}
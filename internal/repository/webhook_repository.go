@@ -0,0 +1,376 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/errors"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/models"
+	"time"
+)
+
+// WebhookRepository - интерфейс для работы с подписками webhook и очередью доставок
+type WebhookRepository interface {
+	GetWebhooksFromDB(ctx context.Context, userID int64) ([]models.Webhooks, error)
+	InsertWebhookToDB(ctx context.Context, webhook models.Webhooks, limit int) (*models.Webhooks, error)
+	UpdateWebhookToDB(ctx context.Context, webhook models.Webhooks) (*models.Webhooks, error)
+	DeleteWebhookFromDB(ctx context.Context, userID, id int64) error
+	GetWebhookDeliveriesFromDB(ctx context.Context, userID, webhookID int64, limit int) ([]models.WebhookDeliveries, error)
+	InsertWebhookDeliveryToDB(ctx context.Context, userID, webhookID int64, event string, payload []byte) (*models.WebhookDeliveries, error)
+	RedeliverWebhookDB(ctx context.Context, userID, webhookID, deliveryID int64) (*models.WebhookDeliveries, error)
+	ClaimWebhookDeliveriesDB(ctx context.Context, limit int, lease time.Duration) ([]models.DueWebhookDelivery, error)
+	MarkWebhookDeliveredDB(ctx context.Context, id int64, lockedUntil time.Time, attempt models.WebhookAttempt) error
+	MarkWebhookDeliveryFailedDB(ctx context.Context, id int64, lockedUntil time.Time, attempt models.WebhookAttempt, lastError string, nextAttemptAt *time.Time) error
+	ReleaseWebhookDeliveryDB(ctx context.Context, id int64, lockedUntil time.Time) error
+	PurgeWebhookDeliveriesDB(ctx context.Context, createdBefore time.Time) (int64, error)
+}
+
+type webhookRepository struct {
+	db *sql.DB
+}
+
+func NewWebhookRepository(db *sql.DB) WebhookRepository {
+	return &webhookRepository{
+		db: db,
+	}
+}
+
+// webhookColumns - поля подписки, которые читаются из БД (порядок совпадает со scanWebhook)
+const webhookColumns = "id,user_id,url,secret,to_json(events),active,created_at"
+
+// scanWebhook - читаем подписку из строки результата
+func scanWebhook(row rowScanner, webhook *models.Webhooks) error {
+	var events []byte
+
+	err := row.Scan(&webhook.ID, &webhook.UserID, &webhook.URL, &webhook.Secret, &events, &webhook.Active, &webhook.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	webhook.Events = make([]string, 0)
+	return json.Unmarshal(events, &webhook.Events)
+}
+
+// webhookDeliveryColumns - поля доставки, которые читаются из БД (порядок совпадает со scanWebhookDelivery)
+const webhookDeliveryColumns = `id,webhook_id,event,payload,status,attempts,next_attempt_at,response_status,response_body,
+	duration_ms,last_error,redelivery_of,created_at,delivered_at`
+
+// scanWebhookDelivery - читаем доставку из строки результата; extra - дополнительные поля после полей доставки
+func scanWebhookDelivery(row rowScanner, delivery *models.WebhookDeliveries, extra ...any) error {
+	var payload []byte
+
+	dest := []any{
+		&delivery.ID,
+		&delivery.WebhookID,
+		&delivery.Event,
+		&payload,
+		&delivery.Status,
+		&delivery.Attempts,
+		&delivery.NextAttemptAt,
+		&delivery.ResponseStatus,
+		&delivery.ResponseBody,
+		&delivery.DurationMs,
+		&delivery.LastError,
+		&delivery.RedeliveryOf,
+		&delivery.CreatedAt,
+		&delivery.DeliveredAt,
+	}
+	if err := row.Scan(append(dest, extra...)...); err != nil {
+		return err
+	}
+
+	delivery.Payload = json.RawMessage(payload)
+	return nil
+}
+
+// GetWebhooksFromDB - подписки пользователя
+func (r *webhookRepository) GetWebhooksFromDB(ctx context.Context, userID int64) ([]models.Webhooks, error) {
+	query := "SELECT " + webhookColumns + " FROM webhooks WHERE user_id = $1 ORDER BY id"
+
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errors.ErrGetWebhooks, err)
+	}
+	defer rows.Close()
+
+	webhooks := make([]models.Webhooks, 0)
+
+	for rows.Next() {
+		var webhook models.Webhooks
+		if err = scanWebhook(rows, &webhook); err != nil {
+			return nil, fmt.Errorf("%w: %v", errors.ErrGetWebhooks, err)
+		}
+		webhooks = append(webhooks, webhook)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %v", errors.ErrGetWebhooks, err)
+	}
+
+	return webhooks, nil
+}
+
+// InsertWebhookToDB - создать подписку, если у пользователя меньше limit подписок
+func (r *webhookRepository) InsertWebhookToDB(ctx context.Context, webhook models.Webhooks, limit int) (*models.Webhooks, error) {
+	query := `INSERT INTO webhooks (user_id,url,secret,events,active)
+		SELECT $1, $2, $3, $4::text[], $5
+		WHERE (SELECT COUNT(*) FROM webhooks WHERE user_id = $1) < $6
+		RETURNING ` + webhookColumns
+
+	var created models.Webhooks
+	err := scanWebhook(r.db.QueryRowContext(ctx, query,
+		webhook.UserID, webhook.URL, webhook.Secret, webhook.Events, webhook.Active, limit), &created)
+	if err == sql.ErrNoRows {
+		return nil, errors.ErrTooManyWebhooks
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errors.ErrWebhookFailed, err)
+	}
+
+	return &created, nil
+}
+
+// UpdateWebhookToDB - изменить адрес, фильтр событий и состояние подписки (секрет не меняется)
+func (r *webhookRepository) UpdateWebhookToDB(ctx context.Context, webhook models.Webhooks) (*models.Webhooks, error) {
+	query := `UPDATE webhooks SET url = $3, events = $4::text[], active = $5
+		WHERE id = $1 AND user_id = $2
+		RETURNING ` + webhookColumns
+
+	var updated models.Webhooks
+	err := scanWebhook(r.db.QueryRowContext(ctx, query,
+		webhook.ID, webhook.UserID, webhook.URL, webhook.Events, webhook.Active), &updated)
+	if err == sql.ErrNoRows {
+		return nil, errors.ErrWebhookNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errors.ErrWebhookFailed, err)
+	}
+
+	return &updated, nil
+}
+
+// DeleteWebhookFromDB - удалить подписку вместе с очередью и журналом доставок
+func (r *webhookRepository) DeleteWebhookFromDB(ctx context.Context, userID, id int64) error {
+	result, err := r.db.ExecContext(ctx, "DELETE FROM webhooks WHERE id = $1 AND user_id = $2", id, userID)
+	if err != nil {
+		return fmt.Errorf("%w: %v", errors.ErrDeleteWebhook, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%w: %v", errors.FailedToCheckAffectedRows, err)
+	}
+
+	if rowsAffected == 0 {
+		return errors.ErrWebhookNotFound
+	}
+
+	return nil
+}
+
+// GetWebhookDeliveriesFromDB - последние доставки подписки пользователя (новые первыми)
+func (r *webhookRepository) GetWebhookDeliveriesFromDB(ctx context.Context, userID, webhookID int64, limit int) ([]models.WebhookDeliveries, error) {
+	if err := checkWebhookOwner(ctx, r.db, userID, webhookID); err != nil {
+		return nil, err
+	}
+
+	query := "SELECT " + webhookDeliveryColumns + ` FROM webhook_deliveries
+		WHERE webhook_id = $1
+		ORDER BY created_at DESC, id DESC
+		LIMIT $2`
+
+	rows, err := r.db.QueryContext(ctx, query, webhookID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errors.ErrGetWebhookLog, err)
+	}
+	defer rows.Close()
+
+	deliveries := make([]models.WebhookDeliveries, 0)
+
+	for rows.Next() {
+		var delivery models.WebhookDeliveries
+		if err = scanWebhookDelivery(rows, &delivery); err != nil {
+			return nil, fmt.Errorf("%w: %v", errors.ErrGetWebhookLog, err)
+		}
+		deliveries = append(deliveries, delivery)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %v", errors.ErrGetWebhookLog, err)
+	}
+
+	return deliveries, nil
+}
+
+// InsertWebhookDeliveryToDB - поставить доставку подписки пользователя в очередь
+func (r *webhookRepository) InsertWebhookDeliveryToDB(ctx context.Context, userID, webhookID int64, event string, payload []byte) (*models.WebhookDeliveries, error) {
+	query := `INSERT INTO webhook_deliveries (webhook_id,event,payload)
+		SELECT id, $3, $4::jsonb FROM webhooks WHERE id = $1 AND user_id = $2
+		RETURNING ` + webhookDeliveryColumns
+
+	var delivery models.WebhookDeliveries
+	err := scanWebhookDelivery(r.db.QueryRowContext(ctx, query, webhookID, userID, event, string(payload)), &delivery)
+	if err == sql.ErrNoRows {
+		return nil, errors.ErrWebhookNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errors.ErrWebhookFailed, err)
+	}
+
+	return &delivery, nil
+}
+
+// RedeliverWebhookDB - поставить в очередь копию доставки (исходная запись остаётся в журнале)
+func (r *webhookRepository) RedeliverWebhookDB(ctx context.Context, userID, webhookID, deliveryID int64) (*models.WebhookDeliveries, error) {
+	if err := checkWebhookOwner(ctx, r.db, userID, webhookID); err != nil {
+		return nil, err
+	}
+
+	query := `INSERT INTO webhook_deliveries (webhook_id,event,payload,redelivery_of)
+		SELECT webhook_id, event, payload, id FROM webhook_deliveries WHERE id = $1 AND webhook_id = $2
+		RETURNING ` + webhookDeliveryColumns
+
+	var delivery models.WebhookDeliveries
+	err := scanWebhookDelivery(r.db.QueryRowContext(ctx, query, deliveryID, webhookID), &delivery)
+	if err == sql.ErrNoRows {
+		return nil, errors.ErrWebhookDeliveryNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errors.ErrRedeliverWebhook, err)
+	}
+
+	return &delivery, nil
+}
+
+// ClaimWebhookDeliveriesDB - захватить доставки активных подписок, которые пора отправить, на время lease.
+// FOR UPDATE SKIP LOCKED позволяет нескольким репликам API разбирать очередь, не мешая друг другу;
+// доставки, захваченные упавшей репликой, снова становятся доступны после истечения lease
+func (r *webhookRepository) ClaimWebhookDeliveriesDB(ctx context.Context, limit int, lease time.Duration) ([]models.DueWebhookDelivery, error) {
+	query := `WITH due AS (
+			SELECT d.id
+			FROM webhook_deliveries d
+			JOIN webhooks w ON w.id = d.webhook_id
+			WHERE w.active
+			  AND ((d.status = 'pending' AND d.next_attempt_at <= now())
+			    OR (d.status = 'sending' AND d.locked_until < now()))
+			ORDER BY d.next_attempt_at, d.id
+			LIMIT $1
+			FOR UPDATE OF d SKIP LOCKED
+		), claimed AS (
+			UPDATE webhook_deliveries SET status = 'sending', locked_until = now() + $2::double precision * interval '1 millisecond', attempts = attempts + 1
+			FROM due
+			WHERE webhook_deliveries.id = due.id
+			RETURNING webhook_deliveries.*
+		)
+		SELECT c.id, c.webhook_id, c.event, c.payload, c.status, c.attempts, c.next_attempt_at, c.response_status,
+		       c.response_body, c.duration_ms, c.last_error, c.redelivery_of, c.created_at, c.delivered_at, w.url, w.secret, c.locked_until
+		FROM claimed c
+		JOIN webhooks w ON w.id = c.webhook_id
+		ORDER BY c.next_attempt_at, c.id`
+
+	rows, err := r.db.QueryContext(ctx, query, limit, lease.Milliseconds())
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errors.ErrDeliverWebhook, err)
+	}
+	defer rows.Close()
+
+	deliveries := make([]models.DueWebhookDelivery, 0)
+
+	for rows.Next() {
+		var delivery models.DueWebhookDelivery
+		if err = scanWebhookDelivery(rows, &delivery.WebhookDeliveries, &delivery.URL, &delivery.Secret, &delivery.LockedUntil); err != nil {
+			return nil, fmt.Errorf("%w: %v", errors.ErrDeliverWebhook, err)
+		}
+		deliveries = append(deliveries, delivery)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %v", errors.ErrDeliverWebhook, err)
+	}
+
+	return deliveries, nil
+}
+
+// MarkWebhookDeliveredDB - отметить доставку успешной.
+// lockedUntil - время захвата из ClaimWebhookDeliveriesDB: если захват истёк и доставку взяла другая реплика,
+// строка не меняется и возвращается ErrWebhookLeaseLost
+func (r *webhookRepository) MarkWebhookDeliveredDB(ctx context.Context, id int64, lockedUntil time.Time, attempt models.WebhookAttempt) error {
+	query := `UPDATE webhook_deliveries
+		SET status = 'delivered', delivered_at = now(), locked_until = NULL, last_error = '',
+		    response_status = $3, response_body = $4, duration_ms = $5
+		WHERE id = $1 AND status = 'sending' AND locked_until = $2`
+
+	return r.execClaimedDelivery(ctx, query, id, lockedUntil, attempt.ResponseStatus, attempt.ResponseBody, attempt.DurationMs)
+}
+
+// MarkWebhookDeliveryFailedDB - записать неудачную попытку. Если nextAttemptAt == nil, попытки закончились
+// и доставка получает статус failed, иначе она вернётся в очередь в nextAttemptAt.
+// Захват проверяется так же, как в MarkWebhookDeliveredDB
+func (r *webhookRepository) MarkWebhookDeliveryFailedDB(ctx context.Context, id int64, lockedUntil time.Time, attempt models.WebhookAttempt, lastError string, nextAttemptAt *time.Time) error {
+	query := `UPDATE webhook_deliveries
+		SET status = CASE WHEN $7::timestamptz IS NULL THEN 'failed' ELSE 'pending' END,
+		    next_attempt_at = COALESCE($7, next_attempt_at),
+		    locked_until = NULL,
+		    response_status = $3, response_body = $4, duration_ms = $5, last_error = $6
+		WHERE id = $1 AND status = 'sending' AND locked_until = $2`
+
+	return r.execClaimedDelivery(ctx, query, id, lockedUntil, attempt.ResponseStatus, attempt.ResponseBody, attempt.DurationMs, lastError, nextAttemptAt)
+}
+
+// ReleaseWebhookDeliveryDB - вернуть захваченную, но не отправленную доставку в очередь; попытка не учитывается
+func (r *webhookRepository) ReleaseWebhookDeliveryDB(ctx context.Context, id int64, lockedUntil time.Time) error {
+	query := `UPDATE webhook_deliveries SET status = 'pending', locked_until = NULL, attempts = attempts - 1
+		WHERE id = $1 AND status = 'sending' AND locked_until = $2`
+
+	return r.execClaimedDelivery(ctx, query, id, lockedUntil)
+}
+
+// execClaimedDelivery - изменить захваченную доставку; ни одной изменённой строки - захват потерян
+func (r *webhookRepository) execClaimedDelivery(ctx context.Context, query string, args ...any) error {
+	result, err := r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("%w: %v", errors.ErrDeliverWebhook, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%w: %v", errors.FailedToCheckAffectedRows, err)
+	}
+
+	if rowsAffected == 0 {
+		return errors.ErrWebhookLeaseLost
+	}
+
+	return nil
+}
+
+// PurgeWebhookDeliveriesDB - удалить завершённые доставки, созданные раньше createdBefore
+func (r *webhookRepository) PurgeWebhookDeliveriesDB(ctx context.Context, createdBefore time.Time) (int64, error) {
+	query := "DELETE FROM webhook_deliveries WHERE status IN ('delivered', 'failed') AND created_at < $1"
+
+	result, err := r.db.ExecContext(ctx, query, createdBefore)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", errors.ErrDeliverWebhook, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", errors.FailedToCheckAffectedRows, err)
+	}
+
+	return rowsAffected, nil
+}
+
+// checkWebhookOwner - проверяем, что подписка принадлежит пользователю
+func checkWebhookOwner(ctx context.Context, q queryRower, userID, id int64) error {
+	var exists bool
+	err := q.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM webhooks WHERE id = $1 AND user_id = $2)", id, userID).Scan(&exists)
+	if err != nil {
+		return fmt.Errorf("%w: %v", errors.ErrGetWebhooks, err)
+	}
+	if !exists {
+		return errors.ErrWebhookNotFound
+	}
+	return nil
+}
@@ -0,0 +1,283 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	goerrors "errors"
+	"fmt"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/blobstore"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/config"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/errors"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/models"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/repository"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	defaultMaxAttachmentSize = 10 << 20  // Максимальный размер файла, если он не задан в конфигурации
+	defaultAttachmentQuota   = 100 << 20 // Объём вложений пользователя, если он не задан в конфигурации
+
+	maxAttachmentNameLength = 255 // Максимальная длина имени файла
+	attachmentCleanupBatch  = 100 // Сколько файлов удаляется за один проход очистки
+)
+
+// allowedAttachmentTypes - типы содержимого, которые можно прикрепить к заметке (определяются по первым байтам файла)
+var allowedAttachmentTypes = map[string]bool{
+	"image/png":       true,
+	"image/jpeg":      true,
+	"image/gif":       true,
+	"image/webp":      true,
+	"application/pdf": true,
+}
+
+// AttachmentService - интерфейс для работы с вложениями заметок
+type AttachmentService interface {
+	MaxFileSize() int64
+	GetAttachments(ctx context.Context, userID, noteID int64) ([]models.Attachments, error)
+	UploadAttachment(ctx context.Context, userID, noteID int64, fileName string, r io.Reader) (*models.Attachments, error)
+	OpenAttachment(ctx context.Context, userID, noteID, id int64) (*models.Attachments, io.ReadCloser, error)
+	DeleteAttachment(ctx context.Context, userID, noteID, id int64) error
+	CleanupOrphanedAttachments(ctx context.Context) (int, error)
+}
+
+type attachmentService struct {
+	repo    repository.AttachmentRepository
+	store   blobstore.BlobStore
+	auth    Authorizer
+	cfg     *config.Config
+	maxSize int64
+	quota   int64
+}
+
+func NewAttachmentService(repo repository.AttachmentRepository, store blobstore.BlobStore, auth Authorizer, cfg *config.Config) AttachmentService {
+	maxSize := cfg.Attachments.MaxFileSize
+	if maxSize <= 0 {
+		maxSize = defaultMaxAttachmentSize
+	}
+
+	quota := cfg.Attachments.UserQuota
+	if quota <= 0 {
+		quota = defaultAttachmentQuota
+	}
+
+	return &attachmentService{
+		repo:    repo,
+		store:   store,
+		auth:    auth,
+		cfg:     cfg,
+		maxSize: maxSize,
+		quota:   quota,
+	}
+}
+
+// MaxFileSize - максимальный размер загружаемого файла в байтах
+func (s *attachmentService) MaxFileSize() int64 {
+	return s.maxSize
+}
+
+// GetAttachments - получаем вложения заметки
+func (s *attachmentService) GetAttachments(ctx context.Context, userID, noteID int64) ([]models.Attachments, error) {
+	ownerID, err := s.auth.AuthorizeNote(ctx, userID, noteID, PermissionRead)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.repo.GetAttachmentsFromDB(ctx, ownerID, noteID)
+}
+
+// UploadAttachment - прикрепить файл к заметке. Файл сначала сохраняется во временный файл, чтобы узнать
+// размер и тип содержимого, затем загружается в хранилище. Объём учитывается в квоте владельца заметки
+func (s *attachmentService) UploadAttachment(ctx context.Context, userID, noteID int64, fileName string, r io.Reader) (*models.Attachments, error) {
+	ownerID, err := s.auth.AuthorizeNote(ctx, userID, noteID, PermissionWrite)
+	if err != nil {
+		return nil, err
+	}
+
+	tmp, err := os.CreateTemp("", "attachment-*")
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", errors.ErrAttachmentFailed, err)
+	}
+	defer func() {
+		tmp.Close()
+		os.Remove(tmp.Name())
+	}()
+
+	// Читаем на байт больше лимита, чтобы отличить файл предельного размера от слишком большого
+	size, err := io.Copy(tmp, io.LimitReader(r, s.maxSize+1))
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if goerrors.As(err, &maxBytesErr) {
+			return nil, errors.ErrAttachmentTooLarge
+		}
+		return nil, fmt.Errorf("%w: %w", errors.ErrAttachmentFailed, err)
+	}
+	if size == 0 {
+		return nil, errors.ErrInvalidAttachment
+	}
+	if size > s.maxSize {
+		return nil, errors.ErrAttachmentTooLarge
+	}
+
+	// http.DetectContentType использует не более 512 первых байт
+	head := make([]byte, 512)
+	n, err := tmp.ReadAt(head, 0)
+	if err != nil && err != io.EOF {
+		return nil, fmt.Errorf("%w: %w", errors.ErrAttachmentFailed, err)
+	}
+
+	contentType := http.DetectContentType(head[:n])
+	if !allowedAttachmentTypes[contentType] {
+		return nil, errors.ErrUnsupportedAttachmentType
+	}
+
+	// Предварительная проверка квоты, чтобы не загружать в хранилище заведомо лишний файл.
+	// Окончательная проверка выполняется при сохранении метаданных
+	used, err := s.repo.GetUsedStorageDB(ctx, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	if used+size > s.quota {
+		return nil, errors.ErrAttachmentQuotaExceeded
+	}
+
+	key, err := newAttachmentKey(ownerID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", errors.ErrAttachmentFailed, err)
+	}
+
+	if _, err = tmp.Seek(0, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("%w: %w", errors.ErrAttachmentFailed, err)
+	}
+
+	if err = s.store.Put(ctx, key, tmp, size, contentType); err != nil {
+		return nil, fmt.Errorf("%w: %w", errors.ErrAttachmentFailed, err)
+	}
+
+	attachment, err := s.repo.InsertAttachmentToDB(ctx, models.Attachments{
+		NoteID:      &noteID,
+		UserID:      ownerID,
+		FileName:    sanitizeFileName(fileName),
+		ContentType: contentType,
+		Size:        size,
+		StorageKey:  key,
+	}, s.quota)
+	if err != nil {
+		// Метаданные не сохранены - файл в хранилище никому не принадлежит. Удаляем его даже при отменённом запросе
+		_ = s.store.Delete(context.WithoutCancel(ctx), key)
+		return nil, err
+	}
+
+	return attachment, nil
+}
+
+// OpenAttachment - открыть содержимое вложения для скачивания. Поток закрывает вызывающий
+func (s *attachmentService) OpenAttachment(ctx context.Context, userID, noteID, id int64) (*models.Attachments, io.ReadCloser, error) {
+	if id <= 0 {
+		return nil, nil, errors.ErrIDCannotBeNegativeOrEqualToZero
+	}
+
+	ownerID, err := s.auth.AuthorizeNote(ctx, userID, noteID, PermissionRead)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	attachment, err := s.repo.GetAttachmentFromDB(ctx, ownerID, noteID, id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	content, err := s.store.Get(ctx, attachment.StorageKey)
+	if goerrors.Is(err, blobstore.ErrNotFound) {
+		return nil, nil, errors.ErrAttachmentNotFound
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %w", errors.ErrGetAttachments, err)
+	}
+
+	return attachment, content, nil
+}
+
+// DeleteAttachment - удалить вложение. Если файл не удалось удалить из хранилища сразу,
+// его удалит фоновая очистка (запись уже откреплена от заметки)
+func (s *attachmentService) DeleteAttachment(ctx context.Context, userID, noteID, id int64) error {
+	if id <= 0 {
+		return errors.ErrIDCannotBeNegativeOrEqualToZero
+	}
+
+	ownerID, err := s.auth.AuthorizeNote(ctx, userID, noteID, PermissionWrite)
+	if err != nil {
+		return err
+	}
+
+	attachment, err := s.repo.DetachAttachmentDB(ctx, ownerID, noteID, id)
+	if err != nil {
+		return err
+	}
+
+	if err = s.store.Delete(ctx, attachment.StorageKey); err == nil {
+		_ = s.repo.DeleteOrphanedAttachmentDB(ctx, attachment.ID)
+	}
+
+	return nil
+}
+
+// CleanupOrphanedAttachments - удалить из хранилища файлы откреплённых вложений и окончательно удалённых заметок.
+// Возвращает количество удалённых файлов
+func (s *attachmentService) CleanupOrphanedAttachments(ctx context.Context) (int, error) {
+	attachments, err := s.repo.GetOrphanedAttachmentsDB(ctx, attachmentCleanupBatch)
+	if err != nil {
+		return 0, err
+	}
+
+	removed := 0
+	for _, attachment := range attachments {
+		if err = s.store.Delete(ctx, attachment.StorageKey); err != nil {
+			return removed, fmt.Errorf("%w: %w", errors.ErrDeleteAttachment, err)
+		}
+		if err = s.repo.DeleteOrphanedAttachmentDB(ctx, attachment.ID); err != nil {
+			return removed, err
+		}
+		removed++
+	}
+
+	return removed, nil
+}
+
+// newAttachmentKey - случайный ключ объекта в хранилище: {ID владельца}/{32 hex-символа}
+func newAttachmentKey(ownerID int64) (string, error) {
+	raw := make([]byte, 16)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%d/%s", ownerID, hex.EncodeToString(raw)), nil
+}
+
+// sanitizeFileName - оставляем только имя файла без пути и управляющих символов
+func sanitizeFileName(name string) string {
+	name = strings.ReplaceAll(name, "\\", "/")
+	name = filepath.Base(strings.TrimSpace(name))
+
+	name = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) || r == utf8.RuneError {
+			return -1
+		}
+		return r
+	}, name)
+
+	if name == "" || name == "." || name == "/" {
+		return "file"
+	}
+
+	if utf8.RuneCountInString(name) > maxAttachmentNameLength {
+		name = string([]rune(name)[:maxAttachmentNameLength])
+	}
+
+	return name
+}
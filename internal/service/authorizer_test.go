@@ -0,0 +1,130 @@
+package service
+
+import (
+	"context"
+	stdErrors "errors"
+	"testing"
+
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/errors"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/models"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/repository"
+)
+
+// accessKey - пользователь и ресурс (заметка или список)
+type accessKey struct {
+	userID, id int64
+}
+
+// access - владелец ресурса и роль пользователя
+type access struct {
+	ownerID int64
+	role    string
+}
+
+// fakeShareRepo - права доступа в памяти; остальные методы ShareRepository не используются
+type fakeShareRepo struct {
+	repository.ShareRepository
+	notes map[accessKey]access
+	lists map[accessKey]access
+}
+
+func (f *fakeShareRepo) GetNoteAccessDB(_ context.Context, userID, noteID int64) (int64, string, error) {
+	a := f.notes[accessKey{userID, noteID}]
+	return a.ownerID, a.role, nil
+}
+
+func (f *fakeShareRepo) GetListAccessDB(_ context.Context, userID, listID int64) (int64, string, error) {
+	a := f.lists[accessKey{userID, listID}]
+	return a.ownerID, a.role, nil
+}
+
+const (
+	ownerUser  = int64(1) // Владелец заметки 10 и списка 20
+	editorUser = int64(2) // Редактор
+	viewerUser = int64(3) // Читатель
+	otherUser  = int64(4) // Пользователь без доступа
+	sharedNote = int64(10)
+	sharedList = int64(20)
+)
+
+func newFakeShareRepo() *fakeShareRepo {
+	return &fakeShareRepo{
+		notes: map[accessKey]access{
+			{ownerUser, sharedNote}:  {ownerUser, models.ShareRoleOwner},
+			{editorUser, sharedNote}: {ownerUser, models.ShareRoleEditor},
+			{viewerUser, sharedNote}: {ownerUser, models.ShareRoleViewer},
+		},
+		lists: map[accessKey]access{
+			{ownerUser, sharedList}:  {ownerUser, models.ShareRoleOwner},
+			{editorUser, sharedList}: {ownerUser, models.ShareRoleEditor},
+			{viewerUser, sharedList}: {ownerUser, models.ShareRoleViewer},
+		},
+	}
+}
+
+func TestAuthorizeNote(t *testing.T) {
+	auth := NewAuthorizer(newFakeShareRepo())
+
+	tests := []struct {
+		name    string
+		userID  int64
+		perm    Permission
+		wantErr error
+	}{
+		{"owner manage", ownerUser, PermissionManage, nil},
+		{"editor write", editorUser, PermissionWrite, nil},
+		{"editor manage", editorUser, PermissionManage, errors.ErrForbidden},
+		{"viewer read", viewerUser, PermissionRead, nil},
+		{"viewer write", viewerUser, PermissionWrite, errors.ErrForbidden},
+		{"other user read", otherUser, PermissionRead, errors.ErrNoteNotFound},
+		{"other user manage", otherUser, PermissionManage, errors.ErrNoteNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ownerID, err := auth.AuthorizeNote(context.Background(), tt.userID, sharedNote, tt.perm)
+			if !stdErrors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil && ownerID != ownerUser {
+				t.Errorf("ownerID = %d, want %d", ownerID, ownerUser)
+			}
+		})
+	}
+}
+
+func TestAuthorizeList(t *testing.T) {
+	auth := NewAuthorizer(newFakeShareRepo())
+
+	tests := []struct {
+		name    string
+		userID  int64
+		perm    Permission
+		wantErr error
+	}{
+		{"owner manage", ownerUser, PermissionManage, nil},
+		{"editor write", editorUser, PermissionWrite, nil},
+		{"viewer write", viewerUser, PermissionWrite, errors.ErrForbidden},
+		{"other user read", otherUser, PermissionRead, errors.ErrListNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := auth.AuthorizeList(context.Background(), tt.userID, sharedList, tt.perm)
+			if !stdErrors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestAuthorizeInvalidID(t *testing.T) {
+	auth := NewAuthorizer(newFakeShareRepo())
+
+	if _, err := auth.AuthorizeNote(context.Background(), ownerUser, 0, PermissionRead); !stdErrors.Is(err, errors.ErrIDCannotBeNegativeOrEqualToZero) {
+		t.Errorf("AuthorizeNote(id=0) err = %v", err)
+	}
+	if _, err := auth.AuthorizeList(context.Background(), 0, sharedList, PermissionRead); !stdErrors.Is(err, errors.ErrIDCannotBeNegativeOrEqualToZero) {
+		t.Errorf("AuthorizeList(user=0) err = %v", err)
+	}
+}
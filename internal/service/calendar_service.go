@@ -0,0 +1,401 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	goerrors "errors"
+	"fmt"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/config"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/errors"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/models"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/repository"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/transport/dto/request"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/transport/dto/response"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/pkg/ical"
+	"html"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	calendarTokenBytes = 32   // Длина токена ленты в байтах (256 бит)
+	maxCalendarTodos   = 1000 // Максимальное количество задач в загружаемом файле
+
+	defaultCalendarSyncInterval = 10 * time.Second // Интервал между загрузками задач по одной ленте, если не задан в конфигурации
+
+	calendarProdID    = "-//todolistjwtca//Notes//RU" // Идентификатор приложения в ленте (PRODID)
+	calendarName      = "Заметки"                     // Название календаря в клиентах
+	calendarUIDDomain = "todolistjwtca"               // Домен в UID задач: note-<id>@todolistjwtca
+)
+
+// CalendarService - интерфейс для работы с лентой задач iCalendar
+type CalendarService interface {
+	CreateCalendarFeed(ctx context.Context, userID int64) (*response.CalendarFeedDTO, error)
+	DeleteCalendarFeed(ctx context.Context, userID int64) error
+	WriteCalendarFeed(ctx context.Context, token string, w io.Writer) error
+	SyncCalendarFeed(ctx context.Context, token string, r io.Reader) (*response.CalendarSyncDTO, error)
+}
+
+type calendarService struct {
+	repo  repository.CalendarRepository
+	lists repository.ListRepository
+	notes NoteService
+	cfg   *config.Config
+	loc   *time.Location
+}
+
+func NewCalendarService(repo repository.CalendarRepository, lists repository.ListRepository, notes NoteService, cfg *config.Config) CalendarService {
+	return &calendarService{
+		repo:  repo,
+		lists: lists,
+		notes: notes,
+		cfg:   cfg,
+		loc:   loadLocation(cfg.DB.TimeZone),
+	}
+}
+
+// CreateCalendarFeed - создать приватную ссылку на ленту или заменить её токен.
+// Токен возвращается один раз, в БД сохраняется только его SHA-256
+func (s *calendarService) CreateCalendarFeed(ctx context.Context, userID int64) (*response.CalendarFeedDTO, error) {
+	if userID <= 0 {
+		return nil, errors.ErrIDCannotBeNegativeOrEqualToZero
+	}
+
+	raw := make([]byte, calendarTokenBytes)
+	if _, err := rand.Read(raw); err != nil {
+		return nil, fmt.Errorf("%w: %w", errors.ErrCalendarFeedFailed, err)
+	}
+	token := base64.RawURLEncoding.EncodeToString(raw)
+
+	feed, err := s.repo.UpsertCalendarFeedDB(ctx, userID, hashShareLinkToken(token))
+	if err != nil {
+		return nil, err
+	}
+
+	return &response.CalendarFeedDTO{
+		Token:     token,
+		Path:      "/ical/" + token + ".ics",
+		CreatedAt: feed.CreatedAt,
+	}, nil
+}
+
+// DeleteCalendarFeed - отозвать ссылку на ленту
+func (s *calendarService) DeleteCalendarFeed(ctx context.Context, userID int64) error {
+	if userID <= 0 {
+		return errors.ErrIDCannotBeNegativeOrEqualToZero
+	}
+
+	return s.repo.DeleteCalendarFeedDB(ctx, userID)
+}
+
+// WriteCalendarFeed - записать в w заметки владельца ленты в виде задач VTODO. Файл формируется потоком
+func (s *calendarService) WriteCalendarFeed(ctx context.Context, token string, w io.Writer) error {
+	userID, err := s.repo.OpenCalendarFeedDB(ctx, hashShareLinkToken(token))
+	if err != nil {
+		return err
+	}
+
+	enc := ical.NewEncoder(w)
+	if err = enc.Begin(calendarProdID, calendarName); err != nil {
+		return fmt.Errorf("%w: %w", errors.ErrGetCalendarFeed, err)
+	}
+
+	now := time.Now().UTC()
+	err = s.repo.GetCalendarTodosDB(ctx, userID, func(note models.CalendarTodo) error {
+		if err := enc.WriteTodo(noteToTodo(note, now)); err != nil {
+			return fmt.Errorf("%w: %w", errors.ErrGetCalendarFeed, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if err = enc.End(); err != nil {
+		return fmt.Errorf("%w: %w", errors.ErrGetCalendarFeed, err)
+	}
+
+	return nil
+}
+
+// noteToTodo - заметка в виде задачи VTODO. Текст и название списка выгружаются без HTML-экранирования
+func noteToTodo(note models.CalendarTodo, now time.Time) ical.Todo {
+	todo := ical.Todo{
+		UID:        noteUID(note),
+		Summary:    html.UnescapeString(note.Note),
+		Status:     ical.StatusNeedsAction,
+		Created:    &note.CreatedAt,
+		DTStamp:    &now,
+		Due:        note.DueAt,
+		Priority:   todoPriority(note.Priority),
+		RRule:      note.RRule,
+		Categories: []string{html.UnescapeString(note.List)},
+	}
+
+	if note.Completed {
+		todo.Status = ical.StatusCompleted
+	}
+
+	if note.RRule != "" {
+		todo.Start = note.RRuleStart
+		if todo.Start == nil {
+			todo.Start = note.DueAt
+		}
+	}
+
+	return todo
+}
+
+// SyncCalendarFeed - применить задачи VTODO, изменённые в календарном клиенте.
+// Задачи сопоставляются с заметками по UID: известные обновляются, новые создаются,
+// отменённые (STATUS:CANCELLED) перемещаются в корзину. Изменения проходят те же проверки,
+// что и пакет операций в режиме best_effort: ошибка одной задачи не мешает остальным.
+// Загрузка по одному токену разрешена не чаще раза в Calendar.SyncInterval
+func (s *calendarService) SyncCalendarFeed(ctx context.Context, token string, r io.Reader) (*response.CalendarSyncDTO, error) {
+	interval := s.cfg.Calendar.SyncInterval
+	if interval <= 0 {
+		interval = defaultCalendarSyncInterval
+	}
+
+	userID, err := s.repo.OpenCalendarSyncDB(ctx, hashShareLinkToken(token), interval)
+	if err != nil {
+		return nil, err
+	}
+
+	todos, err := ical.Decode(r, s.loc)
+	var maxBytesErr *http.MaxBytesError
+	if goerrors.As(err, &maxBytesErr) {
+		return nil, errors.ErrCalendarFileTooLarge
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", errors.ErrInvalidCalendarFile, err)
+	}
+	if len(todos) > maxCalendarTodos {
+		return nil, errors.ErrTooManyCalendarTodos
+	}
+
+	ids := make([]int64, 0, len(todos))
+	uids := make([]string, 0, len(todos))
+	for _, todo := range todos {
+		if id, ok := parseNoteUID(todo.UID); ok {
+			ids = append(ids, id)
+		}
+		uids = append(uids, todo.UID)
+	}
+
+	found, err := s.repo.FindCalendarTodosDB(ctx, userID, ids, uids)
+	if err != nil {
+		return nil, err
+	}
+
+	byID := make(map[int64]models.CalendarTodo, len(found))
+	byUID := make(map[string]models.CalendarTodo, len(found))
+	for _, note := range found {
+		if note.ICalUID != "" {
+			byUID[note.ICalUID] = note
+		} else {
+			byID[note.ID] = note
+		}
+	}
+
+	// Новые задачи попадают в собственный список с названием из CATEGORIES, иначе - в список по умолчанию
+	lists, err := s.lists.GetAllListsFromDB(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	listIDs := make(map[string]int64, len(lists))
+	for _, list := range lists {
+		listIDs[strings.ToLower(html.UnescapeString(list.Name))] = list.ID
+	}
+
+	result := &response.CalendarSyncDTO{
+		Total:  len(todos),
+		Errors: make([]response.CalendarSyncErrorDTO, 0),
+	}
+
+	ops := make([]request.NoteBatchOpDTO, 0, len(todos))
+	owners := make([]int, 0, len(todos)) // Задача, к которой относится операция
+	counters := make([]*int, len(todos)) // Счётчик итога, который увеличивается при успехе задачи
+	failed := make([]bool, len(todos))   // Задачи, завершившиеся ошибкой
+	seen := make(map[string]bool, len(todos))
+
+	fail := func(i int, err error) {
+		failed[i] = true
+		result.Errors = append(result.Errors, response.CalendarSyncErrorDTO{
+			UID:   todos[i].UID,
+			Error: err.Error(),
+			Code:  errors.HTTPStatus(err, http.StatusInternalServerError),
+		})
+	}
+
+	for i, todo := range todos {
+		if seen[todo.UID] {
+			fail(i, fmt.Errorf("%w: повторяющийся UID", errors.ErrInvalidCalendarFile))
+			continue
+		}
+		seen[todo.UID] = true
+
+		note, exists := byUID[todo.UID]
+		if !exists {
+			if id, ok := parseNoteUID(todo.UID); ok {
+				note, exists = byID[id]
+			}
+		}
+
+		switch {
+		case exists && note.Deleted:
+			fail(i, errors.ErrNoteNotFound)
+
+		case todo.Status == ical.StatusCancelled && !exists:
+			counters[i] = &result.Skipped
+
+		case todo.Status == ical.StatusCancelled:
+			ops = append(ops, request.NoteBatchOpDTO{Op: batchOpDelete, ID: note.ID})
+			owners = append(owners, i)
+			counters[i] = &result.Deleted
+
+		case exists:
+			ops = append(ops, request.NoteBatchOpDTO{Op: batchOpUpdate, ID: note.ID, UpdateNoteDTO: todoToNote(todo)})
+			owners = append(owners, i)
+
+			if completed := todoCompleted(todo); completed != note.Completed {
+				ops = append(ops, request.NoteBatchOpDTO{Op: batchOpComplete, ID: note.ID, CheckNoteDTO: request.CheckNoteDTO{Check: completed}})
+				owners = append(owners, i)
+			}
+			counters[i] = &result.Updated
+
+		default:
+			op := request.NoteBatchOpDTO{
+				Op:            batchOpCreate,
+				UpdateNoteDTO: todoToNote(todo),
+				CheckNoteDTO:  request.CheckNoteDTO{Check: todoCompleted(todo)},
+				ICalUID:       todo.UID,
+			}
+			for _, category := range todo.Categories {
+				if listID, ok := listIDs[strings.ToLower(category)]; ok {
+					op.ListID = listID
+					break
+				}
+			}
+
+			ops = append(ops, op)
+			owners = append(owners, i)
+			counters[i] = &result.Created
+		}
+	}
+
+	// Пакет ограничен по размеру, поэтому операции выполняются частями
+	for start := 0; start < len(ops); start += maxNoteBatchOperations {
+		end := min(start+maxNoteBatchOperations, len(ops))
+
+		batch, err := s.notes.ExecuteNoteBatch(ctx, userID, request.NoteBatchDTO{Mode: noteBatchBestEffort, Operations: ops[start:end]})
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", errors.ErrSyncCalendar, err)
+		}
+
+		for _, res := range batch.Results {
+			if i := owners[start+res.Index]; res.Status != batchStatusOK && !failed[i] {
+				failed[i] = true
+				result.Errors = append(result.Errors, response.CalendarSyncErrorDTO{UID: todos[i].UID, Error: res.Error, Code: res.Code})
+			}
+		}
+	}
+
+	for i := range todos {
+		if failed[i] {
+			result.Failed++
+		} else if counters[i] != nil {
+			*counters[i]++
+		}
+	}
+
+	return result, nil
+}
+
+// todoToNote - поля заметки из задачи VTODO (для создания и обновления). Многие календарные клиенты
+// не поддерживают PRIORITY и RRULE, поэтому отсутствующие в задаче DUE, PRIORITY и RRULE не стирают поля заметки
+func todoToNote(todo ical.Todo) request.UpdateNoteDTO {
+	req := request.UpdateNoteDTO{Note: todo.Summary}
+
+	if todo.Priority > 0 {
+		priority := notePriority(todo.Priority)
+		req.Priority = &priority
+	}
+	if todo.RRule != "" {
+		req.RRule = &todo.RRule
+	}
+
+	// У повторяющейся задачи без DUE срок берётся из начала серии
+	due := todo.Due
+	if due == nil && todo.RRule != "" {
+		due = todo.Start
+	}
+	if due != nil {
+		dueAt := due.Format(time.RFC3339)
+		req.DueAt = &dueAt
+	}
+
+	return req
+}
+
+// todoCompleted - выполнена ли задача: STATUS:COMPLETED или время выполнения без статуса
+func todoCompleted(todo ical.Todo) bool {
+	return todo.Status == ical.StatusCompleted || (todo.Status == "" && todo.Completed != nil)
+}
+
+// noteUID - UID задачи: исходный UID из календаря или note-<id>@todolistjwtca
+func noteUID(note models.CalendarTodo) string {
+	if note.ICalUID != "" {
+		return note.ICalUID
+	}
+	return fmt.Sprintf("note-%d@%s", note.ID, calendarUIDDomain)
+}
+
+// parseNoteUID - ID заметки из UID вида note-<id>@todolistjwtca
+func parseNoteUID(uid string) (int64, bool) {
+	value, ok := strings.CutPrefix(uid, "note-")
+	if !ok {
+		return 0, false
+	}
+	if value, ok = strings.CutSuffix(value, "@"+calendarUIDDomain); !ok {
+		return 0, false
+	}
+
+	id, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || id <= 0 {
+		return 0, false
+	}
+	return id, true
+}
+
+// todoPriority - приоритет заметки в шкале iCalendar (1 - наивысший, 9 - наименьший)
+func todoPriority(priority string) int {
+	switch priority {
+	case models.PriorityUrgent:
+		return 1
+	case models.PriorityHigh:
+		return 3
+	case models.PriorityLow:
+		return 9
+	default:
+		return 5
+	}
+}
+
+// notePriority - приоритет заметки по значению PRIORITY (0 - не задан)
+func notePriority(priority int) string {
+	switch {
+	case priority == 0 || priority == 5:
+		return models.PriorityNormal
+	case priority <= 2:
+		return models.PriorityUrgent
+	case priority <= 4:
+		return models.PriorityHigh
+	default:
+		return models.PriorityLow
+	}
+}
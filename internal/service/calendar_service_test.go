@@ -0,0 +1,154 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	stdErrors "errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/config"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/errors"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/models"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/repository"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/pkg/ical"
+)
+
+func TestNoteUIDRoundTrip(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	notes := []models.CalendarTodo{
+		{ID: 1, Note: "первая", CreatedAt: now, List: "Входящие"},
+		{ID: 9007199254740993, Note: "большой ID", CreatedAt: now, List: "Входящие"},
+		{ID: 7, ICalUID: "abc-123@example.com", Note: "из календаря", CreatedAt: now, List: "Работа"},
+	}
+
+	var buf bytes.Buffer
+	enc := ical.NewEncoder(&buf)
+	if err := enc.Begin(calendarProdID, calendarName); err != nil {
+		t.Fatalf("Begin: %v", err)
+	}
+	for _, note := range notes {
+		if err := enc.WriteTodo(noteToTodo(note, now)); err != nil {
+			t.Fatalf("WriteTodo: %v", err)
+		}
+	}
+	if err := enc.End(); err != nil {
+		t.Fatalf("End: %v", err)
+	}
+
+	todos, err := ical.Decode(&buf, time.UTC)
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if len(todos) != len(notes) {
+		t.Fatalf("got %d todos, want %d", len(todos), len(notes))
+	}
+
+	for i, note := range notes {
+		id, ok := parseNoteUID(todos[i].UID)
+		if note.ICalUID != "" {
+			// Исходный UID календаря возвращается как есть и не принимается за ID заметки
+			if todos[i].UID != note.ICalUID || ok {
+				t.Errorf("UID = %q (parsed %v), want original %q", todos[i].UID, ok, note.ICalUID)
+			}
+			continue
+		}
+		if !ok || id != note.ID {
+			t.Errorf("parseNoteUID(%q) = %d, %v; want %d", todos[i].UID, id, ok, note.ID)
+		}
+	}
+}
+
+func TestParseNoteUIDInvalid(t *testing.T) {
+	for _, uid := range []string{
+		"",
+		"note-@todolistjwtca",
+		"note-0@todolistjwtca",
+		"note--5@todolistjwtca",
+		"note-12@example.com",
+		"task-12@todolistjwtca",
+		"note-12abc@todolistjwtca",
+		"note-99999999999999999999@todolistjwtca",
+	} {
+		if id, ok := parseNoteUID(uid); ok {
+			t.Errorf("parseNoteUID(%q) = %d, want not ok", uid, id)
+		}
+	}
+}
+
+func TestTodoCompleted(t *testing.T) {
+	done := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		todo ical.Todo
+		want bool
+	}{
+		{"status completed", ical.Todo{Status: ical.StatusCompleted}, true},
+		{"completed time without status", ical.Todo{Completed: &done}, true},
+		{"needs action overrides completed time", ical.Todo{Status: ical.StatusNeedsAction, Completed: &done}, false},
+		{"in process", ical.Todo{Status: ical.StatusInProcess}, false},
+		{"empty", ical.Todo{}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := todoCompleted(tt.todo); got != tt.want {
+				t.Errorf("todoCompleted = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	// Статус выполненной заметки переживает выгрузку и разбор
+	var buf bytes.Buffer
+	enc := ical.NewEncoder(&buf)
+	_ = enc.Begin(calendarProdID, calendarName)
+	_ = enc.WriteTodo(noteToTodo(models.CalendarTodo{ID: 1, Completed: true, CreatedAt: done}, done))
+	_ = enc.End()
+
+	todos, err := ical.Decode(&buf, time.UTC)
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if !todoCompleted(todos[0]) {
+		t.Error("completed note decoded as not completed")
+	}
+}
+
+// fakeCalendarRepo - ограничение частоты загрузки в памяти; остальные методы не используются
+type fakeCalendarRepo struct {
+	repository.CalendarRepository
+	lastSync map[string]time.Time
+	interval time.Duration // Интервал, переданный сервисом
+}
+
+func (f *fakeCalendarRepo) OpenCalendarSyncDB(_ context.Context, tokenHash string, interval time.Duration) (int64, error) {
+	f.interval = interval
+	if last, ok := f.lastSync[tokenHash]; ok && time.Since(last) < interval {
+		return 0, errors.ErrCalendarSyncTooOften
+	}
+	f.lastSync[tokenHash] = time.Now()
+	return 1, nil
+}
+
+func TestSyncCalendarFeedThrottled(t *testing.T) {
+	repo := &fakeCalendarRepo{lastSync: map[string]time.Time{hashShareLinkToken("token"): time.Now()}}
+	svc := NewCalendarService(repo, nil, nil, &config.Config{})
+
+	body := strings.NewReader("BEGIN:VCALENDAR\r\nEND:VCALENDAR\r\n")
+	_, err := svc.SyncCalendarFeed(context.Background(), "token", body)
+	if !stdErrors.Is(err, errors.ErrCalendarSyncTooOften) {
+		t.Fatalf("err = %v, want ErrCalendarSyncTooOften", err)
+	}
+	if errors.HTTPStatus(err, 0) != http.StatusTooManyRequests {
+		t.Errorf("status = %d, want 429", errors.HTTPStatus(err, 0))
+	}
+	if repo.interval != defaultCalendarSyncInterval {
+		t.Errorf("interval = %v, want default %v", repo.interval, defaultCalendarSyncInterval)
+	}
+	if body.Len() == 0 {
+		t.Error("throttled request body must not be read")
+	}
+}
@@ -0,0 +1,102 @@
+package service
+
+import (
+	"context"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/config"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/errors"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/models"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/repository"
+	"time"
+)
+
+const (
+	maxIdempotencyKeyLength = 255            // Максимальная длина Idempotency-Key
+	defaultIdempotencyTTL   = 24 * time.Hour // Срок хранения ответа, если не задан в конфигурации
+	defaultIdempotencyLock  = time.Minute    // Время, на которое ключ занимается запросом, если не задано в конфигурации
+)
+
+// IdempotencyService - интерфейс для работы с ключами Idempotency-Key
+type IdempotencyService interface {
+	AcquireIdempotencyKey(ctx context.Context, userID int64, key, fingerprint string) (record *models.IdempotencyKeys, replay bool, err error)
+	CompleteIdempotencyKey(ctx context.Context, record models.IdempotencyKeys) error
+	ReleaseIdempotencyKey(ctx context.Context, record models.IdempotencyKeys) error
+	PurgeIdempotencyKeys(ctx context.Context) (int64, error)
+}
+
+type idempotencyService struct {
+	repo repository.IdempotencyRepository
+	cfg  *config.Config
+}
+
+func NewIdempotencyService(repo repository.IdempotencyRepository, cfg *config.Config) IdempotencyService {
+	return &idempotencyService{
+		repo: repo,
+		cfg:  cfg,
+	}
+}
+
+// AcquireIdempotencyKey - занять ключ для выполнения запроса с отпечатком fingerprint.
+// replay == true - запрос с этим ключом уже выполнен, record хранит ответ, который нужно вернуть повторно.
+// Ключ, использованный для другого запроса, - ErrIdempotencyKeyMismatch; ещё выполняющийся - ErrIdempotencyKeyInProgress
+func (s *idempotencyService) AcquireIdempotencyKey(ctx context.Context, userID int64, key, fingerprint string) (*models.IdempotencyKeys, bool, error) {
+	if userID <= 0 {
+		return nil, false, errors.ErrIDCannotBeNegativeOrEqualToZero
+	}
+	if !validIdempotencyKey(key) {
+		return nil, false, errors.ErrInvalidIdempotencyKey
+	}
+
+	lock := s.cfg.Idempotency.Lock
+	if lock <= 0 {
+		lock = defaultIdempotencyLock
+	}
+
+	record, acquired, err := s.repo.AcquireIdempotencyKeyDB(ctx, userID, key, fingerprint, lock)
+	if err != nil {
+		return nil, false, err
+	}
+
+	switch {
+	case acquired:
+		return record, false, nil
+	case record.Fingerprint != fingerprint:
+		return nil, false, errors.ErrIdempotencyKeyMismatch
+	case record.Status != models.IdempotencyCompleted:
+		return nil, false, errors.ErrIdempotencyKeyInProgress
+	}
+
+	return record, true, nil
+}
+
+// CompleteIdempotencyKey - сохранить ответ на запрос, занявший ключ
+func (s *idempotencyService) CompleteIdempotencyKey(ctx context.Context, record models.IdempotencyKeys) error {
+	ttl := s.cfg.Idempotency.TTL
+	if ttl <= 0 {
+		ttl = defaultIdempotencyTTL
+	}
+
+	return s.repo.CompleteIdempotencyKeyDB(ctx, record, ttl)
+}
+
+// ReleaseIdempotencyKey - освободить ключ без сохранения ответа: повтор запроса выполнится заново
+func (s *idempotencyService) ReleaseIdempotencyKey(ctx context.Context, record models.IdempotencyKeys) error {
+	return s.repo.ReleaseIdempotencyKeyDB(ctx, record)
+}
+
+// PurgeIdempotencyKeys - удалить ключи с истёкшим сроком хранения
+func (s *idempotencyService) PurgeIdempotencyKeys(ctx context.Context) (int64, error) {
+	return s.repo.PurgeIdempotencyKeysDB(ctx)
+}
+
+// validIdempotencyKey - ключ из 1..255 видимых символов ASCII
+func validIdempotencyKey(key string) bool {
+	if key == "" || len(key) > maxIdempotencyKeyLength {
+		return false
+	}
+	for i := 0; i < len(key); i++ {
+		if key[i] < '!' || key[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,377 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	goerrors "errors"
+	"fmt"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/blobstore"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/config"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/errors"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/models"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/repository"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/transport/dto/request"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/pkg/taskimport"
+	"html"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	defaultImportLease       = 2 * time.Minute // Время захвата задания, если оно не задано в конфигурации
+	defaultImportMaxFileSize = 20 << 20        // Максимальный размер файла, если он не задан в конфигурации
+	maxImportJobAttempts     = 3               // Сколько раз задание может быть захвачено, прежде чем считаться неудачным
+	importJobsListLimit      = 50              // Сколько последних заданий возвращается в списке
+)
+
+// importSources - поддерживаемые источники импорта
+var importSources = map[string]bool{
+	taskimport.SourceTodoist:     true,
+	taskimport.SourceMSTodo:      true,
+	taskimport.SourceGoogleTasks: true,
+}
+
+// ImportJobService - интерфейс для фонового импорта из Todoist, Microsoft To Do и Google Tasks
+type ImportJobService interface {
+	CreateImportJob(ctx context.Context, userID int64, req request.ImportJobDTO, r io.Reader) (*models.ImportJobs, error)
+	GetImportJobs(ctx context.Context, userID int64) ([]models.ImportJobs, error)
+	GetImportJob(ctx context.Context, userID, id int64) (*models.ImportJobs, error)
+	ProcessNextImportJob(ctx context.Context) (bool, error)
+}
+
+type importJobService struct {
+	repo    repository.ImportJobRepository
+	lists   repository.ListRepository
+	store   blobstore.BlobStore
+	cfg     *config.Config
+	loc     *time.Location
+	lease   time.Duration
+	maxSize int64
+}
+
+func NewImportJobService(repo repository.ImportJobRepository, lists repository.ListRepository, store blobstore.BlobStore, cfg *config.Config) ImportJobService {
+	lease := cfg.Imports.Lease
+	if lease <= 0 {
+		lease = defaultImportLease
+	}
+
+	maxSize := cfg.Imports.MaxFileSize
+	if maxSize <= 0 {
+		maxSize = defaultImportMaxFileSize
+	}
+
+	return &importJobService{
+		repo:    repo,
+		lists:   lists,
+		store:   store,
+		cfg:     cfg,
+		loc:     loadLocation(cfg.DB.TimeZone),
+		lease:   lease,
+		maxSize: maxSize,
+	}
+}
+
+// CreateImportJob - поставить файл экспорта в очередь импорта. Файл разбирается сразу, чтобы
+// некорректный файл был отклонён при загрузке, а заметки создаются в фоне (см. ProcessNextImportJob)
+func (s *importJobService) CreateImportJob(ctx context.Context, userID int64, req request.ImportJobDTO, r io.Reader) (*models.ImportJobs, error) {
+	if userID <= 0 {
+		return nil, errors.ErrIDCannotBeNegativeOrEqualToZero
+	}
+
+	source := strings.ToLower(strings.TrimSpace(req.Source))
+	if !importSources[source] {
+		return nil, errors.ErrInvalidImportSource
+	}
+
+	listName := strings.TrimSpace(req.List)
+	if listName != "" {
+		if _, err := validateList(request.ListDTO{Name: listName}); err != nil {
+			return nil, err
+		}
+	}
+
+	tmp, err := os.CreateTemp("", "import-*")
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", errors.ErrImportJobFailed, err)
+	}
+	defer func() {
+		tmp.Close()
+		os.Remove(tmp.Name())
+	}()
+
+	// Читаем на байт больше лимита, чтобы отличить файл предельного размера от слишком большого
+	size, err := io.Copy(tmp, io.LimitReader(r, s.maxSize+1))
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if goerrors.As(err, &maxBytesErr) {
+			return nil, errors.ErrImportFileTooLarge
+		}
+		return nil, fmt.Errorf("%w: %w", errors.ErrImportJobFailed, err)
+	}
+	if size > s.maxSize {
+		return nil, errors.ErrImportFileTooLarge
+	}
+
+	tasks, err := taskimport.Parse(source, io.NewSectionReader(tmp, 0, size), listName, s.loc)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", errors.ErrInvalidImportFile, err)
+	}
+	if len(tasks) > maxImportRows {
+		return nil, errors.ErrTooManyImportRows
+	}
+
+	key, err := newImportKey(userID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", errors.ErrImportJobFailed, err)
+	}
+
+	if err = s.store.Put(ctx, key, io.NewSectionReader(tmp, 0, size), size, "application/octet-stream"); err != nil {
+		return nil, fmt.Errorf("%w: %w", errors.ErrImportJobFailed, err)
+	}
+
+	job, err := s.repo.InsertImportJobDB(ctx, models.ImportJobs{
+		UserID:     userID,
+		Source:     source,
+		ListName:   listName,
+		StorageKey: key,
+		Total:      len(tasks),
+	})
+	if err != nil {
+		// Задание не сохранено - файл в хранилище никому не нужен. Удаляем его даже при отменённом запросе
+		_ = s.store.Delete(context.WithoutCancel(ctx), key)
+		return nil, err
+	}
+
+	return withProgress(job), nil
+}
+
+// GetImportJobs - последние задания импорта пользователя
+func (s *importJobService) GetImportJobs(ctx context.Context, userID int64) ([]models.ImportJobs, error) {
+	if userID <= 0 {
+		return nil, errors.ErrIDCannotBeNegativeOrEqualToZero
+	}
+
+	jobs, err := s.repo.GetImportJobsFromDB(ctx, userID, importJobsListLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range jobs {
+		withProgress(&jobs[i])
+	}
+
+	return jobs, nil
+}
+
+// GetImportJob - состояние задания: прогресс, а после завершения - итоговый отчёт
+func (s *importJobService) GetImportJob(ctx context.Context, userID, id int64) (*models.ImportJobs, error) {
+	if id <= 0 || userID <= 0 {
+		return nil, errors.ErrIDCannotBeNegativeOrEqualToZero
+	}
+
+	job, err := s.repo.GetImportJobFromDB(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return withProgress(job), nil
+}
+
+// withProgress - процент выполнения задания
+func withProgress(job *models.ImportJobs) *models.ImportJobs {
+	switch {
+	case job.Status == models.ImportJobDone:
+		job.Progress = 100
+	case job.Total > 0:
+		job.Progress = job.Processed * 100 / job.Total
+	}
+	return job
+}
+
+// ProcessNextImportJob - захватить и выполнить одно задание из очереди; false - очередь пуста.
+// Каждая задача сохраняется вместе с отметкой о её обработке, поэтому задание, прерванное
+// остановкой или сбоем реплики, продолжается с места остановки
+func (s *importJobService) ProcessNextImportJob(ctx context.Context) (bool, error) {
+	job, err := s.repo.ClaimImportJobDB(ctx, s.lease)
+	if err != nil || job == nil {
+		return false, err
+	}
+
+	err = s.runImportJob(ctx, *job)
+	switch {
+	case goerrors.Is(err, errors.ErrImportJobLost):
+		// Задание продолжает другой обработчик
+		return true, nil
+	case err != nil && ctx.Err() != nil:
+		// Остановка сервера: задание будет продолжено после истечения захвата
+		return false, nil
+	case err != nil:
+		if finishErr := s.repo.FinishImportJobDB(ctx, *job, models.ImportJobFailed, err.Error()); finishErr != nil {
+			return true, finishErr
+		}
+	default:
+		if err = s.repo.FinishImportJobDB(ctx, *job, models.ImportJobDone, ""); err != nil {
+			return true, err
+		}
+	}
+
+	// Файл больше не нужен. Если удалить не удалось, задание всё равно завершено
+	_ = s.store.Delete(ctx, job.StorageKey)
+
+	return true, nil
+}
+
+// runImportJob - разобрать файл задания и создать заметки, начиная с первой необработанной задачи
+func (s *importJobService) runImportJob(ctx context.Context, job models.ImportJobs) error {
+	if job.Attempts > maxImportJobAttempts {
+		return fmt.Errorf("%w: превышено количество попыток", errors.ErrRunImportJob)
+	}
+
+	file, err := s.store.Get(ctx, job.StorageKey)
+	if err != nil {
+		return fmt.Errorf("%w: %w", errors.ErrRunImportJob, err)
+	}
+	tasks, err := taskimport.Parse(job.Source, file, job.ListName, s.loc)
+	file.Close()
+	if err != nil {
+		return fmt.Errorf("%w: %w", errors.ErrInvalidImportFile, err)
+	}
+
+	if err = s.repo.SetImportJobTotalDB(ctx, job, len(tasks), s.lease); err != nil {
+		return err
+	}
+
+	// Задачи попадают в собственные списки пользователя с тем же названием; недостающие списки создаются
+	lists, err := s.lists.GetAllListsFromDB(ctx, job.UserID)
+	if err != nil {
+		return err
+	}
+	listIDs := make(map[string]int64, len(lists))
+	for _, list := range lists {
+		listIDs[strings.ToLower(html.UnescapeString(list.Name))] = list.ID
+	}
+
+	now := time.Now().UTC()
+
+	for i := job.Processed; i < len(tasks); i++ {
+		if err = ctx.Err(); err != nil {
+			return err
+		}
+
+		task := tasks[i]
+
+		note, items, err := s.importTask(task, now)
+		if err == nil {
+			note.UserID = job.UserID
+			note.ListID, err = s.importListID(ctx, job, listIDs, task.List)
+		}
+		if err == nil {
+			err = s.repo.InsertImportNoteDB(ctx, job, note, items, s.lease)
+		}
+
+		if goerrors.Is(err, errors.ErrImportJobLost) || ctx.Err() != nil {
+			return err
+		}
+		if err != nil {
+			taskErr := models.ImportJobError{Task: i + 1, Title: task.Title, Error: err.Error()}
+			if err = s.repo.FailImportTaskDB(ctx, job, taskErr, s.lease); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// importTask - заметка и пункты чек-листа из задачи файла. Описание задачи дописывается к тексту заметки
+func (s *importJobService) importTask(task taskimport.Task, now time.Time) (models.AllNotes, []models.NoteItems, error) {
+	text := task.Title
+	if task.Notes != "" {
+		text += "\n\n" + task.Notes
+	}
+
+	note := models.AllNotes{
+		Note:      html.EscapeString(strings.TrimSpace(text)),
+		Completed: task.Completed,
+		CreatedAt: now,
+		Priority:  importPriority(task.Priority),
+	}
+	if utf8.RuneCountInString(note.Note) < 3 {
+		return note, nil, errors.ErrNoteTooShort
+	}
+	if task.Due != nil {
+		due := task.Due.UTC()
+		note.DueAt = &due
+	}
+
+	items := make([]models.NoteItems, 0, len(task.Subtasks))
+	for _, subtask := range task.Subtasks {
+		title := subtask.Title
+		if runes := []rune(title); len(runes) > maxItemTextLength {
+			title = string(runes[:maxItemTextLength])
+		}
+
+		itemText, err := validateItemText(title)
+		if err != nil {
+			return note, nil, err
+		}
+		items = append(items, models.NoteItems{Text: itemText, Completed: subtask.Completed, Position: len(items)})
+	}
+
+	return note, items, nil
+}
+
+// importListID - список для задачи: собственный список с тем же названием (создаётся при отсутствии),
+// без названия - список по умолчанию
+func (s *importJobService) importListID(ctx context.Context, job models.ImportJobs, listIDs map[string]int64, name string) (int64, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return s.lists.GetDefaultListIDFromDB(ctx, job.UserID)
+	}
+
+	if id, ok := listIDs[strings.ToLower(name)]; ok {
+		return id, nil
+	}
+
+	list, err := validateList(request.ListDTO{Name: name})
+	if err != nil {
+		return 0, err
+	}
+	list.UserID = job.UserID
+
+	id, err := s.repo.InsertImportListDB(ctx, job, list, s.lease)
+	if err != nil {
+		return 0, err
+	}
+
+	listIDs[strings.ToLower(name)] = id
+	return id, nil
+}
+
+// importPriority - приоритет заметки по приоритету задачи в общей шкале
+func importPriority(priority int) string {
+	switch priority {
+	case taskimport.PriorityUrgent:
+		return models.PriorityUrgent
+	case taskimport.PriorityHigh:
+		return models.PriorityHigh
+	case taskimport.PriorityLow:
+		return models.PriorityLow
+	default:
+		return models.PriorityNormal
+	}
+}
+
+// newImportKey - случайный ключ файла задания в хранилище: imports/{ID пользователя}/{32 hex-символа}
+func newImportKey(userID int64) (string, error) {
+	raw := make([]byte, 16)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("imports/%d/%s", userID, hex.EncodeToString(raw)), nil
+}
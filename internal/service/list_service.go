@@ -0,0 +1,122 @@
+package service
+
+import (
+	"context"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/config"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/errors"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/models"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/repository"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/transport/dto/request"
+	"html"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	maxListNameLength = 100       // Максимальная длина названия списка
+	defaultListColor  = "#808080" // Цвет списка по умолчанию
+)
+
+// listColorRegex - допустимый формат цвета списка
+var listColorRegex = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+
+// ListService - интерфейс для работы с бизнес-логикой списков
+type ListService interface {
+	GetAllLists(ctx context.Context, userID int64) ([]models.Lists, error)
+	CreateList(ctx context.Context, userID int64, req request.ListDTO) (*models.Lists, error)
+	UpdateList(ctx context.Context, userID, id int64, req request.ListDTO) error
+	DeleteList(ctx context.Context, userID, id int64) error
+}
+
+type listService struct {
+	repo repository.ListRepository
+	cfg  *config.Config
+}
+
+func NewListService(repo repository.ListRepository, cfg *config.Config) ListService {
+	return &listService{
+		repo: repo,
+		cfg:  cfg,
+	}
+}
+
+// GetAllLists - получаем все списки пользователя (список по умолчанию создаётся при первом обращении)
+func (s *listService) GetAllLists(ctx context.Context, userID int64) ([]models.Lists, error) {
+	if userID <= 0 {
+		return nil, errors.ErrIDCannotBeNegativeOrEqualToZero
+	}
+
+	if _, err := s.repo.GetDefaultListIDFromDB(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	return s.repo.GetAllListsFromDB(ctx, userID)
+}
+
+// CreateList - создать список, валидация данных
+func (s *listService) CreateList(ctx context.Context, userID int64, req request.ListDTO) (*models.Lists, error) {
+	if userID <= 0 {
+		return nil, errors.ErrIDCannotBeNegativeOrEqualToZero
+	}
+
+	list, err := validateList(req)
+	if err != nil {
+		return nil, err
+	}
+	list.UserID = userID
+
+	return s.repo.InsertListToDB(ctx, list, req.Position)
+}
+
+// UpdateList - обновить список, валидация данных
+func (s *listService) UpdateList(ctx context.Context, userID, id int64, req request.ListDTO) error {
+	if id <= 0 || userID <= 0 {
+		return errors.ErrIDCannotBeNegativeOrEqualToZero
+	}
+
+	list, err := validateList(req)
+	if err != nil {
+		return err
+	}
+	list.ID = id
+	list.UserID = userID
+
+	return s.repo.UpdateListToDB(ctx, list, req.Position)
+}
+
+// DeleteList - удалить список (его заметки переносятся в список по умолчанию), валидация данных
+func (s *listService) DeleteList(ctx context.Context, userID, id int64) error {
+	if id <= 0 || userID <= 0 {
+		return errors.ErrIDCannotBeNegativeOrEqualToZero
+	}
+
+	return s.repo.DeleteListFromDB(ctx, userID, id)
+}
+
+// validateList - очищаем и проверяем поля списка
+func validateList(req request.ListDTO) (models.Lists, error) {
+	list := models.Lists{
+		Name:  html.EscapeString(strings.TrimSpace(req.Name)),
+		Color: strings.TrimSpace(req.Color),
+	}
+
+	length := utf8.RuneCountInString(list.Name)
+	if length == 0 || length > maxListNameLength {
+		return list, errors.ErrInvalidListName
+	}
+
+	if list.Color == "" {
+		list.Color = defaultListColor
+	}
+	if !listColorRegex.MatchString(list.Color) {
+		return list, errors.ErrInvalidListColor
+	}
+	list.Color = strings.ToLower(list.Color)
+
+	if req.Position != nil && *req.Position < 0 {
+		return list, errors.ErrInvalidListPosition
+	}
+
+	return list, nil
+}
@@ -0,0 +1,184 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/errors"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/repository"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/transport/dto/request"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/transport/dto/response"
+	"net/http"
+	"strings"
+)
+
+// maxNoteBatchOperations - максимальное количество операций в одном пакете
+const maxNoteBatchOperations = 100
+
+// Режимы выполнения пакета
+const (
+	noteBatchAtomic     = "atomic"      // Всё или ничего: ошибка любой операции откатывает весь пакет
+	noteBatchBestEffort = "best_effort" // Ошибочные операции откатываются по отдельности, остальные сохраняются
+)
+
+// Статусы операций пакета
+const (
+	batchStatusOK         = "ok"
+	batchStatusError      = "error"
+	batchStatusRolledBack = "rolled_back"
+	batchStatusSkipped    = "skipped"
+)
+
+// Операции пакета
+const (
+	batchOpCreate   = "create"
+	batchOpUpdate   = "update"
+	batchOpComplete = "complete"
+	batchOpDelete   = "delete"
+	batchOpMove     = "move"
+)
+
+// ExecuteNoteBatch - выполнить пакет операций с заметками в одной транзакции.
+// Каждая операция проходит те же проверки, что и отдельный запрос к API
+func (s *noteService) ExecuteNoteBatch(ctx context.Context, userID int64, req request.NoteBatchDTO) (*response.NoteBatchDTO, error) {
+	if userID <= 0 {
+		return nil, errors.ErrIDCannotBeNegativeOrEqualToZero
+	}
+
+	mode := strings.ToLower(strings.TrimSpace(req.Mode))
+	if mode == "" {
+		mode = noteBatchAtomic
+	}
+	if mode != noteBatchAtomic && mode != noteBatchBestEffort {
+		return nil, errors.ErrInvalidNoteBatch
+	}
+	if len(req.Operations) == 0 || len(req.Operations) > maxNoteBatchOperations {
+		return nil, errors.ErrInvalidNoteBatch
+	}
+
+	result := &response.NoteBatchDTO{
+		Mode:    mode,
+		Results: make([]response.NoteBatchResultDTO, len(req.Operations)),
+	}
+	for i, op := range req.Operations {
+		result.Results[i] = response.NoteBatchResultDTO{Index: i, Op: op.Op, ID: op.ID, Status: batchStatusSkipped}
+	}
+
+	// Номер операции, из-за которой откатился пакет в режиме atomic
+	failed := -1
+
+	err := s.repo.ExecNoteBatchDB(ctx, func(batch repository.NoteBatch) error {
+		for i, op := range req.Operations {
+			if mode == noteBatchBestEffort {
+				if err := batch.Savepoint(ctx); err != nil {
+					return err
+				}
+			}
+
+			id, _, err := s.execNoteBatchOp(ctx, batch, userID, op)
+			if err != nil {
+				result.Results[i].Status = batchStatusError
+				result.Results[i].Error = err.Error()
+				result.Results[i].Code = errors.HTTPStatus(err, http.StatusInternalServerError)
+
+				if mode == noteBatchAtomic {
+					failed = i
+					return err
+				}
+
+				if err = batch.RollbackToSavepoint(ctx); err != nil {
+					return err
+				}
+				continue
+			}
+
+			if mode == noteBatchBestEffort {
+				if err = batch.ReleaseSavepoint(ctx); err != nil {
+					return err
+				}
+			}
+
+			result.Results[i].ID = id
+			result.Results[i].Status = batchStatusOK
+		}
+
+		return nil
+	})
+
+	if failed >= 0 {
+		for i := 0; i < failed; i++ {
+			result.Results[i].Status = batchStatusRolledBack
+		}
+		return result, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	result.Committed = true
+	return result, nil
+}
+
+// execNoteBatchOp - выполнить одну операцию пакета; возвращает ID затронутой (или созданной) заметки
+// и её владельца
+func (s *noteService) execNoteBatchOp(ctx context.Context, batch repository.NoteBatch, userID int64, op request.NoteBatchOpDTO) (int64, int64, error) {
+	switch strings.ToLower(strings.TrimSpace(op.Op)) {
+	case batchOpCreate:
+		note, err := s.prepareNewNote(ctx, userID, batchCreateNote(op))
+		if err != nil {
+			return 0, 0, err
+		}
+		note.Completed = op.Check
+		note.ICalUID = op.ICalUID
+		id, err := batch.InsertNoteToDB(ctx, note)
+		if err != nil {
+			return 0, 0, fmt.Errorf("%w: %w", errors.ErrNoteFailed, err)
+		}
+		return id, note.UserID, nil
+
+	case batchOpUpdate:
+		note, err := s.prepareNoteUpdate(ctx, userID, op.ID, op.UpdateNoteDTO)
+		if err != nil {
+			return 0, 0, err
+		}
+		return op.ID, note.UserID, batch.UpdateNoteToDB(ctx, userID, note)
+
+	case batchOpComplete:
+		ownerID, err := s.authorizeNoteChange(ctx, userID, op.ID, PermissionWrite)
+		if err != nil {
+			return 0, 0, err
+		}
+		return op.ID, ownerID, batch.MarkNoteCompletedToDB(ctx, userID, ownerID, op.ID, op.Check, op.Check && op.CompleteItems, s.nextOccurrence)
+
+	case batchOpDelete:
+		ownerID, err := s.authorizeNoteChange(ctx, userID, op.ID, PermissionManage)
+		if err != nil {
+			return 0, 0, err
+		}
+		return op.ID, ownerID, batch.DeleteNoteFromDB(ctx, ownerID, op.ID)
+
+	case batchOpMove:
+		ownerID, err := s.authorizeMoveNote(ctx, userID, op.ID, op.ListID)
+		if err != nil {
+			return 0, 0, err
+		}
+		return op.ID, ownerID, batch.MoveNoteToListDB(ctx, ownerID, op.ID, op.ListID)
+
+	default:
+		return 0, 0, errors.ErrInvalidBatchOp
+	}
+}
+
+// batchCreateNote - поля новой заметки из операции create (отсутствующие поля - значения по умолчанию)
+func batchCreateNote(op request.NoteBatchOpDTO) request.CreateNoteDTO {
+	req := request.CreateNoteDTO{Note: op.Note, ListID: op.ListID}
+	if op.DueAt != nil {
+		req.DueAt = *op.DueAt
+	}
+	if op.Priority != nil {
+		req.Priority = *op.Priority
+	}
+	if op.RRule != nil {
+		req.RRule = *op.RRule
+	}
+	return req
+}
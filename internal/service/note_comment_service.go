@@ -0,0 +1,127 @@
+package service
+
+import (
+	"context"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/config"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/errors"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/models"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/repository"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/transport/dto/request"
+	"html"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxCommentLength - максимальная длина комментария
+const maxCommentLength = 2000
+
+// NoteCommentService - интерфейс для работы с бизнес-логикой комментариев к заметкам
+type NoteCommentService interface {
+	GetComments(ctx context.Context, userID, noteID int64) ([]models.NoteComments, error)
+	CreateComment(ctx context.Context, userID, noteID int64, req request.CommentDTO) (*models.NoteComments, error)
+	UpdateComment(ctx context.Context, userID, noteID, id int64, req request.CommentDTO) error
+	DeleteComment(ctx context.Context, userID, noteID, id int64) error
+}
+
+type noteCommentService struct {
+	repo repository.NoteCommentRepository
+	auth Authorizer
+	cfg  *config.Config
+}
+
+func NewNoteCommentService(repo repository.NoteCommentRepository, auth Authorizer, cfg *config.Config) NoteCommentService {
+	return &noteCommentService{
+		repo: repo,
+		auth: auth,
+		cfg:  cfg,
+	}
+}
+
+// GetComments - получаем комментарии к заметке
+func (s *noteCommentService) GetComments(ctx context.Context, userID, noteID int64) ([]models.NoteComments, error) {
+	ownerID, err := s.auth.AuthorizeNote(ctx, userID, noteID, PermissionRead)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.repo.GetCommentsFromDB(ctx, ownerID, noteID)
+}
+
+// CreateComment - добавить комментарий, валидация данных.
+// Обсуждение не меняет саму заметку, поэтому комментировать может любой, у кого есть доступ к ней
+func (s *noteCommentService) CreateComment(ctx context.Context, userID, noteID int64, req request.CommentDTO) (*models.NoteComments, error) {
+	body, err := validateCommentBody(req.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	ownerID, err := s.auth.AuthorizeNote(ctx, userID, noteID, PermissionRead)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.repo.InsertCommentToDB(ctx, ownerID, noteID, userID, body)
+}
+
+// UpdateComment - изменить комментарий (только автор), валидация данных
+func (s *noteCommentService) UpdateComment(ctx context.Context, userID, noteID, id int64, req request.CommentDTO) error {
+	if id <= 0 {
+		return errors.ErrIDCannotBeNegativeOrEqualToZero
+	}
+
+	body, err := validateCommentBody(req.Body)
+	if err != nil {
+		return err
+	}
+
+	if _, err = s.auth.AuthorizeNote(ctx, userID, noteID, PermissionRead); err != nil {
+		return err
+	}
+
+	comment, err := s.repo.GetCommentFromDB(ctx, noteID, id)
+	if err != nil {
+		return err
+	}
+
+	if comment.UserID != userID {
+		return errors.ErrForbidden
+	}
+
+	return s.repo.UpdateCommentToDB(ctx, noteID, id, userID, body)
+}
+
+// DeleteComment - удалить комментарий. Автор удаляет свой комментарий, владелец заметки - любой
+func (s *noteCommentService) DeleteComment(ctx context.Context, userID, noteID, id int64) error {
+	if id <= 0 {
+		return errors.ErrIDCannotBeNegativeOrEqualToZero
+	}
+
+	if _, err := s.auth.AuthorizeNote(ctx, userID, noteID, PermissionRead); err != nil {
+		return err
+	}
+
+	comment, err := s.repo.GetCommentFromDB(ctx, noteID, id)
+	if err != nil {
+		return err
+	}
+
+	if comment.UserID != userID {
+		if _, err = s.auth.AuthorizeNote(ctx, userID, noteID, PermissionManage); err != nil {
+			return err
+		}
+	}
+
+	return s.repo.DeleteCommentFromDB(ctx, noteID, id)
+}
+
+// validateCommentBody - очищаем и проверяем текст комментария так же, как текст заметки
+func validateCommentBody(body string) (string, error) {
+	body = html.EscapeString(strings.TrimSpace(body))
+
+	length := utf8.RuneCountInString(body)
+	if length == 0 || length > maxCommentLength {
+		return "", errors.ErrInvalidComment
+	}
+
+	return body, nil
+}
@@ -0,0 +1,141 @@
+package service
+
+import (
+	"context"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/config"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/errors"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/models"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/repository"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/transport/dto/request"
+	"html"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxItemTextLength - максимальная длина текста пункта чек-листа
+const maxItemTextLength = 500
+
+// NoteItemService - интерфейс для работы с бизнес-логикой пунктов чек-листа
+type NoteItemService interface {
+	GetItems(ctx context.Context, userID, noteID int64) ([]models.NoteItems, error)
+	CreateItem(ctx context.Context, userID, noteID int64, req request.CreateItemDTO) (*models.NoteItems, error)
+	UpdateItem(ctx context.Context, userID, noteID, id int64, req request.UpdateItemDTO) error
+	DeleteItem(ctx context.Context, userID, noteID, id int64) error
+	ReorderItems(ctx context.Context, userID, noteID int64, req request.ReorderItemsDTO) error
+}
+
+type noteItemService struct {
+	repo repository.NoteItemRepository
+	auth Authorizer
+	cfg  *config.Config
+}
+
+func NewNoteItemService(repo repository.NoteItemRepository, auth Authorizer, cfg *config.Config) NoteItemService {
+	return &noteItemService{
+		repo: repo,
+		auth: auth,
+		cfg:  cfg,
+	}
+}
+
+// GetItems - получаем пункты чек-листа заметки
+func (s *noteItemService) GetItems(ctx context.Context, userID, noteID int64) ([]models.NoteItems, error) {
+	if noteID <= 0 || userID <= 0 {
+		return nil, errors.ErrIDCannotBeNegativeOrEqualToZero
+	}
+
+	ownerID, err := s.auth.AuthorizeNote(ctx, userID, noteID, PermissionRead)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.repo.GetItemsFromDB(ctx, ownerID, noteID)
+}
+
+// CreateItem - добавить пункт чек-листа, валидация данных
+func (s *noteItemService) CreateItem(ctx context.Context, userID, noteID int64, req request.CreateItemDTO) (*models.NoteItems, error) {
+	if noteID <= 0 || userID <= 0 {
+		return nil, errors.ErrIDCannotBeNegativeOrEqualToZero
+	}
+
+	text, err := validateItemText(req.Text)
+	if err != nil {
+		return nil, err
+	}
+
+	ownerID, err := s.auth.AuthorizeNote(ctx, userID, noteID, PermissionWrite)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.repo.InsertItemToDB(ctx, ownerID, noteID, text)
+}
+
+// UpdateItem - изменить текст и/или статус пункта чек-листа, валидация данных
+func (s *noteItemService) UpdateItem(ctx context.Context, userID, noteID, id int64, req request.UpdateItemDTO) error {
+	if noteID <= 0 || id <= 0 || userID <= 0 {
+		return errors.ErrIDCannotBeNegativeOrEqualToZero
+	}
+
+	text := req.Text
+	if text != nil {
+		validated, err := validateItemText(*text)
+		if err != nil {
+			return err
+		}
+		text = &validated
+	}
+
+	ownerID, err := s.auth.AuthorizeNote(ctx, userID, noteID, PermissionWrite)
+	if err != nil {
+		return err
+	}
+
+	return s.repo.UpdateItemToDB(ctx, ownerID, noteID, id, text, req.Completed)
+}
+
+// DeleteItem - удалить пункт чек-листа, валидация данных
+func (s *noteItemService) DeleteItem(ctx context.Context, userID, noteID, id int64) error {
+	if noteID <= 0 || id <= 0 || userID <= 0 {
+		return errors.ErrIDCannotBeNegativeOrEqualToZero
+	}
+
+	ownerID, err := s.auth.AuthorizeNote(ctx, userID, noteID, PermissionWrite)
+	if err != nil {
+		return err
+	}
+
+	return s.repo.DeleteItemFromDB(ctx, ownerID, noteID, id)
+}
+
+// ReorderItems - изменить порядок пунктов чек-листа, валидация данных
+func (s *noteItemService) ReorderItems(ctx context.Context, userID, noteID int64, req request.ReorderItemsDTO) error {
+	if noteID <= 0 || userID <= 0 {
+		return errors.ErrIDCannotBeNegativeOrEqualToZero
+	}
+
+	for _, id := range req.IDs {
+		if id <= 0 {
+			return errors.ErrInvalidItemsOrder
+		}
+	}
+
+	ownerID, err := s.auth.AuthorizeNote(ctx, userID, noteID, PermissionWrite)
+	if err != nil {
+		return err
+	}
+
+	return s.repo.ReorderItemsDB(ctx, ownerID, noteID, req.IDs)
+}
+
+// validateItemText - очищаем и проверяем текст пункта чек-листа
+func validateItemText(text string) (string, error) {
+	text = html.EscapeString(strings.TrimSpace(text))
+
+	length := utf8.RuneCountInString(text)
+	if length == 0 || length > maxItemTextLength {
+		return "", errors.ErrInvalidItemText
+	}
+
+	return text, nil
+}
@@ -0,0 +1,120 @@
+package service
+
+import (
+	"context"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/config"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/errors"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/models"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/repository"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/transport/dto/request"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/transport/dto/response"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/pkg/textdiff"
+	"strconv"
+)
+
+// NoteRevisionService - интерфейс для работы с историей изменений заметок
+type NoteRevisionService interface {
+	GetHistory(ctx context.Context, userID, noteID int64) ([]models.NoteRevisions, error)
+	DiffRevisions(ctx context.Context, userID, noteID int64, query request.DiffRevisionsDTO) (*response.NoteDiffDTO, error)
+	RevertNote(ctx context.Context, userID, noteID int64, rev int) error
+}
+
+type noteRevisionService struct {
+	repo repository.NoteRevisionRepository
+	auth Authorizer
+	cfg  *config.Config
+}
+
+func NewNoteRevisionService(repo repository.NoteRevisionRepository, auth Authorizer, cfg *config.Config) NoteRevisionService {
+	return &noteRevisionService{
+		repo: repo,
+		auth: auth,
+		cfg:  cfg,
+	}
+}
+
+// GetHistory - получаем историю изменений заметки
+func (s *noteRevisionService) GetHistory(ctx context.Context, userID, noteID int64) ([]models.NoteRevisions, error) {
+	if noteID <= 0 || userID <= 0 {
+		return nil, errors.ErrIDCannotBeNegativeOrEqualToZero
+	}
+
+	ownerID, err := s.auth.AuthorizeNote(ctx, userID, noteID, PermissionRead)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.repo.GetRevisionsFromDB(ctx, ownerID, noteID)
+}
+
+// DiffRevisions - различия текста заметки между ревизиями from и to.
+// Если from не указан, сравнивается текст до и после ревизии to
+func (s *noteRevisionService) DiffRevisions(ctx context.Context, userID, noteID int64, query request.DiffRevisionsDTO) (*response.NoteDiffDTO, error) {
+	if noteID <= 0 || userID <= 0 {
+		return nil, errors.ErrIDCannotBeNegativeOrEqualToZero
+	}
+
+	to, err := parseRevision(query.To)
+	if err != nil {
+		return nil, err
+	}
+
+	ownerID, err := s.auth.AuthorizeNote(ctx, userID, noteID, PermissionRead)
+	if err != nil {
+		return nil, err
+	}
+
+	target, err := s.repo.GetRevisionFromDB(ctx, ownerID, noteID, to)
+	if err != nil {
+		return nil, err
+	}
+
+	diff := &response.NoteDiffDTO{To: to}
+	oldText := target.OldNote
+
+	if query.From != "" {
+		from, err := parseRevision(query.From)
+		if err != nil {
+			return nil, err
+		}
+
+		source, err := s.repo.GetRevisionFromDB(ctx, ownerID, noteID, from)
+		if err != nil {
+			return nil, err
+		}
+
+		diff.From = from
+		oldText = source.NewNote
+	}
+
+	diff.Changes = textdiff.Words(oldText, target.NewNote)
+
+	return diff, nil
+}
+
+// RevertNote - вернуть заметку к состоянию после ревизии rev, валидация данных
+func (s *noteRevisionService) RevertNote(ctx context.Context, userID, noteID int64, rev int) error {
+	if noteID <= 0 || userID <= 0 {
+		return errors.ErrIDCannotBeNegativeOrEqualToZero
+	}
+
+	if rev <= 0 {
+		return errors.ErrInvalidRevision
+	}
+
+	ownerID, err := s.auth.AuthorizeNote(ctx, userID, noteID, PermissionWrite)
+	if err != nil {
+		return err
+	}
+
+	return s.repo.RevertNoteToRevisionDB(ctx, userID, ownerID, noteID, rev)
+}
+
+// parseRevision - разбор номера ревизии из запроса
+func parseRevision(value string) (int, error) {
+	rev, err := strconv.Atoi(value)
+	if err != nil || rev <= 0 {
+		return 0, errors.ErrInvalidRevision
+	}
+	return rev, nil
+}
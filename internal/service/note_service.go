@@ -2,12 +2,19 @@ package service
 
 import (
 	"context"
+	"encoding/base64"
+	"encoding/json"
 	"fmt"
 	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/config"
 	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/errors"
 	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/models"
 	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/repository"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/transport/dto/request"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/transport/dto/response"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/pkg/rrule"
 	"html"
+	"io"
+	"strconv"
 	"strings"
 	"time"
 	"unicode/utf8"
@@ -15,104 +22,644 @@ import (
 
 // NoteService - интерфейс для работы с бизнес-логикой заметок
 type NoteService interface {
-	GetAllNotes(ctx context.Context, userID int64) ([]models.AllNotes, error)
-	ValidateNoteBeforeInserting(ctx context.Context, userID int64, note string) error
-	UpdateNoteDataValidation(ctx context.Context, id int64, note string) error
-	DeleteNote(ctx context.Context, id int64) error
-	MarkNoteCompleted(ctx context.Context, id int64, check bool) error
+	GetAllNotes(ctx context.Context, userID int64, query request.GetNotesDTO) (*response.NotesPageDTO, error)
+	GetNote(ctx context.Context, userID, id int64) (*models.AllNotes, error)
+	SearchNotes(ctx context.Context, userID int64, query request.SearchNotesDTO) ([]models.NoteSearchResult, error)
+	ValidateNoteBeforeInserting(ctx context.Context, userID int64, req request.CreateNoteDTO) error
+	UpdateNoteDataValidation(ctx context.Context, userID, id int64, req request.UpdateNoteDTO, ifMatch []int64) (int64, error)
+	GetTodayNotes(ctx context.Context, userID int64) ([]models.AllNotes, error)
+	GetOverdueNotes(ctx context.Context, userID int64) ([]models.AllNotes, error)
+	GetUpcomingNotes(ctx context.Context, userID int64, days string) ([]models.AllNotes, error)
+	DeleteNote(ctx context.Context, userID, id int64) error
+	MarkNoteCompleted(ctx context.Context, userID, id int64, req request.CheckNoteDTO, ifMatch []int64) (int64, error)
 	DeleteAllNotes(ctx context.Context, userID int64) error
-	DeleteAllCompletedNotes(ctx context.Context, userID int64) error
+	DeleteAllCompletedNotes(ctx context.Context, userID, listID int64) error
+	MoveNote(ctx context.Context, userID, id, listID int64) error
+	MoveNotePosition(ctx context.Context, userID, id int64, req request.NotePositionDTO) error
+	GetTrash(ctx context.Context, userID int64) ([]models.AllNotes, error)
+	RestoreNote(ctx context.Context, userID, id int64) error
+	EmptyTrash(ctx context.Context, userID int64) error
+	PurgeTrash(ctx context.Context) (int64, error)
+	ExecuteNoteBatch(ctx context.Context, userID int64, req request.NoteBatchDTO) (*response.NoteBatchDTO, error)
+	GetSyncChanges(ctx context.Context, userID int64, query request.SyncDTO) (*response.SyncDTO, error)
+	PushSyncChanges(ctx context.Context, userID int64, req request.SyncPushDTO) (*response.SyncPushDTO, error)
+	ExportNotes(ctx context.Context, userID int64, format string, w io.Writer) error
+	ImportNotes(ctx context.Context, userID int64, r io.Reader, query request.ImportNotesDTO) (*response.ImportNotesDTO, error)
 }
 
 type noteService struct {
-	repo repository.NoteRepository
-	cfg  *config.Config
+	repo  repository.NoteRepository
+	lists repository.ListRepository
+	auth  Authorizer // Проверка прав доступа к общим заметкам и спискам
+	cfg   *config.Config
+	loc   *time.Location // Часовой пояс для сроков выполнения (DatabaseConfig.TimeZone)
 }
 
-func NewNoteService(repo repository.NoteRepository, cfg *config.Config) NoteService {
+func NewNoteService(repo repository.NoteRepository, lists repository.ListRepository, auth Authorizer, cfg *config.Config) NoteService {
 	return &noteService{
-		repo: repo,
-		cfg:  cfg,
+		repo:  repo,
+		lists: lists,
+		auth:  auth,
+		cfg:   cfg,
+		loc:   loadLocation(cfg.DB.TimeZone),
 	}
 }
 
-// GetAllNotes - получаем все заметки
-func (s *noteService) GetAllNotes(ctx context.Context, userID int64) ([]models.AllNotes, error) {
-	allNotesFromDB, err := s.repo.GetAllNotesFromDB(ctx, userID)
+// loadLocation - загружаем часовой пояс из конфигурации, по умолчанию UTC
+func loadLocation(name string) *time.Location {
+	if name == "" {
+		return time.UTC
+	}
+
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return time.UTC
+	}
+
+	return loc
+}
+
+const (
+	defaultNotesPageLimit = 50  // Размер страницы по умолчанию
+	maxNotesPageLimit     = 100 // Максимальный размер страницы
+
+	defaultSearchLimit   = 20  // Количество результатов поиска по умолчанию
+	maxSearchQueryLength = 200 // Максимальная длина поискового запроса
+
+	defaultUpcomingDays = 7   // Период предстоящих заметок по умолчанию
+	maxUpcomingDays     = 365 // Максимальный период предстоящих заметок
+
+	defaultTrashRetention = 30 * 24 * time.Hour // Срок хранения заметок в корзине, если он не задан в конфигурации
+)
+
+// searchConfigs - конфигурации текстового поиска PostgreSQL для поддерживаемых языков
+var searchConfigs = map[string][]string{
+	"":   {"russian", "english"},
+	"ru": {"russian"},
+	"en": {"english"},
+}
+
+// GetAllNotes - получаем страницу заметок с учётом фильтров, сортировки и курсора.
+// Заметки общего списка (list_id) читаются от имени его владельца
+func (s *noteService) GetAllNotes(ctx context.Context, userID int64, query request.GetNotesDTO) (*response.NotesPageDTO, error) {
+	filter, err := parseNoteFilter(query)
+	if err != nil {
+		return nil, err
+	}
+
+	ownerID := userID
+	if filter.ListID > 0 {
+		if ownerID, err = s.auth.AuthorizeList(ctx, userID, filter.ListID, PermissionRead); err != nil {
+			return nil, err
+		}
+	}
+
+	// Запрашиваем на одну заметку больше, чтобы понять, есть ли следующая страница
+	limit := filter.Limit
+	filter.Limit++
+
+	notes, total, err := s.repo.GetAllNotesFromDB(ctx, ownerID, filter)
 	if err != nil {
 		return nil, err
 	}
 
-	return allNotesFromDB, err
+	s.localizeNotes(notes)
+
+	page := &response.NotesPageDTO{
+		Items: notes,
+		Total: total,
+	}
+
+	if len(notes) > limit {
+		page.Items = notes[:limit]
+
+		last := page.Items[limit-1]
+		page.NextCursor, err = encodeNoteCursor(models.NoteCursor{
+			SortBy:    filter.SortBy,
+			Desc:      filter.Desc,
+			ID:        last.ID,
+			Note:      last.Note,
+			CreatedAt: last.CreatedAt,
+			Position:  last.Position,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return page, nil
 }
 
-// ValidateTheNoteBeforeInserting - валидация заметки перед вставкой
-func (s *noteService) ValidateNoteBeforeInserting(ctx context.Context, userID int64, note string) error {
+// parseNoteFilter - разбор и валидация параметров запроса списка заметок
+func parseNoteFilter(query request.GetNotesDTO) (models.NoteFilter, error) {
+	filter := models.NoteFilter{
+		SortBy: "position",
+		Limit:  defaultNotesPageLimit,
+	}
+
+	if query.ListID != "" {
+		listID, err := strconv.ParseInt(query.ListID, 10, 64)
+		if err != nil || listID <= 0 {
+			return filter, fmt.Errorf("%w: list_id", errors.ErrInvalidNotesQuery)
+		}
+		filter.ListID = listID
+	}
 
-	note = html.EscapeString(strings.TrimSpace(note))
+	if query.Completed != "" {
+		completed, err := strconv.ParseBool(query.Completed)
+		if err != nil {
+			return filter, fmt.Errorf("%w: completed", errors.ErrInvalidNotesQuery)
+		}
+		filter.Completed = &completed
+	}
 
-	if utf8.RuneCountInString(note) < 3 {
-		return errors.ErrNoteTooShort
+	if query.CreatedAfter != "" {
+		createdAfter, err := time.Parse(time.RFC3339, query.CreatedAfter)
+		if err != nil {
+			return filter, fmt.Errorf("%w: created_after", errors.ErrInvalidNotesQuery)
+		}
+		createdAfter = createdAfter.UTC()
+		filter.CreatedAfter = &createdAfter
 	}
 
-	createdAt := time.Now().UTC() // UTC для универсальности
+	if query.CreatedBefore != "" {
+		createdBefore, err := time.Parse(time.RFC3339, query.CreatedBefore)
+		if err != nil {
+			return filter, fmt.Errorf("%w: created_before", errors.ErrInvalidNotesQuery)
+		}
+		createdBefore = createdBefore.UTC()
+		filter.CreatedBefore = &createdBefore
+	}
+
+	if query.Tags != "" {
+		seen := make(map[int64]bool)
+		for _, value := range strings.Split(query.Tags, ",") {
+			tagID, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+			if err != nil || tagID <= 0 {
+				return filter, fmt.Errorf("%w: tags", errors.ErrInvalidNotesQuery)
+			}
+			if !seen[tagID] {
+				seen[tagID] = true
+				filter.TagIDs = append(filter.TagIDs, tagID)
+			}
+		}
+	}
+
+	switch strings.ToLower(query.TagsMode) {
+	case "", "any":
+	case "all":
+		filter.MatchAllTags = true
+	default:
+		return filter, errors.ErrInvalidTagsMode
+	}
+
+	switch query.Sort {
+	case "":
+	case "position", "created_at", "id", "note":
+		filter.SortBy = query.Sort
+	default:
+		return filter, fmt.Errorf("%w: sort", errors.ErrInvalidNotesQuery)
+	}
+
+	switch strings.ToLower(query.Order) {
+	case "", "asc":
+	case "desc":
+		filter.Desc = true
+	default:
+		return filter, fmt.Errorf("%w: order", errors.ErrInvalidNotesQuery)
+	}
+
+	if query.Limit != "" {
+		limit, err := strconv.Atoi(query.Limit)
+		if err != nil || limit <= 0 || limit > maxNotesPageLimit {
+			return filter, fmt.Errorf("%w: limit (1..%d)", errors.ErrInvalidNotesQuery, maxNotesPageLimit)
+		}
+		filter.Limit = limit
+	}
+
+	if query.Cursor != "" {
+		cursor, err := decodeNoteCursor(query.Cursor)
+		if err != nil {
+			return filter, err
+		}
+
+		// Курсор действителен только для той же сортировки, для которой он был выдан
+		if cursor.SortBy != filter.SortBy || cursor.Desc != filter.Desc {
+			return filter, errors.ErrInvalidCursor
+		}
+		filter.After = cursor
+	}
+
+	return filter, nil
+}
+
+// encodeNoteCursor - кодирует курсор в непрозрачную строку
+func encodeNoteCursor(cursor models.NoteCursor) (string, error) {
+	data, err := json.Marshal(cursor)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// decodeNoteCursor - декодирует курсор, полученный от клиента
+func decodeNoteCursor(value string) (*models.NoteCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, errors.ErrInvalidCursor
+	}
+
+	var cursor models.NoteCursor
+	if err = json.Unmarshal(data, &cursor); err != nil || cursor.ID <= 0 {
+		return nil, errors.ErrInvalidCursor
+	}
+
+	return &cursor, nil
+}
+
+// SearchNotes - полнотекстовый поиск по заметкам пользователя. Проверка через Authorizer не нужна:
+// конкретная заметка или список не указываются, а поиск идёт только по собственным заметкам (user_id = userID)
+func (s *noteService) SearchNotes(ctx context.Context, userID int64, query request.SearchNotesDTO) ([]models.NoteSearchResult, error) {
+	q := strings.TrimSpace(query.Query)
+	if q == "" {
+		return nil, errors.ErrEmptySearchQuery
+	}
+	if utf8.RuneCountInString(q) > maxSearchQueryLength {
+		return nil, fmt.Errorf("%w: q", errors.ErrInvalidNotesQuery)
+	}
+
+	// Текст заметок хранится экранированным, поэтому и запрос экранируем так же
+	q = html.EscapeString(q)
+
+	configs, ok := searchConfigs[strings.ToLower(query.Lang)]
+	if !ok {
+		return nil, fmt.Errorf("%w: lang", errors.ErrInvalidNotesQuery)
+	}
+
+	limit := defaultSearchLimit
+	if query.Limit != "" {
+		var err error
+		limit, err = strconv.Atoi(query.Limit)
+		if err != nil || limit <= 0 || limit > maxNotesPageLimit {
+			return nil, fmt.Errorf("%w: limit (1..%d)", errors.ErrInvalidNotesQuery, maxNotesPageLimit)
+		}
+	}
+
+	return s.repo.SearchNotesFromDB(ctx, userID, q, configs, limit)
+}
+
+// ValidateTheNoteBeforeInserting - валидация заметки перед вставкой.
+// Заметка, добавленная редактором в общий список, принадлежит владельцу списка
+func (s *noteService) ValidateNoteBeforeInserting(ctx context.Context, userID int64, req request.CreateNoteDTO) error {
+	note, err := s.prepareNewNote(ctx, userID, req)
+	if err != nil {
+		return err
+	}
 
 	// InsertNoteToDB - добавить новую заметку в БД
-	if err := s.repo.InsertNoteToDB(ctx, userID, note, createdAt); err != nil {
+	if err = s.repo.InsertNoteToDB(ctx, note); err != nil {
 		return fmt.Errorf("%w: %w", errors.ErrNoteFailed, err)
 	}
 
 	return nil
 }
 
-// UpdateNoteDataValidation - обновление заметки, валидация данных
-func (s *noteService) UpdateNoteDataValidation(ctx context.Context, id int64, note string) error {
-	note = html.EscapeString(strings.TrimSpace(note))
+// prepareNewNote - валидация новой заметки и выбор её списка и владельца
+func (s *noteService) prepareNewNote(ctx context.Context, userID int64, req request.CreateNoteDTO) (models.AllNotes, error) {
+	note, err := s.validateNote(req)
+	if err != nil {
+		return note, err
+	}
+
+	note.UserID = userID
+	note.ListID = req.ListID
+	note.CreatedAt = time.Now().UTC() // UTC для универсальности
+
+	if note.ListID > 0 {
+		if note.UserID, err = s.auth.AuthorizeList(ctx, userID, note.ListID, PermissionWrite); err != nil {
+			return note, err
+		}
+	}
+
+	// Без явно указанного списка заметка попадает в список по умолчанию (Inbox)
+	if note.ListID <= 0 {
+		if note.ListID, err = s.lists.GetDefaultListIDFromDB(ctx, userID); err != nil {
+			return note, fmt.Errorf("%w: %w", errors.ErrNoteFailed, err)
+		}
+	}
+
+	return note, nil
+}
+
+// UpdateNoteDataValidation - обновление заметки, валидация данных.
+// ifMatch - допустимые версии заметки из If-Match (nil - без проверки); возвращает новую версию
+func (s *noteService) UpdateNoteDataValidation(ctx context.Context, userID, id int64, req request.UpdateNoteDTO, ifMatch []int64) (int64, error) {
+	note, err := s.prepareNoteUpdate(ctx, userID, id, req)
+	if err != nil {
+		return 0, err
+	}
+
+	return s.repo.UpdateNoteToDB(ctx, userID, note, ifMatch)
+}
+
+// prepareNoteUpdate - валидация изменений заметки и проверка права на её изменение
+func (s *noteService) prepareNoteUpdate(ctx context.Context, userID, id int64, req request.UpdateNoteDTO) (models.NoteUpdate, error) {
+	note, err := s.validateNoteUpdate(req)
+	if err != nil {
+		return note, err
+	}
+
+	ownerID, err := s.authorizeNoteChange(ctx, userID, id, PermissionWrite)
+	if err != nil {
+		return note, err
+	}
+
+	note.ID = id
+	note.UserID = ownerID
+
+	return note, nil
+}
 
+// authorizeNoteChange - проверка ID заметки и права perm на неё; возвращает владельца заметки
+func (s *noteService) authorizeNoteChange(ctx context.Context, userID, id int64, perm Permission) (int64, error) {
+	if id <= 0 || userID <= 0 {
+		return 0, errors.ErrIDCannotBeNegativeOrEqualToZero
+	}
+
+	return s.auth.AuthorizeNote(ctx, userID, id, perm)
+}
+
+// validateNote - очистка и проверка полей новой заметки
+func (s *noteService) validateNote(req request.CreateNoteDTO) (models.AllNotes, error) {
+	var (
+		note models.AllNotes
+		err  error
+	)
+
+	if note.Note, err = cleanNoteText(req.Note); err != nil {
+		return note, err
+	}
+
+	if note.Priority, err = parsePriority(req.Priority); err != nil {
+		return note, err
+	}
+
+	if req.DueAt != "" {
+		dueAt, err := parseDueAt(req.DueAt, s.loc)
+		if err != nil {
+			return note, err
+		}
+		note.DueAt = &dueAt
+	}
+
+	if note.RRule, err = s.parseRRule(req.RRule); err != nil {
+		return note, err
+	}
+	if note.RRule != "" && note.DueAt == nil {
+		return note, errors.ErrRecurrenceRequiresDueAt
+	}
+
+	return note, nil
+}
+
+// validateNoteUpdate - очистка и проверка изменений заметки. Срок для правила повторения может остаться прежним,
+// поэтому его наличие проверяется при обновлении в БД
+func (s *noteService) validateNoteUpdate(req request.UpdateNoteDTO) (models.NoteUpdate, error) {
+	var (
+		note models.NoteUpdate
+		err  error
+	)
+
+	if note.Note, err = cleanNoteText(req.Note); err != nil {
+		return note, err
+	}
+
+	if req.Priority != nil {
+		priority, err := parsePriority(*req.Priority)
+		if err != nil {
+			return note, err
+		}
+		note.Priority = &priority
+	}
+
+	if req.DueAt != nil {
+		note.SetDueAt = true
+		if *req.DueAt != "" {
+			dueAt, err := parseDueAt(*req.DueAt, s.loc)
+			if err != nil {
+				return note, err
+			}
+			note.DueAt = &dueAt
+		}
+	}
+
+	if req.RRule != nil {
+		rule, err := s.parseRRule(*req.RRule)
+		if err != nil {
+			return note, err
+		}
+		if rule != "" && note.SetDueAt && note.DueAt == nil {
+			return note, errors.ErrRecurrenceRequiresDueAt
+		}
+		note.RRule = &rule
+	}
+
+	return note, nil
+}
+
+// cleanNoteText - экранированный текст заметки без пробелов по краям (не короче 3 символов)
+func cleanNoteText(text string) (string, error) {
+	note := html.EscapeString(strings.TrimSpace(text))
 	if utf8.RuneCountInString(note) < 3 {
-		return errors.ErrNoteTooShort
+		return note, errors.ErrNoteTooShort
 	}
+	return note, nil
+}
 
-	if id <= 0 {
-		return errors.ErrIDCannotBeNegativeOrEqualToZero
+// parsePriority - приоритет заметки (пустой - normal)
+func parsePriority(value string) (string, error) {
+	switch priority := strings.ToLower(strings.TrimSpace(value)); priority {
+	case "":
+		return models.PriorityNormal, nil
+	case models.PriorityLow, models.PriorityNormal, models.PriorityHigh, models.PriorityUrgent:
+		return priority, nil
+	default:
+		return "", errors.ErrInvalidPriority
 	}
+}
 
-	if err := s.repo.UpdateNoteToDB(ctx, id, note); err != nil {
-		return err
+// parseRRule - правило повторения в каноническом виде (пустое - заметка не повторяется)
+func (s *noteService) parseRRule(value string) (string, error) {
+	rule := strings.TrimSpace(value)
+	if rule == "" {
+		return "", nil
 	}
 
-	return nil
+	parsed, err := rrule.Parse(rule, s.loc)
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", errors.ErrInvalidRRule, err)
+	}
+	return parsed.String(), nil
 }
 
-// DeleteNote - удалить заметку, валидация данных
-func (s *noteService) DeleteNote(ctx context.Context, id int64) error {
-	if id <= 0 {
-		return errors.ErrIDCannotBeNegativeOrEqualToZero
+// nextOccurrence - срок следующего повторения заметки. Повторения считаются по настенному
+// времени часового пояса сервера, поэтому переход на летнее/зимнее время не сдвигает час
+func (s *noteService) nextOccurrence(rule string, start, due time.Time) (time.Time, bool) {
+	parsed, err := rrule.Parse(rule, s.loc)
+	if err != nil {
+		return time.Time{}, false
 	}
 
-	// DeleteNoteFromDB - удалить заметку из БД
-	if err := s.repo.DeleteNoteFromDB(ctx, id); err != nil {
-		return err
+	next, ok := parsed.Next(start.In(s.loc), due)
+	if !ok {
+		return time.Time{}, false
 	}
 
-	return nil
+	return next.UTC(), true
 }
 
-// MarkNoteCompleted - Отметить заметку выполненной, валидация данных
-func (s *noteService) MarkNoteCompleted(ctx context.Context, id int64, check bool) error {
-	if id <= 0 {
-		return errors.ErrIDCannotBeNegativeOrEqualToZero
+// dueAtLayouts - форматы срока выполнения без часового пояса (интерпретируются в часовом поясе сервера)
+var dueAtLayouts = []string{
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02",
+}
+
+// parseDueAt - разбор срока выполнения. Время без явного смещения считается локальным для loc
+func parseDueAt(value string, loc *time.Location) (time.Time, error) {
+	value = strings.TrimSpace(value)
+
+	if dueAt, err := time.Parse(time.RFC3339, value); err == nil {
+		return dueAt, nil
 	}
 
-	// MarkNoteCompleted - Отметить заметку выполненной в БД
-	if err := s.repo.MarkNoteCompletedToDB(ctx, id, check); err != nil {
+	for _, layout := range dueAtLayouts {
+		if dueAt, err := time.ParseInLocation(layout, value, loc); err == nil {
+			return dueAt, nil
+		}
+	}
+
+	return time.Time{}, errors.ErrInvalidDueAt
+}
+
+// GetNote - получаем заметку (свою или общую) с проверкой права на просмотр
+func (s *noteService) GetNote(ctx context.Context, userID, id int64) (*models.AllNotes, error) {
+	ownerID, err := s.authorizeNoteChange(ctx, userID, id, PermissionRead)
+	if err != nil {
+		return nil, err
+	}
+
+	note, err := s.repo.GetNoteFromDB(ctx, ownerID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	notes := []models.AllNotes{*note}
+	s.localizeNotes(notes)
+	return &notes[0], nil
+}
+
+// localizeNotes - переводим сроки выполнения заметок в часовой пояс сервера
+func (s *noteService) localizeNotes(notes []models.AllNotes) {
+	for i := range notes {
+		if notes[i].DueAt != nil {
+			dueAt := notes[i].DueAt.In(s.loc)
+			notes[i].DueAt = &dueAt
+		}
+	}
+}
+
+// GetTodayNotes - невыполненные заметки со сроком на сегодня (по часовому поясу сервера).
+// Как и GetOverdueNotes и GetUpcomingNotes, выбирает только собственные заметки пользователя, поэтому Authorizer не нужен;
+// общие заметки других пользователей доступны через GET /shared
+func (s *noteService) GetTodayNotes(ctx context.Context, userID int64) ([]models.AllNotes, error) {
+	if userID <= 0 {
+		return nil, errors.ErrIDCannotBeNegativeOrEqualToZero
+	}
+
+	now := time.Now().In(s.loc)
+	startOfDay := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, s.loc)
+	// AddDate вместо Add(24h), чтобы корректно учитывать переход на летнее/зимнее время
+	endOfDay := startOfDay.AddDate(0, 0, 1)
+
+	notes, err := s.repo.GetNotesDueBetweenFromDB(ctx, userID, &startOfDay, endOfDay)
+	if err != nil {
+		return nil, err
+	}
+
+	s.localizeNotes(notes)
+	return notes, nil
+}
+
+// GetOverdueNotes - невыполненные собственные заметки, срок которых уже прошёл
+func (s *noteService) GetOverdueNotes(ctx context.Context, userID int64) ([]models.AllNotes, error) {
+	if userID <= 0 {
+		return nil, errors.ErrIDCannotBeNegativeOrEqualToZero
+	}
+
+	notes, err := s.repo.GetNotesDueBetweenFromDB(ctx, userID, nil, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	s.localizeNotes(notes)
+	return notes, nil
+}
+
+// GetUpcomingNotes - невыполненные собственные заметки со сроком в ближайшие days дней
+func (s *noteService) GetUpcomingNotes(ctx context.Context, userID int64, days string) ([]models.AllNotes, error) {
+	if userID <= 0 {
+		return nil, errors.ErrIDCannotBeNegativeOrEqualToZero
+	}
+
+	period := defaultUpcomingDays
+	if days != "" {
+		var err error
+		period, err = strconv.Atoi(days)
+		if err != nil || period <= 0 || period > maxUpcomingDays {
+			return nil, errors.ErrInvalidDays
+		}
+	}
+
+	now := time.Now().In(s.loc)
+	notes, err := s.repo.GetNotesDueBetweenFromDB(ctx, userID, &now, now.AddDate(0, 0, period))
+	if err != nil {
+		return nil, err
+	}
+
+	s.localizeNotes(notes)
+	return notes, nil
+}
+
+// DeleteNote - удалить заметку (только владелец), валидация данных
+func (s *noteService) DeleteNote(ctx context.Context, userID, id int64) error {
+	ownerID, err := s.authorizeNoteChange(ctx, userID, id, PermissionManage)
+	if err != nil {
+		return err
+	}
+
+	// DeleteNoteFromDB - удалить заметку из БД
+	if err = s.repo.DeleteNoteFromDB(ctx, ownerID, id); err != nil {
 		return err
 	}
 
 	return nil
 }
 
-// DeleteAllNotes - Удалить все заметки, валидация данных
+// MarkNoteCompleted - Отметить заметку выполненной, валидация данных.
+// ifMatch - допустимые версии заметки из If-Match (nil - без проверки); возвращает новую версию
+func (s *noteService) MarkNoteCompleted(ctx context.Context, userID, id int64, req request.CheckNoteDTO, ifMatch []int64) (int64, error) {
+	ownerID, err := s.authorizeNoteChange(ctx, userID, id, PermissionWrite)
+	if err != nil {
+		return 0, err
+	}
+
+	// MarkNoteCompleted - Отметить заметку выполненной в БД
+	// Пункты чек-листа отмечаются только при отметке заметки выполненной, снятие отметки их не затрагивает
+	return s.repo.MarkNoteCompletedToDB(ctx, userID, ownerID, id, req.Check, req.Check && req.CompleteItems, s.nextOccurrence, ifMatch)
+}
+
+// DeleteAllNotes - Удалить все собственные заметки пользователя (общие заметки других пользователей не затрагиваются)
 func (s *noteService) DeleteAllNotes(ctx context.Context, userID int64) error {
 	if userID <= 0 {
 		return errors.ErrIDCannotBeNegativeOrEqualToZero
@@ -126,16 +673,121 @@ func (s *noteService) DeleteAllNotes(ctx context.Context, userID int64) error {
 	return nil
 }
 
-// DeleteAllCompletedNotes - Удалить все выполненные заметки, валидация данных
-func (s *noteService) DeleteAllCompletedNotes(ctx context.Context, userID int64) error {
-	if userID <= 0 {
+// DeleteAllCompletedNotes - Удалить все выполненные заметки (всех списков или одного списка), валидация данных
+func (s *noteService) DeleteAllCompletedNotes(ctx context.Context, userID, listID int64) error {
+	if userID <= 0 || listID < 0 {
 		return errors.ErrIDCannotBeNegativeOrEqualToZero
 	}
 
-	// DeleteAllCompletedNotesFromDB - Удалить все выполненные заметки из БД
-	if err := s.repo.DeleteAllCompletedNotesFromDB(ctx, userID); err != nil {
+	ownerID := userID
+	if listID > 0 {
+		var err error
+		if ownerID, err = s.auth.AuthorizeList(ctx, userID, listID, PermissionManage); err != nil {
+			return err
+		}
+	}
+
+	// DeleteAllCompletedNotesFromDB - Переместить все выполненные заметки в корзину
+	if err := s.repo.DeleteAllCompletedNotesFromDB(ctx, ownerID, listID); err != nil {
 		return err
 	}
 
 	return nil
 }
+
+// MoveNote - перенести заметку в другой список (только владелец заметки и списка), валидация данных
+func (s *noteService) MoveNote(ctx context.Context, userID, id, listID int64) error {
+	ownerID, err := s.authorizeMoveNote(ctx, userID, id, listID)
+	if err != nil {
+		return err
+	}
+
+	return s.repo.MoveNoteToListDB(ctx, ownerID, id, listID)
+}
+
+// authorizeMoveNote - проверка права на перенос заметки в список; возвращает владельца заметки
+func (s *noteService) authorizeMoveNote(ctx context.Context, userID, id, listID int64) (int64, error) {
+	if listID <= 0 {
+		return 0, errors.ErrIDCannotBeNegativeOrEqualToZero
+	}
+
+	ownerID, err := s.authorizeNoteChange(ctx, userID, id, PermissionManage)
+	if err != nil {
+		return 0, err
+	}
+
+	if _, err = s.auth.AuthorizeList(ctx, userID, listID, PermissionManage); err != nil {
+		return 0, err
+	}
+
+	return ownerID, nil
+}
+
+// MoveNotePosition - переместить заметку между соседями (ручная сортировка), валидация данных
+func (s *noteService) MoveNotePosition(ctx context.Context, userID, id int64, req request.NotePositionDTO) error {
+	if id <= 0 || userID <= 0 || req.AfterID < 0 || req.BeforeID < 0 {
+		return errors.ErrIDCannotBeNegativeOrEqualToZero
+	}
+
+	if req.AfterID == 0 && req.BeforeID == 0 {
+		return errors.ErrInvalidNotePosition
+	}
+	if req.AfterID == id || req.BeforeID == id || req.AfterID == req.BeforeID {
+		return errors.ErrInvalidNotePosition
+	}
+
+	// Соседи ищутся среди заметок владельца, поэтому редактор сортирует заметки в его порядке
+	ownerID, err := s.auth.AuthorizeNote(ctx, userID, id, PermissionWrite)
+	if err != nil {
+		return err
+	}
+
+	return s.repo.MoveNotePositionDB(ctx, ownerID, id, req.AfterID, req.BeforeID)
+}
+
+// GetTrash - получаем заметки пользователя из корзины. В корзину заметки перемещает только владелец,
+// поэтому операции с корзиной работают лишь с собственными заметками и не проходят через Authorizer
+func (s *noteService) GetTrash(ctx context.Context, userID int64) ([]models.AllNotes, error) {
+	if userID <= 0 {
+		return nil, errors.ErrIDCannotBeNegativeOrEqualToZero
+	}
+
+	notes, err := s.repo.GetTrashFromDB(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.localizeNotes(notes)
+
+	return notes, nil
+}
+
+// RestoreNote - восстановить заметку из корзины, валидация данных. Authorizer видит только заметки вне корзины,
+// поэтому владелец проверяется в самом запросе (user_id = userID): для чужой заметки, как и при отсутствии доступа,
+// возвращается ErrNoteNotFound
+func (s *noteService) RestoreNote(ctx context.Context, userID, id int64) error {
+	if id <= 0 || userID <= 0 {
+		return errors.ErrIDCannotBeNegativeOrEqualToZero
+	}
+
+	return s.repo.RestoreNoteFromTrashDB(ctx, userID, id)
+}
+
+// EmptyTrash - окончательно удалить собственные заметки пользователя из корзины
+func (s *noteService) EmptyTrash(ctx context.Context, userID int64) error {
+	if userID <= 0 {
+		return errors.ErrIDCannotBeNegativeOrEqualToZero
+	}
+
+	return s.repo.EmptyTrashDB(ctx, userID)
+}
+
+// PurgeTrash - окончательно удалить заметки, пролежавшие в корзине дольше срока хранения (Config.Trash.Retention)
+func (s *noteService) PurgeTrash(ctx context.Context) (int64, error) {
+	retention := s.cfg.Trash.Retention
+	if retention <= 0 {
+		retention = defaultTrashRetention
+	}
+
+	return s.repo.PurgeTrashDB(ctx, time.Now().Add(-retention))
+}
@@ -0,0 +1,372 @@
+package service
+
+import (
+	"context"
+	stdErrors "errors"
+	"testing"
+	"time"
+
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/config"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/errors"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/models"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/repository"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/transport/dto/request"
+)
+
+// fakeNoteRepo - запоминает, от имени какого пользователя были запросы к БД.
+// Методы, не переопределённые здесь, вызывать не должны: встроенный интерфейс nil приведёт к панике
+type fakeNoteRepo struct {
+	repository.NoteRepository
+	calls   []int64           // Пользователь, от имени которого читались или изменялись заметки
+	updated models.NoteUpdate // Последнее изменение, переданное в UpdateNoteToDB
+}
+
+func (f *fakeNoteRepo) GetNoteFromDB(_ context.Context, userID, id int64) (*models.AllNotes, error) {
+	f.calls = append(f.calls, userID)
+	return &models.AllNotes{ID: id, UserID: userID}, nil
+}
+
+func (f *fakeNoteRepo) GetAllNotesFromDB(_ context.Context, userID int64, _ models.NoteFilter) ([]models.AllNotes, int64, error) {
+	f.calls = append(f.calls, userID)
+	return nil, 0, nil
+}
+
+// UpdateNoteToDB - первым аргументом передаётся автор правки, владелец - в note.UserID
+func (f *fakeNoteRepo) UpdateNoteToDB(_ context.Context, _ int64, note models.NoteUpdate, _ []int64) (int64, error) {
+	f.calls = append(f.calls, note.UserID)
+	f.updated = note
+	return 2, nil
+}
+
+func (f *fakeNoteRepo) DeleteNoteFromDB(_ context.Context, userID, _ int64) error {
+	f.calls = append(f.calls, userID)
+	return nil
+}
+
+func (f *fakeNoteRepo) DeleteAllCompletedNotesFromDB(_ context.Context, userID, _ int64) error {
+	f.calls = append(f.calls, userID)
+	return nil
+}
+
+func (f *fakeNoteRepo) SearchNotesFromDB(_ context.Context, userID int64, _ string, _ []string, _ int) ([]models.NoteSearchResult, error) {
+	f.calls = append(f.calls, userID)
+	return nil, nil
+}
+
+func (f *fakeNoteRepo) GetNotesDueBetweenFromDB(_ context.Context, userID int64, _ *time.Time, _ time.Time) ([]models.AllNotes, error) {
+	f.calls = append(f.calls, userID)
+	return nil, nil
+}
+
+func (f *fakeNoteRepo) GetTrashFromDB(_ context.Context, userID int64) ([]models.AllNotes, error) {
+	f.calls = append(f.calls, userID)
+	return nil, nil
+}
+
+// RestoreNoteFromTrashDB - как и запрос в БД, находит заметку только у её владельца
+func (f *fakeNoteRepo) RestoreNoteFromTrashDB(_ context.Context, userID, _ int64) error {
+	f.calls = append(f.calls, userID)
+	if userID != ownerUser {
+		return errors.ErrNoteNotFound
+	}
+	return nil
+}
+
+func (f *fakeNoteRepo) EmptyTrashDB(_ context.Context, userID int64) error {
+	f.calls = append(f.calls, userID)
+	return nil
+}
+
+func (f *fakeNoteRepo) GetSyncChangesFromDB(_ context.Context, userID int64, _ models.SyncToken, _ int) (*models.SyncChanges, error) {
+	f.calls = append(f.calls, userID)
+	return &models.SyncChanges{}, nil
+}
+
+// ExecNoteBatchDB - пакет выполняется на fakeNoteBatch без транзакции
+func (f *fakeNoteRepo) ExecNoteBatchDB(_ context.Context, fn func(batch repository.NoteBatch) error) error {
+	return fn(&fakeNoteBatch{repo: f})
+}
+
+// fakeNoteBatch - как и запрос в БД, находит версию заметки sharedNote только у её владельца
+type fakeNoteBatch struct {
+	repository.NoteBatch
+	repo *fakeNoteRepo
+}
+
+func (b *fakeNoteBatch) UpdateNoteToDB(_ context.Context, _ int64, note models.NoteUpdate) error {
+	b.repo.calls = append(b.repo.calls, note.UserID)
+	b.repo.updated = note
+	return nil
+}
+
+func (b *fakeNoteBatch) LockNoteSyncStateDB(_ context.Context, userID, id int64) (*models.NoteSyncState, error) {
+	b.repo.calls = append(b.repo.calls, userID)
+	if userID != ownerUser || id != sharedNote {
+		return nil, errors.ErrNoteNotFound
+	}
+	return &models.NoteSyncState{ChangeSeq: 7, UpdatedAt: time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)}, nil
+}
+
+func (b *fakeNoteBatch) Savepoint(context.Context) error           { return nil }
+func (b *fakeNoteBatch) RollbackToSavepoint(context.Context) error { return nil }
+func (b *fakeNoteBatch) ReleaseSavepoint(context.Context) error    { return nil }
+
+func newTestNoteService() (NoteService, *fakeNoteRepo) {
+	repo := &fakeNoteRepo{}
+	return NewNoteService(repo, nil, NewAuthorizer(newFakeShareRepo()), &config.Config{}), repo
+}
+
+func TestNoteServiceForeignNote(t *testing.T) {
+	ctx := context.Background()
+	update := request.UpdateNoteDTO{Note: "чужая заметка"}
+
+	tests := []struct {
+		name    string
+		userID  int64
+		call    func(s NoteService, userID int64) error
+		wantErr error
+	}{
+		{"get by stranger", otherUser, func(s NoteService, userID int64) error {
+			_, err := s.GetNote(ctx, userID, sharedNote)
+			return err
+		}, errors.ErrNoteNotFound},
+		{"update by stranger", otherUser, func(s NoteService, userID int64) error {
+			_, err := s.UpdateNoteDataValidation(ctx, userID, sharedNote, update, nil)
+			return err
+		}, errors.ErrNoteNotFound},
+		{"delete by stranger", otherUser, func(s NoteService, userID int64) error {
+			return s.DeleteNote(ctx, userID, sharedNote)
+		}, errors.ErrNoteNotFound},
+		{"update by viewer", viewerUser, func(s NoteService, userID int64) error {
+			_, err := s.UpdateNoteDataValidation(ctx, userID, sharedNote, update, nil)
+			return err
+		}, errors.ErrForbidden},
+		{"delete by editor", editorUser, func(s NoteService, userID int64) error {
+			return s.DeleteNote(ctx, userID, sharedNote)
+		}, errors.ErrForbidden},
+		{"list notes of foreign list", otherUser, func(s NoteService, userID int64) error {
+			_, err := s.GetAllNotes(ctx, userID, request.GetNotesDTO{ListID: "20"})
+			return err
+		}, errors.ErrListNotFound},
+		{"clear completed in list by editor", editorUser, func(s NoteService, userID int64) error {
+			return s.DeleteAllCompletedNotes(ctx, userID, sharedList)
+		}, errors.ErrForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, repo := newTestNoteService()
+
+			err := tt.call(svc, tt.userID)
+			if !stdErrors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if len(repo.calls) != 0 {
+				t.Errorf("repository was called for user %v despite denied access", repo.calls)
+			}
+		})
+	}
+}
+
+func TestNoteServiceSharedNoteUsesOwner(t *testing.T) {
+	ctx := context.Background()
+	svc, repo := newTestNoteService()
+
+	note, err := svc.GetNote(ctx, viewerUser, sharedNote)
+	if err != nil {
+		t.Fatalf("GetNote: %v", err)
+	}
+	if note.UserID != ownerUser {
+		t.Errorf("note.UserID = %d, want owner %d", note.UserID, ownerUser)
+	}
+
+	if _, err = svc.UpdateNoteDataValidation(ctx, editorUser, sharedNote, request.UpdateNoteDTO{Note: "текст"}, nil); err != nil {
+		t.Fatalf("UpdateNoteDataValidation: %v", err)
+	}
+
+	for _, userID := range repo.calls {
+		if userID != ownerUser {
+			t.Errorf("repository called for user %d, want owner %d", userID, ownerUser)
+		}
+	}
+}
+
+// Методы без проверки через Authorizer работают только с собственными заметками:
+// в БД передаётся пользователь из запроса, а не владелец чужой заметки или списка
+func TestNoteServiceOwnNotesOnly(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name string
+		call func(s NoteService) error
+	}{
+		{"search", func(s NoteService) error {
+			_, err := s.SearchNotes(ctx, otherUser, request.SearchNotesDTO{Query: "молоко", Lang: "ru"})
+			return err
+		}},
+		{"today", func(s NoteService) error {
+			_, err := s.GetTodayNotes(ctx, otherUser)
+			return err
+		}},
+		{"overdue", func(s NoteService) error {
+			_, err := s.GetOverdueNotes(ctx, otherUser)
+			return err
+		}},
+		{"upcoming", func(s NoteService) error {
+			_, err := s.GetUpcomingNotes(ctx, otherUser, "")
+			return err
+		}},
+		{"trash", func(s NoteService) error {
+			_, err := s.GetTrash(ctx, otherUser)
+			return err
+		}},
+		{"empty trash", func(s NoteService) error {
+			return s.EmptyTrash(ctx, otherUser)
+		}},
+		{"sync", func(s NoteService) error {
+			_, err := s.GetSyncChanges(ctx, otherUser, request.SyncDTO{})
+			return err
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, repo := newTestNoteService()
+
+			if err := tt.call(svc); err != nil {
+				t.Fatalf("err = %v", err)
+			}
+			if len(repo.calls) != 1 || repo.calls[0] != otherUser {
+				t.Errorf("repository calls = %v, want [%d]", repo.calls, otherUser)
+			}
+		})
+	}
+}
+
+func TestNoteServiceRestoreForeignNote(t *testing.T) {
+	ctx := context.Background()
+	svc, _ := newTestNoteService()
+
+	// Заметка в корзине доступна только владельцу, даже если её список открыт для других
+	for _, userID := range []int64{otherUser, editorUser} {
+		if err := svc.RestoreNote(ctx, userID, sharedNote); !stdErrors.Is(err, errors.ErrNoteNotFound) {
+			t.Errorf("RestoreNote by user %d: err = %v, want %v", userID, err, errors.ErrNoteNotFound)
+		}
+	}
+	if err := svc.RestoreNote(ctx, ownerUser, sharedNote); err != nil {
+		t.Errorf("RestoreNote by owner: %v", err)
+	}
+}
+
+// Обновление меняет только поля, переданные клиентом: запрос только с текстом не стирает срок,
+// приоритет и правило повторения
+func TestUpdateNotePartial(t *testing.T) {
+	ctx := context.Background()
+	empty, high, rule, due := "", "HIGH", "FREQ=WEEKLY", "2030-01-02T10:00"
+
+	tests := []struct {
+		name    string
+		req     request.UpdateNoteDTO
+		check   func(t *testing.T, upd models.NoteUpdate)
+		wantErr error
+	}{
+		{"text only", request.UpdateNoteDTO{Note: "только текст"}, func(t *testing.T, upd models.NoteUpdate) {
+			if upd.SetDueAt || upd.Priority != nil || upd.RRule != nil {
+				t.Errorf("update = %+v, want only note", upd)
+			}
+		}, nil},
+		{"priority", request.UpdateNoteDTO{Note: "текст", Priority: &high}, func(t *testing.T, upd models.NoteUpdate) {
+			if upd.Priority == nil || *upd.Priority != models.PriorityHigh {
+				t.Errorf("priority = %v, want %q", upd.Priority, models.PriorityHigh)
+			}
+		}, nil},
+		{"empty priority is normal", request.UpdateNoteDTO{Note: "текст", Priority: &empty}, func(t *testing.T, upd models.NoteUpdate) {
+			if upd.Priority == nil || *upd.Priority != models.PriorityNormal {
+				t.Errorf("priority = %v, want %q", upd.Priority, models.PriorityNormal)
+			}
+		}, nil},
+		{"clear due", request.UpdateNoteDTO{Note: "текст", DueAt: &empty}, func(t *testing.T, upd models.NoteUpdate) {
+			if !upd.SetDueAt || upd.DueAt != nil {
+				t.Errorf("SetDueAt = %v, DueAt = %v, want cleared", upd.SetDueAt, upd.DueAt)
+			}
+		}, nil},
+		{"rrule keeps due", request.UpdateNoteDTO{Note: "текст", RRule: &rule}, func(t *testing.T, upd models.NoteUpdate) {
+			if upd.SetDueAt || upd.RRule == nil || *upd.RRule != rule {
+				t.Errorf("update = %+v, want rrule only", upd)
+			}
+		}, nil},
+		{"rrule with due", request.UpdateNoteDTO{Note: "текст", RRule: &rule, DueAt: &due}, func(t *testing.T, upd models.NoteUpdate) {
+			if !upd.SetDueAt || upd.DueAt == nil || upd.RRule == nil {
+				t.Errorf("update = %+v, want due and rrule", upd)
+			}
+		}, nil},
+		{"rrule with cleared due", request.UpdateNoteDTO{Note: "текст", RRule: &rule, DueAt: &empty}, nil, errors.ErrRecurrenceRequiresDueAt},
+		{"invalid priority", request.UpdateNoteDTO{Note: "текст", Priority: &rule}, nil, errors.ErrInvalidPriority},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, repo := newTestNoteService()
+
+			_, err := svc.UpdateNoteDataValidation(ctx, ownerUser, sharedNote, tt.req, nil)
+			if !stdErrors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if tt.check != nil {
+				tt.check(t, repo.updated)
+			}
+		})
+	}
+}
+
+func TestPushSyncChangesSharedNote(t *testing.T) {
+	svc, repo := newTestNoteService()
+
+	mutation := func(modifiedAt string) request.SyncMutationDTO {
+		return request.SyncMutationDTO{
+			NoteBatchOpDTO: request.NoteBatchOpDTO{Op: batchOpUpdate, ID: sharedNote, UpdateNoteDTO: request.UpdateNoteDTO{Note: "правка редактора"}},
+			BaseSeq:        3, // Клиент видел версию старше серверной (7)
+			ModifiedAt:     modifiedAt,
+		}
+	}
+
+	tests := []struct {
+		name       string
+		modifiedAt string
+		wantStatus string
+		wantCalls  []int64
+	}{
+		// Серверное изменение позже - правка редактора отклоняется
+		{"server wins", "2029-12-31T00:00:00Z", syncStatusConflict, []int64{ownerUser}},
+		// Правка редактора позже - применяется от имени владельца
+		{"client wins", "2030-01-02T00:00:00Z", syncStatusApplied, []int64{ownerUser, ownerUser, ownerUser}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo.calls = nil
+
+			result, err := svc.PushSyncChanges(context.Background(), editorUser, request.SyncPushDTO{Mutations: []request.SyncMutationDTO{mutation(tt.modifiedAt)}})
+			if err != nil {
+				t.Fatalf("PushSyncChanges: %v", err)
+			}
+
+			r := result.Results[0]
+			if r.Status != tt.wantStatus || r.Conflict == nil || result.Conflicts != 1 {
+				t.Fatalf("status = %q, conflict = %v, conflicts = %d; want %q with conflict", r.Status, r.Conflict, result.Conflicts, tt.wantStatus)
+			}
+			if r.Seq != 7 {
+				t.Errorf("seq = %d, want 7", r.Seq)
+			}
+			if len(repo.calls) != len(tt.wantCalls) {
+				t.Fatalf("calls = %v, want %v", repo.calls, tt.wantCalls)
+			}
+			for i, userID := range tt.wantCalls {
+				if repo.calls[i] != userID {
+					t.Errorf("calls = %v, want %v", repo.calls, tt.wantCalls)
+					break
+				}
+			}
+		})
+	}
+}
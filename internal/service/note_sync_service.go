@@ -0,0 +1,221 @@
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	goerrors "errors"
+	"fmt"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/errors"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/models"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/repository"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/transport/dto/request"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/transport/dto/response"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultSyncLimit = 500  // Количество изменений в ответе по умолчанию
+	maxSyncLimit     = 1000 // Максимальное количество изменений в ответе
+)
+
+// Статусы изменений клиента
+const (
+	syncStatusApplied  = "applied"
+	syncStatusConflict = "conflict"
+)
+
+// Разрешение конфликта
+const (
+	syncClientWins = "client_wins"
+	syncServerWins = "server_wins"
+)
+
+// GetSyncChanges - изменения заметок пользователя (созданные, изменённые, удалённые) после токена since.
+// Синхронизируются только собственные заметки, поэтому Authorizer не нужен; общие заметки других пользователей - через GET /shared
+func (s *noteService) GetSyncChanges(ctx context.Context, userID int64, query request.SyncDTO) (*response.SyncDTO, error) {
+	if userID <= 0 {
+		return nil, errors.ErrIDCannotBeNegativeOrEqualToZero
+	}
+
+	limit := defaultSyncLimit
+	if query.Limit != "" {
+		var err error
+		limit, err = strconv.Atoi(query.Limit)
+		if err != nil || limit <= 0 || limit > maxSyncLimit {
+			return nil, fmt.Errorf("%w: limit (1..%d)", errors.ErrInvalidNotesQuery, maxSyncLimit)
+		}
+	}
+
+	var after models.SyncToken
+	if query.Since != "" {
+		token, err := decodeSyncToken(query.Since)
+		if err != nil {
+			return nil, err
+		}
+		after = *token
+	}
+
+	changes, err := s.repo.GetSyncChangesFromDB(ctx, userID, after, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	next, err := encodeSyncToken(changes.Next)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errors.ErrGetSyncChanges, err)
+	}
+
+	return &response.SyncDTO{
+		Changes:   changes.Notes,
+		Deleted:   changes.Deleted,
+		NextToken: next,
+		HasMore:   changes.HasMore,
+	}, nil
+}
+
+// PushSyncChanges - применить изменения, сделанные клиентом без сети. Каждое изменение выполняется как операция
+// пакета в режиме best_effort. Если заметку изменили на сервере после версии baseSeq, побеждает более позднее
+// изменение: клиентское применяется, если modifiedAt позже серверного updatedAt, иначе отклоняется.
+// Доступ к каждой заметке проверяется через Authorizer, как и в пакетных операциях; конфликты проверяются
+// и для заметок, к которым пользователю открыт доступ (версия блокируется у владельца заметки)
+func (s *noteService) PushSyncChanges(ctx context.Context, userID int64, req request.SyncPushDTO) (*response.SyncPushDTO, error) {
+	if userID <= 0 {
+		return nil, errors.ErrIDCannotBeNegativeOrEqualToZero
+	}
+	if len(req.Mutations) == 0 || len(req.Mutations) > maxNoteBatchOperations {
+		return nil, errors.ErrInvalidSyncPush
+	}
+
+	now := time.Now()
+
+	results := make([]response.SyncPushResultDTO, len(req.Mutations))
+
+	err := s.repo.ExecNoteBatchDB(ctx, func(batch repository.NoteBatch) error {
+		for i, mutation := range req.Mutations {
+			results[i] = response.SyncPushResultDTO{Index: i, ClientID: mutation.ClientID, Op: mutation.Op, ID: mutation.ID}
+
+			if err := batch.Savepoint(ctx); err != nil {
+				return err
+			}
+
+			if err := s.applySyncMutation(ctx, batch, userID, mutation, now, &results[i]); err != nil {
+				results[i].Status = batchStatusError
+				results[i].Conflict = nil
+				results[i].Error = err.Error()
+				results[i].Code = errors.HTTPStatus(err, http.StatusInternalServerError)
+
+				if err = batch.RollbackToSavepoint(ctx); err != nil {
+					return err
+				}
+				continue
+			}
+
+			if err := batch.ReleaseSavepoint(ctx); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := &response.SyncPushDTO{Results: results}
+	for _, r := range results {
+		if r.Conflict != nil {
+			result.Conflicts++
+		}
+	}
+
+	return result, nil
+}
+
+// applySyncMutation - проверить конфликт и применить одно изменение клиента; итог записывается в result
+func (s *noteService) applySyncMutation(ctx context.Context, batch repository.NoteBatch, userID int64, mutation request.SyncMutationDTO, now time.Time, result *response.SyncPushResultDTO) error {
+	modifiedAt := now
+	if mutation.ModifiedAt != "" {
+		t, err := time.Parse(time.RFC3339, mutation.ModifiedAt)
+		if err != nil {
+			return errors.ErrInvalidSyncTime
+		}
+		modifiedAt = t
+	}
+
+	op := strings.ToLower(strings.TrimSpace(mutation.Op))
+
+	if op != batchOpCreate && mutation.BaseSeq > 0 {
+		// Заметка может принадлежать другому пользователю (совместный доступ) - её версию блокируем у владельца
+		ownerID, err := s.authorizeNoteChange(ctx, userID, mutation.ID, PermissionWrite)
+		if err != nil {
+			return err
+		}
+
+		state, err := batch.LockNoteSyncStateDB(ctx, ownerID, mutation.ID)
+		if err != nil && !goerrors.Is(err, errors.ErrNoteNotFound) {
+			return err
+		}
+
+		if state != nil && state.ChangeSeq != mutation.BaseSeq {
+			result.Conflict = &response.SyncConflictDTO{
+				Resolution:      syncClientWins,
+				ServerSeq:       state.ChangeSeq,
+				ServerUpdatedAt: state.UpdatedAt,
+			}
+
+			if !modifiedAt.After(state.UpdatedAt) {
+				result.Conflict.Resolution = syncServerWins
+				result.Status = syncStatusConflict
+				result.Seq = state.ChangeSeq
+				return nil
+			}
+		}
+	}
+
+	id, ownerID, err := s.execNoteBatchOp(ctx, batch, userID, mutation.NoteBatchOpDTO)
+	if err != nil {
+		return err
+	}
+
+	result.ID = id
+	result.Status = syncStatusApplied
+
+	// Новая версия заметки - клиент использует её как baseSeq для следующих изменений
+	state, err := batch.LockNoteSyncStateDB(ctx, ownerID, id)
+	if err != nil && !goerrors.Is(err, errors.ErrNoteNotFound) {
+		return err
+	}
+	if state != nil {
+		result.Seq = state.ChangeSeq
+	}
+
+	return nil
+}
+
+// encodeSyncToken - кодирует позицию синхронизации в непрозрачную строку
+func encodeSyncToken(token models.SyncToken) (string, error) {
+	data, err := json.Marshal(token)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// decodeSyncToken - декодирует токен синхронизации, полученный от клиента
+func decodeSyncToken(value string) (*models.SyncToken, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, errors.ErrInvalidSyncToken
+	}
+
+	var token models.SyncToken
+	if err = json.Unmarshal(data, &token); err != nil || token.Seq < 0 || token.ID < 0 {
+		return nil, errors.ErrInvalidSyncToken
+	}
+
+	return &token, nil
+}
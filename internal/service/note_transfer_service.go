@@ -0,0 +1,578 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	goerrors "errors"
+	"fmt"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/errors"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/models"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/transport/dto/request"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/transport/dto/response"
+	"html"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxImportRows - максимальное количество заметок в одном файле импорта
+const maxImportRows = 5000
+
+// Статусы строк импорта
+const (
+	importStatusOK    = "ok"
+	importStatusError = "error"
+)
+
+// markdownDueMarker - отметка срока в Markdown-чек-листе (формат Obsidian Tasks: "- [ ] текст 📅 2006-01-02")
+const markdownDueMarker = "📅"
+
+// csvNoteHeader - заголовок CSV-экспорта
+var csvNoteHeader = []string{"note", "completed", "created_at", "due_at", "priority", "list", "rrule"}
+
+// csvColumnAliases - названия колонок CSV, которые принимаются при импорте (в том числе из других приложений)
+var csvColumnAliases = map[string]string{
+	"note":       "note",
+	"title":      "note",
+	"text":       "note",
+	"content":    "note",
+	"completed":  "completed",
+	"done":       "completed",
+	"created_at": "created_at",
+	"createdat":  "created_at",
+	"created":    "created_at",
+	"due_at":     "due_at",
+	"dueat":      "due_at",
+	"due":        "due_at",
+	"priority":   "priority",
+	"list":       "list",
+	"rrule":      "rrule",
+}
+
+// importRow - заметка, прочитанная из файла импорта (значения ещё не проверены)
+type importRow struct {
+	Row       int    `json:"-"` // Номер строки файла
+	Note      string `json:"note"`
+	Completed string `json:"-"`
+	CreatedAt string `json:"createdAt"`
+	DueAt     string `json:"dueAt"`
+	Priority  string `json:"priority"`
+	List      string `json:"list"`
+	RRule     string `json:"rrule"`
+}
+
+// parseNoteFormat - формат экспорта и импорта (по умолчанию json)
+func parseNoteFormat(format string) (string, error) {
+	switch format = strings.ToLower(strings.TrimSpace(format)); format {
+	case "":
+		return models.NoteFormatJSON, nil
+	case models.NoteFormatJSON, models.NoteFormatCSV, models.NoteFormatMarkdown:
+		return format, nil
+	default:
+		return "", errors.ErrInvalidNoteFormat
+	}
+}
+
+// ExportNotes - записать собственные заметки пользователя в w в формате json, csv или md.
+// Заметки читаются из БД потоком, поэтому размер выгрузки не ограничен памятью
+func (s *noteService) ExportNotes(ctx context.Context, userID int64, format string, w io.Writer) error {
+	if userID <= 0 {
+		return errors.ErrIDCannotBeNegativeOrEqualToZero
+	}
+
+	format, err := parseNoteFormat(format)
+	if err != nil {
+		return err
+	}
+
+	buf := bufio.NewWriter(w)
+
+	var write func(record models.NoteRecord) error
+	var finish func() error
+
+	switch format {
+	case models.NoteFormatJSON:
+		write, finish = s.jsonNoteWriter(buf)
+	case models.NoteFormatCSV:
+		write, finish = s.csvNoteWriter(buf)
+	default:
+		write, finish = s.markdownNoteWriter(buf)
+	}
+
+	err = s.repo.ExportNotesDB(ctx, userID, func(record models.NoteRecord) error {
+		// В БД текст хранится экранированным, в файле - в исходном виде (импорт экранирует его снова)
+		record.Note = html.UnescapeString(record.Note)
+		record.List = html.UnescapeString(record.List)
+		if record.CreatedAt != nil {
+			createdAt := record.CreatedAt.UTC()
+			record.CreatedAt = &createdAt
+		}
+		if record.DueAt != nil {
+			dueAt := record.DueAt.In(s.loc)
+			record.DueAt = &dueAt
+		}
+		return write(record)
+	})
+	if err != nil {
+		return err
+	}
+
+	if err = finish(); err != nil {
+		return err
+	}
+
+	return buf.Flush()
+}
+
+// jsonNoteWriter - экспорт в JSON-массив; элементы пишутся по одному
+func (s *noteService) jsonNoteWriter(w *bufio.Writer) (func(models.NoteRecord) error, func() error) {
+	first := true
+
+	write := func(record models.NoteRecord) error {
+		data, err := json.Marshal(record)
+		if err != nil {
+			return err
+		}
+
+		prefix := ",\n  "
+		if first {
+			prefix = "[\n  "
+			first = false
+		}
+
+		if _, err = w.WriteString(prefix); err != nil {
+			return err
+		}
+		_, err = w.Write(data)
+		return err
+	}
+
+	finish := func() error {
+		if first {
+			_, err := w.WriteString("[]\n")
+			return err
+		}
+		_, err := w.WriteString("\n]\n")
+		return err
+	}
+
+	return write, finish
+}
+
+// csvNoteWriter - экспорт в CSV с заголовком csvNoteHeader
+func (s *noteService) csvNoteWriter(w *bufio.Writer) (func(models.NoteRecord) error, func() error) {
+	cw := csv.NewWriter(w)
+	header := false
+
+	writeHeader := func() error {
+		if header {
+			return nil
+		}
+		header = true
+		return cw.Write(csvNoteHeader)
+	}
+
+	write := func(record models.NoteRecord) error {
+		if err := writeHeader(); err != nil {
+			return err
+		}
+
+		return cw.Write([]string{
+			record.Note,
+			strconv.FormatBool(record.Completed),
+			formatRecordTime(record.CreatedAt),
+			formatRecordTime(record.DueAt),
+			record.Priority,
+			record.List,
+			record.RRule,
+		})
+	}
+
+	finish := func() error {
+		if err := writeHeader(); err != nil {
+			return err
+		}
+		cw.Flush()
+		return cw.Error()
+	}
+
+	return write, finish
+}
+
+// markdownNoteWriter - экспорт в Markdown-чек-лист: заголовок "## " для каждого списка, заметки - пунктами "- [ ]"
+func (s *noteService) markdownNoteWriter(w *bufio.Writer) (func(models.NoteRecord) error, func() error) {
+	list := ""
+	started := false
+
+	write := func(record models.NoteRecord) error {
+		if !started || record.List != list {
+			if started {
+				if _, err := w.WriteString("\n"); err != nil {
+					return err
+				}
+			}
+			if _, err := fmt.Fprintf(w, "## %s\n\n", record.List); err != nil {
+				return err
+			}
+			list = record.List
+			started = true
+		}
+
+		mark := " "
+		if record.Completed {
+			mark = "x"
+		}
+
+		// Пункт чек-листа занимает одну строку
+		text := strings.Join(strings.Fields(record.Note), " ")
+		if _, err := fmt.Fprintf(w, "- [%s] %s", mark, text); err != nil {
+			return err
+		}
+		if record.DueAt != nil {
+			if _, err := fmt.Fprintf(w, " %s %s", markdownDueMarker, record.DueAt.Format("2006-01-02")); err != nil {
+				return err
+			}
+		}
+		_, err := w.WriteString("\n")
+		return err
+	}
+
+	finish := func() error {
+		return nil
+	}
+
+	return write, finish
+}
+
+// formatRecordTime - время в формате RFC3339 (пустая строка, если не задано)
+func formatRecordTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
+
+// ImportNotes - импорт заметок из файла. Каждая заметка проходит ту же валидацию, что и при создании через API.
+// Заметки с ошибками пропускаются и попадают в отчёт, остальные создаются в одной транзакции.
+// В режиме dryRun файл только проверяется
+func (s *noteService) ImportNotes(ctx context.Context, userID int64, r io.Reader, query request.ImportNotesDTO) (*response.ImportNotesDTO, error) {
+	if userID <= 0 {
+		return nil, errors.ErrIDCannotBeNegativeOrEqualToZero
+	}
+
+	format, err := parseNoteFormat(query.Format)
+	if err != nil {
+		return nil, err
+	}
+
+	dryRun := false
+	if query.DryRun != "" {
+		if dryRun, err = strconv.ParseBool(query.DryRun); err != nil {
+			return nil, fmt.Errorf("%w: dryRun", errors.ErrInvalidImportValue)
+		}
+	}
+
+	var targetListID int64
+	if query.ListID != "" {
+		if targetListID, err = strconv.ParseInt(query.ListID, 10, 64); err != nil || targetListID <= 0 {
+			return nil, errors.ErrIDCannotBeNegativeOrEqualToZero
+		}
+	}
+
+	var rows []importRow
+	switch format {
+	case models.NoteFormatJSON:
+		rows, err = parseJSONImport(r)
+	case models.NoteFormatCSV:
+		rows, err = parseCSVImport(r)
+	default:
+		rows, err = parseMarkdownImport(r)
+	}
+	var maxBytesErr *http.MaxBytesError
+	if goerrors.As(err, &maxBytesErr) {
+		return nil, errors.ErrImportFileTooLarge
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Заметки импортируются только в собственные списки пользователя (по названию)
+	lists, err := s.lists.GetAllListsFromDB(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	listIDs := make(map[string]int64, len(lists))
+	listNames := make(map[int64]string, len(lists))
+	for _, list := range lists {
+		name := html.UnescapeString(list.Name)
+		listIDs[strings.ToLower(name)] = list.ID
+		listNames[list.ID] = name
+		if targetListID == 0 && list.IsDefault {
+			targetListID = list.ID
+		}
+	}
+
+	if _, ok := listNames[targetListID]; targetListID > 0 && !ok {
+		return nil, errors.ErrListNotFound
+	}
+
+	// Списка по умолчанию ещё нет - создаём его, но не при проверке файла
+	if targetListID == 0 && !dryRun {
+		if targetListID, err = s.lists.GetDefaultListIDFromDB(ctx, userID); err != nil {
+			return nil, err
+		}
+		listNames[targetListID] = models.DefaultListName
+	}
+	if targetListID == 0 {
+		listNames[0] = models.DefaultListName
+	}
+
+	result := &response.ImportNotesDTO{
+		DryRun: dryRun,
+		Total:  len(rows),
+		Rows:   make([]response.ImportNoteRowDTO, 0, len(rows)),
+	}
+
+	now := time.Now().UTC()
+	notes := make([]models.AllNotes, 0, len(rows))
+
+	for _, row := range rows {
+		listID, ok := listIDs[strings.ToLower(strings.TrimSpace(row.List))]
+		if !ok {
+			listID = targetListID
+		}
+
+		report := response.ImportNoteRowDTO{
+			Row:    row.Row,
+			Note:   strings.TrimSpace(row.Note),
+			List:   listNames[listID],
+			Status: importStatusOK,
+		}
+
+		note, err := s.importNote(row, now)
+		if err != nil {
+			report.Status = importStatusError
+			report.Error = err.Error()
+			result.Rows = append(result.Rows, report)
+			continue
+		}
+
+		note.UserID = userID
+		note.ListID = listID
+		notes = append(notes, note)
+		result.Rows = append(result.Rows, report)
+	}
+
+	result.Valid = len(notes)
+
+	if dryRun || len(notes) == 0 {
+		return result, nil
+	}
+
+	if err = s.repo.ImportNotesDB(ctx, notes); err != nil {
+		return nil, err
+	}
+
+	result.Created = len(notes)
+	return result, nil
+}
+
+// importNote - проверка одной заметки из файла импорта
+func (s *noteService) importNote(row importRow, now time.Time) (models.AllNotes, error) {
+	note, err := s.validateNote(request.CreateNoteDTO{
+		Note:     row.Note,
+		DueAt:    row.DueAt,
+		Priority: row.Priority,
+		RRule:    row.RRule,
+	})
+	if err != nil {
+		return note, err
+	}
+
+	if value := strings.TrimSpace(row.Completed); value != "" {
+		if note.Completed, err = strconv.ParseBool(value); err != nil {
+			return note, fmt.Errorf("%w: completed", errors.ErrInvalidImportValue)
+		}
+	}
+
+	note.CreatedAt = now
+	if value := strings.TrimSpace(row.CreatedAt); value != "" {
+		createdAt, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return note, fmt.Errorf("%w: createdAt", errors.ErrInvalidImportValue)
+		}
+		note.CreatedAt = createdAt.UTC()
+	}
+
+	return note, nil
+}
+
+// checkImportRows - ограничение количества заметок в файле
+func checkImportRows(rows []importRow) error {
+	if len(rows) > maxImportRows {
+		return errors.ErrTooManyImportRows
+	}
+	return nil
+}
+
+// parseJSONImport - разбор JSON-массива заметок (формат экспорта)
+func parseJSONImport(r io.Reader) ([]importRow, error) {
+	dec := json.NewDecoder(r)
+
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('[') {
+		return nil, fmt.Errorf("%w: ожидается JSON-массив", errors.ErrInvalidImportFile)
+	}
+
+	rows := make([]importRow, 0)
+	for dec.More() {
+		var item struct {
+			importRow
+			Completed *bool `json:"completed"`
+		}
+		if err := dec.Decode(&item); err != nil {
+			return nil, fmt.Errorf("%w: элемент %d: %w", errors.ErrInvalidImportFile, len(rows)+1, err)
+		}
+
+		row := item.importRow
+		row.Row = len(rows) + 1
+		if item.Completed != nil {
+			row.Completed = strconv.FormatBool(*item.Completed)
+		}
+		rows = append(rows, row)
+
+		if err := checkImportRows(rows); err != nil {
+			return nil, err
+		}
+	}
+
+	if _, err := dec.Token(); err != nil {
+		return nil, fmt.Errorf("%w: %w", errors.ErrInvalidImportFile, err)
+	}
+
+	return rows, nil
+}
+
+// parseCSVImport - разбор CSV с заголовком; обязательна только колонка с текстом заметки
+func parseCSVImport(r io.Reader) ([]importRow, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+
+	header, err := cr.Read()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", errors.ErrInvalidImportFile, err)
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		if column, ok := csvColumnAliases[name]; ok {
+			if _, exists := columns[column]; !exists {
+				columns[column] = i
+			}
+		}
+	}
+
+	if _, ok := columns["note"]; !ok {
+		return nil, fmt.Errorf("%w: нет колонки note", errors.ErrInvalidImportFile)
+	}
+
+	field := func(record []string, column string) string {
+		i, ok := columns[column]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return record[i]
+	}
+
+	rows := make([]importRow, 0)
+	for {
+		record, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", errors.ErrInvalidImportFile, err)
+		}
+
+		line, _ := cr.FieldPos(0)
+		rows = append(rows, importRow{
+			Row:       line,
+			Note:      field(record, "note"),
+			Completed: field(record, "completed"),
+			CreatedAt: field(record, "created_at"),
+			DueAt:     field(record, "due_at"),
+			Priority:  field(record, "priority"),
+			List:      field(record, "list"),
+			RRule:     field(record, "rrule"),
+		})
+
+		if err = checkImportRows(rows); err != nil {
+			return nil, err
+		}
+	}
+
+	return rows, nil
+}
+
+// parseMarkdownImport - разбор Markdown-чек-листа: пункты "- [ ]" / "- [x]" (также "*" и "+"),
+// заголовки задают список для следующих пунктов, остальные строки пропускаются
+func parseMarkdownImport(r io.Reader) ([]importRow, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	rows := make([]importRow, 0)
+	list := ""
+	line := 0
+
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+
+		if strings.HasPrefix(text, "#") {
+			list = strings.TrimSpace(strings.TrimLeft(text, "#"))
+			continue
+		}
+
+		if len(text) < 2 || !strings.ContainsRune("-*+", rune(text[0])) {
+			continue
+		}
+
+		item := strings.TrimSpace(text[1:])
+		if len(item) < 3 || item[0] != '[' || item[2] != ']' {
+			continue
+		}
+
+		row := importRow{Row: line, List: list}
+		switch item[1] {
+		case ' ':
+			row.Completed = "false"
+		case 'x', 'X':
+			row.Completed = "true"
+		default:
+			continue
+		}
+
+		row.Note = strings.TrimSpace(item[3:])
+		if i := strings.LastIndex(row.Note, markdownDueMarker); i >= 0 {
+			row.DueAt = strings.TrimSpace(row.Note[i+len(markdownDueMarker):])
+			row.Note = strings.TrimSpace(row.Note[:i])
+		}
+
+		rows = append(rows, row)
+
+		if err := checkImportRows(rows); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %w", errors.ErrInvalidImportFile, err)
+	}
+
+	return rows, nil
+}
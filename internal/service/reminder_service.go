@@ -0,0 +1,225 @@
+package service
+
+import (
+	"context"
+	goerrors "errors"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/config"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/errors"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/models"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/notifier"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/repository"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/transport/dto/request"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/pkg/webhook"
+	"net/mail"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	defaultReminderBatchSize   = 50               // Сколько напоминаний захватывается за проход, если не задано в конфигурации
+	defaultReminderLease       = 2 * time.Minute  // Время захвата напоминания, если не задано в конфигурации
+	defaultReminderMaxAttempts = 5                // Количество попыток отправки, если не задано в конфигурации
+	defaultReminderBackoff     = time.Minute      // Задержка перед первой повторной попыткой, если не задана в конфигурации
+	maxReminderBackoff         = 24 * time.Hour   // Максимальная задержка между попытками
+	defaultWebhookTimeout      = 10 * time.Second // Таймаут webhook, если не задан в конфигурации
+	maxReminderTargetLength    = 2048             // Максимальная длина адреса доставки
+)
+
+// ReminderService - интерфейс для работы с напоминаниями
+type ReminderService interface {
+	GetReminders(ctx context.Context, userID, noteID int64) ([]models.Reminders, error)
+	CreateReminder(ctx context.Context, userID, noteID int64, req request.ReminderDTO) (*models.Reminders, error)
+	DeleteReminder(ctx context.Context, userID, noteID, id int64) error
+	ProcessDueReminders(ctx context.Context) (sent, failed int, err error)
+}
+
+type reminderService struct {
+	repo      repository.ReminderRepository
+	cfg       *config.Config
+	loc       *time.Location
+	notifiers map[string]notifier.Notifier // Каналы доставки по названию (email, webhook)
+}
+
+func NewReminderService(repo repository.ReminderRepository, cfg *config.Config) ReminderService {
+	webhookTimeout := cfg.Reminders.WebhookTimeout
+	if webhookTimeout <= 0 {
+		webhookTimeout = defaultWebhookTimeout
+	}
+
+	notifiers := map[string]notifier.Notifier{
+		models.ReminderChannelWebhook: notifier.NewWebhookNotifier(webhook.NewHTTPClient(webhookTimeout, cfg.Webhooks.AllowPrivateNetworks)),
+	}
+
+	// Email-напоминания доступны, только если настроен SMTP
+	if cfg.SMTP.Host != "" {
+		notifiers[models.ReminderChannelEmail] = notifier.NewSMTPNotifier(cfg.SMTP)
+	}
+
+	return &reminderService{
+		repo:      repo,
+		cfg:       cfg,
+		loc:       loadLocation(cfg.DB.TimeZone),
+		notifiers: notifiers,
+	}
+}
+
+// GetReminders - получаем напоминания заметки
+func (s *reminderService) GetReminders(ctx context.Context, userID, noteID int64) ([]models.Reminders, error) {
+	if noteID <= 0 || userID <= 0 {
+		return nil, errors.ErrIDCannotBeNegativeOrEqualToZero
+	}
+
+	return s.repo.GetRemindersFromDB(ctx, userID, noteID)
+}
+
+// CreateReminder - добавить напоминание к заметке, валидация данных
+func (s *reminderService) CreateReminder(ctx context.Context, userID, noteID int64, req request.ReminderDTO) (*models.Reminders, error) {
+	if noteID <= 0 || userID <= 0 {
+		return nil, errors.ErrIDCannotBeNegativeOrEqualToZero
+	}
+
+	remindAt, err := parseDueAt(req.RemindAt, s.loc)
+	if err != nil {
+		return nil, errors.ErrInvalidRemindAt
+	}
+
+	reminder := models.Reminders{
+		NoteID:   noteID,
+		UserID:   userID,
+		RemindAt: remindAt.UTC(),
+		Channel:  strings.ToLower(strings.TrimSpace(req.Channel)),
+		Target:   strings.TrimSpace(req.Target),
+	}
+
+	if reminder.Channel == "" {
+		reminder.Channel = models.ReminderChannelEmail
+	}
+
+	if len(reminder.Target) > maxReminderTargetLength {
+		return nil, errors.ErrInvalidTarget
+	}
+
+	switch reminder.Channel {
+	case models.ReminderChannelEmail:
+		// Напоминание уходит только на email владельца аккаунта: пустой адрес означает его email,
+		// указанный адрес репозиторий сверяет с email пользователя
+		if reminder.Target != "" {
+			address, err := mail.ParseAddress(reminder.Target)
+			if err != nil {
+				return nil, errors.ErrInvalidTarget
+			}
+			reminder.Target = address.Address
+		}
+	case models.ReminderChannelWebhook:
+		target, err := url.Parse(reminder.Target)
+		if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+			return nil, errors.ErrInvalidTarget
+		}
+	default:
+		return nil, errors.ErrInvalidChannel
+	}
+
+	if _, ok := s.notifiers[reminder.Channel]; !ok {
+		return nil, errors.ErrNotifierUnavailable
+	}
+
+	return s.repo.InsertReminderToDB(ctx, reminder)
+}
+
+// DeleteReminder - удалить напоминание, валидация данных
+func (s *reminderService) DeleteReminder(ctx context.Context, userID, noteID, id int64) error {
+	if noteID <= 0 || id <= 0 || userID <= 0 {
+		return errors.ErrIDCannotBeNegativeOrEqualToZero
+	}
+
+	return s.repo.DeleteReminderFromDB(ctx, userID, noteID, id)
+}
+
+// ProcessDueReminders - захватить напоминания, которые пора отправить, и отправить их.
+// Неудачные попытки записываются и повторяются с экспоненциальной задержкой. Вся пачка отправляется
+// в пределах захвата (leaseDeadline): напоминания, до которых не дошла очередь, возвращаются в очередь,
+// а результат записывается, только если захват всё ещё принадлежит этой реплике
+func (s *reminderService) ProcessDueReminders(ctx context.Context) (sent, failed int, err error) {
+	batchSize := s.cfg.Reminders.BatchSize
+	if batchSize <= 0 {
+		batchSize = defaultReminderBatchSize
+	}
+	lease := s.cfg.Reminders.Lease
+	if lease <= 0 {
+		lease = defaultReminderLease
+	}
+
+	claimedAt := time.Now()
+	reminders, err := s.repo.ClaimDueRemindersDB(ctx, batchSize, lease)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	batchCtx, cancel := context.WithDeadline(ctx, leaseDeadline(claimedAt, lease))
+	defer cancel()
+
+	for i, reminder := range reminders {
+		if batchCtx.Err() != nil {
+			return sent, failed, s.releaseReminders(ctx, reminders[i:])
+		}
+
+		if sendErr := s.send(batchCtx, reminder); sendErr != nil {
+			failed++
+			err = s.repo.MarkReminderFailedDB(ctx, reminder.ID, reminder.LockedUntil, sendErr.Error(), s.nextAttempt(reminder.Attempts))
+		} else {
+			sent++
+			err = s.repo.MarkReminderSentDB(ctx, reminder.ID, reminder.LockedUntil)
+		}
+
+		// Захват потерян - напоминание уже обрабатывает другая реплика, её результат не перезаписываем
+		if err != nil && !goerrors.Is(err, errors.ErrReminderLeaseLost) {
+			return sent, failed, err
+		}
+	}
+
+	return sent, failed, nil
+}
+
+// releaseReminders - вернуть в очередь напоминания, которые не успели отправить до конца захвата
+func (s *reminderService) releaseReminders(ctx context.Context, reminders []models.DueReminder) error {
+	for _, reminder := range reminders {
+		if err := s.repo.ReleaseReminderDB(ctx, reminder.ID, reminder.LockedUntil); err != nil && !goerrors.Is(err, errors.ErrReminderLeaseLost) {
+			return err
+		}
+	}
+	return nil
+}
+
+// send - отправить одно напоминание через его канал. ctx ограничен временем захвата пачки,
+// чтобы другая реплика не взяла напоминание, пока эта ещё ждёт ответа
+func (s *reminderService) send(ctx context.Context, reminder models.DueReminder) error {
+	channel, ok := s.notifiers[reminder.Channel]
+	if !ok {
+		return errors.ErrNotifierUnavailable
+	}
+
+	return channel.Notify(ctx, notifier.Message{
+		ReminderID: reminder.ID,
+		NoteID:     reminder.NoteID,
+		Note:       reminder.Note,
+		DueAt:      reminder.DueAt,
+		RemindAt:   reminder.RemindAt,
+		Target:     reminder.Target,
+	})
+}
+
+// nextAttempt - время следующей попытки после attempts неудачных. nil - попытки закончились
+func (s *reminderService) nextAttempt(attempts int) *time.Time {
+	maxAttempts := s.cfg.Reminders.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = defaultReminderMaxAttempts
+	}
+
+	backoff := s.cfg.Reminders.RetryBackoff
+	if backoff <= 0 {
+		backoff = defaultReminderBackoff
+	}
+
+	return nextRetryAt(attempts, maxAttempts, backoff, maxReminderBackoff)
+}
@@ -0,0 +1,103 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/config"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/errors"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/models"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/notifier"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/repository"
+)
+
+// fakeReminderRepo - очередь напоминаний в памяти; lost - напоминания, захват которых уже перешёл другой реплике
+type fakeReminderRepo struct {
+	repository.ReminderRepository
+	due      []models.DueReminder
+	lost     map[int64]bool
+	sent     []int64
+	failed   []int64
+	released []int64
+}
+
+func (f *fakeReminderRepo) ClaimDueRemindersDB(context.Context, int, time.Duration) ([]models.DueReminder, error) {
+	due := f.due
+	f.due = nil
+	return due, nil
+}
+
+func (f *fakeReminderRepo) MarkReminderSentDB(_ context.Context, id int64, _ time.Time) error {
+	if f.lost[id] {
+		return errors.ErrReminderLeaseLost
+	}
+	f.sent = append(f.sent, id)
+	return nil
+}
+
+func (f *fakeReminderRepo) MarkReminderFailedDB(_ context.Context, id int64, _ time.Time, _ string, _ *time.Time) error {
+	if f.lost[id] {
+		return errors.ErrReminderLeaseLost
+	}
+	f.failed = append(f.failed, id)
+	return nil
+}
+
+func (f *fakeReminderRepo) ReleaseReminderDB(_ context.Context, id int64, _ time.Time) error {
+	f.released = append(f.released, id)
+	return nil
+}
+
+// slowNotifier - канал, который отвечает через delay или по отмене ctx
+type slowNotifier struct {
+	delay time.Duration
+}
+
+func (n slowNotifier) Notify(ctx context.Context, _ notifier.Message) error {
+	select {
+	case <-time.After(n.delay):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func TestProcessDueRemindersLease(t *testing.T) {
+	reminder := func(id int64) models.DueReminder {
+		return models.DueReminder{Reminders: models.Reminders{ID: id, Channel: models.ReminderChannelWebhook, Attempts: 1}}
+	}
+
+	repo := &fakeReminderRepo{
+		due:  []models.DueReminder{reminder(1), reminder(2), reminder(3), reminder(4)},
+		lost: map[int64]bool{2: true},
+	}
+
+	cfg := &config.Config{}
+	cfg.Reminders.Lease = 500 * time.Millisecond
+	svc := &reminderService{
+		repo:      repo,
+		cfg:       cfg,
+		loc:       time.UTC,
+		notifiers: map[string]notifier.Notifier{models.ReminderChannelWebhook: slowNotifier{delay: 150 * time.Millisecond}},
+	}
+
+	// Пачка отправляется 400 мс (leaseDeadline): 1 и 2 успевают, 3 прерывается по сроку, 4 возвращается в очередь.
+	// Захват 2 уже потерян - его результат не записывается и не считается ошибкой
+	sent, failed, err := svc.ProcessDueReminders(context.Background())
+	if err != nil {
+		t.Fatalf("ProcessDueReminders: %v", err)
+	}
+	if sent != 2 || failed != 1 {
+		t.Errorf("sent = %d, failed = %d; want 2 and 1", sent, failed)
+	}
+	if len(repo.sent) != 1 || repo.sent[0] != 1 {
+		t.Errorf("marked sent = %v, want [1]", repo.sent)
+	}
+	if len(repo.failed) != 1 || repo.failed[0] != 3 {
+		t.Errorf("marked failed = %v, want [3]", repo.failed)
+	}
+	if len(repo.released) != 1 || repo.released[0] != 4 {
+		t.Errorf("released = %v, want [4]", repo.released)
+	}
+}
@@ -0,0 +1,33 @@
+package service
+
+import "time"
+
+// retryBackoff - задержка перед следующей попыткой после attempts неудачных:
+// начинается с base и удваивается с каждой попыткой, но не превышает max
+func retryBackoff(attempts int, base, max time.Duration) time.Duration {
+	backoff := base
+	for i := 1; i < attempts && backoff < max; i++ {
+		backoff *= 2
+	}
+	return min(backoff, max)
+}
+
+// nextRetryAt - время следующей попытки после attempts неудачных. nil - попытки закончились
+func nextRetryAt(attempts, maxAttempts int, base, max time.Duration) *time.Time {
+	if attempts >= maxAttempts {
+		return nil
+	}
+
+	next := time.Now().Add(retryBackoff(attempts, base, max))
+	return &next
+}
+
+// leaseReserveDivisor - какая часть захвата очереди остаётся на запись результата последней отправки
+const leaseReserveDivisor = 5
+
+// leaseDeadline - до какого времени реплика может отправлять пачку, захваченную в claimedAt на lease.
+// Последняя пятая часть захвата оставляется на запись результата: после истечения захвата
+// другая реплика может снова взять записи пачки и отправить их повторно
+func leaseDeadline(claimedAt time.Time, lease time.Duration) time.Time {
+	return claimedAt.Add(lease - lease/leaseReserveDivisor)
+}
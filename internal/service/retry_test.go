@@ -0,0 +1,40 @@
+package service
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRetryBackoff(t *testing.T) {
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{0, time.Minute},
+		{1, time.Minute},
+		{2, 2 * time.Minute},
+		{3, 4 * time.Minute},
+		{5, 10 * time.Minute}, // 16 минут ограничены max
+		{100, 10 * time.Minute},
+	}
+	for _, tt := range tests {
+		if got := retryBackoff(tt.attempts, time.Minute, 10*time.Minute); got != tt.want {
+			t.Errorf("retryBackoff(%d) = %v, want %v", tt.attempts, got, tt.want)
+		}
+	}
+}
+
+func TestNextRetryAt(t *testing.T) {
+	if next := nextRetryAt(5, 5, time.Minute, time.Hour); next != nil {
+		t.Errorf("attempts exhausted: got %v, want nil", next)
+	}
+
+	before := time.Now()
+	next := nextRetryAt(2, 5, time.Minute, time.Hour)
+	if next == nil {
+		t.Fatal("got nil, want retry time")
+	}
+	if d := next.Sub(before); d < 2*time.Minute || d > 2*time.Minute+time.Second {
+		t.Errorf("delay = %v, want ~2m", d)
+	}
+}
@@ -0,0 +1,196 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/config"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/errors"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/models"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/repository"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/transport/dto/request"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/transport/dto/response"
+	"time"
+)
+
+const (
+	shareLinkTokenBytes = 32 // Длина токена ссылки в байтах (256 бит)
+
+	minShareLinkPassword = 8  // Минимальная длина пароля ссылки
+	maxShareLinkPassword = 72 // Максимальная длина пароля ссылки (ограничение bcrypt)
+
+	defaultShareLinkPasswordAttempts = 5                // Неверных паролей подряд до блокировки, если не задано в конфигурации
+	defaultShareLinkLockout          = 15 * time.Minute // Время блокировки проверки пароля, если не задано в конфигурации
+)
+
+// ShareLinkService - интерфейс для работы с публичными ссылками на заметки
+type ShareLinkService interface {
+	CreateShareLink(ctx context.Context, userID, noteID int64, req request.ShareLinkDTO) (*response.ShareLinkDTO, error)
+	GetShareLinks(ctx context.Context, userID, noteID int64) ([]models.ShareLinks, error)
+	RevokeShareLink(ctx context.Context, userID, noteID, id int64) error
+	OpenShareLink(ctx context.Context, token, password string) (*response.PublicNoteDTO, error)
+}
+
+type shareLinkService struct {
+	repo repository.ShareLinkRepository
+	auth Authorizer
+	cfg  *config.Config
+	loc  *time.Location
+}
+
+func NewShareLinkService(repo repository.ShareLinkRepository, auth Authorizer, cfg *config.Config) ShareLinkService {
+	return &shareLinkService{
+		repo: repo,
+		auth: auth,
+		cfg:  cfg,
+		loc:  loadLocation(cfg.DB.TimeZone),
+	}
+}
+
+// CreateShareLink - создать ссылку на заметку (только владелец). Токен возвращается один раз,
+// в БД сохраняется только его SHA-256
+func (s *shareLinkService) CreateShareLink(ctx context.Context, userID, noteID int64, req request.ShareLinkDTO) (*response.ShareLinkDTO, error) {
+	link := models.ShareLinks{NoteID: noteID}
+
+	if req.ExpiresAt != "" {
+		expiresAt, err := parseDueAt(req.ExpiresAt, s.loc)
+		if err != nil || !expiresAt.After(time.Now()) {
+			return nil, errors.ErrInvalidShareLinkExpiry
+		}
+		expiresAt = expiresAt.UTC()
+		link.ExpiresAt = &expiresAt
+	}
+
+	if req.Password != "" {
+		if len(req.Password) < minShareLinkPassword || len(req.Password) > maxShareLinkPassword {
+			return nil, errors.ErrInvalidShareLinkPassword
+		}
+
+		hash, err := HashPassword(req.Password)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", errors.ErrShareLinkFailed, err)
+		}
+		link.PasswordHash = hash
+	}
+
+	ownerID, err := s.auth.AuthorizeNote(ctx, userID, noteID, PermissionManage)
+	if err != nil {
+		return nil, err
+	}
+	link.UserID = ownerID
+
+	raw := make([]byte, shareLinkTokenBytes)
+	if _, err = rand.Read(raw); err != nil {
+		return nil, fmt.Errorf("%w: %w", errors.ErrShareLinkFailed, err)
+	}
+	token := base64.RawURLEncoding.EncodeToString(raw)
+	link.TokenHash = hashShareLinkToken(token)
+
+	created, err := s.repo.InsertShareLinkDB(ctx, link)
+	if err != nil {
+		return nil, err
+	}
+
+	return &response.ShareLinkDTO{
+		ShareLinks: *created,
+		Token:      token,
+		Path:       "/s/" + token,
+	}, nil
+}
+
+// GetShareLinks - ссылки на заметку (только владелец)
+func (s *shareLinkService) GetShareLinks(ctx context.Context, userID, noteID int64) ([]models.ShareLinks, error) {
+	ownerID, err := s.auth.AuthorizeNote(ctx, userID, noteID, PermissionManage)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.repo.GetShareLinksFromDB(ctx, ownerID, noteID)
+}
+
+// RevokeShareLink - отозвать ссылку на заметку (только владелец)
+func (s *shareLinkService) RevokeShareLink(ctx context.Context, userID, noteID, id int64) error {
+	if id <= 0 {
+		return errors.ErrIDCannotBeNegativeOrEqualToZero
+	}
+
+	ownerID, err := s.auth.AuthorizeNote(ctx, userID, noteID, PermissionManage)
+	if err != nil {
+		return err
+	}
+
+	return s.repo.RevokeShareLinkDB(ctx, ownerID, noteID, id)
+}
+
+// OpenShareLink - открыть заметку по публичной ссылке (без авторизации). Каждое успешное открытие учитывается.
+// Попытки ввода пароля ограничены для каждой ссылки: после Config.ShareLinks.MaxPasswordAttempts неверных паролей подряд
+// проверка пароля блокируется на Config.ShareLinks.Lockout
+func (s *shareLinkService) OpenShareLink(ctx context.Context, token, password string) (*response.PublicNoteDTO, error) {
+	if token == "" {
+		return nil, errors.ErrShareLinkNotFound
+	}
+
+	link, err := s.repo.GetShareLinkByTokenDB(ctx, hashShareLinkToken(token))
+	if err != nil {
+		return nil, err
+	}
+
+	if link.ExpiresAt != nil && !link.ExpiresAt.After(time.Now()) {
+		return nil, errors.ErrShareLinkExpired
+	}
+
+	if link.HasPassword {
+		if err = s.reservePasswordAttempt(ctx, link.ID); err != nil {
+			return nil, err
+		}
+		if !CheckPasswordHash(password, link.PasswordHash) {
+			return nil, errors.ErrShareLinkPassword
+		}
+	}
+
+	note, items, err := s.repo.OpenShareLinkDB(ctx, link.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	public := &response.PublicNoteDTO{
+		Note:      note.Note,
+		Completed: note.Completed,
+		Priority:  note.Priority,
+		Items:     make([]response.PublicItemDTO, 0, len(items)),
+	}
+
+	if note.DueAt != nil {
+		dueAt := note.DueAt.In(s.loc)
+		public.DueAt = &dueAt
+	}
+
+	for _, item := range items {
+		public.Items = append(public.Items, response.PublicItemDTO{Text: item.Text, Completed: item.Completed})
+	}
+
+	return public, nil
+}
+
+// reservePasswordAttempt - учесть попытку ввода пароля ссылки с ограничениями из конфигурации
+func (s *shareLinkService) reservePasswordAttempt(ctx context.Context, id int64) error {
+	maxAttempts := s.cfg.ShareLinks.MaxPasswordAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = defaultShareLinkPasswordAttempts
+	}
+	lockout := s.cfg.ShareLinks.Lockout
+	if lockout <= 0 {
+		lockout = defaultShareLinkLockout
+	}
+
+	return s.repo.ReserveShareLinkAttemptDB(ctx, id, maxAttempts, lockout)
+}
+
+// hashShareLinkToken - SHA-256 токена ссылки. Токен случайный и длинный, поэтому соль не нужна
+func hashShareLinkToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
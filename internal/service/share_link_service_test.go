@@ -0,0 +1,144 @@
+package service
+
+import (
+	"context"
+	stdErrors "errors"
+	"testing"
+	"time"
+
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/config"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/errors"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/models"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/repository"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/transport/dto/request"
+)
+
+const shareLinkTestPassword = "correct horse"
+
+// fakeShareLinkRepo - одна ссылка с паролем; счётчик попыток ведётся так же, как в БД
+type fakeShareLinkRepo struct {
+	repository.ShareLinkRepository
+	link        models.ShareLinks
+	now         time.Time
+	opened      int
+	maxAttempts int
+	lockout     time.Duration
+}
+
+func newFakeShareLinkRepo(t *testing.T) *fakeShareLinkRepo {
+	hash, err := HashPassword(shareLinkTestPassword)
+	if err != nil {
+		t.Fatalf("HashPassword: %v", err)
+	}
+	return &fakeShareLinkRepo{
+		link: models.ShareLinks{ID: 1, NoteID: sharedNote, PasswordHash: hash, HasPassword: true},
+		now:  time.Now(),
+	}
+}
+
+func (f *fakeShareLinkRepo) GetShareLinkByTokenDB(_ context.Context, tokenHash string) (*models.ShareLinks, error) {
+	if tokenHash != hashShareLinkToken("token") {
+		return nil, errors.ErrShareLinkNotFound
+	}
+	link := f.link
+	return &link, nil
+}
+
+func (f *fakeShareLinkRepo) ReserveShareLinkAttemptDB(_ context.Context, _ int64, maxAttempts int, lockout time.Duration) error {
+	f.maxAttempts, f.lockout = maxAttempts, lockout
+
+	if f.link.LockedUntil != nil {
+		if f.link.LockedUntil.After(f.now) {
+			return errors.ErrShareLinkLocked
+		}
+		f.link.FailedAttempts, f.link.LockedUntil = 0, nil
+	}
+
+	f.link.FailedAttempts++
+	if f.link.FailedAttempts >= maxAttempts {
+		lockedUntil := f.now.Add(lockout)
+		f.link.LockedUntil = &lockedUntil
+	}
+	return nil
+}
+
+func (f *fakeShareLinkRepo) OpenShareLinkDB(_ context.Context, _ int64) (*models.AllNotes, []models.NoteItems, error) {
+	f.opened++
+	f.link.FailedAttempts = 0
+	return &models.AllNotes{ID: sharedNote, Note: "по ссылке"}, nil, nil
+}
+
+func TestOpenShareLinkLockout(t *testing.T) {
+	ctx := context.Background()
+	repo := newFakeShareLinkRepo(t)
+	cfg := &config.Config{}
+	cfg.ShareLinks.MaxPasswordAttempts = 3
+	cfg.ShareLinks.Lockout = time.Minute
+	svc := NewShareLinkService(repo, nil, cfg)
+
+	// Верный пароль сбрасывает счётчик неверных
+	if _, err := svc.OpenShareLink(ctx, "token", "wrong password"); !stdErrors.Is(err, errors.ErrShareLinkPassword) {
+		t.Fatalf("wrong password: err = %v, want %v", err, errors.ErrShareLinkPassword)
+	}
+	if _, err := svc.OpenShareLink(ctx, "token", shareLinkTestPassword); err != nil {
+		t.Fatalf("correct password: %v", err)
+	}
+	if repo.link.FailedAttempts != 0 {
+		t.Errorf("FailedAttempts after success = %d, want 0", repo.link.FailedAttempts)
+	}
+
+	for i := 0; i < cfg.ShareLinks.MaxPasswordAttempts; i++ {
+		if _, err := svc.OpenShareLink(ctx, "token", "wrong password"); !stdErrors.Is(err, errors.ErrShareLinkPassword) {
+			t.Fatalf("attempt %d: err = %v, want %v", i+1, err, errors.ErrShareLinkPassword)
+		}
+	}
+
+	// Во время блокировки не помогает и верный пароль
+	if _, err := svc.OpenShareLink(ctx, "token", shareLinkTestPassword); !stdErrors.Is(err, errors.ErrShareLinkLocked) {
+		t.Fatalf("locked: err = %v, want %v", err, errors.ErrShareLinkLocked)
+	}
+	if repo.opened != 1 {
+		t.Errorf("link opened %d times, want 1", repo.opened)
+	}
+
+	repo.now = repo.now.Add(cfg.ShareLinks.Lockout)
+	if _, err := svc.OpenShareLink(ctx, "token", shareLinkTestPassword); err != nil {
+		t.Fatalf("after lockout: %v", err)
+	}
+}
+
+func TestOpenShareLinkDefaultLimits(t *testing.T) {
+	repo := newFakeShareLinkRepo(t)
+	svc := NewShareLinkService(repo, nil, &config.Config{})
+
+	if _, err := svc.OpenShareLink(context.Background(), "token", shareLinkTestPassword); err != nil {
+		t.Fatalf("OpenShareLink: %v", err)
+	}
+	if repo.maxAttempts != defaultShareLinkPasswordAttempts || repo.lockout != defaultShareLinkLockout {
+		t.Errorf("limits = %d, %v, want %d, %v", repo.maxAttempts, repo.lockout, defaultShareLinkPasswordAttempts, defaultShareLinkLockout)
+	}
+}
+
+func TestOpenShareLinkWithoutPassword(t *testing.T) {
+	repo := newFakeShareLinkRepo(t)
+	repo.link.PasswordHash, repo.link.HasPassword = "", false
+	svc := NewShareLinkService(repo, nil, &config.Config{})
+
+	if _, err := svc.OpenShareLink(context.Background(), "token", ""); err != nil {
+		t.Fatalf("OpenShareLink: %v", err)
+	}
+	if repo.maxAttempts != 0 {
+		t.Error("password attempt reserved for a link without password")
+	}
+}
+
+func TestCreateShareLinkPasswordLength(t *testing.T) {
+	svc := NewShareLinkService(newFakeShareLinkRepo(t), nil, &config.Config{})
+
+	for _, password := range []string{"1234", "1234567"} {
+		_, err := svc.CreateShareLink(context.Background(), ownerUser, sharedNote, request.ShareLinkDTO{Password: password})
+		if !stdErrors.Is(err, errors.ErrInvalidShareLinkPassword) {
+			t.Errorf("password %q: err = %v, want %v", password, err, errors.ErrInvalidShareLinkPassword)
+		}
+	}
+}
@@ -0,0 +1,187 @@
+package service
+
+import (
+	"context"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/config"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/errors"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/models"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/repository"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/transport/dto/request"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/transport/dto/response"
+	"strings"
+	"time"
+)
+
+// ShareService - интерфейс для управления совместным доступом к заметкам и спискам
+type ShareService interface {
+	ShareNote(ctx context.Context, userID, noteID int64, req request.ShareDTO) error
+	GetNoteShares(ctx context.Context, userID, noteID int64) ([]models.Shares, error)
+	RevokeNoteShare(ctx context.Context, userID, noteID, targetID int64) error
+	ShareList(ctx context.Context, userID, listID int64, req request.ShareDTO) error
+	GetListShares(ctx context.Context, userID, listID int64) ([]models.Shares, error)
+	RevokeListShare(ctx context.Context, userID, listID, targetID int64) error
+	GetSharedWithMe(ctx context.Context, userID int64) (*response.SharedWithMeDTO, error)
+}
+
+type shareService struct {
+	repo  repository.ShareRepository
+	notes repository.NoteRepository
+	lists repository.ListRepository
+	auth  Authorizer
+	cfg   *config.Config
+	loc   *time.Location
+}
+
+func NewShareService(repo repository.ShareRepository, notes repository.NoteRepository, lists repository.ListRepository, auth Authorizer, cfg *config.Config) ShareService {
+	return &shareService{
+		repo:  repo,
+		notes: notes,
+		lists: lists,
+		auth:  auth,
+		cfg:   cfg,
+		loc:   loadLocation(cfg.DB.TimeZone),
+	}
+}
+
+// ShareNote - открыть доступ к заметке другому пользователю (только владелец)
+func (s *shareService) ShareNote(ctx context.Context, userID, noteID int64, req request.ShareDTO) error {
+	ownerID, err := s.auth.AuthorizeNote(ctx, userID, noteID, PermissionManage)
+	if err != nil {
+		return err
+	}
+
+	targetID, role, err := s.resolveShare(ctx, ownerID, req)
+	if err != nil {
+		return err
+	}
+
+	return s.repo.UpsertNoteShareDB(ctx, noteID, targetID, role)
+}
+
+// GetNoteShares - пользователи, которым открыт доступ к заметке (только владелец)
+func (s *shareService) GetNoteShares(ctx context.Context, userID, noteID int64) ([]models.Shares, error) {
+	if _, err := s.auth.AuthorizeNote(ctx, userID, noteID, PermissionManage); err != nil {
+		return nil, err
+	}
+
+	return s.repo.GetNoteSharesFromDB(ctx, noteID)
+}
+
+// RevokeNoteShare - закрыть доступ к заметке. Владелец закрывает доступ любому пользователю,
+// остальные могут только отказаться от собственного доступа
+func (s *shareService) RevokeNoteShare(ctx context.Context, userID, noteID, targetID int64) error {
+	if targetID <= 0 {
+		return errors.ErrIDCannotBeNegativeOrEqualToZero
+	}
+
+	perm := PermissionManage
+	if targetID == userID {
+		perm = PermissionRead
+	}
+
+	if _, err := s.auth.AuthorizeNote(ctx, userID, noteID, perm); err != nil {
+		return err
+	}
+
+	return s.repo.DeleteNoteShareDB(ctx, noteID, targetID)
+}
+
+// ShareList - открыть доступ ко всем заметкам списка другому пользователю (только владелец)
+func (s *shareService) ShareList(ctx context.Context, userID, listID int64, req request.ShareDTO) error {
+	ownerID, err := s.auth.AuthorizeList(ctx, userID, listID, PermissionManage)
+	if err != nil {
+		return err
+	}
+
+	targetID, role, err := s.resolveShare(ctx, ownerID, req)
+	if err != nil {
+		return err
+	}
+
+	return s.repo.UpsertListShareDB(ctx, listID, targetID, role)
+}
+
+// GetListShares - пользователи, которым открыт доступ к списку (только владелец)
+func (s *shareService) GetListShares(ctx context.Context, userID, listID int64) ([]models.Shares, error) {
+	if _, err := s.auth.AuthorizeList(ctx, userID, listID, PermissionManage); err != nil {
+		return nil, err
+	}
+
+	return s.repo.GetListSharesFromDB(ctx, listID)
+}
+
+// RevokeListShare - закрыть доступ к списку (владелец - любому пользователю, остальные - только себе)
+func (s *shareService) RevokeListShare(ctx context.Context, userID, listID, targetID int64) error {
+	if targetID <= 0 {
+		return errors.ErrIDCannotBeNegativeOrEqualToZero
+	}
+
+	perm := PermissionManage
+	if targetID == userID {
+		perm = PermissionRead
+	}
+
+	if _, err := s.auth.AuthorizeList(ctx, userID, listID, perm); err != nil {
+		return err
+	}
+
+	return s.repo.DeleteListShareDB(ctx, listID, targetID)
+}
+
+// GetSharedWithMe - заметки и списки других пользователей, к которым пользователю открыт доступ
+func (s *shareService) GetSharedWithMe(ctx context.Context, userID int64) (*response.SharedWithMeDTO, error) {
+	if userID <= 0 {
+		return nil, errors.ErrIDCannotBeNegativeOrEqualToZero
+	}
+
+	notes, err := s.notes.GetSharedNotesFromDB(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range notes {
+		if notes[i].DueAt != nil {
+			dueAt := notes[i].DueAt.In(s.loc)
+			notes[i].DueAt = &dueAt
+		}
+	}
+
+	lists, err := s.lists.GetSharedListsFromDB(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &response.SharedWithMeDTO{
+		Notes: notes,
+		Lists: lists,
+	}, nil
+}
+
+// resolveShare - проверяем роль и находим пользователя, которому открывается доступ
+func (s *shareService) resolveShare(ctx context.Context, ownerID int64, req request.ShareDTO) (int64, string, error) {
+	role := strings.ToLower(strings.TrimSpace(req.Role))
+	switch role {
+	case "":
+		role = models.ShareRoleViewer
+	case models.ShareRoleViewer, models.ShareRoleEditor:
+	default:
+		return 0, "", errors.ErrInvalidShareRole
+	}
+
+	login := strings.TrimSpace(req.User)
+	if login == "" {
+		return 0, "", errors.ErrInvalidShareUser
+	}
+
+	user, err := s.repo.FindUserByLoginDB(ctx, login)
+	if err != nil {
+		return 0, "", err
+	}
+
+	// Владелец и так имеет полный доступ
+	if user.ID == ownerID {
+		return 0, "", errors.ErrInvalidShareUser
+	}
+
+	return user.ID, role, nil
+}
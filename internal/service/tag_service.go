@@ -0,0 +1,113 @@
+package service
+
+import (
+	"context"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/config"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/errors"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/models"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/repository"
+	"html"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxTagNameLength - максимальная длина названия метки
+const maxTagNameLength = 50
+
+// TagService - интерфейс для работы с бизнес-логикой меток
+type TagService interface {
+	GetAllTags(ctx context.Context, userID int64) ([]models.Tags, error)
+	CreateTag(ctx context.Context, userID int64, name string) (*models.Tags, error)
+	UpdateTag(ctx context.Context, userID, id int64, name string) error
+	DeleteTag(ctx context.Context, userID, id int64) error
+	AttachTag(ctx context.Context, userID, noteID, tagID int64) error
+	DetachTag(ctx context.Context, userID, noteID, tagID int64) error
+}
+
+type tagService struct {
+	repo repository.TagRepository
+	cfg  *config.Config
+}
+
+func NewTagService(repo repository.TagRepository, cfg *config.Config) TagService {
+	return &tagService{
+		repo: repo,
+		cfg:  cfg,
+	}
+}
+
+// GetAllTags - получаем все метки пользователя
+func (s *tagService) GetAllTags(ctx context.Context, userID int64) ([]models.Tags, error) {
+	if userID <= 0 {
+		return nil, errors.ErrIDCannotBeNegativeOrEqualToZero
+	}
+
+	return s.repo.GetAllTagsFromDB(ctx, userID)
+}
+
+// CreateTag - создать метку, валидация данных
+func (s *tagService) CreateTag(ctx context.Context, userID int64, name string) (*models.Tags, error) {
+	if userID <= 0 {
+		return nil, errors.ErrIDCannotBeNegativeOrEqualToZero
+	}
+
+	name, err := validateTagName(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.repo.InsertTagToDB(ctx, userID, name)
+}
+
+// UpdateTag - переименовать метку, валидация данных
+func (s *tagService) UpdateTag(ctx context.Context, userID, id int64, name string) error {
+	if id <= 0 || userID <= 0 {
+		return errors.ErrIDCannotBeNegativeOrEqualToZero
+	}
+
+	name, err := validateTagName(name)
+	if err != nil {
+		return err
+	}
+
+	return s.repo.UpdateTagToDB(ctx, userID, id, name)
+}
+
+// DeleteTag - удалить метку, валидация данных
+func (s *tagService) DeleteTag(ctx context.Context, userID, id int64) error {
+	if id <= 0 || userID <= 0 {
+		return errors.ErrIDCannotBeNegativeOrEqualToZero
+	}
+
+	return s.repo.DeleteTagFromDB(ctx, userID, id)
+}
+
+// AttachTag - прикрепить метку к заметке, валидация данных
+func (s *tagService) AttachTag(ctx context.Context, userID, noteID, tagID int64) error {
+	if noteID <= 0 || tagID <= 0 || userID <= 0 {
+		return errors.ErrIDCannotBeNegativeOrEqualToZero
+	}
+
+	return s.repo.AttachTagToNoteDB(ctx, userID, noteID, tagID)
+}
+
+// DetachTag - открепить метку от заметки, валидация данных
+func (s *tagService) DetachTag(ctx context.Context, userID, noteID, tagID int64) error {
+	if noteID <= 0 || tagID <= 0 || userID <= 0 {
+		return errors.ErrIDCannotBeNegativeOrEqualToZero
+	}
+
+	return s.repo.DetachTagFromNoteDB(ctx, userID, noteID, tagID)
+}
+
+// validateTagName - очищаем и проверяем название метки
+func validateTagName(name string) (string, error) {
+	name = html.EscapeString(strings.TrimSpace(name))
+
+	length := utf8.RuneCountInString(name)
+	if length == 0 || length > maxTagNameLength {
+		return "", errors.ErrInvalidTagName
+	}
+
+	return name, nil
+}
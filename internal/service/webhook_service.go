@@ -0,0 +1,318 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	goerrors "errors"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/config"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/errors"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/models"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/repository"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/transport/dto/request"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/pkg/webhook"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	maxWebhooksPerUser            = 10                  // Максимальное количество подписок у пользователя
+	maxWebhookURLLength           = 2048                // Максимальная длина адреса получателя
+	webhookSecretBytes            = 32                  // Длина секрета подписи в байтах
+	defaultWebhookDeliveriesLimit = 50                  // Сколько доставок возвращается в журнале по умолчанию
+	maxWebhookDeliveriesLimit     = 200                 // Максимальное количество доставок в журнале за запрос
+	defaultWebhookBatchSize       = 50                  // Сколько доставок захватывается за проход, если не задано в конфигурации
+	defaultWebhookLease           = time.Minute         // Время захвата доставки, если не задано в конфигурации
+	defaultWebhookSendTimeout     = 10 * time.Second    // Таймаут запроса к получателю, если не задан в конфигурации
+	defaultWebhookMaxAttempts     = 8                   // Количество попыток доставки, если не задано в конфигурации
+	defaultWebhookBackoff         = 30 * time.Second    // Задержка перед первой повторной попыткой, если не задана в конфигурации
+	maxWebhookBackoff             = 6 * time.Hour       // Максимальная задержка между попытками
+	defaultWebhookRetention       = 30 * 24 * time.Hour // Срок хранения журнала доставок, если не задан в конфигурации
+)
+
+// webhookEvents - типы событий, на которые можно подписаться
+var webhookEvents = []string{
+	models.WebhookEventNoteCreated,
+	models.WebhookEventNoteUpdated,
+	models.WebhookEventNoteCompleted,
+	models.WebhookEventNoteDeleted,
+}
+
+// WebhookService - интерфейс для работы с исходящими webhook
+type WebhookService interface {
+	GetWebhooks(ctx context.Context, userID int64) ([]models.Webhooks, error)
+	CreateWebhook(ctx context.Context, userID int64, req request.WebhookDTO) (*models.Webhooks, error)
+	UpdateWebhook(ctx context.Context, userID, id int64, req request.WebhookDTO) (*models.Webhooks, error)
+	DeleteWebhook(ctx context.Context, userID, id int64) error
+	PingWebhook(ctx context.Context, userID, id int64) (*models.WebhookDeliveries, error)
+	GetWebhookDeliveries(ctx context.Context, userID, id int64, limit int) ([]models.WebhookDeliveries, error)
+	RedeliverWebhook(ctx context.Context, userID, id, deliveryID int64) (*models.WebhookDeliveries, error)
+	ProcessWebhookDeliveries(ctx context.Context) (delivered, failed int, err error)
+	PurgeWebhookDeliveries(ctx context.Context) (int64, error)
+}
+
+type webhookService struct {
+	repo   repository.WebhookRepository
+	cfg    *config.Config
+	client *webhook.Client
+}
+
+func NewWebhookService(repo repository.WebhookRepository, cfg *config.Config) WebhookService {
+	timeout := cfg.Webhooks.Timeout
+	if timeout <= 0 {
+		timeout = defaultWebhookSendTimeout
+	}
+
+	return &webhookService{
+		repo:   repo,
+		cfg:    cfg,
+		client: webhook.NewClient(webhook.NewHTTPClient(timeout, cfg.Webhooks.AllowPrivateNetworks)),
+	}
+}
+
+// GetWebhooks - получаем подписки пользователя (без секретов)
+func (s *webhookService) GetWebhooks(ctx context.Context, userID int64) ([]models.Webhooks, error) {
+	if userID <= 0 {
+		return nil, errors.ErrIDCannotBeNegativeOrEqualToZero
+	}
+
+	webhooks, err := s.repo.GetWebhooksFromDB(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range webhooks {
+		webhooks[i].Secret = ""
+	}
+
+	return webhooks, nil
+}
+
+// CreateWebhook - создать подписку, валидация данных. Секрет подписи возвращается только здесь
+func (s *webhookService) CreateWebhook(ctx context.Context, userID int64, req request.WebhookDTO) (*models.Webhooks, error) {
+	if userID <= 0 {
+		return nil, errors.ErrIDCannotBeNegativeOrEqualToZero
+	}
+
+	hook, err := newWebhook(req)
+	if err != nil {
+		return nil, err
+	}
+
+	secret := make([]byte, webhookSecretBytes)
+	if _, err = rand.Read(secret); err != nil {
+		return nil, errors.ErrWebhookFailed
+	}
+
+	hook.UserID = userID
+	hook.Secret = "whsec_" + hex.EncodeToString(secret)
+
+	return s.repo.InsertWebhookToDB(ctx, hook, maxWebhooksPerUser)
+}
+
+// UpdateWebhook - изменить подписку, валидация данных
+func (s *webhookService) UpdateWebhook(ctx context.Context, userID, id int64, req request.WebhookDTO) (*models.Webhooks, error) {
+	if id <= 0 || userID <= 0 {
+		return nil, errors.ErrIDCannotBeNegativeOrEqualToZero
+	}
+
+	hook, err := newWebhook(req)
+	if err != nil {
+		return nil, err
+	}
+
+	hook.ID = id
+	hook.UserID = userID
+
+	updated, err := s.repo.UpdateWebhookToDB(ctx, hook)
+	if err != nil {
+		return nil, err
+	}
+
+	updated.Secret = ""
+	return updated, nil
+}
+
+// DeleteWebhook - удалить подписку, валидация данных
+func (s *webhookService) DeleteWebhook(ctx context.Context, userID, id int64) error {
+	if id <= 0 || userID <= 0 {
+		return errors.ErrIDCannotBeNegativeOrEqualToZero
+	}
+
+	return s.repo.DeleteWebhookFromDB(ctx, userID, id)
+}
+
+// PingWebhook - поставить в очередь проверочную доставку
+func (s *webhookService) PingWebhook(ctx context.Context, userID, id int64) (*models.WebhookDeliveries, error) {
+	if id <= 0 || userID <= 0 {
+		return nil, errors.ErrIDCannotBeNegativeOrEqualToZero
+	}
+
+	payload, err := json.Marshal(map[string]any{
+		"event":      models.WebhookEventPing,
+		"occurredAt": time.Now().UTC(),
+		"data":       map[string]any{"webhookID": id},
+	})
+	if err != nil {
+		return nil, errors.ErrWebhookFailed
+	}
+
+	return s.repo.InsertWebhookDeliveryToDB(ctx, userID, id, models.WebhookEventPing, payload)
+}
+
+// GetWebhookDeliveries - журнал доставок подписки
+func (s *webhookService) GetWebhookDeliveries(ctx context.Context, userID, id int64, limit int) ([]models.WebhookDeliveries, error) {
+	if id <= 0 || userID <= 0 {
+		return nil, errors.ErrIDCannotBeNegativeOrEqualToZero
+	}
+
+	if limit <= 0 {
+		limit = defaultWebhookDeliveriesLimit
+	}
+	limit = min(limit, maxWebhookDeliveriesLimit)
+
+	return s.repo.GetWebhookDeliveriesFromDB(ctx, userID, id, limit)
+}
+
+// RedeliverWebhook - повторно отправить доставку из журнала
+func (s *webhookService) RedeliverWebhook(ctx context.Context, userID, id, deliveryID int64) (*models.WebhookDeliveries, error) {
+	if id <= 0 || deliveryID <= 0 || userID <= 0 {
+		return nil, errors.ErrIDCannotBeNegativeOrEqualToZero
+	}
+
+	return s.repo.RedeliverWebhookDB(ctx, userID, id, deliveryID)
+}
+
+// ProcessWebhookDeliveries - захватить доставки, которые пора отправить, и отправить их.
+// Неудачные попытки записываются в журнал и повторяются с экспоненциальной задержкой. Вся пачка
+// отправляется в пределах захвата (leaseDeadline): доставки, до которых не дошла очередь, возвращаются
+// в очередь, а результат записывается, только если захват всё ещё принадлежит этой реплике
+func (s *webhookService) ProcessWebhookDeliveries(ctx context.Context) (delivered, failed int, err error) {
+	batchSize := s.cfg.Webhooks.BatchSize
+	if batchSize <= 0 {
+		batchSize = defaultWebhookBatchSize
+	}
+	lease := s.cfg.Webhooks.Lease
+	if lease <= 0 {
+		lease = defaultWebhookLease
+	}
+
+	claimedAt := time.Now()
+	deliveries, err := s.repo.ClaimWebhookDeliveriesDB(ctx, batchSize, lease)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	batchCtx, cancel := context.WithDeadline(ctx, leaseDeadline(claimedAt, lease))
+	defer cancel()
+
+	for i, delivery := range deliveries {
+		if batchCtx.Err() != nil {
+			return delivered, failed, s.releaseDeliveries(ctx, deliveries[i:])
+		}
+
+		attempt, sendErr := s.send(batchCtx, delivery)
+		if sendErr != nil {
+			failed++
+			err = s.repo.MarkWebhookDeliveryFailedDB(ctx, delivery.ID, delivery.LockedUntil, attempt, sendErr.Error(), s.nextAttempt(delivery.Attempts))
+		} else {
+			delivered++
+			err = s.repo.MarkWebhookDeliveredDB(ctx, delivery.ID, delivery.LockedUntil, attempt)
+		}
+
+		// Захват потерян - доставку уже обрабатывает другая реплика, её результат не перезаписываем
+		if err != nil && !goerrors.Is(err, errors.ErrWebhookLeaseLost) {
+			return delivered, failed, err
+		}
+	}
+
+	return delivered, failed, nil
+}
+
+// releaseDeliveries - вернуть в очередь доставки, которые не успели отправить до конца захвата
+func (s *webhookService) releaseDeliveries(ctx context.Context, deliveries []models.DueWebhookDelivery) error {
+	for _, delivery := range deliveries {
+		if err := s.repo.ReleaseWebhookDeliveryDB(ctx, delivery.ID, delivery.LockedUntil); err != nil && !goerrors.Is(err, errors.ErrWebhookLeaseLost) {
+			return err
+		}
+	}
+	return nil
+}
+
+// PurgeWebhookDeliveries - удалить из журнала завершённые доставки старше срока хранения
+func (s *webhookService) PurgeWebhookDeliveries(ctx context.Context) (int64, error) {
+	retention := s.cfg.Webhooks.Retention
+	if retention <= 0 {
+		retention = defaultWebhookRetention
+	}
+
+	return s.repo.PurgeWebhookDeliveriesDB(ctx, time.Now().Add(-retention))
+}
+
+// send - отправить одну доставку. ctx ограничен временем захвата пачки,
+// чтобы другая реплика не взяла доставку, пока эта ещё ждёт ответа
+func (s *webhookService) send(ctx context.Context, delivery models.DueWebhookDelivery) (models.WebhookAttempt, error) {
+	resp, err := s.client.Send(ctx, webhook.Request{
+		URL:        delivery.URL,
+		Secret:     delivery.Secret,
+		Event:      delivery.Event,
+		DeliveryID: delivery.ID,
+		Body:       delivery.Payload,
+	})
+
+	attempt := models.WebhookAttempt{
+		ResponseBody: resp.Body,
+		DurationMs:   int(resp.Duration.Milliseconds()),
+	}
+	if resp.StatusCode != 0 {
+		status := resp.StatusCode
+		attempt.ResponseStatus = &status
+	}
+
+	return attempt, err
+}
+
+// nextAttempt - время следующей попытки после attempts неудачных. nil - попытки закончились
+func (s *webhookService) nextAttempt(attempts int) *time.Time {
+	maxAttempts := s.cfg.Webhooks.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = defaultWebhookMaxAttempts
+	}
+
+	backoff := s.cfg.Webhooks.RetryBackoff
+	if backoff <= 0 {
+		backoff = defaultWebhookBackoff
+	}
+
+	return nextRetryAt(attempts, maxAttempts, backoff, maxWebhookBackoff)
+}
+
+// newWebhook - проверить адрес и фильтр событий подписки
+func newWebhook(req request.WebhookDTO) (models.Webhooks, error) {
+	hook := models.Webhooks{
+		URL:    strings.TrimSpace(req.URL),
+		Events: make([]string, 0, len(req.Events)),
+		Active: req.Active == nil || *req.Active,
+	}
+
+	target, err := url.Parse(hook.URL)
+	if err != nil || len(hook.URL) > maxWebhookURLLength ||
+		(target.Scheme != "http" && target.Scheme != "https") || target.Hostname() == "" || target.User != nil {
+		return hook, errors.ErrInvalidWebhookURL
+	}
+
+	for _, event := range req.Events {
+		event = strings.ToLower(strings.TrimSpace(event))
+		if !slices.Contains(webhookEvents, event) {
+			return hook, errors.ErrInvalidWebhookEvent
+		}
+		if !slices.Contains(hook.Events, event) {
+			hook.Events = append(hook.Events, event)
+		}
+	}
+
+	return hook, nil
+}
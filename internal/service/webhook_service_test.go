@@ -0,0 +1,161 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/config"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/errors"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/models"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/repository"
+)
+
+// fakeWebhookRepo - очередь доставок в памяти; остальные методы WebhookRepository не используются
+type fakeWebhookRepo struct {
+	repository.WebhookRepository
+	due       []models.DueWebhookDelivery
+	delivered []int64
+	failed    map[int64]*time.Time // ID доставки -> время следующей попытки (nil - попытки закончились)
+	lost      map[int64]bool       // Доставки, захват которых уже перешёл другой реплике
+	released  []int64
+}
+
+func (f *fakeWebhookRepo) ClaimWebhookDeliveriesDB(context.Context, int, time.Duration) ([]models.DueWebhookDelivery, error) {
+	due := f.due
+	f.due = nil
+	return due, nil
+}
+
+func (f *fakeWebhookRepo) MarkWebhookDeliveredDB(_ context.Context, id int64, _ time.Time, _ models.WebhookAttempt) error {
+	if f.lost[id] {
+		return errors.ErrWebhookLeaseLost
+	}
+	f.delivered = append(f.delivered, id)
+	return nil
+}
+
+func (f *fakeWebhookRepo) MarkWebhookDeliveryFailedDB(_ context.Context, id int64, _ time.Time, _ models.WebhookAttempt, _ string, next *time.Time) error {
+	if f.lost[id] {
+		return errors.ErrWebhookLeaseLost
+	}
+	f.failed[id] = next
+	return nil
+}
+
+func (f *fakeWebhookRepo) ReleaseWebhookDeliveryDB(_ context.Context, id int64, _ time.Time) error {
+	f.released = append(f.released, id)
+	return nil
+}
+
+func TestProcessWebhookDeliveriesRetries(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/down" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	delivery := func(id int64, path string, attempts int) models.DueWebhookDelivery {
+		return models.DueWebhookDelivery{
+			WebhookDeliveries: models.WebhookDeliveries{ID: id, Event: "note.created", Payload: []byte(`{}`), Attempts: attempts},
+			URL:               srv.URL + path,
+			Secret:            "secret",
+		}
+	}
+
+	repo := &fakeWebhookRepo{
+		due: []models.DueWebhookDelivery{
+			delivery(1, "/ok", 1),
+			delivery(2, "/down", 1), // Первая неудача - повтор через RetryBackoff
+			delivery(3, "/down", 3), // Третья неудача - задержка удвоена дважды
+			delivery(4, "/down", 4), // Попытки закончились
+		},
+		failed: make(map[int64]*time.Time),
+	}
+
+	cfg := &config.Config{}
+	cfg.Webhooks.AllowPrivateNetworks = true // Получатель httptest слушает loopback
+	cfg.Webhooks.MaxAttempts = 4
+	cfg.Webhooks.RetryBackoff = time.Minute
+
+	started := time.Now()
+	delivered, failed, err := NewWebhookService(repo, cfg).ProcessWebhookDeliveries(context.Background())
+	if err != nil {
+		t.Fatalf("ProcessWebhookDeliveries: %v", err)
+	}
+	if delivered != 1 || failed != 3 {
+		t.Fatalf("delivered = %d, failed = %d; want 1 and 3", delivered, failed)
+	}
+	if len(repo.delivered) != 1 || repo.delivered[0] != 1 {
+		t.Errorf("delivered IDs = %v, want [1]", repo.delivered)
+	}
+
+	wantDelay := map[int64]time.Duration{2: time.Minute, 3: 4 * time.Minute}
+	for id, delay := range wantDelay {
+		next := repo.failed[id]
+		if next == nil {
+			t.Errorf("delivery %d: no retry scheduled", id)
+			continue
+		}
+		if d := next.Sub(started); d < delay || d > delay+5*time.Second {
+			t.Errorf("delivery %d: retry in %v, want ~%v", id, d, delay)
+		}
+	}
+
+	if next, ok := repo.failed[4]; !ok || next != nil {
+		t.Errorf("delivery 4: next attempt = %v, want nil after MaxAttempts", next)
+	}
+}
+
+func TestProcessWebhookDeliveriesLease(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(150 * time.Millisecond):
+		case <-r.Context().Done():
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	delivery := func(id int64) models.DueWebhookDelivery {
+		return models.DueWebhookDelivery{
+			WebhookDeliveries: models.WebhookDeliveries{ID: id, Event: "note.created", Payload: []byte(`{}`), Attempts: 1},
+			URL:               srv.URL,
+			Secret:            "secret",
+		}
+	}
+
+	repo := &fakeWebhookRepo{
+		due:    []models.DueWebhookDelivery{delivery(1), delivery(2), delivery(3), delivery(4)},
+		failed: make(map[int64]*time.Time),
+		lost:   map[int64]bool{2: true},
+	}
+
+	cfg := &config.Config{}
+	cfg.Webhooks.AllowPrivateNetworks = true
+	cfg.Webhooks.Lease = 500 * time.Millisecond
+
+	// Пачка отправляется 400 мс (leaseDeadline): 1 и 2 успевают, 3 прерывается по сроку, 4 возвращается в очередь.
+	// Захват 2 уже потерян - его результат не записывается и не считается ошибкой
+	delivered, failed, err := NewWebhookService(repo, cfg).ProcessWebhookDeliveries(context.Background())
+	if err != nil {
+		t.Fatalf("ProcessWebhookDeliveries: %v", err)
+	}
+	if delivered != 2 || failed != 1 {
+		t.Errorf("delivered = %d, failed = %d; want 2 and 1", delivered, failed)
+	}
+	if len(repo.delivered) != 1 || repo.delivered[0] != 1 {
+		t.Errorf("delivered IDs = %v, want [1]", repo.delivered)
+	}
+	if _, ok := repo.failed[3]; !ok || len(repo.failed) != 1 {
+		t.Errorf("failed IDs = %v, want only 3", repo.failed)
+	}
+	if len(repo.released) != 1 || repo.released[0] != 4 {
+		t.Errorf("released = %v, want [4]", repo.released)
+	}
+}
@@ -0,0 +1,6 @@
+package request
+
+// CommentDTO DTO для создания и изменения комментария к заметке
+type CommentDTO struct {
+	Body string `json:"body"` // Текст комментария
+}
@@ -0,0 +1,17 @@
+package request
+
+// CreateItemDTO DTO для добавления пункта чек-листа
+type CreateItemDTO struct {
+	Text string `json:"text"`
+}
+
+// UpdateItemDTO DTO для изменения пункта чек-листа (передаются только изменяемые поля)
+type UpdateItemDTO struct {
+	Text      *string `json:"text"`
+	Completed *bool   `json:"completed"`
+}
+
+// ReorderItemsDTO DTO для изменения порядка пунктов чек-листа
+type ReorderItemsDTO struct {
+	IDs []int64 `json:"ids"` // ID всех пунктов заметки в новом порядке
+}
@@ -0,0 +1,13 @@
+package request
+
+// ListDTO DTO для создания и обновления списка
+type ListDTO struct {
+	Name     string `json:"name"`
+	Color    string `json:"color"`    // #RRGGBB (по умолчанию #808080)
+	Position *int   `json:"position"` // Если не указана, список добавляется в конец
+}
+
+// MoveNoteDTO DTO для переноса заметки в другой список
+type MoveNoteDTO struct {
+	ListID int64 `json:"listID"`
+}
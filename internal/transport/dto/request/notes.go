@@ -2,15 +2,99 @@ package request
 
 // CreateNote DTO для входящего запроса
 type CreateNoteDTO struct {
-	Note string `json:"note"`
+	Note     string `json:"note"`
+	DueAt    string `json:"dueAt"`    // RFC3339 или локальное время в часовом поясе сервера (2006-01-02T15:04, 2006-01-02)
+	Priority string `json:"priority"` // low | normal | high | urgent (по умолчанию normal)
+	ListID   int64  `json:"listID"`   // Список заметки (0 - список по умолчанию), при обновлении не используется
+	RRule    string `json:"rrule"`    // Правило повторения RFC 5545, например FREQ=MONTHLY;BYMONTHDAY=1 (требует dueAt)
 }
 
-// UpdateNote DTO для входящего запроса
+// UpdateNote DTO для входящего запроса. Срок, приоритет и правило повторения, которых нет в запросе (nil), не меняются
 type UpdateNoteDTO struct {
-	CreateNoteDTO
+	Note     string  `json:"note"`
+	DueAt    *string `json:"dueAt"`    // Новый срок в формате CreateNoteDTO.DueAt ("" - убрать срок)
+	Priority *string `json:"priority"` // low | normal | high | urgent ("" - normal)
+	RRule    *string `json:"rrule"`    // Новое правило повторения ("" - заметка больше не повторяется)
 }
 
 // CheckNoteDTO DTO для входящего запроса
 type CheckNoteDTO struct {
-	Check bool `json:"check"`
+	Check         bool `json:"check"`
+	CompleteItems bool `json:"completeItems"` // При отметке выполненной отметить и все пункты чек-листа
+}
+
+// GetNotesDTO параметры запроса списка заметок (query string)
+type GetNotesDTO struct {
+	ListID        string // ID списка
+	Completed     string // true | false
+	CreatedAfter  string // RFC3339
+	CreatedBefore string // RFC3339
+	Tags          string // ID меток через запятую
+	TagsMode      string // any | all
+	Sort          string // position | created_at | id | note
+	Order         string // asc | desc
+	Limit         string // Размер страницы
+	Cursor        string // Непрозрачный курсор next_cursor из предыдущего ответа
+}
+
+// SearchNotesDTO параметры полнотекстового поиска заметок (query string)
+type SearchNotesDTO struct {
+	Query string // Поисковый запрос
+	Lang  string // ru | en (пусто - оба языка)
+	Limit string // Максимальное количество результатов
+}
+
+// NotePositionDTO DTO для перемещения заметки между соседями (drag-and-drop)
+type NotePositionDTO struct {
+	AfterID  int64 `json:"afterId"`  // Заметка, после которой нужно поставить перемещаемую (0 - не указана)
+	BeforeID int64 `json:"beforeId"` // Заметка, перед которой нужно поставить перемещаемую (0 - не указана)
+}
+
+// NoteBatchDTO DTO пакета операций с заметками (POST /notes/batch)
+type NoteBatchDTO struct {
+	Mode       string           `json:"mode"`       // atomic (по умолчанию) - всё или ничего | best_effort - ошибочные операции пропускаются
+	Operations []NoteBatchOpDTO `json:"operations"` // Операции выполняются по порядку, не более 100
+}
+
+// NoteBatchOpDTO одна операция пакета
+type NoteBatchOpDTO struct {
+	Op            string `json:"op"` // create | update | complete | delete | move
+	ID            int64  `json:"id"` // Заметка (для всех операций, кроме create)
+	UpdateNoteDTO        // Поля заметки для create и update (при update отсутствующие поля не меняются)
+	ListID        int64  `json:"listID"` // Список для create и move (0 при create - список по умолчанию)
+	CheckNoteDTO         // Для create: check - создать заметку сразу выполненной
+
+	ICalUID string `json:"-"` // UID задачи календаря для create (только при синхронизации с календарём)
+}
+
+// ImportNotesDTO параметры импорта заметок (query string)
+type ImportNotesDTO struct {
+	Format string // json | csv | md (по умолчанию json)
+	DryRun string // true - только проверить файл, ничего не создавая
+	ListID string // Список для заметок без списка или с неизвестным списком (по умолчанию Inbox)
+}
+
+// ImportJobDTO параметры фонового импорта из другого сервиса (query string)
+type ImportJobDTO struct {
+	Source string // todoist | mstodo | gtasks
+	List   string // Список для задач без проекта (по умолчанию Inbox); отсутствующий список создаётся
+}
+
+// SyncDTO параметры получения изменений заметок (query string)
+type SyncDTO struct {
+	Since string // Токен next_token из предыдущего ответа (пусто - все заметки)
+	Limit string // Максимальное количество изменений в ответе
+}
+
+// SyncPushDTO DTO изменений, сделанных клиентом без сети (POST /sync)
+type SyncPushDTO struct {
+	Mutations []SyncMutationDTO `json:"mutations"` // Изменения применяются по порядку, не более 100
+}
+
+// SyncMutationDTO одно изменение клиента: операция пакета с версией, на основе которой оно сделано
+type SyncMutationDTO struct {
+	NoteBatchOpDTO
+	ClientID   string `json:"clientID"`   // Идентификатор изменения на клиенте (возвращается в результате)
+	BaseSeq    int64  `json:"baseSeq"`    // changeSeq заметки, которую видел клиент (0 - без проверки конфликта)
+	ModifiedAt string `json:"modifiedAt"` // Время изменения на клиенте, RFC3339 (пусто - время загрузки)
 }
@@ -0,0 +1,8 @@
+package request
+
+// ReminderDTO DTO для создания напоминания
+type ReminderDTO struct {
+	RemindAt string `json:"remindAt"` // RFC3339 или локальное время в часовом поясе сервера (2006-01-02T15:04)
+	Channel  string `json:"channel"`  // email | webhook (по умолчанию email)
+	Target   string `json:"target"`   // Email (по умолчанию email пользователя) или URL webhook
+}
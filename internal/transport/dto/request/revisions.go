@@ -0,0 +1,7 @@
+package request
+
+// DiffRevisionsDTO параметры сравнения ревизий заметки (query string)
+type DiffRevisionsDTO struct {
+	From string // Номер ревизии, с которой сравниваем (если не указан - состояние до ревизии to)
+	To   string // Номер ревизии, которую сравниваем
+}
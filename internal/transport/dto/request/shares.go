@@ -0,0 +1,13 @@
+package request
+
+// ShareDTO DTO для открытия доступа к заметке или списку
+type ShareDTO struct {
+	User string `json:"user"` // Имя пользователя или email
+	Role string `json:"role"` // viewer | editor (по умолчанию viewer)
+}
+
+// ShareLinkDTO DTO для создания публичной ссылки на заметку
+type ShareLinkDTO struct {
+	ExpiresAt string `json:"expiresAt"` // RFC3339 или локальное время в часовом поясе сервера (пусто - бессрочно)
+	Password  string `json:"password"`  // Пароль для открытия ссылки (пусто - без пароля)
+}
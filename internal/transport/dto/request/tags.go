@@ -0,0 +1,6 @@
+package request
+
+// TagDTO DTO для создания и переименования метки
+type TagDTO struct {
+	Name string `json:"name"`
+}
@@ -0,0 +1,8 @@
+package request
+
+// WebhookDTO DTO для создания и изменения подписки webhook
+type WebhookDTO struct {
+	URL    string   `json:"url"`    // Адрес получателя (http или https)
+	Events []string `json:"events"` // Типы событий: note.created, note.updated, note.completed, note.deleted (пустой - все)
+	Active *bool    `json:"active"` // Подписка включена (по умолчанию true)
+}
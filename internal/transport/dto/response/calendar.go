@@ -0,0 +1,28 @@
+package response
+
+import "time"
+
+// CalendarFeedDTO - приватная ссылка на ленту задач. Токен возвращается только один раз, в БД хранится его хеш
+type CalendarFeedDTO struct {
+	Token     string    `json:"token"`     // Токен ленты
+	Path      string    `json:"path"`      // Путь для подписки в календаре: /ical/{token}.ics
+	CreatedAt time.Time `json:"createdAt"` // Время создания токена
+}
+
+// CalendarSyncDTO - итог загрузки задач из календарного клиента
+type CalendarSyncDTO struct {
+	Total   int                    `json:"total"`   // Задач VTODO в файле
+	Created int                    `json:"created"` // Создано заметок
+	Updated int                    `json:"updated"` // Обновлено заметок
+	Deleted int                    `json:"deleted"` // Перемещено в корзину (STATUS:CANCELLED)
+	Skipped int                    `json:"skipped"` // Пропущено (отменённые задачи, которых нет среди заметок)
+	Failed  int                    `json:"failed"`  // Задачи с ошибками
+	Errors  []CalendarSyncErrorDTO `json:"errors"`  // Ошибки по задачам
+}
+
+// CalendarSyncErrorDTO - ошибка загрузки одной задачи
+type CalendarSyncErrorDTO struct {
+	UID   string `json:"uid"`   // UID задачи
+	Error string `json:"error"` // Текст ошибки
+	Code  int    `json:"code"`  // HTTP-статус, который вернул бы отдельный запрос
+}
@@ -1 +1,45 @@
 package response
+
+import "github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/models"
+
+// NotesPageDTO - страница заметок для ответа клиенту
+type NotesPageDTO struct {
+	Items      []models.AllNotes `json:"items"`       // Заметки текущей страницы
+	Total      int64             `json:"total"`       // Общее количество заметок с учётом фильтров
+	NextCursor string            `json:"next_cursor"` // Курсор следующей страницы (пустой, если страниц больше нет)
+}
+
+// NoteBatchDTO - результат пакета операций с заметками
+type NoteBatchDTO struct {
+	Mode      string               `json:"mode"`      // atomic | best_effort
+	Committed bool                 `json:"committed"` // Изменения сохранены (в режиме atomic - только если все операции успешны)
+	Results   []NoteBatchResultDTO `json:"results"`   // Результаты в порядке операций запроса
+}
+
+// NoteBatchResultDTO - результат одной операции пакета
+type NoteBatchResultDTO struct {
+	Index  int    `json:"index"`           // Номер операции в запросе (с 0)
+	Op     string `json:"op"`              // Операция из запроса
+	ID     int64  `json:"id,omitempty"`    // Заметка (для create - созданная)
+	Status string `json:"status"`          // ok | error | rolled_back (откачена из-за ошибки другой операции) | skipped (не выполнялась)
+	Error  string `json:"error,omitempty"` // Описание ошибки
+	Code   int    `json:"code,omitempty"`  // HTTP-статус, соответствующий ошибке
+}
+
+// ImportNotesDTO - результат импорта заметок
+type ImportNotesDTO struct {
+	DryRun  bool               `json:"dryRun"`  // Файл только проверен, заметки не созданы
+	Total   int                `json:"total"`   // Заметок в файле
+	Valid   int                `json:"valid"`   // Заметок без ошибок (будут созданы или созданы)
+	Created int                `json:"created"` // Создано заметок
+	Rows    []ImportNoteRowDTO `json:"rows"`    // Результаты по заметкам файла
+}
+
+// ImportNoteRowDTO - результат импорта одной заметки
+type ImportNoteRowDTO struct {
+	Row    int    `json:"row"`             // Номер строки файла (для JSON - номер элемента массива, с 1)
+	Note   string `json:"note"`            // Текст заметки
+	List   string `json:"list,omitempty"`  // Список, в который попадёт заметка
+	Status string `json:"status"`          // ok | error
+	Error  string `json:"error,omitempty"` // Описание ошибки
+}
@@ -0,0 +1,10 @@
+package response
+
+import "github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/pkg/textdiff"
+
+// NoteDiffDTO - различия текста заметки между двумя ревизиями
+type NoteDiffDTO struct {
+	From    int               `json:"from"`    // Ревизия, с которой сравниваем (0 - состояние до ревизии to)
+	To      int               `json:"to"`      // Ревизия, которую сравниваем
+	Changes []textdiff.Change `json:"changes"` // Фрагменты различий (equal | insert | delete)
+}
@@ -0,0 +1,34 @@
+package response
+
+import (
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/models"
+	"time"
+)
+
+// SharedWithMeDTO - заметки и списки других пользователей, доступные текущему
+type SharedWithMeDTO struct {
+	Notes []models.SharedNote `json:"notes"` // Заметки, доступные лично или через общий список
+	Lists []models.SharedList `json:"lists"` // Общие списки
+}
+
+// ShareLinkDTO - созданная публичная ссылка. Токен возвращается только один раз, в БД хранится его хеш
+type ShareLinkDTO struct {
+	models.ShareLinks
+	Token string `json:"token"` // Токен ссылки
+	Path  string `json:"path"`  // Путь для открытия заметки: /s/{token}
+}
+
+// PublicNoteDTO - содержимое заметки, открытой по публичной ссылке (без служебных полей)
+type PublicNoteDTO struct {
+	Note      string          `json:"note"`      // Текст заметки
+	Completed bool            `json:"completed"` // Статус выполнения
+	DueAt     *time.Time      `json:"dueAt"`     // Срок выполнения
+	Priority  string          `json:"priority"`  // Приоритет
+	Items     []PublicItemDTO `json:"items"`     // Пункты чек-листа
+}
+
+// PublicItemDTO - пункт чек-листа заметки, открытой по публичной ссылке
+type PublicItemDTO struct {
+	Text      string `json:"text"`      // Текст пункта
+	Completed bool   `json:"completed"` // Статус выполнения
+}
@@ -0,0 +1,41 @@
+package response
+
+import (
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/models"
+	"time"
+)
+
+// SyncDTO - изменения заметок с момента предыдущей синхронизации
+type SyncDTO struct {
+	Changes   []models.AllNotes       `json:"changes"`    // Созданные и изменённые заметки (deletedAt - заметка в корзине)
+	Deleted   []models.NoteTombstones `json:"deleted"`    // Окончательно удалённые заметки
+	NextToken string                  `json:"next_token"` // Токен для следующего запроса (since)
+	HasMore   bool                    `json:"has_more"`   // Есть ещё изменения - запросить сразу с next_token
+}
+
+// SyncPushDTO - результат применения изменений клиента
+type SyncPushDTO struct {
+	Results   []SyncPushResultDTO `json:"results"`   // Результаты в порядке изменений запроса
+	Conflicts int                 `json:"conflicts"` // Количество конфликтов (в том числе разрешённых в пользу клиента)
+}
+
+// SyncPushResultDTO - результат одного изменения клиента
+type SyncPushResultDTO struct {
+	Index    int              `json:"index"`              // Номер изменения в запросе (с 0)
+	ClientID string           `json:"clientID,omitempty"` // Идентификатор изменения на клиенте
+	Op       string           `json:"op"`                 // Операция из запроса
+	ID       int64            `json:"id,omitempty"`       // Заметка (для create - созданная)
+	Status   string           `json:"status"`             // applied | conflict (изменение отклонено) | error
+	Seq      int64            `json:"seq,omitempty"`      // changeSeq заметки после применения (при конфликте - серверная версия)
+	Conflict *SyncConflictDTO `json:"conflict,omitempty"` // Отчёт о конфликте
+	Error    string           `json:"error,omitempty"`    // Описание ошибки
+	Code     int              `json:"code,omitempty"`     // HTTP-статус, соответствующий ошибке
+}
+
+// SyncConflictDTO - заметку изменили на сервере после версии, которую видел клиент.
+// Побеждает изменение, сделанное позже (last-writer-wins)
+type SyncConflictDTO struct {
+	Resolution      string    `json:"resolution"`      // client_wins - изменение клиента применено | server_wins - отклонено
+	ServerSeq       int64     `json:"serverSeq"`       // changeSeq серверной версии на момент конфликта
+	ServerUpdatedAt time.Time `json:"serverUpdatedAt"` // Время изменения серверной версии
+}
@@ -0,0 +1,64 @@
+package worker
+
+import (
+	"context"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/config"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/service"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/pkg/logging"
+	"time"
+)
+
+// defaultAttachmentCleanupInterval - интервал удаления файлов, если он не задан в конфигурации
+const defaultAttachmentCleanupInterval = 10 * time.Minute
+
+// AttachmentCleaner - фоновая задача, удаляющая из хранилища файлы удалённых вложений и окончательно удалённых заметок
+type AttachmentCleaner struct {
+	attachmentService service.AttachmentService
+	interval          time.Duration
+	logger            *logging.Logger
+}
+
+// NewAttachmentCleaner создаёт фоновую задачу удаления файлов вложений
+func NewAttachmentCleaner(attachmentService service.AttachmentService, cfg *config.Config, logger *logging.Logger) *AttachmentCleaner {
+	interval := cfg.Attachments.CleanupInterval
+	if interval <= 0 {
+		interval = defaultAttachmentCleanupInterval
+	}
+
+	return &AttachmentCleaner{
+		attachmentService: attachmentService,
+		interval:          interval,
+		logger:            logger,
+	}
+}
+
+// Run запускает очистку сразу и затем с заданным интервалом, пока не будет отменён ctx
+func (c *AttachmentCleaner) Run(ctx context.Context) {
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+
+	for {
+		c.cleanup(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// cleanup - один проход удаления файлов
+func (c *AttachmentCleaner) cleanup(ctx context.Context) {
+	removed, err := c.attachmentService.CleanupOrphanedAttachments(ctx)
+	if err != nil {
+		if ctx.Err() == nil {
+			c.logger.Errorf("Ошибка при удалении файлов вложений: %s", err)
+		}
+		return
+	}
+
+	if removed > 0 {
+		c.logger.Infof("Из хранилища удалено файлов вложений: %d", removed)
+	}
+}
@@ -0,0 +1,62 @@
+package worker
+
+import (
+	"context"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/events"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/repository"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/pkg/logging"
+	"time"
+)
+
+// Задержка перед переподключением к БД (удваивается до максимальной)
+const (
+	minListenBackoff = time.Second
+	maxListenBackoff = 30 * time.Second
+)
+
+// EventListener - фоновая задача, получающая события заметок из PostgreSQL и передающая их брокеру.
+// Запускается в каждой реплике API: NOTIFY доставляется всем подписанным соединениям
+type EventListener struct {
+	repo   repository.NoteEventRepository
+	broker *events.Broker
+	logger *logging.Logger
+}
+
+// NewEventListener создаёт получателя событий заметок
+func NewEventListener(repo repository.NoteEventRepository, broker *events.Broker, logger *logging.Logger) *EventListener {
+	return &EventListener{
+		repo:   repo,
+		broker: broker,
+		logger: logger,
+	}
+}
+
+// Run слушает события, переподключаясь при обрыве соединения, пока не будет отменён ctx.
+// После переподключения подписчики получают resync: события за время обрыва потеряны
+func (l *EventListener) Run(ctx context.Context) {
+	backoff := minListenBackoff
+	failed := false // Было ли прервано получение событий
+
+	for {
+		err := l.repo.ListenNoteEventsDB(ctx, func() {
+			if failed {
+				l.broker.Resync()
+			}
+			backoff = minListenBackoff
+		}, l.broker.Publish)
+
+		if ctx.Err() != nil {
+			return
+		}
+		l.logger.Errorf("Ошибка при получении событий заметок: %s", err)
+		failed = true
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+
+		backoff = min(backoff*2, maxListenBackoff)
+	}
+}
@@ -0,0 +1,64 @@
+package worker
+
+import (
+	"context"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/config"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/service"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/pkg/logging"
+	"time"
+)
+
+// defaultIdempotencyPurgeInterval - интервал удаления ключей, если он не задан в конфигурации
+const defaultIdempotencyPurgeInterval = time.Hour
+
+// IdempotencyPurger - фоновая задача, удаляющая ключи Idempotency-Key с истёкшим сроком хранения
+type IdempotencyPurger struct {
+	idempotencyService service.IdempotencyService
+	interval           time.Duration
+	logger             *logging.Logger
+}
+
+// NewIdempotencyPurger создаёт фоновую задачу удаления ключей Idempotency-Key
+func NewIdempotencyPurger(idempotencyService service.IdempotencyService, cfg *config.Config, logger *logging.Logger) *IdempotencyPurger {
+	interval := cfg.Idempotency.PurgeInterval
+	if interval <= 0 {
+		interval = defaultIdempotencyPurgeInterval
+	}
+
+	return &IdempotencyPurger{
+		idempotencyService: idempotencyService,
+		interval:           interval,
+		logger:             logger,
+	}
+}
+
+// Run запускает удаление сразу и затем с заданным интервалом, пока не будет отменён ctx
+func (p *IdempotencyPurger) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		p.purge(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// purge - один проход удаления ключей
+func (p *IdempotencyPurger) purge(ctx context.Context) {
+	purged, err := p.idempotencyService.PurgeIdempotencyKeys(ctx)
+	if err != nil {
+		if ctx.Err() == nil {
+			p.logger.Errorf("Ошибка при удалении ключей Idempotency-Key: %s", err)
+		}
+		return
+	}
+
+	if purged > 0 {
+		p.logger.Infof("Удалено ключей Idempotency-Key с истёкшим сроком: %d", purged)
+	}
+}
@@ -0,0 +1,63 @@
+package worker
+
+import (
+	"context"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/config"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/service"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/pkg/logging"
+	"time"
+)
+
+// defaultImportPollInterval - интервал проверки очереди импорта, если он не задан в конфигурации
+const defaultImportPollInterval = 5 * time.Second
+
+// ImportRunner - фоновая задача, выполняющая задания импорта из других сервисов.
+// Безопасна для запуска в нескольких репликах API: задания захватываются в БД
+type ImportRunner struct {
+	importJobService service.ImportJobService
+	interval         time.Duration
+	logger           *logging.Logger
+}
+
+// NewImportRunner создаёт обработчик очереди импорта
+func NewImportRunner(importJobService service.ImportJobService, cfg *config.Config, logger *logging.Logger) *ImportRunner {
+	interval := cfg.Imports.PollInterval
+	if interval <= 0 {
+		interval = defaultImportPollInterval
+	}
+
+	return &ImportRunner{
+		importJobService: importJobService,
+		interval:         interval,
+		logger:           logger,
+	}
+}
+
+// Run проверяет очередь сразу и затем с заданным интервалом, пока не будет отменён ctx
+func (r *ImportRunner) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		r.process(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// process - выполнить задания, пока очередь не опустеет
+func (r *ImportRunner) process(ctx context.Context) {
+	for ctx.Err() == nil {
+		processed, err := r.importJobService.ProcessNextImportJob(ctx)
+		if err != nil && ctx.Err() == nil {
+			r.logger.Errorf("Ошибка при выполнении задания импорта: %s", err)
+		}
+		if !processed {
+			return
+		}
+	}
+}
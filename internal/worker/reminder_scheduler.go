@@ -0,0 +1,62 @@
+package worker
+
+import (
+	"context"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/config"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/service"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/pkg/logging"
+	"time"
+)
+
+// defaultReminderPollInterval - интервал проверки напоминаний, если он не задан в конфигурации
+const defaultReminderPollInterval = 30 * time.Second
+
+// ReminderScheduler - фоновая задача, отправляющая напоминания, срок которых наступил.
+// Безопасна для запуска в нескольких репликах API: напоминания захватываются в БД
+type ReminderScheduler struct {
+	reminderService service.ReminderService
+	interval        time.Duration
+	logger          *logging.Logger
+}
+
+// NewReminderScheduler создаёт планировщик напоминаний
+func NewReminderScheduler(reminderService service.ReminderService, cfg *config.Config, logger *logging.Logger) *ReminderScheduler {
+	interval := cfg.Reminders.PollInterval
+	if interval <= 0 {
+		interval = defaultReminderPollInterval
+	}
+
+	return &ReminderScheduler{
+		reminderService: reminderService,
+		interval:        interval,
+		logger:          logger,
+	}
+}
+
+// Run проверяет напоминания сразу и затем с заданным интервалом, пока не будет отменён ctx
+func (s *ReminderScheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		s.process(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// process - один проход отправки напоминаний
+func (s *ReminderScheduler) process(ctx context.Context) {
+	sent, failed, err := s.reminderService.ProcessDueReminders(ctx)
+	if err != nil && ctx.Err() == nil {
+		s.logger.Errorf("Ошибка при отправке напоминаний: %s", err)
+	}
+
+	if sent > 0 || failed > 0 {
+		s.logger.Infof("Напоминания: отправлено %d, с ошибкой %d", sent, failed)
+	}
+}
@@ -0,0 +1,64 @@
+package worker
+
+import (
+	"context"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/config"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/service"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/pkg/logging"
+	"time"
+)
+
+// defaultPurgeInterval - интервал очистки корзины, если он не задан в конфигурации
+const defaultPurgeInterval = time.Hour
+
+// TrashPurger - фоновая задача, окончательно удаляющая заметки с истёкшим сроком хранения в корзине
+type TrashPurger struct {
+	noteService service.NoteService
+	interval    time.Duration
+	logger      *logging.Logger
+}
+
+// NewTrashPurger создаёт фоновую задачу очистки корзины
+func NewTrashPurger(noteService service.NoteService, cfg *config.Config, logger *logging.Logger) *TrashPurger {
+	interval := cfg.Trash.PurgeInterval
+	if interval <= 0 {
+		interval = defaultPurgeInterval
+	}
+
+	return &TrashPurger{
+		noteService: noteService,
+		interval:    interval,
+		logger:      logger,
+	}
+}
+
+// Run запускает очистку сразу и затем с заданным интервалом, пока не будет отменён ctx
+func (p *TrashPurger) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		p.purge(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// purge - один проход очистки корзины
+func (p *TrashPurger) purge(ctx context.Context) {
+	purged, err := p.noteService.PurgeTrash(ctx)
+	if err != nil {
+		if ctx.Err() == nil {
+			p.logger.Errorf("Ошибка при очистке корзины: %s", err)
+		}
+		return
+	}
+
+	if purged > 0 {
+		p.logger.Infof("Из корзины окончательно удалено заметок: %d", purged)
+	}
+}
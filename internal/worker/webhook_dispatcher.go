@@ -0,0 +1,82 @@
+package worker
+
+import (
+	"context"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/config"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/internal/service"
+	"github.com/Igrok95Ronin/todolistjwtca.drpetproject.ru-api.git/pkg/logging"
+	"time"
+)
+
+const (
+	defaultWebhookPollInterval   = 5 * time.Second // Интервал проверки очереди доставок, если он не задан в конфигурации
+	webhookDeliveryPurgeInterval = time.Hour       // Интервал очистки журнала доставок
+)
+
+// WebhookDispatcher - фоновая задача, отправляющая webhook из очереди доставок и очищающая старый журнал.
+// Безопасна для запуска в нескольких репликах API: доставки захватываются в БД
+type WebhookDispatcher struct {
+	webhookService service.WebhookService
+	interval       time.Duration
+	logger         *logging.Logger
+	lastPurge      time.Time
+}
+
+// NewWebhookDispatcher создаёт фоновую задачу доставки webhook
+func NewWebhookDispatcher(webhookService service.WebhookService, cfg *config.Config, logger *logging.Logger) *WebhookDispatcher {
+	interval := cfg.Webhooks.PollInterval
+	if interval <= 0 {
+		interval = defaultWebhookPollInterval
+	}
+
+	return &WebhookDispatcher{
+		webhookService: webhookService,
+		interval:       interval,
+		logger:         logger,
+	}
+}
+
+// Run проверяет очередь сразу и затем с заданным интервалом, пока не будет отменён ctx
+func (d *WebhookDispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+
+	for {
+		d.process(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// process - один проход отправки доставок; раз в час удаляются завершённые доставки старше срока хранения
+func (d *WebhookDispatcher) process(ctx context.Context) {
+	delivered, failed, err := d.webhookService.ProcessWebhookDeliveries(ctx)
+	if err != nil && ctx.Err() == nil {
+		d.logger.Errorf("Ошибка при доставке webhook: %s", err)
+	}
+
+	if delivered > 0 || failed > 0 {
+		d.logger.Infof("Webhook: доставлено %d, с ошибкой %d", delivered, failed)
+	}
+
+	if time.Since(d.lastPurge) < webhookDeliveryPurgeInterval {
+		return
+	}
+
+	purged, err := d.webhookService.PurgeWebhookDeliveries(ctx)
+	if err != nil {
+		if ctx.Err() == nil {
+			d.logger.Errorf("Ошибка при очистке журнала доставок webhook: %s", err)
+		}
+		return
+	}
+	d.lastPurge = time.Now()
+
+	if purged > 0 {
+		d.logger.Infof("Из журнала доставок webhook удалено записей: %d", purged)
+	}
+}
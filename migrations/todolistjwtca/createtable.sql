@@ -8,11 +8,423 @@ CREATE TABLE users (
                        created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP -- Время создания записи
 );
 
+-- Создаем таблицу lists (списки/проекты пользователя)
+CREATE TABLE lists (
+                       id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+                       user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE, -- Владелец списка
+                       name TEXT NOT NULL, -- Название списка
+                       color TEXT NOT NULL DEFAULT '#808080', -- Цвет списка в формате #RRGGBB
+                       position INTEGER NOT NULL DEFAULT 0, -- Порядок списка в интерфейсе
+                       is_default BOOLEAN NOT NULL DEFAULT FALSE, -- Список по умолчанию (Inbox)
+                       created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP -- Время создания записи
+);
+
+-- У пользователя может быть только один список по умолчанию
+CREATE UNIQUE INDEX idx_lists_user_default ON lists (user_id) WHERE is_default;
+
 -- Создаем таблицу all_notes
 CREATE TABLE all_notes (
                            id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
                            note TEXT NOT NULL, -- Поле заметки обязательно для заполнения
                            completed BOOLEAN DEFAULT FALSE, -- По умолчанию задача не выполнена
                            user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE, -- Связь с таблицей users, при удалении пользователя удаляются его заметки
-                           created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP -- Время создания записи
+                           list_id BIGINT NOT NULL REFERENCES lists(id) ON DELETE CASCADE, -- Список, в котором находится заметка
+                           created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP, -- Время создания записи
+                           due_at TIMESTAMPTZ, -- Срок выполнения (с часовым поясом, может быть NULL)
+                           priority TEXT NOT NULL DEFAULT 'normal' CHECK (priority IN ('low', 'normal', 'high', 'urgent')), -- Приоритет задачи
+                           position BIGINT NOT NULL DEFAULT 0, -- Позиция заметки при ручной сортировке (с промежутками между соседями)
+                           deleted_at TIMESTAMPTZ, -- Время перемещения в корзину (NULL - заметка не удалена)
+                           rrule TEXT NOT NULL DEFAULT '', -- Правило повторения RFC 5545 (пустое - заметка не повторяется)
+                           rrule_start TIMESTAMPTZ, -- Начало серии повторений (DTSTART)
+                           previous_id BIGINT REFERENCES all_notes(id) ON DELETE SET NULL, -- Выполненное повторение, из которого создана заметка
+                           ical_uid TEXT, -- UID задачи, созданной в календарном клиенте (NULL - используется note-<id>@todolistjwtca)
+                           change_seq BIGINT NOT NULL DEFAULT 0, -- Номер последнего изменения (ID транзакции, заполняется триггером)
+                           updated_at TIMESTAMPTZ NOT NULL DEFAULT now(), -- Время последнего изменения (заполняется триггером)
+                           version BIGINT NOT NULL DEFAULT 1, -- Версия заметки для ETag / If-Match (увеличивается триггером при каждом изменении)
+                           search_vector TSVECTOR GENERATED ALWAYS AS (
+                               to_tsvector('russian', coalesce(note, '')) || to_tsvector('english', coalesce(note, ''))
+                           ) STORED -- Поисковый вектор заметки (русская и английская морфология)
+);
+
+-- Индекс для полнотекстового поиска по заметкам
+CREATE INDEX idx_all_notes_search_vector ON all_notes USING GIN (search_vector);
+
+-- Индекс для выборок заметок списка
+CREATE INDEX idx_all_notes_list_id ON all_notes (list_id);
+
+-- Индекс для выборок по сроку выполнения (сегодня, просроченные, предстоящие)
+CREATE INDEX idx_all_notes_user_due_at ON all_notes (user_id, due_at) WHERE due_at IS NOT NULL;
+
+-- Индекс для ручной сортировки заметок
+CREATE INDEX idx_all_notes_user_position ON all_notes (user_id, position, id);
+
+-- UID задач календаря уникальны в пределах пользователя (включая заметки в корзине)
+CREATE UNIQUE INDEX idx_all_notes_user_ical_uid ON all_notes (user_id, ical_uid) WHERE ical_uid IS NOT NULL;
+
+-- Индекс для корзины и фоновой очистки удалённых заметок
+CREATE INDEX idx_all_notes_deleted_at ON all_notes (deleted_at) WHERE deleted_at IS NOT NULL;
+
+-- У каждого выполненного повторения может быть только одно следующее
+CREATE UNIQUE INDEX idx_all_notes_previous_id ON all_notes (previous_id) WHERE previous_id IS NOT NULL;
+
+-- Индекс для синхронизации: изменения заметок пользователя по порядку
+CREATE INDEX idx_all_notes_user_change_seq ON all_notes (user_id, change_seq, id);
+
+-- Создаем таблицу tags (метки пользователя)
+CREATE TABLE tags (
+                      id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+                      user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE, -- Владелец метки
+                      name TEXT NOT NULL, -- Название метки
+                      created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP, -- Время создания записи
+                      UNIQUE (user_id, name) -- Названия меток уникальны в пределах пользователя
+);
+
+-- Создаем таблицу note_tags (связь многие-ко-многим между заметками и метками)
+CREATE TABLE note_tags (
+                           note_id BIGINT NOT NULL REFERENCES all_notes(id) ON DELETE CASCADE, -- При удалении заметки удаляются её связи
+                           tag_id BIGINT NOT NULL REFERENCES tags(id) ON DELETE CASCADE, -- При удалении метки она снимается со всех заметок
+                           PRIMARY KEY (note_id, tag_id)
+);
+
+CREATE INDEX idx_note_tags_tag_id ON note_tags (tag_id);
+
+-- Создаем таблицу note_items (пункты чек-листа внутри заметки)
+CREATE TABLE note_items (
+                            id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+                            note_id BIGINT NOT NULL REFERENCES all_notes(id) ON DELETE CASCADE, -- При удалении заметки удаляются её пункты
+                            text TEXT NOT NULL, -- Текст пункта
+                            completed BOOLEAN NOT NULL DEFAULT FALSE, -- Статус выполнения пункта
+                            position INTEGER NOT NULL DEFAULT 0, -- Порядок пункта внутри заметки
+                            created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP -- Время создания записи
+);
+
+CREATE INDEX idx_note_items_note_id ON note_items (note_id, position);
+
+-- Создаем таблицу note_revisions (история изменений заметок)
+CREATE TABLE note_revisions (
+                                id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+                                note_id BIGINT NOT NULL REFERENCES all_notes(id) ON DELETE CASCADE, -- При удалении заметки удаляется её история
+                                rev INTEGER NOT NULL, -- Номер ревизии внутри заметки (1, 2, 3...)
+                                user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE, -- Кто внёс изменение
+                                action TEXT NOT NULL CHECK (action IN ('update', 'complete', 'revert')), -- Тип изменения
+                                old_note TEXT NOT NULL, -- Текст до изменения
+                                new_note TEXT NOT NULL, -- Текст после изменения
+                                old_completed BOOLEAN NOT NULL, -- Статус выполнения до изменения
+                                new_completed BOOLEAN NOT NULL, -- Статус выполнения после изменения
+                                old_due_at TIMESTAMPTZ, -- Срок выполнения до изменения
+                                new_due_at TIMESTAMPTZ, -- Срок выполнения после изменения
+                                old_priority TEXT NOT NULL, -- Приоритет до изменения
+                                new_priority TEXT NOT NULL, -- Приоритет после изменения
+                                created_at TIMESTAMPTZ NOT NULL DEFAULT now(), -- Когда внесено изменение
+                                UNIQUE (note_id, rev)
+);
+
+-- Создаем таблицу reminders (напоминания о заметках)
+CREATE TABLE reminders (
+                           id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+                           note_id BIGINT NOT NULL REFERENCES all_notes(id) ON DELETE CASCADE, -- Заметка, о которой напоминаем
+                           user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE, -- Владелец напоминания
+                           remind_at TIMESTAMPTZ NOT NULL, -- Когда отправить напоминание
+                           channel TEXT NOT NULL CHECK (channel IN ('email', 'webhook')), -- Канал доставки
+                           target TEXT NOT NULL, -- Email или URL webhook
+                           status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'sending', 'sent', 'failed')), -- Статус доставки
+                           attempts INTEGER NOT NULL DEFAULT 0, -- Количество попыток отправки
+                           next_attempt_at TIMESTAMPTZ NOT NULL, -- Время следующей попытки
+                           locked_until TIMESTAMPTZ, -- До какого времени напоминание захвачено планировщиком
+                           last_error TEXT NOT NULL DEFAULT '', -- Ошибка последней неудачной попытки
+                           sent_at TIMESTAMPTZ, -- Время успешной отправки
+                           created_at TIMESTAMPTZ NOT NULL DEFAULT now() -- Время создания записи
+);
+
+-- Индекс для выборки напоминаний, которые пора отправить
+CREATE INDEX idx_reminders_due ON reminders (next_attempt_at) WHERE status IN ('pending', 'sending');
+
+CREATE INDEX idx_reminders_note_id ON reminders (note_id);
+
+-- Создаем таблицу note_shares (доступ к заметке для других пользователей)
+CREATE TABLE note_shares (
+                             note_id BIGINT NOT NULL REFERENCES all_notes(id) ON DELETE CASCADE, -- Общая заметка
+                             user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE, -- Пользователь, которому открыт доступ
+                             role TEXT NOT NULL CHECK (role IN ('viewer', 'editor')), -- Роль: просмотр или редактирование
+                             created_at TIMESTAMPTZ NOT NULL DEFAULT now(), -- Когда открыт доступ
+                             PRIMARY KEY (note_id, user_id)
+);
+
+CREATE INDEX idx_note_shares_user_id ON note_shares (user_id);
+
+-- Создаем таблицу list_shares (доступ ко всем заметкам списка для других пользователей)
+CREATE TABLE list_shares (
+                             list_id BIGINT NOT NULL REFERENCES lists(id) ON DELETE CASCADE, -- Общий список
+                             user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE, -- Пользователь, которому открыт доступ
+                             role TEXT NOT NULL CHECK (role IN ('viewer', 'editor')), -- Роль: просмотр или редактирование
+                             created_at TIMESTAMPTZ NOT NULL DEFAULT now(), -- Когда открыт доступ
+                             PRIMARY KEY (list_id, user_id)
+);
+
+CREATE INDEX idx_list_shares_user_id ON list_shares (user_id);
+
+-- Создаем таблицу share_links (публичные ссылки на заметки только для чтения)
+CREATE TABLE share_links (
+                             id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+                             note_id BIGINT NOT NULL REFERENCES all_notes(id) ON DELETE CASCADE, -- Заметка, доступная по ссылке
+                             user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE, -- Кто создал ссылку (владелец заметки)
+                             token_hash TEXT NOT NULL UNIQUE, -- SHA-256 токена ссылки (сам токен не хранится)
+                             password_hash TEXT NOT NULL DEFAULT '', -- bcrypt-хеш пароля (пустой - без пароля)
+                             expires_at TIMESTAMPTZ, -- Срок действия ссылки (NULL - бессрочно)
+                             revoked_at TIMESTAMPTZ, -- Время отзыва ссылки
+                             access_count BIGINT NOT NULL DEFAULT 0, -- Количество успешных открытий
+                             last_accessed_at TIMESTAMPTZ, -- Время последнего открытия
+                             failed_attempts INT NOT NULL DEFAULT 0, -- Неверные пароли подряд (сбрасывается при успешном открытии)
+                             locked_until TIMESTAMPTZ, -- До какого времени проверка пароля заблокирована после серии неверных паролей
+                             created_at TIMESTAMPTZ NOT NULL DEFAULT now() -- Время создания записи
+);
+
+CREATE INDEX idx_share_links_note_id ON share_links (note_id);
+
+-- Создаем таблицу note_comments (обсуждение заметок)
+CREATE TABLE note_comments (
+                               id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+                               note_id BIGINT NOT NULL REFERENCES all_notes(id) ON DELETE CASCADE, -- Заметка, к которой относится комментарий
+                               user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE, -- Автор комментария
+                               body TEXT NOT NULL, -- Текст комментария
+                               created_at TIMESTAMPTZ NOT NULL DEFAULT now(), -- Время создания
+                               edited_at TIMESTAMPTZ -- Время последнего изменения (NULL - не изменялся)
 );
+
+CREATE INDEX idx_note_comments_note_id ON note_comments (note_id, created_at);
+
+-- Создаем таблицу attachments (файлы, прикреплённые к заметкам; содержимое лежит в BlobStore)
+CREATE TABLE attachments (
+                             id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+                             note_id BIGINT REFERENCES all_notes(id) ON DELETE SET NULL, -- NULL - заметка удалена, файл ждёт удаления из хранилища
+                             user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE, -- Владелец заметки (учитывается в квоте)
+                             file_name TEXT NOT NULL, -- Исходное имя файла
+                             content_type TEXT NOT NULL, -- Тип содержимого, определённый по первым байтам файла
+                             size BIGINT NOT NULL, -- Размер файла в байтах
+                             storage_key TEXT NOT NULL UNIQUE, -- Ключ объекта в хранилище
+                             created_at TIMESTAMPTZ NOT NULL DEFAULT now() -- Время загрузки
+);
+
+CREATE INDEX idx_attachments_note_id ON attachments (note_id);
+
+-- Индекс для очистки файлов окончательно удалённых заметок
+CREATE INDEX idx_attachments_orphaned ON attachments (id) WHERE note_id IS NULL;
+
+-- Создаем таблицу calendar_feeds (приватные ссылки на ленту задач iCalendar; у пользователя одна ссылка)
+CREATE TABLE calendar_feeds (
+                                user_id BIGINT PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE, -- Владелец ленты
+                                token_hash TEXT NOT NULL UNIQUE, -- SHA-256 токена (сам токен не хранится)
+                                created_at TIMESTAMPTZ NOT NULL DEFAULT now(), -- Время создания (или смены) токена
+                                last_accessed_at TIMESTAMPTZ, -- Время последнего обращения календарного клиента
+                                last_synced_at TIMESTAMPTZ -- Время последней загрузки задач (ограничение частоты PUT /ical/:token)
+);
+
+-- Создаем таблицу import_jobs (фоновый импорт из Todoist, Microsoft To Do и Google Tasks)
+CREATE TABLE import_jobs (
+                             id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+                             user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE, -- Владелец задания
+                             source TEXT NOT NULL CHECK (source IN ('todoist', 'mstodo', 'gtasks')), -- Источник файла экспорта
+                             list_name TEXT NOT NULL DEFAULT '', -- Список для задач без проекта (пустой - список по умолчанию)
+                             storage_key TEXT NOT NULL, -- Ключ загруженного файла в хранилище (удаляется после завершения)
+                             status TEXT NOT NULL DEFAULT 'queued' CHECK (status IN ('queued', 'running', 'done', 'failed')), -- Состояние задания
+                             total INTEGER NOT NULL DEFAULT 0, -- Задач в файле
+                             processed INTEGER NOT NULL DEFAULT 0, -- Обработано задач (с этого места задание продолжается после сбоя)
+                             lists_created INTEGER NOT NULL DEFAULT 0, -- Создано списков
+                             notes_created INTEGER NOT NULL DEFAULT 0, -- Создано заметок
+                             items_created INTEGER NOT NULL DEFAULT 0, -- Создано пунктов чек-листа (из подзадач)
+                             failed INTEGER NOT NULL DEFAULT 0, -- Задачи, которые не удалось импортировать
+                             errors JSONB NOT NULL DEFAULT '[]', -- Ошибки по задачам (не более 100)
+                             error TEXT NOT NULL DEFAULT '', -- Причина, по которой задание не выполнено
+                             attempts INTEGER NOT NULL DEFAULT 0, -- Количество захватов задания обработчиком
+                             locked_until TIMESTAMPTZ, -- До какого времени задание захвачено обработчиком
+                             created_at TIMESTAMPTZ NOT NULL DEFAULT now(), -- Время загрузки файла
+                             started_at TIMESTAMPTZ, -- Время начала обработки
+                             finished_at TIMESTAMPTZ -- Время завершения
+);
+
+CREATE INDEX idx_import_jobs_user_id ON import_jobs (user_id, created_at DESC);
+
+-- Индекс для выбора заданий в очереди
+CREATE INDEX idx_import_jobs_pending ON import_jobs (created_at, id) WHERE status IN ('queued', 'running');
+
+-- Создаем таблицу note_tombstones (окончательно удалённые заметки для синхронизации клиентов).
+-- Внешнего ключа на users нет: при удалении пользователя каскадом удаляются его заметки,
+-- и триггер добавляет надгробия в той же команде
+CREATE TABLE note_tombstones (
+                                 note_id BIGINT PRIMARY KEY, -- ID удалённой заметки
+                                 user_id BIGINT NOT NULL, -- Владелец заметки
+                                 change_seq BIGINT NOT NULL, -- Номер изменения (ID транзакции удаления)
+                                 deleted_at TIMESTAMPTZ NOT NULL DEFAULT now() -- Время удаления
+);
+
+CREATE INDEX idx_note_tombstones_user_change_seq ON note_tombstones (user_id, change_seq, note_id);
+
+-- Номер изменения - ID транзакции (xid8 не переполняется). Изменения транзакции с меньшим ID могут стать
+-- видимы позже изменений с большим, поэтому синхронизация отдаёт только изменения завершённых транзакций
+-- (ID меньше xmin текущего снимка) - так клиент не пропустит изменение, закоммиченное позже
+CREATE FUNCTION note_change_seq() RETURNS TRIGGER AS $$
+BEGIN
+    NEW.change_seq := pg_current_xact_id()::text::bigint;
+    NEW.updated_at := clock_timestamp();
+    IF TG_OP = 'UPDATE' THEN
+        NEW.version := OLD.version + 1;
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER all_notes_change_seq BEFORE INSERT OR UPDATE ON all_notes
+    FOR EACH ROW EXECUTE FUNCTION note_change_seq();
+
+-- Окончательное удаление заметки оставляет надгробие (перемещение в корзину - обычное изменение)
+CREATE FUNCTION note_tombstone() RETURNS TRIGGER AS $$
+BEGIN
+    INSERT INTO note_tombstones (note_id, user_id, change_seq)
+    VALUES (OLD.id, OLD.user_id, pg_current_xact_id()::text::bigint)
+    ON CONFLICT (note_id) DO UPDATE SET change_seq = EXCLUDED.change_seq, deleted_at = now();
+    RETURN OLD;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER all_notes_tombstone AFTER DELETE ON all_notes
+    FOR EACH ROW EXECUTE FUNCTION note_tombstone();
+
+-- Изменение пунктов чек-листа, меток, комментариев и вложений - изменение заметки (прогресс, метки
+-- и счётчики входят в её представление, а от версии зависит ETag)
+CREATE FUNCTION note_touch_parent() RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        UPDATE all_notes SET updated_at = now() WHERE id = OLD.note_id;
+        RETURN OLD;
+    END IF;
+    UPDATE all_notes SET updated_at = now() WHERE id = NEW.note_id;
+    IF TG_OP = 'UPDATE' AND OLD.note_id IS DISTINCT FROM NEW.note_id THEN
+        UPDATE all_notes SET updated_at = now() WHERE id = OLD.note_id;
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER note_items_touch_note AFTER INSERT OR UPDATE OR DELETE ON note_items
+    FOR EACH ROW EXECUTE FUNCTION note_touch_parent();
+
+CREATE TRIGGER note_tags_touch_note AFTER INSERT OR UPDATE OR DELETE ON note_tags
+    FOR EACH ROW EXECUTE FUNCTION note_touch_parent();
+
+CREATE TRIGGER note_comments_touch_note AFTER INSERT OR UPDATE OR DELETE ON note_comments
+    FOR EACH ROW EXECUTE FUNCTION note_touch_parent();
+
+CREATE TRIGGER attachments_touch_note AFTER INSERT OR UPDATE OR DELETE ON attachments
+    FOR EACH ROW EXECUTE FUNCTION note_touch_parent();
+
+-- События заметок для клиентов с открытым потоком GET /events. NOTIFY доставляется после коммита
+-- всем экземплярам API, каждый рассылает событие своим подписчикам. Полезная нагрузка - только
+-- идентификаторы: клиент получает заметку через GET /sync
+-- Для подписок webhook событие сохраняется в очередь доставок (webhook_deliveries, см. ниже)
+CREATE FUNCTION note_event() RETURNS TRIGGER AS $$
+DECLARE
+    kind TEXT := 'updated';
+    note all_notes%ROWTYPE;
+    seq BIGINT;
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        -- Заметка из корзины удаляется окончательно: клиенты уже получили событие deleted
+        IF OLD.deleted_at IS NOT NULL THEN
+            RETURN NULL;
+        END IF;
+        kind := 'deleted';
+        note := OLD;
+        seq := pg_current_xact_id()::text::bigint;
+    ELSE
+        note := NEW;
+        seq := NEW.change_seq;
+        IF TG_OP = 'INSERT' THEN
+            kind := 'created';
+        ELSIF NEW.deleted_at IS NOT NULL AND OLD.deleted_at IS NULL THEN
+            kind := 'deleted';
+        ELSIF NEW.completed IS TRUE AND OLD.completed IS NOT TRUE THEN
+            kind := 'completed';
+        END IF;
+    END IF;
+
+    PERFORM pg_notify('note_events', json_build_object(
+        'type', kind, 'userID', note.user_id, 'noteID', note.id, 'listID', note.list_id, 'seq', seq
+    )::text);
+
+    -- Исходящие webhook: доставки записываются в той же транзакции, что и изменение (outbox)
+    INSERT INTO webhook_deliveries (webhook_id, event, payload)
+    SELECT w.id, 'note.' || kind, jsonb_build_object(
+        'event', 'note.' || kind,
+        'occurredAt', now(),
+        'data', jsonb_build_object(
+            'noteID', note.id, 'listID', note.list_id, 'note', note.note, 'completed', note.completed,
+            'dueAt', note.due_at, 'priority', note.priority, 'deletedAt', note.deleted_at, 'seq', seq
+        )
+    )
+    FROM webhooks w
+    WHERE w.user_id = note.user_id AND w.active
+      AND (cardinality(w.events) = 0 OR 'note.' || kind = ANY (w.events));
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER all_notes_event AFTER INSERT OR UPDATE OR DELETE ON all_notes
+    FOR EACH ROW EXECUTE FUNCTION note_event();
+
+-- Создаем таблицу webhooks (подписки пользователя на события заметок)
+CREATE TABLE webhooks (
+                          id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+                          user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE, -- Владелец подписки
+                          url TEXT NOT NULL, -- Адрес получателя (http или https)
+                          secret TEXT NOT NULL, -- Секрет для подписи HMAC-SHA256 (показывается пользователю только при создании)
+                          events TEXT[] NOT NULL DEFAULT '{}', -- Типы событий (пустой массив - все события)
+                          active BOOLEAN NOT NULL DEFAULT TRUE, -- Неактивной подписке события не записываются
+                          created_at TIMESTAMPTZ NOT NULL DEFAULT now() -- Время создания
+);
+
+CREATE INDEX idx_webhooks_user_id ON webhooks (user_id);
+
+-- Создаем таблицу webhook_deliveries (очередь доставок и журнал попыток)
+CREATE TABLE webhook_deliveries (
+                                    id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+                                    webhook_id BIGINT NOT NULL REFERENCES webhooks(id) ON DELETE CASCADE, -- Подписка
+                                    event TEXT NOT NULL, -- Тип события (note.created, note.updated, note.completed, note.deleted, ping)
+                                    payload JSONB NOT NULL, -- Тело запроса
+                                    status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'sending', 'delivered', 'failed')), -- Статус доставки
+                                    attempts INTEGER NOT NULL DEFAULT 0, -- Количество попыток
+                                    next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT now(), -- Время следующей попытки
+                                    locked_until TIMESTAMPTZ, -- До какого времени доставка захвачена обработчиком
+                                    response_status INTEGER, -- HTTP-статус последнего ответа (NULL - ответа не было)
+                                    response_body TEXT NOT NULL DEFAULT '', -- Начало тела последнего ответа
+                                    duration_ms INTEGER, -- Длительность последней попытки
+                                    last_error TEXT NOT NULL DEFAULT '', -- Ошибка последней неудачной попытки
+                                    redelivery_of BIGINT REFERENCES webhook_deliveries(id) ON DELETE SET NULL, -- Исходная доставка (при повторной доставке вручную)
+                                    created_at TIMESTAMPTZ NOT NULL DEFAULT now(), -- Время события
+                                    delivered_at TIMESTAMPTZ -- Время успешной доставки
+);
+
+-- Индекс для выборки доставок, которые пора отправить
+CREATE INDEX idx_webhook_deliveries_due ON webhook_deliveries (next_attempt_at) WHERE status IN ('pending', 'sending');
+
+-- Индекс для журнала доставок подписки
+CREATE INDEX idx_webhook_deliveries_webhook_id ON webhook_deliveries (webhook_id, created_at DESC, id DESC);
+
+-- Индекс для удаления старых записей журнала доставок
+CREATE INDEX idx_webhook_deliveries_finished ON webhook_deliveries (created_at) WHERE status IN ('delivered', 'failed');
+
+-- Создаем таблицу idempotency_keys (ключи Idempotency-Key: повтор запроса возвращает сохранённый ответ)
+CREATE TABLE idempotency_keys (
+                                  user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE, -- Владелец ключа
+                                  key TEXT NOT NULL, -- Значение заголовка Idempotency-Key
+                                  fingerprint TEXT NOT NULL, -- Отпечаток запроса (метод, путь, параметры и тело)
+                                  status TEXT NOT NULL DEFAULT 'processing' CHECK (status IN ('processing', 'completed')), -- Запрос выполняется или ответ сохранён
+                                  response_status INTEGER, -- HTTP-статус сохранённого ответа
+                                  response_headers JSONB NOT NULL DEFAULT '{}', -- Заголовки сохранённого ответа (Content-Type, ETag, Location)
+                                  response_body BYTEA, -- Тело сохранённого ответа
+                                  created_at TIMESTAMPTZ NOT NULL DEFAULT now(), -- Время первого запроса
+                                  expires_at TIMESTAMPTZ NOT NULL, -- После этого времени ключ можно использовать заново
+                                  PRIMARY KEY (user_id, key)
+);
+
+-- Индекс для удаления ключей с истёкшим сроком хранения
+CREATE INDEX idx_idempotency_keys_expires_at ON idempotency_keys (expires_at);
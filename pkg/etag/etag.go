@@ -0,0 +1,94 @@
+// Package etag - ETag и условные запросы (If-Match, If-None-Match, RFC 9110)
+package etag
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// FromVersion - сильный ETag версии ресурса: "<version>"
+func FromVersion(version int64) string {
+	return `"` + strconv.FormatInt(version, 10) + `"`
+}
+
+// FromBody - слабый ETag содержимого ответа: W/"<начало sha256>"
+func FromBody(body []byte) string {
+	sum := sha256.Sum256(body)
+	return `W/"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// ParseVersions - версии из заголовка If-Match. nil - условия нет (заголовок пуст или "*").
+// Слабые и нечисловые ETag не совпадают ни с одной версией (If-Match использует строгое сравнение),
+// поэтому для них возвращается пустой срез, а не nil
+func ParseVersions(header string) []int64 {
+	header = strings.TrimSpace(header)
+	if header == "" || header == "*" {
+		return nil
+	}
+
+	versions := make([]int64, 0, 1)
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if len(tag) < 2 || tag[0] != '"' || tag[len(tag)-1] != '"' {
+			continue
+		}
+		if version, err := strconv.ParseInt(tag[1:len(tag)-1], 10, 64); err == nil {
+			versions = append(versions, version)
+		}
+	}
+
+	return versions
+}
+
+// NoneMatch - совпадает ли etag с заголовком If-None-Match (слабое сравнение: префикс W/ не учитывается)
+func NoneMatch(header, etag string) bool {
+	header = strings.TrimSpace(header)
+	if header == "" {
+		return false
+	}
+	if header == "*" {
+		return true
+	}
+
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, tag := range strings.Split(header, ",") {
+		if strings.TrimPrefix(strings.TrimSpace(tag), "W/") == etag {
+			return true
+		}
+	}
+
+	return false
+}
+
+// WriteJSON - отправить v в формате JSON с ETag. Если клиент прислал совпадающий If-None-Match,
+// отправляется 304 без тела. Если etag пуст, используется слабый ETag содержимого (FromBody)
+func WriteJSON(w http.ResponseWriter, r *http.Request, etag string, v any) error {
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(v); err != nil {
+		return err
+	}
+
+	if etag == "" {
+		etag = FromBody(buf.Bytes())
+	}
+
+	// Ответы зависят от пользователя: общие кэши их не хранят, клиент перепроверяет ETag при каждом запросе
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", "private, no-cache")
+
+	if NoneMatch(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return nil
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
@@ -0,0 +1,473 @@
+// Package ical реализует кодирование и разбор задач iCalendar (RFC 5545, компонент VTODO):
+// экранирование текста, свёртку строк длиннее 75 октетов и значения DATE / DATE-TIME.
+package ical
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Статусы задачи (свойство STATUS)
+const (
+	StatusNeedsAction = "NEEDS-ACTION"
+	StatusInProcess   = "IN-PROCESS"
+	StatusCompleted   = "COMPLETED"
+	StatusCancelled   = "CANCELLED"
+)
+
+// maxLineOctets - максимальная длина строки без CRLF (RFC 5545, 3.1)
+const maxLineOctets = 75
+
+const (
+	dateLayout     = "20060102"
+	dateTimeLayout = "20060102T150405"
+	utcLayout      = "20060102T150405Z"
+)
+
+// ErrInvalidCalendar - данные не являются корректным календарём iCalendar
+var ErrInvalidCalendar = errors.New("некорректный файл iCalendar")
+
+// Todo - задача VTODO
+type Todo struct {
+	UID          string
+	Summary      string
+	Description  string
+	Status       string
+	Created      *time.Time
+	LastModified *time.Time
+	DTStamp      *time.Time
+	Completed    *time.Time // Время выполнения (свойство COMPLETED)
+	Start        *time.Time // DTSTART
+	Due          *time.Time
+	DueIsDate    bool // DUE задан датой без времени (VALUE=DATE)
+	Priority     int  // 0 - не задан, 1 - наивысший, 9 - наименьший
+	RRule        string
+	Categories   []string
+}
+
+// Encoder пишет календарь построчно, не собирая его в памяти
+type Encoder struct {
+	w   *bufio.Writer
+	err error
+}
+
+// NewEncoder создаёт Encoder, пишущий в w
+func NewEncoder(w io.Writer) *Encoder {
+	return &Encoder{w: bufio.NewWriter(w)}
+}
+
+// Begin начинает календарь: prodID - идентификатор приложения (PRODID), name - название календаря в клиентах
+func (e *Encoder) Begin(prodID, name string) error {
+	e.line("BEGIN:VCALENDAR")
+	e.line("VERSION:2.0")
+	e.line("PRODID:" + prodID)
+	e.line("CALSCALE:GREGORIAN")
+	if name != "" {
+		e.line("X-WR-CALNAME:" + EscapeText(name))
+	}
+	return e.err
+}
+
+// WriteTodo записывает задачу
+func (e *Encoder) WriteTodo(todo Todo) error {
+	e.line("BEGIN:VTODO")
+	e.line("UID:" + EscapeText(todo.UID))
+
+	dtstamp := time.Now()
+	if todo.DTStamp != nil {
+		dtstamp = *todo.DTStamp
+	}
+	e.line("DTSTAMP:" + FormatUTC(dtstamp))
+
+	if todo.Created != nil {
+		e.line("CREATED:" + FormatUTC(*todo.Created))
+	}
+	if todo.LastModified != nil {
+		e.line("LAST-MODIFIED:" + FormatUTC(*todo.LastModified))
+	}
+	e.line("SUMMARY:" + EscapeText(todo.Summary))
+	if todo.Description != "" {
+		e.line("DESCRIPTION:" + EscapeText(todo.Description))
+	}
+	if todo.Status != "" {
+		e.line("STATUS:" + todo.Status)
+	}
+	if todo.Completed != nil {
+		e.line("COMPLETED:" + FormatUTC(*todo.Completed))
+	}
+	if todo.Status == StatusCompleted {
+		e.line("PERCENT-COMPLETE:100")
+	}
+	if todo.Start != nil {
+		e.line("DTSTART:" + FormatUTC(*todo.Start))
+	}
+	if todo.Due != nil {
+		if todo.DueIsDate {
+			e.line("DUE;VALUE=DATE:" + todo.Due.Format(dateLayout))
+		} else {
+			e.line("DUE:" + FormatUTC(*todo.Due))
+		}
+	}
+	if todo.Priority > 0 {
+		e.line("PRIORITY:" + strconv.Itoa(todo.Priority))
+	}
+	if todo.RRule != "" {
+		e.line("RRULE:" + todo.RRule)
+	}
+	if len(todo.Categories) > 0 {
+		categories := make([]string, len(todo.Categories))
+		for i, category := range todo.Categories {
+			categories[i] = EscapeText(category)
+		}
+		e.line("CATEGORIES:" + strings.Join(categories, ","))
+	}
+	e.line("END:VTODO")
+
+	return e.err
+}
+
+// End завершает календарь и сбрасывает буфер
+func (e *Encoder) End() error {
+	e.line("END:VCALENDAR")
+	if e.err != nil {
+		return e.err
+	}
+	return e.w.Flush()
+}
+
+// line записывает строку содержимого со свёрткой: продолжение начинается с пробела,
+// строка не длиннее 75 октетов и не разрывает UTF-8 символы
+func (e *Encoder) line(value string) {
+	if e.err != nil {
+		return
+	}
+
+	limit := maxLineOctets
+	for len(value) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(value[cut]) {
+			cut--
+		}
+
+		if _, e.err = e.w.WriteString(value[:cut] + "\r\n "); e.err != nil {
+			return
+		}
+		value = value[cut:]
+		limit = maxLineOctets - 1 // Пробел в начале строки продолжения входит в длину
+	}
+
+	_, e.err = e.w.WriteString(value + "\r\n")
+}
+
+// EscapeText экранирует значение типа TEXT: обратная косая черта, ";", "," и переводы строк
+func EscapeText(value string) string {
+	var b strings.Builder
+	for _, r := range value {
+		switch r {
+		case '\\':
+			b.WriteString(`\\`)
+		case ';':
+			b.WriteString(`\;`)
+		case ',':
+			b.WriteString(`\,`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// UnescapeText восстанавливает значение типа TEXT
+func UnescapeText(value string) string {
+	if !strings.Contains(value, `\`) {
+		return value
+	}
+
+	var b strings.Builder
+	escaped := false
+	for _, r := range value {
+		if !escaped {
+			if r == '\\' {
+				escaped = true
+			} else {
+				b.WriteRune(r)
+			}
+			continue
+		}
+
+		escaped = false
+		switch r {
+		case 'n', 'N':
+			b.WriteByte('\n')
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// FormatUTC - значение DATE-TIME в UTC (20060102T150405Z)
+func FormatUTC(t time.Time) string {
+	return t.UTC().Format(utcLayout)
+}
+
+// property - разобранная строка содержимого NAME;PARAM=VALUE:value
+type property struct {
+	name   string
+	params map[string]string
+	value  string
+}
+
+// Decode разбирает календарь и возвращает его задачи VTODO.
+// Время без смещения и без известного TZID считается временем в loc
+func Decode(r io.Reader, loc *time.Location) ([]Todo, error) {
+	lines, err := unfold(r)
+	if err != nil {
+		return nil, err
+	}
+
+	todos := make([]Todo, 0)
+	inCalendar := false
+	var todo *Todo
+	nested := 0 // Вложенные в VTODO компоненты (VALARM), их свойства пропускаются
+
+	for _, raw := range lines {
+		if raw == "" {
+			continue
+		}
+
+		prop, err := parseProperty(raw)
+		if err != nil {
+			return nil, err
+		}
+
+		switch {
+		case prop.name == "BEGIN" && strings.EqualFold(prop.value, "VCALENDAR") && !inCalendar:
+			inCalendar = true
+			continue
+		case !inCalendar:
+			return nil, fmt.Errorf("%w: нет BEGIN:VCALENDAR", ErrInvalidCalendar)
+		case prop.name == "BEGIN" && todo == nil && strings.EqualFold(prop.value, "VTODO"):
+			todo = &Todo{}
+			continue
+		case prop.name == "BEGIN" && todo != nil:
+			nested++
+			continue
+		case prop.name == "END" && todo != nil && nested > 0:
+			nested--
+			continue
+		case prop.name == "END" && todo != nil:
+			if !strings.EqualFold(prop.value, "VTODO") {
+				return nil, fmt.Errorf("%w: незакрытый VTODO", ErrInvalidCalendar)
+			}
+			if todo.UID == "" {
+				return nil, fmt.Errorf("%w: у VTODO нет UID", ErrInvalidCalendar)
+			}
+			todos = append(todos, *todo)
+			todo = nil
+			continue
+		case prop.name == "END" && strings.EqualFold(prop.value, "VCALENDAR"):
+			return todos, nil
+		}
+
+		if todo == nil || nested > 0 {
+			continue
+		}
+
+		if err = todo.set(prop, loc); err != nil {
+			return nil, err
+		}
+	}
+
+	return nil, fmt.Errorf("%w: нет END:VCALENDAR", ErrInvalidCalendar)
+}
+
+// set - заполняет поле задачи по свойству; неизвестные свойства пропускаются
+func (t *Todo) set(prop property, loc *time.Location) error {
+	var err error
+
+	switch prop.name {
+	case "UID":
+		t.UID = UnescapeText(prop.value)
+	case "SUMMARY":
+		t.Summary = UnescapeText(prop.value)
+	case "DESCRIPTION":
+		t.Description = UnescapeText(prop.value)
+	case "STATUS":
+		t.Status = strings.ToUpper(strings.TrimSpace(prop.value))
+	case "PRIORITY":
+		if t.Priority, err = strconv.Atoi(strings.TrimSpace(prop.value)); err != nil || t.Priority < 0 || t.Priority > 9 {
+			return fmt.Errorf("%w: PRIORITY %q", ErrInvalidCalendar, prop.value)
+		}
+	case "RRULE":
+		t.RRule = strings.TrimSpace(prop.value)
+	case "CATEGORIES":
+		for _, category := range splitUnescaped(prop.value, ',') {
+			if category = strings.TrimSpace(UnescapeText(category)); category != "" {
+				t.Categories = append(t.Categories, category)
+			}
+		}
+	case "CREATED", "LAST-MODIFIED", "DTSTAMP", "COMPLETED", "DTSTART", "DUE":
+		value, isDate, err := parseTime(prop, loc)
+		if err != nil {
+			return err
+		}
+		switch prop.name {
+		case "CREATED":
+			t.Created = &value
+		case "LAST-MODIFIED":
+			t.LastModified = &value
+		case "DTSTAMP":
+			t.DTStamp = &value
+		case "COMPLETED":
+			t.Completed = &value
+		case "DTSTART":
+			t.Start = &value
+		case "DUE":
+			t.Due = &value
+			t.DueIsDate = isDate
+		}
+	}
+
+	return nil
+}
+
+// parseTime - значение DATE или DATE-TIME с учётом параметров VALUE и TZID
+func parseTime(prop property, loc *time.Location) (time.Time, bool, error) {
+	value := strings.TrimSpace(prop.value)
+
+	if strings.EqualFold(prop.params["VALUE"], "DATE") || len(value) == len(dateLayout) {
+		t, err := time.ParseInLocation(dateLayout, value, loc)
+		if err != nil {
+			return time.Time{}, false, fmt.Errorf("%w: %s %q", ErrInvalidCalendar, prop.name, prop.value)
+		}
+		return t, true, nil
+	}
+
+	if strings.HasSuffix(value, "Z") {
+		t, err := time.Parse(utcLayout, value)
+		if err != nil {
+			return time.Time{}, false, fmt.Errorf("%w: %s %q", ErrInvalidCalendar, prop.name, prop.value)
+		}
+		return t, false, nil
+	}
+
+	if tzid := prop.params["TZID"]; tzid != "" {
+		if tz, err := time.LoadLocation(strings.TrimPrefix(tzid, "/")); err == nil {
+			loc = tz
+		}
+	}
+
+	t, err := time.ParseInLocation(dateTimeLayout, value, loc)
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("%w: %s %q", ErrInvalidCalendar, prop.name, prop.value)
+	}
+	return t, false, nil
+}
+
+// unfold - читаем строки содержимого, склеивая строки продолжения (начинаются с пробела или табуляции)
+func unfold(r io.Reader) ([]string, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	lines := make([]string, 0)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if len(line) > 0 && (line[0] == ' ' || line[0] == '\t') && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidCalendar, err)
+	}
+
+	return lines, nil
+}
+
+// parseProperty - разбор строки NAME;PARAM=VALUE;PARAM="VALUE":value (":" и ";" в кавычках не разделяют)
+func parseProperty(line string) (property, error) {
+	quoted := false
+	colon := -1
+	for i := 0; i < len(line); i++ {
+		switch line[i] {
+		case '"':
+			quoted = !quoted
+		case ':':
+			if !quoted {
+				colon = i
+			}
+		}
+		if colon >= 0 {
+			break
+		}
+	}
+
+	if colon <= 0 {
+		return property{}, fmt.Errorf("%w: строка %q", ErrInvalidCalendar, line)
+	}
+
+	parts := splitQuoted(line[:colon], ';')
+	prop := property{
+		name:   strings.ToUpper(strings.TrimSpace(parts[0])),
+		params: make(map[string]string, len(parts)-1),
+		value:  line[colon+1:],
+	}
+
+	for _, param := range parts[1:] {
+		name, value, _ := strings.Cut(param, "=")
+		prop.params[strings.ToUpper(strings.TrimSpace(name))] = strings.Trim(value, `"`)
+	}
+
+	if prop.name == "BEGIN" || prop.name == "END" {
+		prop.value = strings.ToUpper(strings.TrimSpace(prop.value))
+	}
+
+	return prop, nil
+}
+
+// splitQuoted - разбиение по sep без учёта разделителей в двойных кавычках
+func splitQuoted(value string, sep byte) []string {
+	parts := make([]string, 0, 2)
+	quoted := false
+	start := 0
+	for i := 0; i < len(value); i++ {
+		switch value[i] {
+		case '"':
+			quoted = !quoted
+		case sep:
+			if !quoted {
+				parts = append(parts, value[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(parts, value[start:])
+}
+
+// splitUnescaped - разбиение по sep без учёта экранированных разделителей ("\,")
+func splitUnescaped(value string, sep byte) []string {
+	parts := make([]string, 0, 2)
+	start := 0
+	for i := 0; i < len(value); i++ {
+		switch value[i] {
+		case '\\':
+			i++
+		case sep:
+			parts = append(parts, value[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, value[start:])
+}
@@ -0,0 +1,226 @@
+package ical
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+	_ "time/tzdata" // TZID в тестах не зависит от системных часовых поясов
+	"unicode/utf8"
+)
+
+// encode - календарь из одной или нескольких задач
+func encode(t *testing.T, todos ...Todo) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	enc := NewEncoder(&buf)
+	if err := enc.Begin("-//test//RU", "Тест"); err != nil {
+		t.Fatalf("Begin: %v", err)
+	}
+	for _, todo := range todos {
+		if err := enc.WriteTodo(todo); err != nil {
+			t.Fatalf("WriteTodo: %v", err)
+		}
+	}
+	if err := enc.End(); err != nil {
+		t.Fatalf("End: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func ptr(t time.Time) *time.Time { return &t }
+
+func TestRoundTrip(t *testing.T) {
+	created := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	due := time.Date(2026, 2, 1, 18, 0, 0, 0, time.UTC)
+	done := time.Date(2026, 1, 31, 12, 0, 0, 0, time.UTC)
+
+	tests := []Todo{
+		{
+			UID:        "note-42@todolistjwtca",
+			Summary:    "Купить молоко, хлеб; и \\ сыр",
+			Status:     StatusNeedsAction,
+			Created:    &created,
+			DTStamp:    &created,
+			Due:        &due,
+			Priority:   1,
+			Categories: []string{"Дом, семья", "Покупки"},
+		},
+		{
+			UID:         "external-uid-with,comma",
+			Summary:     "Выполнено",
+			Description: "строка 1\nстрока 2",
+			Status:      StatusCompleted,
+			DTStamp:     &created,
+			Completed:   &done,
+		},
+		{
+			UID:       "date-only",
+			Summary:   "Весь день",
+			DTStamp:   &created,
+			Due:       ptr(time.Date(2026, 3, 8, 0, 0, 0, 0, time.UTC)),
+			DueIsDate: true,
+		},
+		{
+			UID:     "recurring",
+			Summary: "Каждый месяц",
+			DTStamp: &created,
+			Start:   &due,
+			Due:     &due,
+			RRule:   "FREQ=MONTHLY;BYMONTHDAY=1",
+		},
+	}
+
+	for _, want := range tests {
+		t.Run(want.UID, func(t *testing.T) {
+			got, err := Decode(bytes.NewReader(encode(t, want)), time.UTC)
+			if err != nil {
+				t.Fatalf("Decode: %v", err)
+			}
+			if len(got) != 1 {
+				t.Fatalf("got %d todos, want 1", len(got))
+			}
+			if !reflect.DeepEqual(got[0], want) {
+				t.Errorf("round trip mismatch:\ngot  %+v\nwant %+v", got[0], want)
+			}
+		})
+	}
+}
+
+func TestLineFolding(t *testing.T) {
+	long := strings.Repeat("длинный текст заметки с кириллицей ", 10)
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	data := encode(t, Todo{UID: "fold", Summary: long, DTStamp: &now})
+
+	if !bytes.HasSuffix(data, []byte("END:VCALENDAR\r\n")) {
+		t.Error("calendar must end with CRLF")
+	}
+
+	folded := 0
+	for _, line := range strings.Split(strings.TrimSuffix(string(data), "\r\n"), "\r\n") {
+		if len(line) > maxLineOctets {
+			t.Errorf("line is %d octets, want <= %d: %q", len(line), maxLineOctets, line)
+		}
+		if !utf8.ValidString(line) {
+			t.Errorf("folding split a UTF-8 character: %q", line)
+		}
+		if strings.HasPrefix(line, " ") {
+			folded++
+		}
+	}
+	if folded == 0 {
+		t.Fatal("long SUMMARY was not folded")
+	}
+
+	todos, err := Decode(bytes.NewReader(data), time.UTC)
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if todos[0].Summary != long {
+		t.Errorf("unfolded summary = %q, want %q", todos[0].Summary, long)
+	}
+}
+
+func TestUnfoldTab(t *testing.T) {
+	data := "BEGIN:VCALENDAR\r\nBEGIN:VTODO\r\nUID:tab\r\nSUMMARY:нача\r\n\tло\r\nEND:VTODO\r\nEND:VCALENDAR\r\n"
+
+	todos, err := Decode(strings.NewReader(data), time.UTC)
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if todos[0].Summary != "начало" {
+		t.Errorf("Summary = %q, want %q", todos[0].Summary, "начало")
+	}
+}
+
+func TestEscapeText(t *testing.T) {
+	tests := []struct {
+		in, escaped string
+	}{
+		{"простой текст", "простой текст"},
+		{`a\b`, `a\\b`},
+		{"a;b,c", `a\;b\,c`},
+		{"строка\nстрока", `строка\nстрока`},
+	}
+
+	for _, tt := range tests {
+		if got := EscapeText(tt.in); got != tt.escaped {
+			t.Errorf("EscapeText(%q) = %q, want %q", tt.in, got, tt.escaped)
+		}
+		if got := UnescapeText(tt.escaped); got != tt.in {
+			t.Errorf("UnescapeText(%q) = %q, want %q", tt.escaped, got, tt.in)
+		}
+	}
+
+	// \r выбрасывается, \N читается как перевод строки
+	if got := EscapeText("a\r\nb"); got != `a\nb` {
+		t.Errorf("EscapeText(CRLF) = %q", got)
+	}
+	if got := UnescapeText(`a\Nb`); got != "a\nb" {
+		t.Errorf("UnescapeText(\\N) = %q", got)
+	}
+}
+
+func TestDecodeTimes(t *testing.T) {
+	moscow, err := time.LoadLocation("Europe/Moscow")
+	if err != nil {
+		t.Fatalf("load location: %v", err)
+	}
+
+	data := strings.Join([]string{
+		"BEGIN:VCALENDAR",
+		"BEGIN:VTODO",
+		"UID:times",
+		`DUE;TZID="Europe/Berlin":20260701T120000`,
+		"DTSTART:20260701T090000",
+		"COMPLETED:20260702T100000Z",
+		"BEGIN:VALARM",
+		"TRIGGER:-PT15M",
+		"SUMMARY:не заголовок задачи",
+		"END:VALARM",
+		"END:VTODO",
+		"END:VCALENDAR",
+		"",
+	}, "\r\n")
+
+	todos, err := Decode(strings.NewReader(data), moscow)
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	todo := todos[0]
+
+	if want := time.Date(2026, 7, 1, 10, 0, 0, 0, time.UTC); !todo.Due.Equal(want) {
+		t.Errorf("Due = %v, want %v (TZID Europe/Berlin)", todo.Due, want)
+	}
+	if want := time.Date(2026, 7, 1, 6, 0, 0, 0, time.UTC); !todo.Start.Equal(want) {
+		t.Errorf("Start = %v, want %v (floating time in loc)", todo.Start, want)
+	}
+	if want := time.Date(2026, 7, 2, 10, 0, 0, 0, time.UTC); !todo.Completed.Equal(want) {
+		t.Errorf("Completed = %v, want %v", todo.Completed, want)
+	}
+	if todo.Summary != "" {
+		t.Errorf("Summary = %q: VALARM properties must be skipped", todo.Summary)
+	}
+}
+
+func TestDecodeInvalid(t *testing.T) {
+	tests := map[string]string{
+		"no calendar":        "BEGIN:VTODO\r\nUID:x\r\nEND:VTODO\r\n",
+		"no end":             "BEGIN:VCALENDAR\r\nBEGIN:VTODO\r\nUID:x\r\nEND:VTODO\r\n",
+		"todo without uid":   "BEGIN:VCALENDAR\r\nBEGIN:VTODO\r\nSUMMARY:x\r\nEND:VTODO\r\nEND:VCALENDAR\r\n",
+		"bad priority":       "BEGIN:VCALENDAR\r\nBEGIN:VTODO\r\nUID:x\r\nPRIORITY:10\r\nEND:VTODO\r\nEND:VCALENDAR\r\n",
+		"bad due":            "BEGIN:VCALENDAR\r\nBEGIN:VTODO\r\nUID:x\r\nDUE:завтра\r\nEND:VTODO\r\nEND:VCALENDAR\r\n",
+		"line without colon": "BEGIN:VCALENDAR\r\nSUMMARY\r\nEND:VCALENDAR\r\n",
+	}
+
+	for name, data := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := Decode(strings.NewReader(data), time.UTC); !errors.Is(err, ErrInvalidCalendar) {
+				t.Errorf("err = %v, want ErrInvalidCalendar", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,505 @@
+// Package rrule реализует подмножество правил повторения RFC 5545 (RRULE):
+// FREQ (DAILY, WEEKLY, MONTHLY, YEARLY), INTERVAL, COUNT, UNTIL, BYDAY, BYMONTHDAY, BYMONTH и WKST.
+package rrule
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Частота повторения
+const (
+	Daily   = "DAILY"
+	Weekly  = "WEEKLY"
+	Monthly = "MONTHLY"
+	Yearly  = "YEARLY"
+)
+
+// maxPeriods - ограничение количества перебираемых периодов, чтобы правило,
+// не дающее совпадений (например, 30 февраля), не зацикливало расчёт
+const maxPeriods = 100000
+
+// ErrInvalidRule - правило не соответствует поддерживаемому подмножеству RFC 5545
+var ErrInvalidRule = errors.New("некорректное правило повторения")
+
+// WeekdayNum - день недели с необязательным порядковым номером (например, 2MO, -1FR)
+type WeekdayNum struct {
+	N       int          // Порядковый номер внутри месяца/года (0 - каждый)
+	Weekday time.Weekday // День недели
+}
+
+// Rule - разобранное правило повторения
+type Rule struct {
+	Freq       string
+	Interval   int
+	Count      int        // 0 - без ограничения
+	Until      *time.Time // nil - без ограничения
+	ByDay      []WeekdayNum
+	ByMonthDay []int
+	ByMonth    []int
+	WeekStart  time.Weekday
+}
+
+var weekdays = map[string]time.Weekday{
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+	"SU": time.Sunday,
+}
+
+// Parse разбирает и проверяет правило вида "FREQ=MONTHLY;BYMONTHDAY=1".
+// Префикс "RRULE:" допускается. UNTIL без смещения (YYYYMMDD, YYYYMMDDTHHMMSS) считается временем в loc
+func Parse(value string, loc *time.Location) (*Rule, error) {
+	value = strings.TrimPrefix(strings.TrimSpace(value), "RRULE:")
+	if value == "" {
+		return nil, fmt.Errorf("%w: пустое правило", ErrInvalidRule)
+	}
+
+	rule := &Rule{Interval: 1, WeekStart: time.Monday}
+	seen := make(map[string]bool)
+
+	for _, part := range strings.Split(value, ";") {
+		name, val, ok := strings.Cut(part, "=")
+		name = strings.ToUpper(strings.TrimSpace(name))
+		val = strings.ToUpper(strings.TrimSpace(val))
+		if !ok || name == "" || val == "" {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidRule, part)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("%w: %s указан несколько раз", ErrInvalidRule, name)
+		}
+		seen[name] = true
+
+		var err error
+		switch name {
+		case "FREQ":
+			switch val {
+			case Daily, Weekly, Monthly, Yearly:
+				rule.Freq = val
+			default:
+				err = fmt.Errorf("неподдерживаемая частота %s", val)
+			}
+		case "INTERVAL":
+			rule.Interval, err = parseInt(val, 1, 1000)
+		case "COUNT":
+			rule.Count, err = parseInt(val, 1, 10000)
+		case "UNTIL":
+			var until time.Time
+			until, err = parseUntil(val, loc)
+			rule.Until = &until
+		case "BYDAY":
+			rule.ByDay, err = parseByDay(val)
+		case "BYMONTHDAY":
+			rule.ByMonthDay, err = parseIntList(val, -31, 31)
+		case "BYMONTH":
+			rule.ByMonth, err = parseIntList(val, 1, 12)
+		case "WKST":
+			weekday, ok := weekdays[val]
+			if !ok {
+				err = fmt.Errorf("некорректный WKST %s", val)
+			}
+			rule.WeekStart = weekday
+		default:
+			err = fmt.Errorf("параметр %s не поддерживается", name)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidRule, err)
+		}
+	}
+
+	if rule.Freq == "" {
+		return nil, fmt.Errorf("%w: не указан FREQ", ErrInvalidRule)
+	}
+	if rule.Count > 0 && rule.Until != nil {
+		return nil, fmt.Errorf("%w: COUNT и UNTIL нельзя указывать вместе", ErrInvalidRule)
+	}
+	for _, day := range rule.ByDay {
+		// Порядковый номер дня недели имеет смысл только для месячных и годовых правил
+		if day.N != 0 && rule.Freq != Monthly && rule.Freq != Yearly {
+			return nil, fmt.Errorf("%w: BYDAY с номером допустим только для MONTHLY и YEARLY", ErrInvalidRule)
+		}
+		if day.N != 0 && rule.Freq == Yearly && len(rule.ByMonth) == 0 && (day.N > 53 || day.N < -53) {
+			return nil, fmt.Errorf("%w: номер дня недели в году должен быть от -53 до 53", ErrInvalidRule)
+		}
+	}
+	if len(rule.ByMonthDay) > 0 && rule.Freq == Weekly {
+		return nil, fmt.Errorf("%w: BYMONTHDAY нельзя использовать с WEEKLY", ErrInvalidRule)
+	}
+
+	return rule, nil
+}
+
+// String возвращает правило в каноническом виде RFC 5545
+func (r *Rule) String() string {
+	parts := []string{"FREQ=" + r.Freq}
+
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+	if r.Count > 0 {
+		parts = append(parts, "COUNT="+strconv.Itoa(r.Count))
+	}
+	if r.Until != nil {
+		parts = append(parts, "UNTIL="+r.Until.UTC().Format("20060102T150405Z"))
+	}
+	if len(r.ByDay) > 0 {
+		days := make([]string, 0, len(r.ByDay))
+		for _, day := range r.ByDay {
+			prefix := ""
+			if day.N != 0 {
+				prefix = strconv.Itoa(day.N)
+			}
+			days = append(days, prefix+weekdayName(day.Weekday))
+		}
+		parts = append(parts, "BYDAY="+strings.Join(days, ","))
+	}
+	if len(r.ByMonthDay) > 0 {
+		parts = append(parts, "BYMONTHDAY="+joinInts(r.ByMonthDay))
+	}
+	if len(r.ByMonth) > 0 {
+		parts = append(parts, "BYMONTH="+joinInts(r.ByMonth))
+	}
+	if r.WeekStart != time.Monday {
+		parts = append(parts, "WKST="+weekdayName(r.WeekStart))
+	}
+
+	return strings.Join(parts, ";")
+}
+
+// Next возвращает первое повторение строго после after для серии, начинающейся в dtstart.
+// Расчёт ведётся по настенному времени в часовом поясе dtstart, поэтому при переходе
+// на летнее/зимнее время повторение остаётся в тот же час. ok == false, если повторений больше нет
+func (r *Rule) Next(dtstart, after time.Time) (time.Time, bool) {
+	var next time.Time
+	found := false
+
+	r.iterate(dtstart, func(occurrence time.Time) bool {
+		if occurrence.After(after) {
+			next, found = occurrence, true
+			return false
+		}
+		return true
+	})
+
+	return next, found
+}
+
+// Between возвращает повторения серии в интервале [from, to), не больше limit штук
+func (r *Rule) Between(dtstart, from, to time.Time, limit int) []time.Time {
+	occurrences := make([]time.Time, 0)
+
+	r.iterate(dtstart, func(occurrence time.Time) bool {
+		if !occurrence.Before(to) || len(occurrences) >= limit {
+			return false
+		}
+		if !occurrence.Before(from) {
+			occurrences = append(occurrences, occurrence)
+		}
+		return true
+	})
+
+	return occurrences
+}
+
+// iterate перебирает повторения по возрастанию, пока yield возвращает true
+func (r *Rule) iterate(dtstart time.Time, yield func(time.Time) bool) {
+	loc := dtstart.Location()
+	hour, minute, second := dtstart.Clock()
+	count := 0
+
+	// Начало первого периода (день, неделя, месяц или год, содержащие dtstart)
+	year, month, day := dtstart.Date()
+	switch r.Freq {
+	case Weekly:
+		offset := (int(dtstart.Weekday()) - int(r.WeekStart) + 7) % 7
+		day -= offset
+	case Monthly:
+		day = 1
+	case Yearly:
+		month, day = time.January, 1
+	}
+	period := time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+
+	for i := 0; i < maxPeriods; i++ {
+		for _, date := range r.candidates(period, dtstart) {
+			occurrence := time.Date(date.Year(), date.Month(), date.Day(), hour, minute, second, dtstart.Nanosecond(), loc)
+			if occurrence.Before(dtstart) {
+				continue
+			}
+			if r.Until != nil && occurrence.After(*r.Until) {
+				return
+			}
+
+			count++
+			if r.Count > 0 && count > r.Count {
+				return
+			}
+			if !yield(occurrence) {
+				return
+			}
+		}
+
+		switch r.Freq {
+		case Daily:
+			period = period.AddDate(0, 0, r.Interval)
+		case Weekly:
+			period = period.AddDate(0, 0, 7*r.Interval)
+		case Monthly:
+			period = period.AddDate(0, r.Interval, 0)
+		case Yearly:
+			period = period.AddDate(r.Interval, 0, 0)
+		}
+	}
+}
+
+// candidates - даты повторений внутри периода (даты в UTC, время суток не учитывается)
+func (r *Rule) candidates(period, dtstart time.Time) []time.Time {
+	var dates []time.Time
+
+	switch r.Freq {
+	case Daily:
+		dates = []time.Time{period}
+	case Weekly:
+		if len(r.ByDay) == 0 {
+			dates = []time.Time{period.AddDate(0, 0, (int(dtstart.Weekday())-int(period.Weekday())+7)%7)}
+			break
+		}
+		for i := 0; i < 7; i++ {
+			dates = append(dates, period.AddDate(0, 0, i))
+		}
+	case Monthly:
+		dates = r.monthDates(period.Year(), period.Month(), dtstart)
+	case Yearly:
+		switch {
+		case len(r.ByMonth) > 0:
+			for _, month := range r.ByMonth {
+				dates = append(dates, r.monthDates(period.Year(), time.Month(month), dtstart)...)
+			}
+		case len(r.ByMonthDay) > 0:
+			for month := time.January; month <= time.December; month++ {
+				dates = append(dates, r.monthDates(period.Year(), month, dtstart)...)
+			}
+		case len(r.ByDay) > 0:
+			dates = r.weekdayDates(period, period.AddDate(1, 0, 0))
+		default:
+			if date, ok := dayOfMonth(period.Year(), dtstart.Month(), dtstart.Day()); ok {
+				dates = []time.Time{date}
+			}
+		}
+	}
+
+	filtered := dates[:0]
+	for _, date := range dates {
+		if r.matches(date) {
+			filtered = append(filtered, date)
+		}
+	}
+
+	sort.Slice(filtered, func(i, j int) bool { return filtered[i].Before(filtered[j]) })
+
+	// Одна и та же дата может попасть в кандидаты несколько раз (например, BYDAY=MO,1MO)
+	unique := filtered[:0]
+	for i, date := range filtered {
+		if i == 0 || !date.Equal(filtered[i-1]) {
+			unique = append(unique, date)
+		}
+	}
+
+	return unique
+}
+
+// monthDates - даты месяца по BYMONTHDAY/BYDAY, либо день месяца dtstart
+func (r *Rule) monthDates(year int, month time.Month, dtstart time.Time) []time.Time {
+	var dates []time.Time
+
+	switch {
+	case len(r.ByMonthDay) > 0:
+		for _, monthDay := range r.ByMonthDay {
+			if date, ok := dayOfMonth(year, month, monthDay); ok {
+				dates = append(dates, date)
+			}
+		}
+	case len(r.ByDay) > 0:
+		first := time.Date(year, month, 1, 0, 0, 0, 0, time.UTC)
+		dates = r.weekdayDates(first, first.AddDate(0, 1, 0))
+	default:
+		// Месяцы без нужного числа (например, 31-го) пропускаются, как требует RFC 5545
+		if date, ok := dayOfMonth(year, month, dtstart.Day()); ok {
+			dates = []time.Time{date}
+		}
+	}
+
+	return dates
+}
+
+// weekdayDates - дни недели из BYDAY в интервале [from, to) с учётом порядковых номеров
+func (r *Rule) weekdayDates(from, to time.Time) []time.Time {
+	var dates []time.Time
+
+	for _, day := range r.ByDay {
+		var matched []time.Time
+		for date := from; date.Before(to); date = date.AddDate(0, 0, 1) {
+			if date.Weekday() == day.Weekday {
+				matched = append(matched, date)
+			}
+		}
+
+		switch {
+		case day.N == 0:
+			dates = append(dates, matched...)
+		case day.N > 0 && day.N <= len(matched):
+			dates = append(dates, matched[day.N-1])
+		case day.N < 0 && -day.N <= len(matched):
+			dates = append(dates, matched[len(matched)+day.N])
+		}
+	}
+
+	return dates
+}
+
+// matches - проверка даты ограничивающими фильтрами BYMONTH, BYMONTHDAY и BYDAY
+func (r *Rule) matches(date time.Time) bool {
+	if len(r.ByMonth) > 0 && !containsInt(r.ByMonth, int(date.Month())) {
+		return false
+	}
+
+	if len(r.ByMonthDay) > 0 && r.Freq != Monthly && r.Freq != Yearly {
+		ok := false
+		for _, monthDay := range r.ByMonthDay {
+			if d, valid := dayOfMonth(date.Year(), date.Month(), monthDay); valid && d.Equal(date) {
+				ok = true
+				break
+			}
+		}
+		if !ok {
+			return false
+		}
+	}
+
+	// Для месячных и годовых правил BYDAY вместе с BYMONTHDAY работает как фильтр
+	if len(r.ByDay) > 0 && (r.Freq == Daily || r.Freq == Weekly || len(r.ByMonthDay) > 0) {
+		ok := false
+		for _, day := range r.ByDay {
+			if day.Weekday == date.Weekday() {
+				ok = true
+				break
+			}
+		}
+		if !ok {
+			return false
+		}
+	}
+
+	return true
+}
+
+// dayOfMonth - дата по номеру дня месяца (отрицательный номер считается с конца месяца)
+func dayOfMonth(year int, month time.Month, day int) (time.Time, bool) {
+	last := time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC).Day()
+	if day < 0 {
+		day = last + day + 1
+	}
+	if day < 1 || day > last {
+		return time.Time{}, false
+	}
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC), true
+}
+
+// parseUntil - разбор UTC-времени (с суффиксом Z) или локального времени/даты в loc
+func parseUntil(value string, loc *time.Location) (time.Time, error) {
+	if until, err := time.Parse("20060102T150405Z", value); err == nil {
+		return until, nil
+	}
+	if until, err := time.ParseInLocation("20060102T150405", value, loc); err == nil {
+		return until, nil
+	}
+	if until, err := time.ParseInLocation("20060102", value, loc); err == nil {
+		// Дата без времени включает весь день
+		return until.AddDate(0, 0, 1).Add(-time.Second), nil
+	}
+	return time.Time{}, fmt.Errorf("некорректный UNTIL %s", value)
+}
+
+// parseByDay - разбор списка дней недели вида MO,2TU,-1FR
+func parseByDay(value string) ([]WeekdayNum, error) {
+	var days []WeekdayNum
+
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if len(item) < 2 {
+			return nil, fmt.Errorf("некорректный BYDAY %s", item)
+		}
+
+		weekday, ok := weekdays[item[len(item)-2:]]
+		if !ok {
+			return nil, fmt.Errorf("некорректный BYDAY %s", item)
+		}
+
+		day := WeekdayNum{Weekday: weekday}
+		if prefix := item[:len(item)-2]; prefix != "" {
+			n, err := strconv.Atoi(prefix)
+			if err != nil || n == 0 || n > 53 || n < -53 {
+				return nil, fmt.Errorf("некорректный BYDAY %s", item)
+			}
+			day.N = n
+		}
+		days = append(days, day)
+	}
+
+	return days, nil
+}
+
+// parseInt - разбор целого числа в диапазоне [min, max]
+func parseInt(value string, min, max int) (int, error) {
+	n, err := strconv.Atoi(value)
+	if err != nil || n < min || n > max {
+		return 0, fmt.Errorf("значение %s вне диапазона %d..%d", value, min, max)
+	}
+	return n, nil
+}
+
+// parseIntList - разбор списка ненулевых целых чисел в диапазоне [min, max]
+func parseIntList(value string, min, max int) ([]int, error) {
+	var values []int
+	for _, item := range strings.Split(value, ",") {
+		n, err := parseInt(strings.TrimSpace(item), min, max)
+		if err != nil || n == 0 {
+			return nil, fmt.Errorf("некорректное значение %s", item)
+		}
+		values = append(values, n)
+	}
+	return values, nil
+}
+
+func containsInt(values []int, value int) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func joinInts(values []int) string {
+	items := make([]string, 0, len(values))
+	for _, v := range values {
+		items = append(items, strconv.Itoa(v))
+	}
+	return strings.Join(items, ",")
+}
+
+func weekdayName(weekday time.Weekday) string {
+	for name, day := range weekdays {
+		if day == weekday {
+			return name
+		}
+	}
+	return ""
+}
@@ -0,0 +1,246 @@
+package rrule
+
+import (
+	"errors"
+	"testing"
+	"time"
+	_ "time/tzdata" // Часовые пояса для тестов перехода на летнее время не зависят от системы
+)
+
+func mustLoad(t *testing.T, name string) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Fatalf("load location %s: %v", name, err)
+	}
+	return loc
+}
+
+func TestParseInvalid(t *testing.T) {
+	tests := []string{
+		"",
+		"RRULE:",
+		"INTERVAL=2",                        // нет FREQ
+		"FREQ=HOURLY",                       // неподдерживаемая частота
+		"FREQ=DAILY;FREQ=WEEKLY",            // повтор параметра
+		"FREQ=DAILY;COUNT=3;UNTIL=20260101", // COUNT и UNTIL вместе
+		"FREQ=WEEKLY;BYDAY=2MO",             // номер дня только для MONTHLY/YEARLY
+		"FREQ=YEARLY;BYDAY=54MO",            // номер вне -53..53
+		"FREQ=WEEKLY;BYMONTHDAY=1",          // BYMONTHDAY с WEEKLY
+		"FREQ=MONTHLY;BYDAY=XX",             // неизвестный день
+		"FREQ=MONTHLY;BYMONTHDAY=32",        // день вне диапазона
+		"FREQ=YEARLY;BYMONTH=13",            // месяц вне диапазона
+		"FREQ=DAILY;INTERVAL=0",             // интервал вне диапазона
+		"FREQ=DAILY;UNTIL=tomorrow",         // некорректный UNTIL
+		"FREQ=DAILY;BYSETPOS=1",             // неподдерживаемый параметр
+		"FREQ=DAILY;COUNT",                  // нет значения
+	}
+
+	for _, value := range tests {
+		t.Run(value, func(t *testing.T) {
+			if _, err := Parse(value, time.UTC); !errors.Is(err, ErrInvalidRule) {
+				t.Errorf("Parse(%q) err = %v, want ErrInvalidRule", value, err)
+			}
+		})
+	}
+}
+
+func TestParseString(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"FREQ=DAILY", "FREQ=DAILY"},
+		{"RRULE:FREQ=MONTHLY;BYMONTHDAY=1", "FREQ=MONTHLY;BYMONTHDAY=1"},
+		{"freq=weekly;byday=mo,we;interval=2", "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,WE"},
+		{"FREQ=MONTHLY;BYDAY=-1FR", "FREQ=MONTHLY;BYDAY=-1FR"},
+		{"FREQ=DAILY;UNTIL=20260105T100000Z", "FREQ=DAILY;UNTIL=20260105T100000Z"},
+		{"FREQ=WEEKLY;WKST=SU", "FREQ=WEEKLY;WKST=SU"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			rule, err := Parse(tt.in, time.UTC)
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			if got := rule.String(); got != tt.want {
+				t.Errorf("String() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBetween(t *testing.T) {
+	utc := func(y int, m time.Month, d, h int) time.Time { return time.Date(y, m, d, h, 0, 0, 0, time.UTC) }
+
+	tests := []struct {
+		name    string
+		rule    string
+		dtstart time.Time
+		want    []time.Time
+	}{
+		{
+			name:    "daily count",
+			rule:    "FREQ=DAILY;COUNT=3",
+			dtstart: utc(2026, 1, 30, 9),
+			want:    []time.Time{utc(2026, 1, 30, 9), utc(2026, 1, 31, 9), utc(2026, 2, 1, 9)},
+		},
+		{
+			name:    "daily until inclusive",
+			rule:    "FREQ=DAILY;INTERVAL=2;UNTIL=20260105T090000Z",
+			dtstart: utc(2026, 1, 1, 9),
+			want:    []time.Time{utc(2026, 1, 1, 9), utc(2026, 1, 3, 9), utc(2026, 1, 5, 9)},
+		},
+		{
+			name:    "until date-only covers whole day",
+			rule:    "FREQ=DAILY;UNTIL=20260102",
+			dtstart: utc(2026, 1, 1, 23),
+			want:    []time.Time{utc(2026, 1, 1, 23), utc(2026, 1, 2, 23)},
+		},
+		{
+			name:    "weekly byday",
+			rule:    "FREQ=WEEKLY;BYDAY=MO,FR;COUNT=4",
+			dtstart: utc(2026, 1, 7, 8), // среда
+			want:    []time.Time{utc(2026, 1, 9, 8), utc(2026, 1, 12, 8), utc(2026, 1, 16, 8), utc(2026, 1, 19, 8)},
+		},
+		{
+			name:    "weekly without byday keeps weekday",
+			rule:    "FREQ=WEEKLY;INTERVAL=2;COUNT=3",
+			dtstart: utc(2026, 1, 7, 8),
+			want:    []time.Time{utc(2026, 1, 7, 8), utc(2026, 1, 21, 8), utc(2026, 2, 4, 8)},
+		},
+		{
+			name:    "monthly last friday",
+			rule:    "FREQ=MONTHLY;BYDAY=-1FR;COUNT=3",
+			dtstart: utc(2026, 1, 1, 12),
+			want:    []time.Time{utc(2026, 1, 30, 12), utc(2026, 2, 27, 12), utc(2026, 3, 27, 12)},
+		},
+		{
+			name:    "monthly second monday",
+			rule:    "FREQ=MONTHLY;BYDAY=2MO;COUNT=2",
+			dtstart: utc(2026, 1, 1, 12),
+			want:    []time.Time{utc(2026, 1, 12, 12), utc(2026, 2, 9, 12)},
+		},
+		{
+			name:    "monthly 31st skips short months",
+			rule:    "FREQ=MONTHLY;BYMONTHDAY=31;COUNT=3",
+			dtstart: utc(2026, 1, 31, 0),
+			want:    []time.Time{utc(2026, 1, 31, 0), utc(2026, 3, 31, 0), utc(2026, 5, 31, 0)},
+		},
+		{
+			name:    "monthly last day",
+			rule:    "FREQ=MONTHLY;BYMONTHDAY=-1;COUNT=2",
+			dtstart: utc(2026, 1, 15, 0),
+			want:    []time.Time{utc(2026, 1, 31, 0), utc(2026, 2, 28, 0)},
+		},
+		{
+			name:    "yearly leap day",
+			rule:    "FREQ=YEARLY;COUNT=2",
+			dtstart: utc(2024, 2, 29, 10),
+			want:    []time.Time{utc(2024, 2, 29, 10), utc(2028, 2, 29, 10)},
+		},
+		{
+			name:    "yearly bymonth byday",
+			rule:    "FREQ=YEARLY;BYMONTH=11;BYDAY=4TH;COUNT=2",
+			dtstart: utc(2026, 1, 1, 0),
+			want:    []time.Time{utc(2026, 11, 26, 0), utc(2027, 11, 25, 0)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, err := Parse(tt.rule, time.UTC)
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+
+			got := rule.Between(tt.dtstart, tt.dtstart, tt.dtstart.AddDate(10, 0, 0), 100)
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d occurrences %v, want %d %v", len(got), got, len(tt.want), tt.want)
+			}
+			for i := range got {
+				if !got[i].Equal(tt.want[i]) {
+					t.Errorf("occurrence %d = %v, want %v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestNextAcrossDST(t *testing.T) {
+	tests := []struct {
+		name     string
+		location string
+		rule     string
+		dtstart  [5]int // год, месяц, день, час, минута в часовом поясе location
+		want     [5]int
+		elapsed  time.Duration // Реальная длительность до повторения с учётом перевода часов
+	}{
+		// Весной часы переводят вперёд: повторение остаётся в 09:00 по местному времени
+		{"new york spring forward", "America/New_York", "FREQ=DAILY", [5]int{2026, 3, 7, 9, 0}, [5]int{2026, 3, 8, 9, 0}, 23 * time.Hour},
+		// Осенью часы переводят назад
+		{"berlin fall back", "Europe/Berlin", "FREQ=WEEKLY", [5]int{2026, 10, 21, 18, 30}, [5]int{2026, 10, 28, 18, 30}, 7*24*time.Hour + time.Hour},
+		{"monthly across DST", "Europe/Berlin", "FREQ=MONTHLY;BYMONTHDAY=15", [5]int{2026, 3, 15, 7, 0}, [5]int{2026, 4, 15, 7, 0}, 31*24*time.Hour - time.Hour},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			loc := mustLoad(t, tt.location)
+			rule, err := Parse(tt.rule, loc)
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+
+			d := tt.dtstart
+			dtstart := time.Date(d[0], time.Month(d[1]), d[2], d[3], d[4], 0, 0, loc)
+			next, ok := rule.Next(dtstart, dtstart)
+			if !ok {
+				t.Fatal("Next: no occurrence")
+			}
+
+			w := tt.want
+			want := time.Date(w[0], time.Month(w[1]), w[2], w[3], w[4], 0, 0, loc)
+			if !next.Equal(want) {
+				t.Errorf("Next = %v, want %v", next, want)
+			}
+			if elapsed := next.Sub(dtstart); elapsed != tt.elapsed {
+				t.Errorf("elapsed = %v, want %v", elapsed, tt.elapsed)
+			}
+		})
+	}
+}
+
+func TestNextExhausted(t *testing.T) {
+	rule, err := Parse("FREQ=DAILY;COUNT=2", time.UTC)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	dtstart := time.Date(2026, 1, 1, 9, 0, 0, 0, time.UTC)
+	if _, ok := rule.Next(dtstart, dtstart.AddDate(0, 0, 1)); ok {
+		t.Error("Next after last occurrence: ok = true, want false")
+	}
+
+	// Правило без совпадений (30 февраля) не должно зацикливаться
+	never, err := Parse("FREQ=YEARLY;BYMONTH=2;BYMONTHDAY=30", time.UTC)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if _, ok := never.Next(dtstart, dtstart); ok {
+		t.Error("impossible rule produced an occurrence")
+	}
+}
+
+func TestUntilLocalTime(t *testing.T) {
+	loc := mustLoad(t, "Europe/Moscow")
+	rule, err := Parse("FREQ=DAILY;UNTIL=20260103T100000", loc)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	dtstart := time.Date(2026, 1, 1, 10, 0, 0, 0, loc)
+	got := rule.Between(dtstart, dtstart, dtstart.AddDate(0, 1, 0), 10)
+	if len(got) != 3 {
+		t.Fatalf("got %v, want 3 occurrences up to local 10:00 on Jan 3", got)
+	}
+}
@@ -0,0 +1,66 @@
+package taskimport
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+)
+
+// googleTasksExport - Tasks.json из Google Takeout: списки задач с задачами
+type googleTasksExport struct {
+	Items []struct {
+		Title string       `json:"title"`
+		Items []googleTask `json:"items"`
+	} `json:"items"`
+}
+
+type googleTask struct {
+	ID      string `json:"id"`
+	Title   string `json:"title"`
+	Notes   string `json:"notes"`
+	Status  string `json:"status"` // needsAction | completed
+	Due     string `json:"due"`    // RFC 3339, значима только дата
+	Parent  string `json:"parent"` // Родительская задача (для подзадач)
+	Deleted bool   `json:"deleted"`
+}
+
+// parseGoogleTasks - выгрузка Google Tasks. Приоритетов в Google Tasks нет
+func parseGoogleTasks(r io.Reader, loc *time.Location) ([]Task, error) {
+	var export googleTasksExport
+	if err := json.NewDecoder(r).Decode(&export); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidFile, err)
+	}
+
+	b := newBuilder()
+	for _, list := range export.Items {
+		children := make([]pending, 0)
+
+		for _, item := range list.Items {
+			if item.Deleted {
+				continue
+			}
+
+			task := Task{
+				List:      list.Title,
+				Title:     item.Title,
+				Notes:     item.Notes,
+				Completed: item.Status == "completed",
+			}
+			if due, err := time.Parse(time.RFC3339, item.Due); err == nil {
+				due = dateIn(due.UTC(), loc)
+				task.Due = &due
+			}
+
+			if item.Parent != "" {
+				children = append(children, pending{id: item.ID, parentID: item.Parent, task: task})
+				continue
+			}
+			b.add(item.ID, task)
+		}
+
+		b.attach(children)
+	}
+
+	return b.tasks, nil
+}
@@ -0,0 +1,122 @@
+package taskimport
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// msTodoList - список Microsoft To Do в формате Graph API (todoTaskList) вместе с задачами
+type msTodoList struct {
+	DisplayName string       `json:"displayName"`
+	Tasks       []msTodoTask `json:"tasks"`
+}
+
+type msTodoTask struct {
+	Title string `json:"title"`
+	Body  *struct {
+		Content     string `json:"content"`
+		ContentType string `json:"contentType"` // text | html
+	} `json:"body"`
+	Status         string      `json:"status"`     // notStarted | inProgress | completed | waitingOnOthers | deferred
+	Importance     string      `json:"importance"` // low | normal | high
+	DueDateTime    *msDateTime `json:"dueDateTime"`
+	ChecklistItems []struct {
+		DisplayName string `json:"displayName"`
+		IsChecked   bool   `json:"isChecked"`
+	} `json:"checklistItems"`
+}
+
+// msDateTime - dateTimeTimeZone из Graph API: время без смещения и название часового пояса
+type msDateTime struct {
+	DateTime string `json:"dateTime"`
+	TimeZone string `json:"timeZone"`
+}
+
+// parseMSTodo - выгрузка Microsoft To Do: массив списков, {"lists": [...]} или ответ Graph API {"value": [...]}
+func parseMSTodo(r io.Reader, loc *time.Location) ([]Task, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidFile, err)
+	}
+
+	var lists []msTodoList
+	if data = bytes.TrimSpace(data); len(data) > 0 && data[0] == '[' {
+		err = json.Unmarshal(data, &lists)
+	} else {
+		var export struct {
+			Lists []msTodoList `json:"lists"`
+			Value []msTodoList `json:"value"`
+		}
+		err = json.Unmarshal(data, &export)
+		lists = append(export.Lists, export.Value...)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidFile, err)
+	}
+
+	b := newBuilder()
+	for _, list := range lists {
+		for _, item := range list.Tasks {
+			task := Task{
+				List:      list.DisplayName,
+				Title:     item.Title,
+				Completed: item.Status == "completed",
+				Due:       parseMSDateTime(item.DueDateTime, loc),
+				Priority:  msTodoPriority(item.Importance),
+			}
+			if item.Body != nil && !strings.EqualFold(item.Body.ContentType, "html") {
+				task.Notes = item.Body.Content
+			}
+			for _, checklist := range item.ChecklistItems {
+				if title := strings.TrimSpace(checklist.DisplayName); title != "" {
+					task.Subtasks = append(task.Subtasks, Subtask{Title: title, Completed: checklist.IsChecked})
+				}
+			}
+
+			b.add("", task)
+		}
+	}
+
+	return b.tasks, nil
+}
+
+// msTodoPriority - приоритет по важности задачи
+func msTodoPriority(importance string) int {
+	switch strings.ToLower(importance) {
+	case "high":
+		return PriorityHigh
+	case "low":
+		return PriorityLow
+	case "normal":
+		return PriorityNormal
+	default:
+		return PriorityNone
+	}
+}
+
+// parseMSDateTime - срок To Do. Часовые пояса Windows ("Pacific Standard Time") не поддерживаются
+// и считаются UTC; полночь означает срок без времени - это дата в loc
+func parseMSDateTime(value *msDateTime, loc *time.Location) *time.Time {
+	if value == nil || value.DateTime == "" {
+		return nil
+	}
+
+	tz, err := time.LoadLocation(value.TimeZone)
+	if err != nil {
+		tz = time.UTC
+	}
+
+	t, err := time.ParseInLocation("2006-01-02T15:04:05", value.DateTime, tz)
+	if err != nil {
+		return nil
+	}
+
+	if t.Hour() == 0 && t.Minute() == 0 && t.Second() == 0 {
+		t = dateIn(t, loc)
+	}
+	return &t
+}
@@ -0,0 +1,188 @@
+// Package taskimport разбирает файлы экспорта других менеджеров задач - Todoist (CSV-шаблон проекта
+// и JSON-резервная копия), Microsoft To Do и Google Tasks (JSON) - в общий вид: списки, задачи
+// со сроками, приоритетом и статусом выполнения, подзадачи.
+package taskimport
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// Источники (форматы файлов экспорта)
+const (
+	SourceTodoist     = "todoist" // CSV-шаблон проекта или JSON-резервная копия (Sync API)
+	SourceMSTodo      = "mstodo"  // Списки и задачи Microsoft To Do в формате Graph API
+	SourceGoogleTasks = "gtasks"  // Tasks.json из Google Takeout
+)
+
+// Приоритет задачи в общей шкале
+const (
+	PriorityNone = iota
+	PriorityLow
+	PriorityNormal
+	PriorityHigh
+	PriorityUrgent
+)
+
+var (
+	// ErrUnknownSource - источник не поддерживается
+	ErrUnknownSource = errors.New("taskimport: неизвестный источник")
+	// ErrInvalidFile - файл не соответствует формату источника
+	ErrInvalidFile = errors.New("taskimport: некорректный файл экспорта")
+)
+
+// Task - задача из файла экспорта. Подзадачи любой вложенности собраны в корневой задаче
+type Task struct {
+	List      string     // Проект или список (пустой - список не указан)
+	Title     string     // Текст задачи
+	Notes     string     // Описание и комментарии
+	Completed bool       // Статус выполнения
+	Due       *time.Time // Срок выполнения
+	Priority  int        // PriorityNone ... PriorityUrgent
+	Subtasks  []Subtask  // Подзадачи в порядке файла
+}
+
+// Subtask - подзадача
+type Subtask struct {
+	Title     string
+	Completed bool
+}
+
+// Parse разбирает файл экспорта источника source. defaultList - список для задач, у которых в файле
+// нет проекта (CSV-шаблон Todoist описывает один проект без названия). Даты без времени считаются
+// полночью в loc. Задачи и подзадачи без текста пропускаются
+func Parse(source string, r io.Reader, defaultList string, loc *time.Location) ([]Task, error) {
+	br := bufio.NewReader(r)
+	first, err := skipBOM(br)
+	if err != nil {
+		return nil, err
+	}
+
+	var tasks []Task
+	switch source {
+	case SourceTodoist:
+		if first == '{' {
+			tasks, err = parseTodoistJSON(br, loc)
+		} else {
+			tasks, err = parseTodoistCSV(br, loc)
+		}
+	case SourceMSTodo:
+		tasks, err = parseMSTodo(br, loc)
+	case SourceGoogleTasks:
+		tasks, err = parseGoogleTasks(br, loc)
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownSource, source)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range tasks {
+		if tasks[i].List == "" {
+			tasks[i].List = defaultList
+		}
+	}
+
+	return tasks, nil
+}
+
+// skipBOM - пропускаем BOM и пробельные символы в начале файла; возвращает первый значимый байт
+func skipBOM(br *bufio.Reader) (byte, error) {
+	if bom, _ := br.Peek(3); string(bom) == "\xef\xbb\xbf" {
+		_, _ = br.Discard(3)
+	}
+
+	for {
+		b, err := br.Peek(1)
+		if err == io.EOF {
+			return 0, fmt.Errorf("%w: пустой файл", ErrInvalidFile)
+		}
+		if err != nil {
+			return 0, fmt.Errorf("%w: %w", ErrInvalidFile, err)
+		}
+
+		switch b[0] {
+		case ' ', '\t', '\r', '\n':
+			_, _ = br.Discard(1)
+		default:
+			return b[0], nil
+		}
+	}
+}
+
+// builder - собирает задачи, относя подзадачи к корневой задаче
+type builder struct {
+	tasks []Task
+	roots map[string]int // ID задачи в файле -> индекс корневой задачи в tasks
+}
+
+func newBuilder() *builder {
+	return &builder{tasks: make([]Task, 0), roots: make(map[string]int)}
+}
+
+// add - добавить корневую задачу с идентификатором id (может быть пустым)
+func (b *builder) add(id string, task Task) {
+	task.Title = strings.TrimSpace(task.Title)
+	task.Notes = strings.TrimSpace(task.Notes)
+	if task.Title == "" {
+		return
+	}
+
+	b.tasks = append(b.tasks, task)
+	if id != "" {
+		b.roots[id] = len(b.tasks) - 1
+	}
+}
+
+// addSubtask - добавить подзадачу к корневой задаче, в которую входит parentID.
+// Возвращает false, если такой задачи нет
+func (b *builder) addSubtask(id, parentID string, subtask Subtask) bool {
+	root, ok := b.roots[parentID]
+	if !ok {
+		return false
+	}
+
+	if id != "" {
+		b.roots[id] = root
+	}
+
+	if subtask.Title = strings.TrimSpace(subtask.Title); subtask.Title != "" {
+		b.tasks[root].Subtasks = append(b.tasks[root].Subtasks, subtask)
+	}
+	return true
+}
+
+// dateIn - полночь даты t в часовом поясе loc (для сроков без времени)
+func dateIn(t time.Time, loc *time.Location) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+}
+
+// pending - подзадача, родитель которой может стоять в файле позже неё
+type pending struct {
+	id       string
+	parentID string
+	task     Task // Задача целиком - на случай, если родителя в файле нет
+}
+
+// attach - добавляем подзадачи, пока находятся их родители. Если ни одну подзадачу добавить
+// не удалось, первая из оставшихся становится обычной задачей (её родителя в файле нет)
+func (b *builder) attach(items []pending) {
+	for len(items) > 0 {
+		rest := make([]pending, 0, len(items))
+		for _, item := range items {
+			if !b.addSubtask(item.id, item.parentID, Subtask{Title: item.task.Title, Completed: item.task.Completed}) {
+				rest = append(rest, item)
+			}
+		}
+
+		if len(rest) == len(items) {
+			b.add(rest[0].id, rest[0].task)
+			rest = rest[1:]
+		}
+		items = rest
+	}
+}
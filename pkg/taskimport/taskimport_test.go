@@ -0,0 +1,204 @@
+package taskimport
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+	_ "time/tzdata" // Часовые пояса из файлов экспорта не зависят от системы
+)
+
+func mustLoad(t *testing.T, name string) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Fatalf("load location %s: %v", name, err)
+	}
+	return loc
+}
+
+func at(loc *time.Location, year int, month time.Month, day, hour, minute int) *time.Time {
+	t := time.Date(year, month, day, hour, minute, 0, 0, loc)
+	return &t
+}
+
+// assertTasks - сравнение задач; сроки сравниваются как моменты времени, без учёта *time.Location
+func assertTasks(t *testing.T, got, want []Task) {
+	t.Helper()
+
+	if len(got) != len(want) {
+		t.Fatalf("got %d tasks, want %d:\n%+v", len(got), len(want), got)
+	}
+
+	for i := range want {
+		g, w := got[i], want[i]
+		if (g.Due == nil) != (w.Due == nil) || (g.Due != nil && !g.Due.Equal(*w.Due)) {
+			t.Errorf("task %d %q: Due = %v, want %v", i, w.Title, g.Due, w.Due)
+		}
+		g.Due, w.Due = nil, nil
+		if !reflect.DeepEqual(g, w) {
+			t.Errorf("task %d:\ngot  %+v\nwant %+v", i, g, w)
+		}
+	}
+}
+
+func parseFixture(t *testing.T, source, name string, loc *time.Location) []Task {
+	t.Helper()
+
+	f, err := os.Open(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatalf("open fixture: %v", err)
+	}
+	defer f.Close()
+
+	tasks, err := Parse(source, f, "Импорт", loc)
+	if err != nil {
+		t.Fatalf("Parse(%s, %s): %v", source, name, err)
+	}
+	return tasks
+}
+
+func TestParseTodoistCSV(t *testing.T) {
+	moscow := mustLoad(t, "Europe/Moscow")
+
+	assertTasks(t, parseFixture(t, SourceTodoist, "todoist.csv", moscow), []Task{
+		{
+			List:     "Импорт",
+			Title:    "Подготовить отчёт",
+			Notes:    "Квартальный\n\nНе забыть приложения",
+			Due:      at(moscow, 2026, 3, 10, 0, 0),
+			Priority: PriorityUrgent,
+			Subtasks: []Subtask{{Title: "Собрать цифры"}, {Title: "Сверить, с бухгалтерией"}},
+		},
+		{
+			List:     "Импорт",
+			Title:    "Позвонить клиенту",
+			Due:      at(moscow, 2026, 3, 11, 15, 30),
+			Priority: PriorityHigh,
+		},
+		{
+			List:  "Импорт",
+			Title: "Без срока", // Срок на естественном языке не разбирается
+		},
+	})
+}
+
+func TestParseTodoistJSON(t *testing.T) {
+	moscow := mustLoad(t, "Europe/Moscow")
+	berlin := mustLoad(t, "Europe/Berlin")
+
+	assertTasks(t, parseFixture(t, SourceTodoist, "todoist.json", moscow), []Task{
+		{
+			List:     "Работа",
+			Title:    "Релиз",
+			Notes:    "Версия 2.0",
+			Due:      at(berlin, 2026, 4, 1, 10, 0),
+			Priority: PriorityUrgent,
+			Subtasks: []Subtask{
+				{Title: "Подзадача раньше родителя", Completed: true},
+				{Title: "Вложенная подзадача"},
+			},
+		},
+		{
+			List:      "Входящие",
+			Title:     "Купить хлеб",
+			Completed: true,
+			Due:       at(moscow, 2026, 4, 2, 0, 0),
+			Priority:  PriorityNormal,
+		},
+		{
+			// Родителя нет в файле - подзадача становится обычной задачей
+			List:     "Импорт",
+			Title:    "Сирота",
+			Due:      at(time.UTC, 2026, 4, 3, 8, 0),
+			Priority: PriorityHigh,
+		},
+	})
+}
+
+func TestParseMSTodo(t *testing.T) {
+	moscow := mustLoad(t, "Europe/Moscow")
+
+	assertTasks(t, parseFixture(t, SourceMSTodo, "mstodo.json", moscow), []Task{
+		{
+			List:     "Покупки",
+			Title:    "Молоко",
+			Notes:    "2 литра",
+			Due:      at(moscow, 2026, 5, 1, 0, 0), // Полночь - срок без времени
+			Priority: PriorityHigh,
+			Subtasks: []Subtask{{Title: "Обезжиренное", Completed: true}},
+		},
+		{
+			List:      "Покупки",
+			Title:     "Хлеб", // HTML-описание пропускается
+			Completed: true,
+			Due:       at(moscow, 2026, 5, 2, 18, 30),
+			Priority:  PriorityLow,
+		},
+		{
+			List:     "Работа",
+			Title:    "Созвон",
+			Due:      at(time.UTC, 2026, 5, 3, 9, 0), // Часовой пояс Windows считается UTC
+			Priority: PriorityNormal,
+		},
+	})
+}
+
+func TestParseGoogleTasks(t *testing.T) {
+	moscow := mustLoad(t, "Europe/Moscow")
+
+	assertTasks(t, parseFixture(t, SourceGoogleTasks, "gtasks.json", moscow), []Task{
+		{
+			List:     "Мои задачи",
+			Title:    "Переезд",
+			Notes:    "В субботу",
+			Due:      at(moscow, 2026, 6, 6, 0, 0),
+			Subtasks: []Subtask{{Title: "Подзадача", Completed: true}},
+		},
+		{
+			List:      "Дом",
+			Title:     "Полить цветы",
+			Completed: true,
+		},
+	})
+}
+
+func TestParseMSTodoArray(t *testing.T) {
+	data := `[{"displayName": "Список", "tasks": [{"title": "Задача", "status": "notStarted"}]}]`
+
+	tasks, err := Parse(SourceMSTodo, strings.NewReader(data), "Импорт", time.UTC)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	assertTasks(t, tasks, []Task{{List: "Список", Title: "Задача"}})
+}
+
+func TestParseInvalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		source  string
+		data    string
+		wantErr error
+	}{
+		{"unknown source", "trello", "{}", ErrUnknownSource},
+		{"empty file", SourceTodoist, "", ErrInvalidFile},
+		{"only BOM and spaces", SourceGoogleTasks, "\xef\xbb\xbf \r\n", ErrInvalidFile},
+		{"todoist broken json", SourceTodoist, `{"items": [`, ErrInvalidFile},
+		{"todoist bad flag", SourceTodoist, `{"items": [{"content": "x", "checked": "yes"}]}`, ErrInvalidFile},
+		{"todoist csv without TYPE", SourceTodoist, "CONTENT,PRIORITY\nЗадача,1\n", ErrInvalidFile},
+		{"todoist csv without CONTENT", SourceTodoist, "TYPE,PRIORITY\ntask,1\n", ErrInvalidFile},
+		{"mstodo not json", SourceMSTodo, "title;status", ErrInvalidFile},
+		{"gtasks wrong shape", SourceGoogleTasks, `{"items": "tasks"}`, ErrInvalidFile},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Parse(tt.source, strings.NewReader(tt.data), "Импорт", time.UTC); !errors.Is(err, tt.wantErr) {
+				t.Errorf("err = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,236 @@
+package taskimport
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// flexString - идентификатор Todoist: в старых резервных копиях число, в новых строка
+type flexString string
+
+func (s *flexString) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if bytes.Equal(data, []byte("null")) {
+		*s = ""
+		return nil
+	}
+	if len(data) > 0 && data[0] == '"' {
+		var value string
+		if err := json.Unmarshal(data, &value); err != nil {
+			return err
+		}
+		*s = flexString(value)
+		return nil
+	}
+	*s = flexString(data)
+	return nil
+}
+
+// flexBool - флаг Todoist: true/false или 0/1
+type flexBool bool
+
+func (b *flexBool) UnmarshalJSON(data []byte) error {
+	switch string(bytes.TrimSpace(data)) {
+	case "true", "1":
+		*b = true
+	case "false", "0", "null":
+		*b = false
+	default:
+		return fmt.Errorf("некорректный флаг %s", data)
+	}
+	return nil
+}
+
+// todoistBackup - резервная копия Todoist (Sync API): проекты и задачи
+type todoistBackup struct {
+	Projects []struct {
+		ID   flexString `json:"id"`
+		Name string     `json:"name"`
+	} `json:"projects"`
+	Items []todoistItem `json:"items"`
+	Tasks []todoistItem `json:"tasks"` // Выгрузка REST API
+}
+
+type todoistItem struct {
+	ID          flexString `json:"id"`
+	ProjectID   flexString `json:"project_id"`
+	ParentID    flexString `json:"parent_id"`
+	Content     string     `json:"content"`
+	Description string     `json:"description"`
+	Checked     flexBool   `json:"checked"`
+	IsCompleted flexBool   `json:"is_completed"`
+	IsDeleted   flexBool   `json:"is_deleted"`
+	Priority    int        `json:"priority"` // 4 - наивысший (p1), 1 - без приоритета (p4)
+	Due         *struct {
+		Date     string `json:"date"`     // 2006-01-02, 2006-01-02T15:04:05 (плавающее время) или с Z
+		Timezone string `json:"timezone"` // Часовой пояс плавающего времени
+	} `json:"due"`
+}
+
+// parseTodoistJSON - резервная копия Todoist в JSON
+func parseTodoistJSON(r io.Reader, loc *time.Location) ([]Task, error) {
+	var backup todoistBackup
+	if err := json.NewDecoder(r).Decode(&backup); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidFile, err)
+	}
+
+	projects := make(map[flexString]string, len(backup.Projects))
+	for _, project := range backup.Projects {
+		projects[project.ID] = project.Name
+	}
+
+	b := newBuilder()
+	children := make([]pending, 0)
+
+	for _, item := range append(backup.Items, backup.Tasks...) {
+		if item.IsDeleted {
+			continue
+		}
+
+		task := Task{
+			List:      projects[item.ProjectID],
+			Title:     item.Content,
+			Notes:     item.Description,
+			Completed: bool(item.Checked || item.IsCompleted),
+			Priority:  todoistPriority(item.Priority),
+		}
+		if item.Due != nil {
+			task.Due = parseTodoistDue(item.Due.Date, item.Due.Timezone, loc)
+		}
+
+		if item.ParentID != "" {
+			children = append(children, pending{id: string(item.ID), parentID: string(item.ParentID), task: task})
+			continue
+		}
+		b.add(string(item.ID), task)
+	}
+	b.attach(children)
+
+	return b.tasks, nil
+}
+
+// parseTodoistCSV - CSV-шаблон проекта Todoist: TYPE,CONTENT,DESCRIPTION,PRIORITY,INDENT,...,DATE,...
+// Строки task с INDENT > 1 - подзадачи ближайшей предыдущей задачи, строки note - комментарии к ней
+func parseTodoistCSV(r io.Reader, loc *time.Location) ([]Task, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidFile, err)
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToUpper(strings.TrimSpace(name))] = i
+	}
+	if _, ok := columns["TYPE"]; !ok {
+		return nil, fmt.Errorf("%w: нет колонки TYPE", ErrInvalidFile)
+	}
+	if _, ok := columns["CONTENT"]; !ok {
+		return nil, fmt.Errorf("%w: нет колонки CONTENT", ErrInvalidFile)
+	}
+
+	b := newBuilder()
+	root := "" // Ключ последней задачи верхнего уровня
+
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidFile, err)
+		}
+
+		field := func(name string) string {
+			if i, ok := columns[name]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+
+		switch strings.ToLower(field("TYPE")) {
+		case "task":
+			indent, _ := strconv.Atoi(field("INDENT"))
+			if indent > 1 && root != "" {
+				b.addSubtask("", root, Subtask{Title: field("CONTENT")})
+				continue
+			}
+
+			priority, _ := strconv.Atoi(field("PRIORITY"))
+			root = strconv.Itoa(line)
+			b.add(root, Task{
+				Title:    field("CONTENT"),
+				Notes:    field("DESCRIPTION"),
+				Due:      parseTodoistDue(field("DATE"), field("TIMEZONE"), loc),
+				Priority: todoistCSVPriority(priority),
+			})
+
+		case "note":
+			if i, ok := b.roots[root]; ok && field("CONTENT") != "" {
+				b.tasks[i].Notes = strings.TrimSpace(b.tasks[i].Notes + "\n\n" + field("CONTENT"))
+			}
+		}
+	}
+
+	return b.tasks, nil
+}
+
+// todoistPriority - приоритет из JSON (4 - наивысший, 1 - без приоритета)
+func todoistPriority(priority int) int {
+	switch priority {
+	case 4:
+		return PriorityUrgent
+	case 3:
+		return PriorityHigh
+	case 2:
+		return PriorityNormal
+	default:
+		return PriorityNone
+	}
+}
+
+// todoistCSVPriority - приоритет из CSV-шаблона (1 - наивысший, как p1 в интерфейсе; 4 - без приоритета)
+func todoistCSVPriority(priority int) int {
+	switch priority {
+	case 1:
+		return PriorityUrgent
+	case 2:
+		return PriorityHigh
+	case 3:
+		return PriorityNormal
+	default:
+		return PriorityNone
+	}
+}
+
+// parseTodoistDue - срок Todoist. Сроки на естественном языке ("every monday") не разбираются
+func parseTodoistDue(value, timezone string, loc *time.Location) *time.Time {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil
+	}
+
+	if tz, err := time.LoadLocation(timezone); timezone != "" && err == nil {
+		loc = tz
+	}
+
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return &t
+	}
+	for _, layout := range []string{"2006-01-02T15:04:05", "2006-01-02 15:04", "2006-01-02"} {
+		if t, err := time.ParseInLocation(layout, value, loc); err == nil {
+			return &t
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,150 @@
+package textdiff
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Типы фрагментов различий
+const (
+	Equal  = "equal"
+	Insert = "insert"
+	Delete = "delete"
+)
+
+// maxCells - ограничение размера таблицы LCS; для более длинных текстов различие
+// возвращается целиком (удаление старого текста и вставка нового)
+const maxCells = 4_000_000
+
+// Change - фрагмент различий между двумя текстами
+type Change struct {
+	Type string `json:"type"` // equal | insert | delete
+	Text string `json:"text"` // Текст фрагмента
+}
+
+// Words сравнивает тексты по словам (с сохранением пробелов и переводов строк)
+// и возвращает последовательность фрагментов, из которой восстанавливаются оба текста
+func Words(oldText, newText string) []Change {
+	a, b := tokenize(oldText), tokenize(newText)
+
+	// Общие начало и конец не участвуют в вычислении LCS
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	changes := make([]Change, 0)
+	changes = appendChange(changes, Equal, a[:prefix])
+	changes = append(changes, diffTokens(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])...)
+	changes = appendChange(changes, Equal, a[len(a)-suffix:])
+
+	return merge(changes)
+}
+
+// diffTokens - различия двух последовательностей токенов через наибольшую общую подпоследовательность
+func diffTokens(a, b []string) []Change {
+	changes := make([]Change, 0)
+
+	if len(a) == 0 || len(b) == 0 || (len(a)+1)*(len(b)+1) > maxCells {
+		changes = appendChange(changes, Delete, a)
+		return appendChange(changes, Insert, b)
+	}
+
+	// lcs[i][j] - длина LCS для a[i:] и b[j:]
+	lcs := make([][]int32, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int32, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			changes = append(changes, Change{Type: Equal, Text: a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			changes = append(changes, Change{Type: Delete, Text: a[i]})
+			i++
+		default:
+			changes = append(changes, Change{Type: Insert, Text: b[j]})
+			j++
+		}
+	}
+	changes = appendChange(changes, Delete, a[i:])
+	changes = appendChange(changes, Insert, b[j:])
+
+	return changes
+}
+
+// tokenize - разбиваем текст на слова, пробельные последовательности и отдельные знаки
+func tokenize(text string) []string {
+	tokens := make([]string, 0)
+
+	start := -1
+	kind := 0 // 1 - слово, 2 - пробелы
+	for i, r := range text {
+		current := 0
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			current = 1
+		case unicode.IsSpace(r):
+			current = 2
+		}
+
+		if start >= 0 && (current == 0 || current != kind) {
+			tokens = append(tokens, text[start:i])
+			start = -1
+		}
+
+		if current == 0 {
+			// Знаки препинания и прочие символы - отдельными токенами
+			_, size := utf8.DecodeRuneInString(text[i:])
+			tokens = append(tokens, text[i:i+size])
+			continue
+		}
+		if start < 0 {
+			start, kind = i, current
+		}
+	}
+	if start >= 0 {
+		tokens = append(tokens, text[start:])
+	}
+
+	return tokens
+}
+
+// appendChange - добавляем фрагмент из нескольких токенов, если он не пустой
+func appendChange(changes []Change, kind string, tokens []string) []Change {
+	if len(tokens) == 0 {
+		return changes
+	}
+	return append(changes, Change{Type: kind, Text: strings.Join(tokens, "")})
+}
+
+// merge - объединяем соседние фрагменты одного типа
+func merge(changes []Change) []Change {
+	merged := make([]Change, 0, len(changes))
+	for _, change := range changes {
+		if n := len(merged); n > 0 && merged[n-1].Type == change.Type {
+			merged[n-1].Text += change.Text
+			continue
+		}
+		merged = append(merged, change)
+	}
+	return merged
+}
@@ -0,0 +1,183 @@
+// Package webhook отправляет подписанные webhook-запросы и проверяет подпись на стороне получателя.
+//
+// Тело запроса подписывается HMAC-SHA256 с секретом подписки: подпись вычисляется от строки
+// "<timestamp>.<тело>" и передаётся в заголовке X-Webhook-Signature в виде "sha256=<hex>",
+// время отправки (Unix, секунды) - в X-Webhook-Timestamp. Получатель проверяет подпись через Verify
+// и отбрасывает запросы со старым временем, чтобы перехваченный запрос нельзя было повторить.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// Заголовки запроса
+const (
+	HeaderEvent     = "X-Webhook-Event"     // Тип события, например note.created
+	HeaderDelivery  = "X-Webhook-Delivery"  // ID доставки (при повторной доставке - новый)
+	HeaderTimestamp = "X-Webhook-Timestamp" // Время отправки, Unix (секунды)
+	HeaderSignature = "X-Webhook-Signature" // sha256=<hex HMAC-SHA256(secret, timestamp + "." + body)>
+)
+
+// signaturePrefix - схема подписи в заголовке
+const signaturePrefix = "sha256="
+
+// maxResponseBody - сколько байт ответа получателя сохраняется для журнала доставок
+const maxResponseBody = 1024
+
+var (
+	// ErrInvalidSignature - подпись отсутствует или не совпадает
+	ErrInvalidSignature = errors.New("webhook: некорректная подпись")
+	// ErrStaleTimestamp - время отправки вне допустимого окна
+	ErrStaleTimestamp = errors.New("webhook: устаревший запрос")
+	// ErrPrivateAddress - адрес получателя во внутренней сети
+	ErrPrivateAddress = errors.New("webhook: адрес во внутренней сети запрещён")
+)
+
+// Sign - подпись тела запроса, отправленного в timestamp
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return signaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify - проверить подпись запроса на стороне получателя. tolerance - допустимое расхождение
+// времени отправки с now (0 - время не проверяется)
+func Verify(secret string, header http.Header, body []byte, tolerance time.Duration, now time.Time) error {
+	timestamp, err := strconv.ParseInt(header.Get(HeaderTimestamp), 10, 64)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+
+	expected := Sign(secret, timestamp, body)
+	if !hmac.Equal([]byte(expected), []byte(header.Get(HeaderSignature))) {
+		return ErrInvalidSignature
+	}
+
+	if tolerance > 0 {
+		sent := time.Unix(timestamp, 0)
+		if now.Sub(sent) > tolerance || sent.Sub(now) > tolerance {
+			return ErrStaleTimestamp
+		}
+	}
+
+	return nil
+}
+
+// Request - одна попытка доставки
+type Request struct {
+	URL        string
+	Secret     string
+	Event      string
+	DeliveryID int64
+	Body       []byte // JSON
+}
+
+// Response - ответ получателя
+type Response struct {
+	StatusCode int           // HTTP-статус (0 - ответа нет)
+	Duration   time.Duration // Время от отправки до получения ответа
+	Body       string        // Начало тела ответа (не более 1 КиБ)
+}
+
+// Client отправляет webhook-запросы
+type Client struct {
+	http *http.Client
+}
+
+// NewClient - клиент поверх httpClient (например, NewHTTPClient или клиент httptest.Server)
+func NewClient(httpClient *http.Client) *Client {
+	return &Client{http: httpClient}
+}
+
+// NewHTTPClient - HTTP-клиент для доставок: перенаправления не выполняются (ответ 3xx - неудача).
+// Если allowPrivate == false, соединения с адресами внутренних сетей (loopback, RFC 1918, link-local)
+// запрещены; проверяется адрес, к которому выполняется подключение, поэтому подмена DNS не помогает
+func NewHTTPClient(timeout time.Duration, allowPrivate bool) *http.Client {
+	dialer := &net.Dialer{Timeout: timeout}
+	if !allowPrivate {
+		dialer.Control = func(_, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			ip := net.ParseIP(host)
+			if ip == nil || isPrivate(ip) {
+				return fmt.Errorf("%w: %s", ErrPrivateAddress, host)
+			}
+			return nil
+		}
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = dialer.DialContext
+	transport.Proxy = nil
+
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// isPrivate - адрес не из публичного интернета
+func isPrivate(ip net.IP) bool {
+	return ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() || ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() || ip.IsMulticast()
+}
+
+// Send - отправить подписанный POST-запрос. Ответ не из диапазона 2xx считается ошибкой;
+// Response возвращается и в этом случае (StatusCode == 0, если ответа нет)
+func (c *Client) Send(ctx context.Context, req Request) (*Response, error) {
+	timestamp := time.Now().Unix()
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, req.URL, bytes.NewReader(req.Body))
+	if err != nil {
+		return &Response{}, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("User-Agent", "todolistjwtca-webhooks/1.0")
+	httpReq.Header.Set(HeaderEvent, req.Event)
+	httpReq.Header.Set(HeaderDelivery, strconv.FormatInt(req.DeliveryID, 10))
+	httpReq.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	httpReq.Header.Set(HeaderSignature, Sign(req.Secret, timestamp, req.Body))
+
+	started := time.Now()
+	resp, err := c.http.Do(httpReq)
+	if err != nil {
+		return &Response{Duration: time.Since(started)}, fmt.Errorf("webhook: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxResponseBody))
+	// Дочитываем тело, чтобы соединение могло быть переиспользовано
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	result := &Response{
+		StatusCode: resp.StatusCode,
+		Duration:   time.Since(started),
+		Body:       strings.ReplaceAll(strings.ToValidUTF8(string(body), ""), "\x00", ""),
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return result, fmt.Errorf("webhook: неожиданный статус ответа %s", resp.Status)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,205 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestSignVerify(t *testing.T) {
+	now := time.Unix(1767225600, 0)
+	body := []byte(`{"event":"note.created"}`)
+
+	header := func(secret string, timestamp int64, body []byte) http.Header {
+		h := http.Header{}
+		h.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+		h.Set(HeaderSignature, Sign(secret, timestamp, body))
+		return h
+	}
+
+	tests := []struct {
+		name      string
+		header    http.Header
+		body      []byte
+		tolerance time.Duration
+		wantErr   error
+	}{
+		{"valid", header("secret", now.Unix(), body), body, 5 * time.Minute, nil},
+		{"wrong secret", header("other", now.Unix(), body), body, 5 * time.Minute, ErrInvalidSignature},
+		{"tampered body", header("secret", now.Unix(), body), []byte(`{"event":"note.deleted"}`), 5 * time.Minute, ErrInvalidSignature},
+		{"missing headers", http.Header{}, body, 5 * time.Minute, ErrInvalidSignature},
+		{"stale", header("secret", now.Add(-10*time.Minute).Unix(), body), body, 5 * time.Minute, ErrStaleTimestamp},
+		{"from the future", header("secret", now.Add(10*time.Minute).Unix(), body), body, 5 * time.Minute, ErrStaleTimestamp},
+		{"no tolerance", header("secret", now.Add(-24*time.Hour).Unix(), body), body, 0, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := Verify("secret", tt.header, tt.body, tt.tolerance, now); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Verify err = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	// Подпись от другого времени не подходит, даже если тело то же
+	h := header("secret", now.Unix(), body)
+	h.Set(HeaderTimestamp, strconv.FormatInt(now.Unix()+1, 10))
+	if err := Verify("secret", h, body, 0, now); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("replayed signature with new timestamp: err = %v", err)
+	}
+}
+
+func TestSendSignedRequest(t *testing.T) {
+	body := []byte(`{"noteID":42}`)
+
+	var verifyErr error
+	var gotEvent, gotDelivery string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received, _ := io.ReadAll(r.Body)
+		verifyErr = Verify("s3cret", r.Header, received, time.Minute, time.Now())
+		gotEvent = r.Header.Get(HeaderEvent)
+		gotDelivery = r.Header.Get(HeaderDelivery)
+		w.WriteHeader(http.StatusAccepted)
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	client := NewClient(NewHTTPClient(time.Second, true))
+	resp, err := client.Send(context.Background(), Request{URL: srv.URL, Secret: "s3cret", Event: "note.updated", DeliveryID: 17, Body: body})
+	if err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+
+	if verifyErr != nil {
+		t.Errorf("receiver failed to verify signature: %v", verifyErr)
+	}
+	if gotEvent != "note.updated" || gotDelivery != "17" {
+		t.Errorf("headers: event=%q delivery=%q", gotEvent, gotDelivery)
+	}
+	if resp.StatusCode != http.StatusAccepted || resp.Body != "ok" {
+		t.Errorf("response = %+v", resp)
+	}
+}
+
+// Клиент не повторяет запрос сам: повтор - это новая попытка (Send) с новой подписью
+func TestSendRetries(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received, _ := io.ReadAll(r.Body)
+		if err := Verify("secret", r.Header, received, time.Minute, time.Now()); err != nil {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if calls.Add(1) <= 2 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			_, _ = w.Write([]byte(strings.Repeat("x", 4096)))
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	client := NewClient(NewHTTPClient(time.Second, true))
+	req := Request{URL: srv.URL, Secret: "secret", Event: "note.created", DeliveryID: 1, Body: []byte(`{}`)}
+
+	for attempt := 1; attempt <= 2; attempt++ {
+		resp, err := client.Send(context.Background(), req)
+		if err == nil {
+			t.Fatalf("attempt %d: expected error for 503", attempt)
+		}
+		if resp.StatusCode != http.StatusServiceUnavailable {
+			t.Errorf("attempt %d: StatusCode = %d", attempt, resp.StatusCode)
+		}
+		if len(resp.Body) != maxResponseBody {
+			t.Errorf("attempt %d: response body is %d bytes, want truncated to %d", attempt, len(resp.Body), maxResponseBody)
+		}
+	}
+
+	resp, err := client.Send(context.Background(), req)
+	if err != nil {
+		t.Fatalf("third attempt: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK || calls.Load() != 3 {
+		t.Errorf("StatusCode = %d, calls = %d", resp.StatusCode, calls.Load())
+	}
+}
+
+func TestSendDoesNotFollowRedirects(t *testing.T) {
+	var redirected atomic.Bool
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		redirected.Store(true)
+	}))
+	defer target.Close()
+
+	srv := httptest.NewServer(http.RedirectHandler(target.URL, http.StatusFound))
+	defer srv.Close()
+
+	resp, err := NewClient(NewHTTPClient(time.Second, true)).Send(context.Background(), Request{URL: srv.URL, Body: []byte(`{}`)})
+	if err == nil {
+		t.Fatal("3xx must be treated as failure")
+	}
+	if resp.StatusCode != http.StatusFound {
+		t.Errorf("StatusCode = %d, want 302", resp.StatusCode)
+	}
+	if redirected.Load() {
+		t.Error("redirect was followed")
+	}
+}
+
+func TestNewHTTPClientBlocksPrivateNetworks(t *testing.T) {
+	var called atomic.Bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called.Store(true)
+	}))
+	defer srv.Close()
+
+	resp, err := NewClient(NewHTTPClient(time.Second, false)).Send(context.Background(), Request{URL: srv.URL, Body: []byte(`{}`)})
+	if !errors.Is(err, ErrPrivateAddress) {
+		t.Fatalf("err = %v, want ErrPrivateAddress", err)
+	}
+	if resp.StatusCode != 0 {
+		t.Errorf("StatusCode = %d, want 0 (no response)", resp.StatusCode)
+	}
+	if called.Load() {
+		t.Error("request reached loopback receiver")
+	}
+
+	// Имя хоста тоже проверяется по адресу подключения
+	_, port, _ := net.SplitHostPort(strings.TrimPrefix(srv.URL, "http://"))
+	_, err = NewClient(NewHTTPClient(time.Second, false)).Send(context.Background(), Request{URL: "http://localhost:" + port, Body: []byte(`{}`)})
+	if !errors.Is(err, ErrPrivateAddress) {
+		t.Errorf("localhost: err = %v, want ErrPrivateAddress", err)
+	}
+}
+
+func TestIsPrivate(t *testing.T) {
+	tests := map[string]bool{
+		"127.0.0.1":       true,
+		"10.1.2.3":        true,
+		"172.16.0.1":      true,
+		"192.168.1.10":    true,
+		"169.254.169.254": true, // Метаданные облака
+		"0.0.0.0":         true,
+		"224.0.0.1":       true,
+		"::1":             true,
+		"fe80::1":         true,
+		"fd00::1":         true,
+		"8.8.8.8":         false,
+		"93.184.216.34":   false,
+		"2606:4700::1111": false,
+	}
+
+	for addr, want := range tests {
+		if got := isPrivate(net.ParseIP(addr)); got != want {
+			t.Errorf("isPrivate(%s) = %v, want %v", addr, got, want)
+		}
+	}
+}